@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/R-eSPeCT/todo-list/internal/config"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/notify"
+	"github.com/R-eSPeCT/todo-list/internal/reminder"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/R-eSPeCT/todo-list/internal/trash"
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	// Загружаем конфигурацию
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Подключаемся к базе данных
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+
+	// Redis хранит аренды напоминаний, общие для всех экземпляров планировщика
+	redisCache, err := cache.NewRedisCache(*config.NewRedisConfig())
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	defer redisCache.Close()
+
+	// Каналы доставки напоминаний
+	notifiers := map[string]notify.Notifier{
+		models.ReminderChannelInApp:   notify.NewInboxNotifier(repository.NewNotificationRepository(db)),
+		models.ReminderChannelWebhook: notify.NewWebhookNotifier(&http.Client{Timeout: cfg.Reminders.WebhookTimeout}, cfg.Reminders.WebhookSecret),
+	}
+	if cfg.Reminders.SMTPAddr != "" {
+		notifiers[models.ReminderChannelEmail] = notify.NewSMTPNotifier(notify.SMTPConfig{
+			Addr:     cfg.Reminders.SMTPAddr,
+			Username: cfg.Reminders.SMTPUsername,
+			Password: cfg.Reminders.SMTPPassword,
+			From:     cfg.Reminders.SMTPFrom,
+		})
+	} else {
+		log.Println("SMTP_ADDR is not set, email reminders will fail")
+	}
+
+	scheduler := reminder.NewScheduler(repository.NewReminderRepository(db), redisCache, notifiers, reminder.Config{
+		PollInterval: cfg.Reminders.PollInterval,
+		LeaseTTL:     cfg.Reminders.LeaseTTL,
+		BatchSize:    cfg.Reminders.BatchSize,
+		MaxAttempts:  cfg.Reminders.MaxAttempts,
+		RetryBackoff: cfg.Reminders.RetryBackoff,
+	})
+
+	// Останавливаемся по сигналу; начатая доставка завершается с отменой контекста
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Корзина очищается в том же процессе, что и доставка напоминаний, вместе с содержимым
+	// вложений удаленных задач
+	blobStore, err := storage.New(cfg.Attachments.StorageConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	purger := trash.NewPurger(repository.NewTodoRepository(db), repository.NewAttachmentRepository(db), blobStore, trash.Config{
+		Retention:     cfg.Trash.Retention,
+		PurgeInterval: cfg.Trash.PurgeInterval,
+		BatchSize:     cfg.Trash.PurgeBatchSize,
+	})
+	go func() {
+		log.Printf("Starting trash purger, retention %s", cfg.Trash.Retention)
+		if err := purger.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Trash purger stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Starting reminder scheduler, polling every %s", cfg.Reminders.PollInterval)
+	if err := scheduler.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("Reminder scheduler stopped: %v", err)
+	}
+	log.Println("Reminder scheduler stopped")
+}
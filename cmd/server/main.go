@@ -11,9 +11,16 @@ import (
 	"syscall"
 	"time"
 
+	todopb "github.com/R-eSPeCT/todo-list/api/proto/todo"
+	userpb "github.com/R-eSPeCT/todo-list/api/proto/user"
 	"github.com/R-eSPeCT/todo-list/internal/auth"
 	"github.com/R-eSPeCT/todo-list/internal/config"
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/grpc/interceptor"
+	"github.com/R-eSPeCT/todo-list/internal/grpc/server"
 	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
 	_ "github.com/lib/pq"
 )
 
@@ -40,21 +47,72 @@ func main() {
 		log.Fatalf("Failed to ping database: %v", err)
 	}
 
+	// Подключаемся к Redis для rate limiting и рассылки событий
+	redisCache, err := cache.NewRedisCache(*config.NewRedisConfig())
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	defer redisCache.Close()
+
+	// Запускаем брокер событий изменения задач
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := events.NewBroker(redisCache, events.DefaultConfig())
+	go func() {
+		if err := broker.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Todo event subscription stopped, falling back to in-process delivery: %v", err)
+		}
+	}()
+
 	// Создаем репозитории
 	userRepo := repository.NewUserRepository(db)
+	todoHistoryRepo := repository.NewTodoHistoryRepository(db)
+	transactor := repository.NewTransactor(db)
+	shareRepo := repository.NewShareRepository(db)
+	todoRepo := repository.NewRecurringTodoRepository(repository.NewSubtaskTodoRepository(
+		repository.NewEventTodoRepository(
+			repository.NewHistoryTodoRepository(repository.NewTodoRepository(db), todoHistoryRepo), shareRepo, broker),
+		shareRepo,
+		transactor,
+		cfg.Subtasks.Policies(),
+	))
+	todoService := services.NewTodoService(todoRepo, shareRepo, repository.NewProjectRepository(db))
+
+	// Загружаем ключи подписи токенов. Каталог ключей общий с REST API, поэтому замененные
+	// ключи хранятся не меньше времени жизни токенов обоих серверов.
+	keys, err := auth.LoadKeySet(cfg.JWT.KeysDir, cfg.JWT.SigningAlgorithm)
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	go keys.RunRotation(ctx, cfg.JWT.KeyRotationInterval, max(cfg.JWTExpiration, cfg.GRPC.TokenDuration))
+
+	// Создаем интерцепторы
+	jwtManager := interceptor.NewJWTManagerWithKeys(keys, cfg.GRPC.TokenDuration)
+	refreshTokens := auth.NewRefreshTokenManager(repository.NewRefreshTokenRepository(db), cfg.GRPC.RefreshTokenDuration)
+	denylist := auth.NewTokenDenylist(redisCache, cfg.GRPC.TokenDuration)
+	authInterceptor := interceptor.NewAuthInterceptor(jwtManager, denylist, server.UserPublicMethods)
+	rateLimitInterceptor := interceptor.NewRateLimitInterceptor(interceptor.RateLimitConfig{
+		Cache:     redisCache,
+		Max:       cfg.RateLimitMax,
+		Duration:  cfg.RateLimitWindow,
+		KeyPrefix: "grpc_rate_limit",
+	})
 
 	// Создаем конфигурацию gRPC сервера
-	grpcConfig := auth.ServerConfig{
+	grpcConfig := server.ServerConfig{
 		MaxConnectionIdle:     15 * time.Minute,
 		MaxConnectionAge:      30 * time.Minute,
 		MaxConnectionAgeGrace: 5 * time.Second,
 		Time:                  5 * time.Second,
 		Timeout:               1 * time.Second,
-		MaxRecvMsgSize:        4 * 1024 * 1024, // 4MB
+		MaxRecvMsgSize:        cfg.GRPC.MaxRequestSize,
 	}
 
-	// Создаем gRPC сервер
-	grpcServer := auth.NewGRPCServer(userRepo, []byte(cfg.GRPC.JWTSecretKey), grpcConfig)
+	// Создаем gRPC сервер и регистрируем сервисы
+	grpcServer := server.NewGRPCServer(grpcConfig, authInterceptor, rateLimitInterceptor)
+	todopb.RegisterTodoServiceServer(grpcServer, server.NewTodoServer(todoRepo, todoService, broker, transactor))
+	userpb.RegisterUserServiceServer(grpcServer, server.NewUserServer(userRepo, jwtManager, refreshTokens))
 
 	// Создаем TCP listener для gRPC
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
@@ -79,13 +137,24 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Создаем контекст с таймаутом для graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
 
-	// Останавливаем сервер
-	grpcServer.Stop()
+	// Закрываем подписки, чтобы стримы WatchTodos завершились и не задерживали GracefulStop
+	broker.Close()
+	cancel()
 
-	// Ожидаем завершения всех горутин
-	<-ctx.Done()
+	// Останавливаем сервер, дожидаясь завершения активных запросов
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
 	log.Println("Server stopped")
 }
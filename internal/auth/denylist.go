@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
+)
+
+// denylistKeyPrefix префикс ключей отзыва токенов в кэше
+const denylistKeyPrefix = "token_denylist"
+
+// TokenDenylist хранит отозванные access токены в кэше.
+// Отдельный токен отзывается по jti на оставшееся время его жизни; все токены пользователя,
+// выданные до некоторого момента, отзываются отметкой времени пользователя.
+type TokenDenylist struct {
+	cache cache.Cache
+	// watermarkTTL время хранения отметки пользователя; должно быть не меньше
+	// максимального времени жизни access токенов
+	watermarkTTL time.Duration
+}
+
+// NewTokenDenylist создает новый список отозванных токенов
+func NewTokenDenylist(cache cache.Cache, watermarkTTL time.Duration) *TokenDenylist {
+	return &TokenDenylist{
+		cache:        cache,
+		watermarkTTL: watermarkTTL,
+	}
+}
+
+// Revoke отзывает токен с идентификатором jti до момента его истечения
+func (d *TokenDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return fmt.Errorf("token has no jti")
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// Токен уже истек и будет отклонен без записи в кэше
+		return nil
+	}
+	return d.cache.Set(ctx, jtiKey(jti), 1, ttl)
+}
+
+// RevokeIssuedBefore отзывает все токены пользователя, выданные раньше before.
+// iat хранится с точностью до секунды, поэтому отзываются и токены, выданные в ту же секунду,
+// что и before: новые токены действительны со следующей секунды.
+func (d *TokenDenylist) RevokeIssuedBefore(ctx context.Context, userID string, before time.Time) error {
+	return d.cache.Set(ctx, userKey(userID), before.Unix(), d.watermarkTTL)
+}
+
+// IsRevoked проверяет, отозван ли токен с указанными jti, владельцем и временем выдачи (Unix)
+func (d *TokenDenylist) IsRevoked(ctx context.Context, jti, userID string, issuedAt int64) (bool, error) {
+	if jti != "" {
+		revoked, err := d.cache.Exists(ctx, jtiKey(jti))
+		if err != nil {
+			return false, fmt.Errorf("failed to check token jti: %w", err)
+		}
+		if revoked {
+			return true, nil
+		}
+	}
+
+	value, err := d.cache.Get(ctx, userKey(userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to check user token watermark: %w", err)
+	}
+	if value == nil {
+		return false, nil
+	}
+
+	watermark, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid user token watermark: %w", err)
+	}
+	return issuedAt <= watermark, nil
+}
+
+func jtiKey(jti string) string {
+	return fmt.Sprintf("%s:jti:%s", denylistKeyPrefix, jti)
+}
+
+func userKey(userID string) string {
+	return fmt.Sprintf("%s:user:%s", denylistKeyPrefix, userID)
+}
@@ -6,16 +6,34 @@ import (
 
 	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
+// DefaultTokenDuration время жизни токена, выдаваемого NewJWTManager
+const DefaultTokenDuration = 24 * time.Hour
+
 // JWTManager handles JWT operations
 type JWTManager struct {
-	secretKey []byte
+	keys          *KeySet
+	tokenDuration time.Duration
 }
 
-// NewJWTManager creates a new JWT manager
+// NewJWTManager создает JWT менеджер, подписывающий токены симметричным ключом HS256
 func NewJWTManager(secretKey []byte) *JWTManager {
-	return &JWTManager{secretKey: secretKey}
+	return NewJWTManagerWithKeys(NewHMACKeySet(secretKey), DefaultTokenDuration)
+}
+
+// NewJWTManagerWithKeys создает JWT менеджер, подписывающий токены ключами из набора keys
+func NewJWTManagerWithKeys(keys *KeySet, tokenDuration time.Duration) *JWTManager {
+	return &JWTManager{
+		keys:          keys,
+		tokenDuration: tokenDuration,
+	}
+}
+
+// TokenDuration возвращает время жизни выдаваемых токенов
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
 }
 
 // Claims представляет структуру JWT claims
@@ -25,30 +43,25 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
-// Generate создает новый JWT токен для пользователя
+// Generate создает новый JWT токен для пользователя.
+// Токен получает уникальный jti, по которому его можно отозвать до истечения срока.
 func (m *JWTManager) Generate(user *models.User) (string, error) {
 	claims := Claims{
 		UserID: user.ID.String(),
 		Email:  user.Email,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+			Id:        uuid.New().String(),
+			ExpiresAt: time.Now().Add(m.tokenDuration).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secretKey)
+	return m.keys.Sign(claims)
 }
 
 // Validate проверяет JWT токен и возвращает claims
 func (m *JWTManager) Validate(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.secretKey, nil
-	})
-
+	token, err := m.keys.Parse(tokenString, &Claims{})
 	if err != nil {
 		return nil, err
 	}
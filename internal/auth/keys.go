@@ -0,0 +1,453 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Алгоритмы подписи, поддерживаемые KeySet
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const (
+	// rsaKeyBits размер генерируемых RSA ключей
+	rsaKeyBits = 2048
+	// rotationCheckInterval период проверки необходимости ротации ключей
+	rotationCheckInterval = time.Minute
+	// minReloadInterval минимальный интервал между перечитываниями каталога
+	// при проверке токена с неизвестным kid
+	minReloadInterval = 10 * time.Second
+)
+
+// ErrUnknownKeyID возвращается, если токен подписан неизвестным ключом
+var ErrUnknownKeyID = errors.New("unknown signing key id")
+
+// signingKey ключ подписи или проверки токенов
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	// private ключ подписи; nil для ключей, используемых только для проверки
+	private interface{}
+	// public ключ проверки подписи
+	public    interface{}
+	createdAt time.Time
+	// path файл ключа; пустой для ключей, не хранящихся на диске
+	path string
+}
+
+// KeySet хранит ключи подписи JWT. Токены подписываются самым новым закрытым ключом
+// и содержат его идентификатор в заголовке kid; проверка принимает любой загруженный ключ.
+//
+// Ключи хранятся в каталоге в формате PEM: закрытые ключи (PKCS#8 или PKCS#1) в файлах
+// <kid>.pem, открытые ключи, используемые только для проверки, в файлах <kid>.pub.pem.
+type KeySet struct {
+	dir       string
+	algorithm string
+
+	mu         sync.RWMutex
+	keys       map[string]*signingKey
+	signing    *signingKey
+	lastReload time.Time
+}
+
+// NewHMACKeySet создает набор из одного симметричного ключа HS256 без kid
+func NewHMACKeySet(secret []byte) *KeySet {
+	key := &signingKey{
+		method:    jwt.SigningMethodHS256,
+		private:   secret,
+		public:    secret,
+		createdAt: time.Now(),
+	}
+	return &KeySet{
+		keys:    map[string]*signingKey{key.id: key},
+		signing: key,
+	}
+}
+
+// LoadKeySet загружает ключи из каталога dir. Если в каталоге нет закрытых ключей,
+// создается новый ключ алгоритма algorithm (RS256 или EdDSA).
+func LoadKeySet(dir, algorithm string) (*KeySet, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	s := &KeySet{
+		dir:       dir,
+		algorithm: algorithm,
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	if s.signingKey() == nil {
+		if err := s.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Reload перечитывает ключи из каталога, подхватывая ключи, созданные другими экземплярами сервера
+func (s *KeySet) Reload() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read keys directory: %w", err)
+	}
+
+	keys := make(map[string]*signingKey)
+	var signing *signingKey
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+
+		key, err := loadKeyFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		keys[key.id] = key
+		if key.private != nil && (signing == nil || key.createdAt.After(signing.createdAt)) {
+			signing = key
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.signing = signing
+	s.lastReload = time.Now()
+	return nil
+}
+
+// Rotate создает новый ключ, сохраняет его в каталог и начинает подписывать им токены.
+// Предыдущие ключи остаются доступными для проверки.
+func (s *KeySet) Rotate() error {
+	if s.dir == "" {
+		return fmt.Errorf("key set is not backed by a directory")
+	}
+
+	key, err := generateKey(s.algorithm)
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	key.path = filepath.Join(s.dir, key.id+".pem")
+	if err := writeFileAtomic(key.path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		return fmt.Errorf("failed to save private key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.id] = key
+	s.signing = key
+	return nil
+}
+
+// RunRotation ротирует ключ подписи раз в interval до отмены ctx. Замененные закрытые ключи
+// удаляются через retention после замены, поэтому retention должен быть не меньше времени
+// жизни токенов. Каталог перечитывается при каждой проверке.
+func (s *KeySet) RunRotation(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(min(interval, rotationCheckInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Reload(); err != nil {
+			log.Printf("Failed to reload signing keys: %v", err)
+			continue
+		}
+		if signing := s.signingKey(); signing == nil || time.Since(signing.createdAt) >= interval {
+			if err := s.Rotate(); err != nil {
+				log.Printf("Failed to rotate signing key: %v", err)
+				continue
+			}
+		}
+		s.prune(retention)
+	}
+}
+
+// Sign подписывает claims текущим ключом
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := s.signingKey()
+	if key == nil {
+		return "", fmt.Errorf("no signing key available")
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	if key.id != "" {
+		token.Header["kid"] = key.id
+	}
+	return token.SignedString(key.private)
+}
+
+// Parse проверяет подпись токена ключом из заголовка kid и заполняет claims
+func (s *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.verificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Алгоритм определяется ключом, а не заголовком токена
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.public, nil
+	})
+}
+
+// JWK открытый ключ в формате JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS набор открытых ключей, публикуемый для проверки токенов другими сервисами
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает открытые ключи всех асимметричных ключей набора
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			// Симметричные ключи не публикуются
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// signingKey возвращает текущий ключ подписи
+func (s *KeySet) signingKey() *signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signing
+}
+
+// verificationKey возвращает ключ проверки по kid. Неизвестный kid может означать, что
+// другой экземпляр сервера уже выполнил ротацию, поэтому каталог перечитывается (не чаще minReloadInterval).
+func (s *KeySet) verificationKey(kid string) (*signingKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := s.dir != "" && time.Since(s.lastReload) >= minReloadInterval
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if stale {
+		if err := s.Reload(); err != nil {
+			log.Printf("Failed to reload signing keys: %v", err)
+		}
+		s.mu.RLock()
+		key, ok = s.keys[kid]
+		s.mu.RUnlock()
+		if ok {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKeyID
+}
+
+// prune удаляет закрытые ключи, замененные более retention назад.
+// Открытые ключи (*.pub.pem) не удаляются.
+func (s *KeySet) prune(retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var private []*signingKey
+	for _, key := range s.keys {
+		if key.private != nil {
+			private = append(private, key)
+		}
+	}
+	sort.Slice(private, func(i, j int) bool { return private[i].createdAt.Before(private[j].createdAt) })
+
+	// Ключ заменен в момент создания следующего за ним ключа
+	for i := 0; i < len(private)-1; i++ {
+		key := private[i]
+		if key == s.signing || time.Since(private[i+1].createdAt) < retention {
+			continue
+		}
+		if key.path != "" {
+			if err := os.Remove(key.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Failed to remove retired signing key %s: %v", key.id, err)
+				continue
+			}
+		}
+		delete(s.keys, key.id)
+	}
+}
+
+// loadKeyFile загружает ключ из PEM файла. kid берется из имени файла.
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s has unsupported PEM type %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", path, err)
+	}
+
+	key := &signingKey{
+		id:        strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub"),
+		createdAt: info.ModTime(),
+		path:      path,
+	}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = parsed
+		parsed = signer.Public()
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+		key.public = public
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = public
+	default:
+		return nil, fmt.Errorf("key %s has unsupported type %T", path, parsed)
+	}
+	return key, nil
+}
+
+// generateKey создает новый ключ алгоритма algorithm с уникальным kid.
+// kid начинается со времени создания, поэтому ключи сортируются по возрасту.
+func generateKey(algorithm string) (*signingKey, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate key id: %w", err)
+	}
+	now := time.Now()
+	key := &signingKey{
+		id:        now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		createdAt: now,
+	}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.private = private
+		key.public = &private.PublicKey
+	case AlgorithmEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.private = private
+		key.public = public
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	return key, nil
+}
+
+// writeFileAtomic записывает файл через временный файл, чтобы другие экземпляры
+// сервера не прочитали ключ частично
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
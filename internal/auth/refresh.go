@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefreshToken возвращается для неизвестного, истекшего или отозванного refresh токена
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused возвращается при повторном использовании уже замененного refresh токена.
+	// Все токены семейства при этом отзываются.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenManager выдает и ротирует refresh токены.
+// Клиенту отдается случайная строка, на сервере хранится только ее SHA-256.
+type RefreshTokenManager struct {
+	repo     repository.RefreshTokenRepository
+	duration time.Duration
+}
+
+// NewRefreshTokenManager создает новый менеджер refresh токенов
+func NewRefreshTokenManager(repo repository.RefreshTokenRepository, duration time.Duration) *RefreshTokenManager {
+	return &RefreshTokenManager{
+		repo:     repo,
+		duration: duration,
+	}
+}
+
+// Issue выдает refresh токен, открывающий новое семейство (используется при входе)
+func (m *RefreshTokenManager) Issue(ctx context.Context, userID uuid.UUID) (string, error) {
+	raw, token, err := m.newToken(userID, uuid.New())
+	if err != nil {
+		return "", err
+	}
+	if err := m.repo.Create(ctx, token); err != nil {
+		return "", fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return raw, nil
+}
+
+// Rotate заменяет refresh токен новым из того же семейства и возвращает его вместе с ID пользователя.
+// Предъявление уже замененного токена означает его кражу: семейство отзывается целиком.
+func (m *RefreshTokenManager) Rotate(ctx context.Context, refreshToken string) (string, uuid.UUID, error) {
+	current, err := m.repo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+		return "", uuid.Nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	if current.RotatedAt != nil {
+		m.revokeFamily(ctx, current.FamilyID)
+		return "", uuid.Nil, ErrRefreshTokenReused
+	}
+	if current.RevokedAt != nil || !time.Now().Before(current.ExpiresAt) {
+		return "", uuid.Nil, ErrInvalidRefreshToken
+	}
+
+	raw, next, err := m.newToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+	err = m.repo.Rotate(ctx, current.ID, next)
+	if errors.Is(err, repository.ErrRefreshTokenInactive) {
+		// Токен заменили параллельным запросом с тем же значением
+		m.revokeFamily(ctx, current.FamilyID)
+		return "", uuid.Nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return "", uuid.Nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return raw, current.UserID, nil
+}
+
+// RevokeAll отзывает все refresh токены пользователя
+func (m *RefreshTokenManager) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	return m.repo.RevokeByUserID(ctx, userID)
+}
+
+// newToken генерирует значение токена и запись для его хранения
+func (m *RefreshTokenManager) newToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now().UTC()
+	return raw, &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(raw),
+		ExpiresAt: now.Add(m.duration),
+		CreatedAt: now,
+	}, nil
+}
+
+// revokeFamily отзывает семейство токенов; ошибка только логируется,
+// клиент в любом случае получает отказ
+func (m *RefreshTokenManager) revokeFamily(ctx context.Context, familyID uuid.UUID) {
+	if err := m.repo.RevokeFamily(ctx, familyID); err != nil {
+		log.Printf("Failed to revoke refresh token family %s: %v", familyID, err)
+	}
+}
+
+// hashRefreshToken возвращает хеш, под которым токен хранится в базе
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,281 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
+	"github.com/google/uuid"
+)
+
+// Channel канал, в который публикуются изменения задач
+const Channel = "todo_events"
+
+// ErrResumeWindowExceeded возвращается, если точка возобновления подписки старше
+// сохраненной истории и часть событий могла быть потеряна
+var ErrResumeWindowExceeded = errors.New("resume point is outside of the event history window")
+
+// Publisher публикует изменения задач
+type Publisher interface {
+	Publish(ctx context.Context, event models.TodoEvent) error
+}
+
+// Config содержит настройки брокера событий
+type Config struct {
+	// HistorySize количество последних событий, хранимых для каждого пользователя
+	HistorySize int
+	// HistoryTTL время, в течение которого событие доступно для возобновления подписки
+	HistoryTTL time.Duration
+	// BufferSize размер буфера событий одного подписчика
+	BufferSize int
+}
+
+// DefaultConfig возвращает настройки брокера по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		HistorySize: 100,
+		HistoryTTL:  time.Hour,
+		BufferSize:  64,
+	}
+}
+
+// Broker рассылает изменения задач подписчикам.
+// Если задан PubSub, события проходят через Redis и доходят до подписчиков на всех
+// экземплярах сервера; без него (или пока подписка на Redis не активна) события
+// доставляются только внутри процесса.
+type Broker struct {
+	pubsub cache.PubSub
+	config Config
+	remote atomic.Bool
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+	history     map[uuid.UUID]*userHistory
+	startedAt   time.Time
+	// sweptAt время последней очистки истории всех пользователей
+	sweptAt time.Time
+}
+
+// userHistory хранит последние события пользователя для возобновления подписки
+type userHistory struct {
+	events []models.TodoEvent
+	// truncatedAt время самого нового из вытесненных событий
+	truncatedAt time.Time
+}
+
+// NewBroker создает новый брокер событий. pubsub может быть nil.
+func NewBroker(pubsub cache.PubSub, config Config) *Broker {
+	return &Broker{
+		pubsub:      pubsub,
+		config:      config,
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+		history:     make(map[uuid.UUID]*userHistory),
+		startedAt:   time.Now().UTC(),
+		sweptAt:     time.Now().UTC(),
+	}
+}
+
+// Run подписывается на канал событий в Redis и рассылает полученные события локальным
+// подписчикам до отмены ctx. Без PubSub сразу возвращает nil.
+func (b *Broker) Run(ctx context.Context) error {
+	if b.pubsub == nil {
+		return nil
+	}
+
+	messages, err := b.pubsub.Subscribe(ctx, Channel)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", Channel, err)
+	}
+
+	b.remote.Store(true)
+	defer b.remote.Store(false)
+
+	for msg := range messages {
+		var event models.TodoEvent
+		if err := json.Unmarshal(msg, &event); err != nil {
+			log.Printf("Failed to decode todo event: %v", err)
+			continue
+		}
+		b.dispatch(event)
+	}
+
+	return ctx.Err()
+}
+
+// Publish публикует событие. При недоступности Redis событие доставляется локально.
+func (b *Broker) Publish(ctx context.Context, event models.TodoEvent) error {
+	if b.remote.Load() {
+		err := b.pubsub.Publish(ctx, Channel, event)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to publish todo event to Redis, delivering locally: %v", err)
+	}
+
+	b.dispatch(event)
+	return nil
+}
+
+// Subscribe подписывает на изменения задач пользователя. Если since не нулевое,
+// сначала отправляются сохраненные события, произошедшие позже since.
+func (b *Broker) Subscribe(userID uuid.UUID, since time.Time) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []models.TodoEvent
+	if !since.IsZero() {
+		now := time.Now().UTC()
+		h := b.history[userID]
+		if h != nil {
+			b.prune(h, now)
+			if len(h.events) == 0 {
+				delete(b.history, userID)
+			}
+		}
+		if since.Before(b.startedAt) || (h != nil && since.Before(h.truncatedAt)) {
+			return nil, ErrResumeWindowExceeded
+		}
+		// История без событий удаляется вместе со временем вытеснения, поэтому без истории
+		// возобновление возможно только в пределах HistoryTTL
+		if h == nil && since.Before(now.Add(-b.config.HistoryTTL)) {
+			return nil, ErrResumeWindowExceeded
+		}
+		if h != nil {
+			for _, event := range h.events {
+				if event.OccurredAt.After(since) {
+					replay = append(replay, event)
+				}
+			}
+		}
+	}
+
+	sub := &Subscription{
+		userID: userID,
+		events: make(chan models.TodoEvent, b.config.BufferSize+len(replay)),
+		broker: b,
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Close закрывает все подписки. Клиенты получат закрытие канала событий и смогут
+// переподключиться к другому экземпляру сервера.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subscribers {
+		for sub := range subs {
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// dispatch сохраняет событие в истории и рассылает его локальным подписчикам
+func (b *Broker) dispatch(event models.TodoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.history[event.UserID]
+	if h == nil {
+		h = &userHistory{}
+		b.history[event.UserID] = h
+	}
+	h.events = append(h.events, event)
+	now := time.Now().UTC()
+	b.prune(h, now)
+	if now.Sub(b.sweptAt) >= b.config.HistoryTTL {
+		b.sweep(now)
+	}
+
+	for sub := range b.subscribers[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			// Подписчик не успевает читать события: отключаем его, чтобы он
+			// переподключился с since и получил пропущенное из истории
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// prune удаляет из истории события сверх лимита и устаревшие события
+func (b *Broker) prune(h *userHistory, now time.Time) {
+	drop := 0
+	if len(h.events) > b.config.HistorySize {
+		drop = len(h.events) - b.config.HistorySize
+	}
+	for drop < len(h.events) && now.Sub(h.events[drop].OccurredAt) > b.config.HistoryTTL {
+		drop++
+	}
+	if drop == 0 {
+		return
+	}
+
+	h.truncatedAt = h.events[drop-1].OccurredAt
+	h.events = append(h.events[:0], h.events[drop:]...)
+}
+
+// sweep удаляет историю пользователей, все события которых вышли за HistoryTTL.
+// Вызывается под b.mu.
+func (b *Broker) sweep(now time.Time) {
+	for userID, h := range b.history {
+		b.prune(h, now)
+		if len(h.events) == 0 {
+			delete(b.history, userID)
+		}
+	}
+	b.sweptAt = now
+}
+
+// unsubscribe удаляет подписчика и закрывает его канал. Вызывается под b.mu.
+func (b *Broker) unsubscribe(sub *Subscription) {
+	subs, ok := b.subscribers[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.userID)
+	}
+	close(sub.events)
+}
+
+// Subscription представляет подписку на изменения задач пользователя
+type Subscription struct {
+	userID uuid.UUID
+	events chan models.TodoEvent
+	broker *Broker
+}
+
+// Events возвращает канал событий. Канал закрывается при отписке или если
+// подписчик не успевал читать события.
+func (s *Subscription) Events() <-chan models.TodoEvent {
+	return s.events
+}
+
+// Close отменяет подписку
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.unsubscribe(s)
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEvent(eventType string, userID uuid.UUID) models.TodoEvent {
+	return models.NewTodoEvent(eventType, &models.Todo{ID: uuid.New(), UserID: userID})
+}
+
+func receive(t *testing.T, sub *Subscription) models.TodoEvent {
+	select {
+	case event, ok := <-sub.Events():
+		require.True(t, ok, "канал событий закрыт")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("событие не получено")
+		return models.TodoEvent{}
+	}
+}
+
+func TestBroker_PublishInProcess(t *testing.T) {
+	broker := NewBroker(nil, DefaultConfig())
+	userID := uuid.New()
+	otherUserID := uuid.New()
+
+	sub, err := broker.Subscribe(userID, time.Time{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, broker.Publish(context.Background(), newTestEvent(models.TodoEventCreated, otherUserID)))
+	event := newTestEvent(models.TodoEventUpdated, userID)
+	require.NoError(t, broker.Publish(context.Background(), event))
+
+	received := receive(t, sub)
+	assert.Equal(t, event.ID, received.ID)
+	assert.Equal(t, models.TodoEventUpdated, received.Type)
+}
+
+func TestBroker_ResumeFromTimestamp(t *testing.T) {
+	broker := NewBroker(nil, DefaultConfig())
+	userID := uuid.New()
+
+	first := newTestEvent(models.TodoEventCreated, userID)
+	require.NoError(t, broker.Publish(context.Background(), first))
+	time.Sleep(time.Millisecond)
+	second := newTestEvent(models.TodoEventDeleted, userID)
+	require.NoError(t, broker.Publish(context.Background(), second))
+
+	sub, err := broker.Subscribe(userID, first.OccurredAt)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	assert.Equal(t, second.ID, receive(t, sub).ID)
+}
+
+func TestBroker_ResumeWindowExceeded(t *testing.T) {
+	config := DefaultConfig()
+	config.HistorySize = 1
+	broker := NewBroker(nil, config)
+	userID := uuid.New()
+
+	_, err := broker.Subscribe(userID, time.Now().Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrResumeWindowExceeded)
+
+	first := newTestEvent(models.TodoEventCreated, userID)
+	require.NoError(t, broker.Publish(context.Background(), first))
+	time.Sleep(time.Millisecond)
+	second := newTestEvent(models.TodoEventUpdated, userID)
+	second.OccurredAt = first.OccurredAt.Add(time.Millisecond)
+	require.NoError(t, broker.Publish(context.Background(), second))
+	third := newTestEvent(models.TodoEventUpdated, userID)
+	third.OccurredAt = second.OccurredAt.Add(time.Millisecond)
+	require.NoError(t, broker.Publish(context.Background(), third))
+
+	// Событие second вытеснено из истории, возобновление с first невозможно
+	_, err = broker.Subscribe(userID, first.OccurredAt)
+	assert.ErrorIs(t, err, ErrResumeWindowExceeded)
+}
+
+func TestBroker_SlowSubscriberIsDisconnected(t *testing.T) {
+	config := DefaultConfig()
+	config.BufferSize = 1
+	broker := NewBroker(nil, config)
+	userID := uuid.New()
+
+	sub, err := broker.Subscribe(userID, time.Time{})
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(context.Background(), newTestEvent(models.TodoEventCreated, userID)))
+	require.NoError(t, broker.Publish(context.Background(), newTestEvent(models.TodoEventCreated, userID)))
+
+	receive(t, sub)
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+
+	// Повторное закрытие отключенной подписки безопасно
+	sub.Close()
+}
+
+func TestBroker_HistoryEviction(t *testing.T) {
+	config := DefaultConfig()
+	config.HistoryTTL = 20 * time.Millisecond
+	broker := NewBroker(nil, config)
+	userID := uuid.New()
+	otherUserID := uuid.New()
+
+	first := newTestEvent(models.TodoEventCreated, userID)
+	require.NoError(t, broker.Publish(context.Background(), first))
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, broker.Publish(context.Background(), newTestEvent(models.TodoEventCreated, otherUserID)))
+
+	// История пользователя, все события которого устарели, удалена при очистке
+	broker.mu.Lock()
+	assert.NotContains(t, broker.history, userID)
+	assert.Contains(t, broker.history, otherUserID)
+	broker.mu.Unlock()
+
+	_, err := broker.Subscribe(userID, first.OccurredAt)
+	assert.ErrorIs(t, err, ErrResumeWindowExceeded)
+
+	sub, err := broker.Subscribe(userID, time.Now().Add(-time.Millisecond))
+	require.NoError(t, err)
+	sub.Close()
+}
@@ -2,6 +2,9 @@ package interceptor
 
 import (
 	"context"
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -9,15 +12,29 @@ import (
 	"strings"
 )
 
+// contextKey тип ключей контекста, используемых интерцепторами
+type contextKey string
+
+// userIDKey ключ контекста, под которым хранится ID аутентифицированного пользователя
+const userIDKey contextKey = "user_id"
+
+// UserIDFromContext возвращает ID пользователя, добавленный в контекст AuthInterceptor
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok && userID != ""
+}
+
 // AuthInterceptor представляет интерцептор для аутентификации
 type AuthInterceptor struct {
 	jwtManager *JWTManager
+	// Список отозванных токенов; nil отключает проверку
+	denylist *auth.TokenDenylist
 	// Методы, не требующие аутентификации
 	publicMethods map[string]bool
 }
 
-// NewAuthInterceptor создает новый интерцептор аутентификации
-func NewAuthInterceptor(jwtManager *JWTManager, publicMethods []string) *AuthInterceptor {
+// NewAuthInterceptor создает новый интерцептор аутентификации. denylist может быть nil.
+func NewAuthInterceptor(jwtManager *JWTManager, denylist *auth.TokenDenylist, publicMethods []string) *AuthInterceptor {
 	methods := make(map[string]bool)
 	for _, method := range publicMethods {
 		methods[method] = true
@@ -25,6 +42,7 @@ func NewAuthInterceptor(jwtManager *JWTManager, publicMethods []string) *AuthInt
 
 	return &AuthInterceptor{
 		jwtManager:    jwtManager,
+		denylist:      denylist,
 		publicMethods: methods,
 	}
 }
@@ -46,9 +64,7 @@ func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 			return nil, err
 		}
 
-		// Добавляем ID пользователя в контекст
-		newCtx := context.WithValue(ctx, "user_id", userID)
-		return handler(newCtx, req)
+		return handler(withUser(ctx, userID), req)
 	}
 }
 
@@ -88,7 +104,7 @@ func (i *AuthInterceptor) authorize(ctx context.Context) (string, error) {
 	}
 
 	accessToken := values[0]
-	if strings.HasPrefix(accessToken, "Bearer ") {
+	if !strings.HasPrefix(accessToken, "Bearer ") {
 		return "", status.Errorf(codes.Unauthenticated, "invalid authorization format")
 	}
 
@@ -98,6 +114,16 @@ func (i *AuthInterceptor) authorize(ctx context.Context) (string, error) {
 		return "", status.Errorf(codes.Unauthenticated, "access token is invalid: %v", err)
 	}
 
+	if i.denylist != nil {
+		revoked, err := i.denylist.IsRevoked(ctx, claims.Id, claims.UserID, claims.IssuedAt)
+		if err != nil {
+			return "", status.Error(codes.Internal, "token revocation check failed")
+		}
+		if revoked {
+			return "", status.Error(codes.Unauthenticated, "access token has been revoked")
+		}
+	}
+
 	return claims.UserID, nil
 }
 
@@ -115,6 +141,15 @@ func newWrappedStream(stream grpc.ServerStream, userID string) grpc.ServerStream
 }
 
 func (w *wrappedStream) Context() context.Context {
-	ctx := w.ServerStream.Context()
-	return context.WithValue(ctx, "user_id", w.userID)
+	return withUser(w.ServerStream.Context(), w.userID)
+}
+
+// withUser добавляет в контекст ID пользователя и его же как автора изменений задач
+func withUser(ctx context.Context, userID string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	actor := models.Actor{Source: models.ChangeSourceGRPC}
+	if id, err := uuid.Parse(userID); err == nil {
+		actor.UserID = &id
+	}
+	return models.WithActor(ctx, actor)
 }
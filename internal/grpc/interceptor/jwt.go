@@ -2,13 +2,15 @@ package interceptor
 
 import (
 	"fmt"
+	"github.com/R-eSPeCT/todo-list/internal/auth"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"time"
 )
 
 // JWTManager представляет менеджер JWT токенов
 type JWTManager struct {
-	secretKey     string
+	keys          *auth.KeySet
 	tokenDuration time.Duration
 }
 
@@ -18,42 +20,41 @@ type UserClaims struct {
 	UserID string `json:"user_id"`
 }
 
-// NewJWTManager создает новый менеджер JWT
+// NewJWTManager создает новый менеджер JWT с симметричным ключом HS256
 func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+	return NewJWTManagerWithKeys(auth.NewHMACKeySet([]byte(secretKey)), tokenDuration)
+}
+
+// NewJWTManagerWithKeys создает менеджер JWT, подписывающий токены ключами из набора keys
+func NewJWTManagerWithKeys(keys *auth.KeySet, tokenDuration time.Duration) *JWTManager {
 	return &JWTManager{
-		secretKey:     secretKey,
+		keys:          keys,
 		tokenDuration: tokenDuration,
 	}
 }
 
-// Generate создает новый JWT токен для пользователя
+// Generate создает новый JWT токен для пользователя с уникальным jti
 func (m *JWTManager) Generate(userID string) (string, error) {
 	claims := UserClaims{
 		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
 			ExpiresAt: time.Now().Add(m.tokenDuration).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 		UserID: userID,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	return m.keys.Sign(claims)
+}
+
+// TokenDuration возвращает время жизни выдаваемых токенов
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
 }
 
 // Verify проверяет JWT токен и возвращает claims
 func (m *JWTManager) Verify(accessToken string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(
-		accessToken,
-		&UserClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			_, ok := token.Method.(*jwt.SigningMethodHMAC)
-			if !ok {
-				return nil, fmt.Errorf("unexpected token signing method")
-			}
-			return []byte(m.secretKey), nil
-		},
-	)
-
+	token, err := m.keys.Parse(accessToken, &UserClaims{})
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
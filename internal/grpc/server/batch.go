@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+
+	pb "github.com/R-eSPeCT/todo-list/api/proto/todo"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchOperations максимальное число операций в BatchTodos
+const maxBatchOperations = 100
+
+// BatchTodos выполняет операции пакета по порядку в одной транзакции. Каждая операция
+// выполняется так же, как соответствующий метод сервиса. По умолчанию ошибка операции
+// отменяет весь пакет, с best_effort — только эту операцию.
+func (s *TodoServer) BatchTodos(ctx context.Context, req *pb.BatchTodosRequest) (*pb.BatchTodosResponse, error) {
+	if _, err := userIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+
+	operations := req.GetOperations()
+	if len(operations) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "operations are required")
+	}
+	if len(operations) > maxBatchOperations {
+		return nil, status.Error(codes.InvalidArgument, "too many operations")
+	}
+
+	todos := make([]*pb.TodoResponse, len(operations))
+	outcomes, committed, err := repository.RunBatch(ctx, s.tx, len(operations), !req.GetBestEffort(),
+		func(ctx context.Context, i int) error {
+			var err error
+			todos[i], err = s.applyBatchOperation(ctx, operations[i])
+			return err
+		})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to run batch")
+	}
+
+	resp := &pb.BatchTodosResponse{Committed: committed}
+	for i, outcome := range outcomes {
+		result := &pb.BatchTodoResult{Index: int32(i), Status: outcome.Status}
+		switch outcome.Status {
+		case repository.BatchStatusOK:
+			result.Todo = todos[i]
+		case repository.BatchStatusFailed:
+			st := status.Convert(outcome.Err)
+			result.Code = int32(st.Code())
+			result.Error = st.Message()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// applyBatchOperation выполняет операцию пакета методом сервиса
+func (s *TodoServer) applyBatchOperation(ctx context.Context, op *pb.BatchTodoOperation) (*pb.TodoResponse, error) {
+	switch operation := op.GetOperation().(type) {
+	case *pb.BatchTodoOperation_Create:
+		return s.CreateTodo(ctx, operation.Create)
+	case *pb.BatchTodoOperation_Update:
+		return s.UpdateTodo(ctx, operation.Update)
+	case *pb.BatchTodoOperation_Delete:
+		_, err := s.DeleteTodo(ctx, operation.Delete)
+		return nil, err
+	case *pb.BatchTodoOperation_Move:
+		return s.MoveTodo(ctx, operation.Move)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "operation is required")
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/R-eSPeCT/todo-list/api/proto/todo"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// directTransactor выполняет функцию без транзакции
+type directTransactor struct{}
+
+func (directTransactor) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestTodoServer_BatchTodos(t *testing.T) {
+	userID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Отчет", Status: "pending", Priority: "medium", Version: 2}
+	missingID := uuid.New()
+
+	operations := []*pb.BatchTodoOperation{
+		{Operation: &pb.BatchTodoOperation_Create{Create: &pb.CreateTodoRequest{Title: "План"}}},
+		{Operation: &pb.BatchTodoOperation_Update{Update: &pb.UpdateTodoRequest{Id: todo.ID.String(), Status: "completed"}}},
+		{Operation: &pb.BatchTodoOperation_Delete{Delete: &pb.DeleteTodoRequest{Id: missingID.String()}}},
+		{Operation: &pb.BatchTodoOperation_Delete{Delete: &pb.DeleteTodoRequest{Id: todo.ID.String(), ExpectedVersion: 1}}},
+		{},
+	}
+
+	tests := []struct {
+		name          string
+		bestEffort    bool
+		wantCommitted bool
+		wantStatuses  []string
+		wantCodes     []codes.Code
+	}{
+		{
+			name:         "ошибка отменяет пакет",
+			wantStatuses: []string{"rolled_back", "rolled_back", "failed", "skipped", "skipped"},
+			wantCodes:    []codes.Code{codes.OK, codes.OK, codes.NotFound, codes.OK, codes.OK},
+		},
+		{
+			name:          "ошибки отдельных операций",
+			bestEffort:    true,
+			wantCommitted: true,
+			wantStatuses:  []string{"ok", "ok", "failed", "failed", "failed"},
+			wantCodes:     []codes.Code{codes.OK, codes.OK, codes.NotFound, codes.Aborted, codes.InvalidArgument},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTodoRepository)
+			repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			repo.On("GetByID", mock.Anything, todo.ID).Return(todo, nil)
+			repo.On("GetByID", mock.Anything, missingID).Return(nil, repository.ErrTodoNotFound)
+			repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+			srv := NewTodoServer(repo, services.NewTodoService(repo, nil, nil), nil, directTransactor{})
+
+			resp, err := srv.BatchTodos(authContext(t, userID), &pb.BatchTodosRequest{
+				Operations: operations,
+				BestEffort: tt.bestEffort,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCommitted, resp.GetCommitted())
+			require.Len(t, resp.GetResults(), len(operations))
+			for i, result := range resp.GetResults() {
+				assert.Equal(t, tt.wantStatuses[i], result.GetStatus(), "operation %d", i)
+				assert.Equal(t, tt.wantCodes[i], codes.Code(result.GetCode()), "operation %d", i)
+				assert.Equal(t, result.GetStatus() == repository.BatchStatusOK, result.GetTodo() != nil, "operation %d", i)
+			}
+			repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTodoServer_BatchTodos_Validation(t *testing.T) {
+	srv := NewTodoServer(new(MockTodoRepository), nil, nil, directTransactor{})
+	ctx := authContext(t, uuid.New())
+
+	_, err := srv.BatchTodos(ctx, &pb.BatchTodosRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	operations := make([]*pb.BatchTodoOperation, maxBatchOperations+1)
+	_, err = srv.BatchTodos(ctx, &pb.BatchTodosRequest{Operations: operations})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/grpc/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// ServerConfig содержит конфигурацию gRPC сервера
+type ServerConfig struct {
+	MaxConnectionIdle     time.Duration
+	MaxConnectionAge      time.Duration
+	MaxConnectionAgeGrace time.Duration
+	Time                  time.Duration
+	Timeout               time.Duration
+	MaxRecvMsgSize        int
+}
+
+// NewGRPCServer создает gRPC сервер с интерцепторами rate limiting и аутентификации.
+// Rate limiting выполняется первым, чтобы неаутентифицированные запросы тоже учитывались.
+func NewGRPCServer(
+	cfg ServerConfig,
+	authInterceptor *interceptor.AuthInterceptor,
+	rateLimitInterceptor *interceptor.RateLimitInterceptor,
+) *grpc.Server {
+	keepaliveParams := keepalive.ServerParameters{
+		MaxConnectionIdle:     cfg.MaxConnectionIdle,
+		MaxConnectionAge:      cfg.MaxConnectionAge,
+		MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+		Time:                  cfg.Time,
+		Timeout:               cfg.Timeout,
+	}
+
+	return grpc.NewServer(
+		grpc.KeepaliveParams(keepaliveParams),
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.ChainUnaryInterceptor(
+			rateLimitInterceptor.Unary(),
+			authInterceptor.Unary(),
+		),
+		grpc.ChainStreamInterceptor(
+			rateLimitInterceptor.Stream(),
+			authInterceptor.Stream(),
+		),
+	)
+}
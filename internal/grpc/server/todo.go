@@ -0,0 +1,847 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	pb "github.com/R-eSPeCT/todo-list/api/proto/todo"
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/grpc/interceptor"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultPerPage размер страницы ListTodos, если клиент его не указал
+	defaultPerPage = 20
+	// maxPerPage максимальный размер страницы ListTodos
+	maxPerPage = 100
+	// defaultOccurrencesCount число дат ListOccurrences, если клиент его не указал
+	defaultOccurrencesCount = 10
+	// maxOccurrencesCount максимальное число дат ListOccurrences
+	maxOccurrencesCount = 100
+)
+
+// TodoServer реализует gRPC сервис TodoService поверх репозитория задач.
+// ID пользователя всегда берется из токена, поле user_id в запросах игнорируется.
+type TodoServer struct {
+	pb.UnimplementedTodoServiceServer
+	repo repository.TodoRepository
+	// todos проверяет доступ пользователя к задаче, запрошенной по ID
+	todos  services.TodoService
+	broker *events.Broker
+	// tx выполняет операции BatchTodos в одной транзакции
+	tx repository.Transactor
+}
+
+// NewTodoServer создает новый экземпляр TodoServer
+func NewTodoServer(repo repository.TodoRepository, todos services.TodoService, broker *events.Broker, tx repository.Transactor) *TodoServer {
+	return &TodoServer{
+		repo:   repo,
+		todos:  todos,
+		broker: broker,
+		tx:     tx,
+	}
+}
+
+// CreateTodo создает новую задачу для текущего пользователя
+func (s *TodoServer) CreateTodo(ctx context.Context, req *pb.CreateTodoRequest) (*pb.TodoResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.GetTitle()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	todoStatus := req.GetStatus()
+	if todoStatus == "" {
+		todoStatus = "pending"
+	}
+	if !isValidStatus(todoStatus) {
+		return nil, status.Error(codes.InvalidArgument, "invalid status")
+	}
+
+	priority := req.GetPriority()
+	if priority == "" {
+		priority = "medium"
+	}
+	if !isValidPriority(priority) {
+		return nil, status.Error(codes.InvalidArgument, "invalid priority")
+	}
+
+	parentID, err := parseParentID(req.GetParentId())
+	if err != nil {
+		return nil, err
+	}
+	projectID, err := parseProjectID(req.GetProjectId())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	todo := &models.Todo{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Status:      todoStatus,
+		Priority:    priority,
+		ParentID:    parentID,
+		ProjectID:   projectID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.GetDueDate() != nil {
+		todo.DueDate = req.GetDueDate().AsTime()
+	}
+	if req.GetRecurrence() != "" {
+		todo.Recurrence = &models.TodoRecurrence{Rule: req.GetRecurrence(), Timezone: req.GetTimezone()}
+	}
+
+	if err := s.todos.Create(ctx, todo); err != nil {
+		return nil, repositoryError(err, "failed to create todo")
+	}
+
+	return toTodoResponse(todo), nil
+}
+
+// GetTodo возвращает задачу текущего пользователя по ID
+func (s *TodoServer) GetTodo(ctx context.Context, req *pb.GetTodoRequest) (*pb.TodoResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.getOwnedTodo(ctx, req.GetId(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTodoResponse(todo), nil
+}
+
+// UpdateTodo обновляет задачу текущего пользователя. Пустые поля запроса не изменяются.
+// Флаг this_and_future применяет изменения повторяющейся задачи к этому и последующим
+// повторениям, а новое правило recurrence всегда действует на последующие повторения.
+func (s *TodoServer) UpdateTodo(ctx context.Context, req *pb.UpdateTodoRequest) (*pb.TodoResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.getOwnedTodo(ctx, req.GetId(), userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExpectedVersion(todo, req.GetExpectedVersion()); err != nil {
+		return nil, err
+	}
+
+	rule, timezone := req.Recurrence, req.GetTimezone()
+	if len(req.GetUpdateMask().GetPaths()) > 0 {
+		rule, timezone, err = applyUpdateMask(todo, req)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if req.GetTitle() != "" {
+			todo.Title = req.GetTitle()
+		}
+		if req.GetDescription() != "" {
+			todo.Description = req.GetDescription()
+		}
+		if req.GetStatus() != "" {
+			if !isValidStatus(req.GetStatus()) {
+				return nil, status.Error(codes.InvalidArgument, "invalid status")
+			}
+			todo.Status = req.GetStatus()
+		}
+		if req.GetPriority() != "" {
+			if !isValidPriority(req.GetPriority()) {
+				return nil, status.Error(codes.InvalidArgument, "invalid priority")
+			}
+			todo.Priority = req.GetPriority()
+		}
+		if req.GetParentId() != "" {
+			parentID, err := parseParentID(req.GetParentId())
+			if err != nil {
+				return nil, err
+			}
+			todo.ParentID = parentID
+		}
+		if req.GetDueDate() != nil {
+			todo.DueDate = req.GetDueDate().AsTime()
+		}
+	}
+	if req.GetThisAndFuture() || rule != nil {
+		newRule := ""
+		if todo.Recurrence != nil {
+			newRule = todo.Recurrence.Rule
+		}
+		if rule != nil {
+			newRule = *rule
+		}
+		if req.GetThisAndFuture() && newRule == "" && todo.Recurrence == nil {
+			return nil, status.Error(codes.FailedPrecondition, "todo is not recurring")
+		}
+
+		// Нулевой SeriesID запрашивает новую серию, начинающуюся с этой задачи
+		todo.Recurrence = nil
+		if newRule != "" {
+			todo.Recurrence = &models.TodoRecurrence{Rule: newRule, Timezone: timezone}
+		}
+	}
+	todo.UpdatedAt = time.Now()
+
+	if err := s.todos.Update(ctx, userID, todo); err != nil {
+		return nil, repositoryError(err, "failed to update todo")
+	}
+
+	return toTodoResponse(todo), nil
+}
+
+// applyUpdateMask меняет поля задачи из маски update_mask запроса; пустые значения очищают
+// поля. Возвращает новое правило повторения (nil, если не меняется) и его часовой пояс.
+// Изменение только часового пояса повторяющейся задачи сохраняет ее правило.
+func applyUpdateMask(todo *models.Todo, req *pb.UpdateTodoRequest) (*string, string, error) {
+	var rule *string
+	timezone := ""
+	if todo.Recurrence != nil {
+		timezone = todo.Recurrence.Timezone
+	}
+
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "title":
+			if strings.TrimSpace(req.GetTitle()) == "" {
+				return nil, "", status.Error(codes.InvalidArgument, "title is required")
+			}
+			todo.Title = req.GetTitle()
+		case "description":
+			todo.Description = req.GetDescription()
+		case "status":
+			if !isValidStatus(req.GetStatus()) {
+				return nil, "", status.Error(codes.InvalidArgument, "invalid status")
+			}
+			todo.Status = req.GetStatus()
+		case "priority":
+			if !isValidPriority(req.GetPriority()) {
+				return nil, "", status.Error(codes.InvalidArgument, "invalid priority")
+			}
+			todo.Priority = req.GetPriority()
+		case "parent_id":
+			todo.ParentID = nil
+			if req.GetParentId() != "" {
+				parentID, err := parseParentID(req.GetParentId())
+				if err != nil {
+					return nil, "", err
+				}
+				todo.ParentID = parentID
+			}
+		case "due_date":
+			todo.DueDate = time.Time{}
+			if req.GetDueDate() != nil {
+				todo.DueDate = req.GetDueDate().AsTime()
+			}
+		case "recurrence":
+			value := req.GetRecurrence()
+			rule = &value
+		case "timezone":
+			timezone = req.GetTimezone()
+			if rule == nil && todo.Recurrence != nil {
+				value := todo.Recurrence.Rule
+				rule = &value
+			}
+		default:
+			return nil, "", status.Errorf(codes.InvalidArgument, "invalid update mask path %q", path)
+		}
+	}
+	return rule, timezone, nil
+}
+
+// DeleteTodo удаляет задачу текущего пользователя
+func (s *TodoServer) DeleteTodo(ctx context.Context, req *pb.DeleteTodoRequest) (*emptypb.Empty, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.getOwnedTodo(ctx, req.GetId(), userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExpectedVersion(todo, req.GetExpectedVersion()); err != nil {
+		return nil, err
+	}
+
+	if err := s.todos.Delete(ctx, userID, todo.ID, int(req.GetExpectedVersion())); err != nil {
+		return nil, repositoryError(err, "failed to delete todo")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListTodos возвращает страницу задач текущего пользователя. Следующая страница
+// запрашивается с next_page_token и теми же фильтрами и сортировкой.
+func (s *TodoServer) ListTodos(ctx context.Context, req *pb.ListTodosRequest) (*pb.ListTodosResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := listTodosFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Лишняя задача показывает, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	todos, err := s.repo.List(ctx, userID, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get todos")
+	}
+
+	resp := &pb.ListTodosResponse{}
+	if len(todos) > limit {
+		todos = todos[:limit]
+		sort, desc := filter.SortOrder()
+		resp.NextPageToken = models.NewTodoCursor(todos[limit-1], sort, desc).Encode()
+	}
+	resp.Todos = make([]*pb.TodoResponse, 0, len(todos))
+	for _, todo := range todos {
+		resp.Todos = append(resp.Todos, toTodoResponse(todo))
+	}
+
+	return resp, nil
+}
+
+// listTodosFilter разбирает фильтры, сортировку и страницу запроса ListTodos
+func listTodosFilter(req *pb.ListTodosRequest) (models.TodoFilter, error) {
+	var filter models.TodoFilter
+	var err error
+	if filter.ProjectID, err = parseProjectID(req.GetProjectId()); err != nil {
+		return filter, err
+	}
+	if filter.TagsAny, err = parseTagIDs(req.GetTagsAny()); err != nil {
+		return filter, err
+	}
+	if filter.TagsAll, err = parseTagIDs(req.GetTagsAll()); err != nil {
+		return filter, err
+	}
+	if filter.TagsNone, err = parseTagIDs(req.GetTagsNone()); err != nil {
+		return filter, err
+	}
+	for _, todoStatus := range req.GetStatuses() {
+		if !isValidStatus(todoStatus) {
+			return filter, status.Error(codes.InvalidArgument, "invalid status")
+		}
+	}
+	filter.Statuses = req.GetStatuses()
+	for _, priority := range req.GetPriorities() {
+		if !isValidPriority(priority) {
+			return filter, status.Error(codes.InvalidArgument, "invalid priority")
+		}
+	}
+	filter.Priorities = req.GetPriorities()
+	filter.DueAfter = optionalTime(req.GetDueAfter())
+	filter.DueBefore = optionalTime(req.GetDueBefore())
+	filter.CreatedAfter = optionalTime(req.GetCreatedAfter())
+	filter.CreatedBefore = optionalTime(req.GetCreatedBefore())
+	filter.Text = strings.TrimSpace(req.GetQuery())
+
+	if err := filter.SetSort(req.GetSort(), req.GetOrder()); err != nil {
+		return filter, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	filter.Limit = int(req.GetPerPage())
+	if filter.Limit < 1 {
+		filter.Limit = defaultPerPage
+	}
+	if filter.Limit > maxPerPage {
+		filter.Limit = maxPerPage
+	}
+
+	if req.GetPageToken() != "" {
+		sort, desc := filter.SortOrder()
+		if filter.After, err = models.DecodeTodoCursor(req.GetPageToken(), sort, desc); err != nil {
+			return filter, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+	return filter, nil
+}
+
+// optionalTime возвращает время метки или nil, если метка не задана
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// GetGroupedTodos возвращает задачи текущего пользователя, сгруппированные по статусу и приоритету
+// или по исполнителю
+func (s *TodoServer) GetGroupedTodos(ctx context.Context, req *pb.GetGroupedTodosRequest) (*pb.GroupedTodosResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID, err := parseProjectID(req.GetProjectId())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetGroupBy() != "" && !models.IsValidTodoGroupBy(req.GetGroupBy()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid group_by")
+	}
+
+	groups, err := s.repo.GetGroupedTodos(ctx, userID, models.TodoFilter{ProjectID: projectID, GroupBy: req.GetGroupBy()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get grouped todos")
+	}
+
+	resp := &pb.GroupedTodosResponse{
+		Groups: make([]*pb.TodoGroup, 0, len(groups)),
+	}
+	for _, group := range groups {
+		pbGroup := &pb.TodoGroup{
+			Status:   group.Status,
+			Priority: group.Priority,
+			Count:    int32(group.Count),
+			Todos:    make([]*pb.TodoResponse, 0, len(group.Tasks)),
+		}
+		if group.Assignee != nil {
+			pbGroup.AssigneeId = group.Assignee.String()
+		}
+		for _, todo := range group.Tasks {
+			pbGroup.Todos = append(pbGroup.Todos, toTodoResponse(todo))
+		}
+		for _, count := range group.TagCounts {
+			pbGroup.TagCounts = append(pbGroup.TagCounts, &pb.TagCount{
+				Tag:   toTag(count.Tag),
+				Count: int32(count.Count),
+			})
+		}
+		resp.Groups = append(resp.Groups, pbGroup)
+	}
+
+	return resp, nil
+}
+
+// GetSubtasks возвращает задачу текущего пользователя вместе со всеми подзадачами
+func (s *TodoServer) GetSubtasks(ctx context.Context, req *pb.GetSubtasksRequest) (*pb.TodoTree, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todoID, err := parseTodoID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	subtree, err := s.todos.GetSubtree(ctx, userID, todoID)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get subtasks")
+	}
+
+	root := models.BuildTodoTree(subtree, todoID)
+	if root == nil {
+		return nil, status.Error(codes.NotFound, "todo not found")
+	}
+	return toTodoTree(root), nil
+}
+
+// MoveTodo переносит задачу текущего пользователя вместе с подзадачами в конец другого проекта.
+// Перенесенная задача становится задачей верхнего уровня.
+func (s *TodoServer) MoveTodo(ctx context.Context, req *pb.MoveTodoRequest) (*pb.TodoResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID, err := parseProjectID(req.GetProjectId())
+	if err != nil {
+		return nil, err
+	}
+	if projectID == nil {
+		return nil, status.Error(codes.InvalidArgument, "project ID is required")
+	}
+
+	todoID, err := parseTodoID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	moved, err := s.todos.MoveToProject(ctx, userID, todoID, *projectID, time.Now())
+	if err != nil {
+		return nil, repositoryError(err, "failed to move todo")
+	}
+	return toTodoResponse(moved[0]), nil
+}
+
+// SkipOccurrence отменяет повторение задачи текущего пользователя и создает следующее повторение серии
+func (s *TodoServer) SkipOccurrence(ctx context.Context, req *pb.SkipOccurrenceRequest) (*pb.TodoResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.getRecurringTodo(ctx, req.GetId(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	todo.Status = models.TodoStatusCancelled
+	todo.UpdatedAt = time.Now()
+	if err := s.todos.Update(ctx, userID, todo); err != nil {
+		return nil, repositoryError(err, "failed to skip occurrence")
+	}
+
+	return toTodoResponse(todo), nil
+}
+
+// ListOccurrences возвращает плановые даты следующих повторений задачи текущего пользователя
+func (s *TodoServer) ListOccurrences(ctx context.Context, req *pb.ListOccurrencesRequest) (*pb.ListOccurrencesResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	count := int(req.GetCount())
+	if count == 0 {
+		count = defaultOccurrencesCount
+	}
+	if count < 0 || count > maxOccurrencesCount {
+		return nil, status.Error(codes.InvalidArgument, "invalid count")
+	}
+
+	todo, err := s.getRecurringTodo(ctx, req.GetId(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := todo.Recurrence.Upcoming(count)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list occurrences")
+	}
+
+	resp := &pb.ListOccurrencesResponse{}
+	for _, occurrence := range occurrences {
+		resp.Occurrences = append(resp.Occurrences, timestamppb.New(occurrence))
+	}
+	return resp, nil
+}
+
+// WatchTodos отправляет клиенту изменения задач текущего пользователя в реальном времени
+func (s *TodoServer) WatchTodos(req *pb.WatchTodosRequest, stream pb.TodoService_WatchTodosServer) error {
+	ctx := stream.Context()
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if req.GetSince() != nil {
+		since = req.GetSince().AsTime()
+	}
+
+	sub, err := s.broker.Subscribe(userID, since)
+	if errors.Is(err, events.ErrResumeWindowExceeded) {
+		return status.Error(codes.OutOfRange, "changes since the requested time are no longer available, reload todos")
+	}
+	if err != nil {
+		return status.Error(codes.Internal, "failed to subscribe to todo changes")
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return status.Error(codes.Unavailable, "subscription lagged behind, reconnect with since")
+			}
+			if err := stream.Send(toTodoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// getOwnedTodo загружает задачу, доступную пользователю.
+// Недоступные задачи возвращаются как NotFound, чтобы не раскрывать их существование.
+func (s *TodoServer) getOwnedTodo(ctx context.Context, id string, userID uuid.UUID) (*models.Todo, error) {
+	todoID, err := parseTodoID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.todos.GetByID(ctx, userID, todoID)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get todo")
+	}
+
+	return todo, nil
+}
+
+// getRecurringTodo загружает задачу пользователя и проверяет, что она повторяющаяся
+func (s *TodoServer) getRecurringTodo(ctx context.Context, id string, userID uuid.UUID) (*models.Todo, error) {
+	todo, err := s.getOwnedTodo(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Recurrence == nil {
+		return nil, status.Error(codes.FailedPrecondition, "todo is not recurring")
+	}
+	return todo, nil
+}
+
+// userIDFromContext извлекает ID пользователя, добавленный AuthInterceptor
+func userIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	rawID, ok := interceptor.UserIDFromContext(ctx)
+	if !ok {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	userID, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "invalid user ID in token")
+	}
+
+	return userID, nil
+}
+
+// parseTodoID разбирает ID задачи из запроса
+func parseTodoID(id string) (uuid.UUID, error) {
+	todoID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid todo ID format")
+	}
+	return todoID, nil
+}
+
+// parseParentID разбирает ID родительской задачи; пустая строка означает задачу верхнего уровня
+func parseParentID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	parentID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid parent ID format")
+	}
+	return &parentID, nil
+}
+
+// parseProjectID разбирает ID проекта; пустая строка означает, что проект не указан
+func parseProjectID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	projectID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid project ID format")
+	}
+	return &projectID, nil
+}
+
+// parseTagIDs разбирает ID тегов фильтра
+func parseTagIDs(ids []string) ([]uuid.UUID, error) {
+	var tagIDs []uuid.UUID
+	for _, id := range ids {
+		tagID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid tag ID format")
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+	return tagIDs, nil
+}
+
+// repositoryError преобразует ошибку репозитория в gRPC статус
+func repositoryError(err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrTodoNotFound):
+		return status.Error(codes.NotFound, "todo not found")
+	case errors.Is(err, repository.ErrInvalidParent):
+		return status.Error(codes.InvalidArgument, "invalid parent todo")
+	case errors.Is(err, repository.ErrInvalidProject):
+		return status.Error(codes.InvalidArgument, "invalid project")
+	case errors.Is(err, repository.ErrInvalidRecurrence):
+		return status.Error(codes.InvalidArgument, "invalid recurrence rule, timezone or due date")
+	case errors.Is(err, repository.ErrTodoHasSubtasks):
+		return status.Error(codes.FailedPrecondition, "todo has subtasks")
+	case errors.Is(err, repository.ErrTodoHasOpenSubtasks):
+		return status.Error(codes.FailedPrecondition, "todo has open subtasks")
+	case errors.Is(err, repository.ErrTodoVersionMismatch):
+		return status.Error(codes.Aborted, "todo version mismatch")
+	}
+	return status.Error(codes.Internal, msg)
+}
+
+// checkExpectedVersion возвращает Aborted, если задана ожидаемая версия задачи и она
+// не совпадает с текущей. Версия прочитанной задачи проверяется и при сохранении.
+func checkExpectedVersion(todo *models.Todo, expected int64) error {
+	if expected != 0 && expected != int64(todo.Version) {
+		return status.Error(codes.Aborted, "todo version mismatch")
+	}
+	return nil
+}
+
+// toTodoResponse преобразует модель задачи в gRPC сообщение
+func toTodoResponse(todo *models.Todo) *pb.TodoResponse {
+	resp := &pb.TodoResponse{
+		Id:          todo.ID.String(),
+		UserId:      todo.UserID.String(),
+		Title:       todo.Title,
+		Description: todo.Description,
+		Status:      todo.Status,
+		Priority:    todo.Priority,
+		CreatedAt:   timestamppb.New(todo.CreatedAt),
+		UpdatedAt:   timestamppb.New(todo.UpdatedAt),
+		Version:     int64(todo.Version),
+	}
+	resp.CommentCount = int32(todo.CommentCount)
+	if todo.ParentID != nil {
+		resp.ParentId = todo.ParentID.String()
+	}
+	if todo.ProjectID != nil {
+		resp.ProjectId = todo.ProjectID.String()
+	}
+	resp.Position = int32(todo.Position)
+	if !todo.DueDate.IsZero() {
+		resp.DueDate = timestamppb.New(todo.DueDate)
+	}
+	if todo.Recurrence != nil {
+		resp.Recurrence = &pb.TodoRecurrence{
+			SeriesId:       todo.Recurrence.SeriesID.String(),
+			Rule:           todo.Recurrence.Rule,
+			Timezone:       todo.Recurrence.Timezone,
+			Start:          timestamppb.New(todo.Recurrence.Start),
+			OccurrenceDate: timestamppb.New(todo.Recurrence.OccurrenceDate),
+		}
+	}
+	if todo.Progress != nil {
+		resp.Progress = &pb.TodoProgress{
+			Done:  int32(todo.Progress.Done),
+			Total: int32(todo.Progress.Total),
+		}
+	}
+	for _, tag := range todo.Tags {
+		resp.Tags = append(resp.Tags, toTag(tag))
+	}
+	for _, assignee := range todo.Assignees {
+		resp.AssigneeIds = append(resp.AssigneeIds, assignee.String())
+	}
+	return resp
+}
+
+// toTag преобразует тег задачи в gRPC сообщение
+func toTag(tag models.TodoTag) *pb.Tag {
+	return &pb.Tag{
+		Id:    tag.ID.String(),
+		Name:  tag.Name,
+		Color: tag.Color,
+	}
+}
+
+// toTodoTree преобразует дерево подзадач в gRPC сообщение
+func toTodoTree(node *models.TodoNode) *pb.TodoTree {
+	tree := &pb.TodoTree{
+		Todo:     toTodoResponse(node.Todo),
+		Subtasks: make([]*pb.TodoTree, 0, len(node.Subtasks)),
+	}
+	for _, subtask := range node.Subtasks {
+		tree.Subtasks = append(tree.Subtasks, toTodoTree(subtask))
+	}
+	return tree
+}
+
+// toTodoEvent преобразует событие изменения задачи в gRPC сообщение
+func toTodoEvent(event models.TodoEvent) *pb.TodoEvent {
+	eventType := pb.TodoEventType_TODO_EVENT_TYPE_UNSPECIFIED
+	switch event.Type {
+	case models.TodoEventCreated:
+		eventType = pb.TodoEventType_TODO_EVENT_TYPE_CREATED
+	case models.TodoEventUpdated:
+		eventType = pb.TodoEventType_TODO_EVENT_TYPE_UPDATED
+	case models.TodoEventDeleted:
+		eventType = pb.TodoEventType_TODO_EVENT_TYPE_DELETED
+	case models.TodoEventCommentCreated:
+		eventType = pb.TodoEventType_TODO_EVENT_TYPE_COMMENT_CREATED
+	case models.TodoEventCommentUpdated:
+		eventType = pb.TodoEventType_TODO_EVENT_TYPE_COMMENT_UPDATED
+	case models.TodoEventCommentDeleted:
+		eventType = pb.TodoEventType_TODO_EVENT_TYPE_COMMENT_DELETED
+	}
+
+	resp := &pb.TodoEvent{
+		Id:         event.ID.String(),
+		Type:       eventType,
+		Todo:       toTodoResponse(event.Todo),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+	if event.Comment != nil {
+		resp.Comment = toComment(event.Comment)
+	}
+	return resp
+}
+
+// toComment преобразует комментарий к задаче в gRPC сообщение
+func toComment(comment *models.Comment) *pb.Comment {
+	resp := &pb.Comment{
+		Id:        comment.ID.String(),
+		TodoId:    comment.TodoID.String(),
+		UserId:    comment.UserID.String(),
+		Body:      comment.Body,
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+	}
+	if comment.ParentID != nil {
+		resp.ParentId = comment.ParentID.String()
+	}
+	for _, mention := range comment.Mentions {
+		resp.MentionIds = append(resp.MentionIds, mention.String())
+	}
+	if comment.EditedAt != nil {
+		resp.EditedAt = timestamppb.New(*comment.EditedAt)
+	}
+	if comment.DeletedAt != nil {
+		resp.DeletedAt = timestamppb.New(*comment.DeletedAt)
+	}
+	return resp
+}
+
+// isValidStatus проверяет, является ли статус допустимым
+func isValidStatus(status string) bool {
+	validStatuses := []string{"pending", "in_progress", "completed", "cancelled"}
+	for _, s := range validStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidPriority проверяет, является ли приоритет допустимым
+func isValidPriority(priority string) bool {
+	validPriorities := []string{"low", "medium", "high"}
+	for _, p := range validPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
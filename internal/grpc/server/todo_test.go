@@ -0,0 +1,1123 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/R-eSPeCT/todo-list/api/proto/todo"
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/grpc/interceptor"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type MockTodoRepository struct {
+	mock.Mock
+}
+
+func (m *MockTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	args := m.Called(ctx, todo)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	// Как и база данных, каждый вызов возвращает новую копию задачи
+	todo := *args.Get(0).(*models.Todo)
+	return &todo, args.Error(1)
+}
+
+func (m *MockTodoRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) List(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]*models.Todo, error) {
+	args := m.Called(ctx, userID, filter)
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	args := m.Called(ctx, todo)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) GetGroupedTodos(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]models.TodoGroup, error) {
+	args := m.Called(ctx, userID, filter)
+	return args.Get(0).([]models.TodoGroup), args.Error(1)
+}
+
+func (m *MockTodoRepository) GetSubtree(ctx context.Context, id uuid.UUID) ([]*models.Todo, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) CountOpenSubtasks(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTodoRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	args := m.Called(ctx, id, updatedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	args := m.Called(ctx, id, projectID, updatedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) CreateSeries(ctx context.Context, series *models.TodoSeries) error {
+	args := m.Called(ctx, series)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) GetSeries(ctx context.Context, id uuid.UUID) (*models.TodoSeries, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TodoSeries), args.Error(1)
+}
+
+func (m *MockTodoRepository) CreateOccurrence(ctx context.Context, previous, next *models.Todo) (bool, error) {
+	args := m.Called(ctx, previous, next)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTodoRepository) Search(ctx context.Context, userID uuid.UUID, query string, filter models.TodoFilter) ([]*models.TodoSearchResult, error) {
+	args := m.Called(ctx, userID, query, filter)
+	return args.Get(0).([]*models.TodoSearchResult), args.Error(1)
+}
+
+func (m *MockTodoRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) Restore(ctx context.Context, id, userID uuid.UUID) ([]*models.Todo, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+func (m *MockTodoRepository) Purge(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Int(0), args.Error(1)
+}
+
+const testSecret = "test-secret-key"
+
+// newTodoServer создает сервер над репозиторием без общего доступа, событий и транзакций
+func newTodoServer(repo repository.TodoRepository) *TodoServer {
+	return NewTodoServer(repo, services.NewTodoService(repo, nil, nil), nil, nil)
+}
+
+// authContext прогоняет токен пользователя через AuthInterceptor и возвращает полученный контекст
+func authContext(t *testing.T, userID uuid.UUID) context.Context {
+	jwtManager := interceptor.NewJWTManager(testSecret, time.Minute)
+	token, err := jwtManager.Generate(userID.String())
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	authInterceptor := interceptor.NewAuthInterceptor(jwtManager, nil, nil)
+
+	var authCtx context.Context
+	_, err = authInterceptor.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/todo.TodoService/GetTodo"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			authCtx = ctx
+			return nil, nil
+		})
+	require.NoError(t, err)
+	return authCtx
+}
+
+func TestTodoServer_CreateTodo(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		req       *pb.CreateTodoRequest
+		setupMock func(repo *MockTodoRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name: "успешное создание",
+			ctx:  authContext(t, userID),
+			req:  &pb.CreateTodoRequest{Title: "Задача", UserId: uuid.New().String()},
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+					return todo.UserID == userID && todo.Status == "pending" && todo.Priority == "medium"
+				})).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:      "пустой заголовок",
+			ctx:       authContext(t, userID),
+			req:       &pb.CreateTodoRequest{},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:      "неверный статус",
+			ctx:       authContext(t, userID),
+			req:       &pb.CreateTodoRequest{Title: "Задача", Status: "unknown"},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:      "без аутентификации",
+			ctx:       context.Background(),
+			req:       &pb.CreateTodoRequest{Title: "Задача"},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTodoRepository)
+			tt.setupMock(repo)
+			srv := newTodoServer(repo)
+
+			resp, err := srv.CreateTodo(tt.ctx, tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, userID.String(), resp.GetUserId())
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTodoServer_GetTodo(t *testing.T) {
+	userID := uuid.New()
+	ownTodo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Своя задача"}
+	foreignTodo := &models.Todo{ID: uuid.New(), UserID: uuid.New(), Title: "Чужая задача"}
+	missingID := uuid.New()
+
+	repo := new(MockTodoRepository)
+	repo.On("GetByID", mock.Anything, ownTodo.ID).Return(ownTodo, nil)
+	repo.On("GetByID", mock.Anything, foreignTodo.ID).Return(foreignTodo, nil)
+	repo.On("GetByID", mock.Anything, missingID).Return(nil, repository.ErrTodoNotFound)
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	tests := []struct {
+		name     string
+		id       string
+		wantCode codes.Code
+	}{
+		{name: "своя задача", id: ownTodo.ID.String(), wantCode: codes.OK},
+		{name: "чужая задача", id: foreignTodo.ID.String(), wantCode: codes.NotFound},
+		{name: "несуществующая задача", id: missingID.String(), wantCode: codes.NotFound},
+		{name: "неверный ID", id: "invalid-id", wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.GetTodo(ctx, &pb.GetTodoRequest{Id: tt.id})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestTodoServer_DeleteTodo_ForeignTodo(t *testing.T) {
+	userID := uuid.New()
+	foreignTodo := &models.Todo{ID: uuid.New(), UserID: uuid.New()}
+
+	repo := new(MockTodoRepository)
+	repo.On("GetByID", mock.Anything, foreignTodo.ID).Return(foreignTodo, nil)
+	srv := newTodoServer(repo)
+
+	_, err := srv.DeleteTodo(authContext(t, userID), &pb.DeleteTodoRequest{Id: foreignTodo.ID.String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTodoServer_ListTodos(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+	todos := make([]*models.Todo, 3)
+	for i := range todos {
+		todos[i] = &models.Todo{ID: uuid.New(), UserID: userID, CreatedAt: now.Add(-time.Duration(i) * time.Hour)}
+	}
+
+	repo := new(MockTodoRepository)
+	repo.On("List", mock.Anything, userID, models.TodoFilter{Limit: 3}).Return(todos, nil).Once()
+	repo.On("List", mock.Anything, userID, mock.MatchedBy(func(filter models.TodoFilter) bool {
+		return filter.Limit == 3 && filter.After != nil && filter.After.ID == todos[1].ID
+	})).Return(todos[2:], nil).Once()
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	resp, err := srv.ListTodos(ctx, &pb.ListTodosRequest{PerPage: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTodos(), 2)
+	assert.Equal(t, todos[1].ID.String(), resp.GetTodos()[1].GetId())
+	require.NotEmpty(t, resp.GetNextPageToken())
+
+	resp, err = srv.ListTodos(ctx, &pb.ListTodosRequest{PerPage: 2, PageToken: resp.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTodos(), 1)
+	assert.Equal(t, todos[2].ID.String(), resp.GetTodos()[0].GetId())
+	assert.Empty(t, resp.GetNextPageToken())
+	repo.AssertExpectations(t)
+}
+
+func TestTodoServer_ListTodos_Filters(t *testing.T) {
+	userID := uuid.New()
+	dueBefore := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := new(MockTodoRepository)
+	repo.On("List", mock.Anything, userID, models.TodoFilter{
+		Statuses:   []string{"pending", "in_progress"},
+		Priorities: []string{"high"},
+		DueBefore:  &dueBefore,
+		Text:       "report",
+		Sort:       models.TodoSortDueDate,
+		Limit:      defaultPerPage + 1,
+	}).Return([]*models.Todo{}, nil)
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	_, err := srv.ListTodos(ctx, &pb.ListTodosRequest{
+		Statuses:   []string{"pending", "in_progress"},
+		Priorities: []string{"high"},
+		DueBefore:  timestamppb.New(dueBefore),
+		Query:      " report ",
+		Sort:       models.TodoSortDueDate,
+	})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	tests := []struct {
+		name string
+		req  *pb.ListTodosRequest
+	}{
+		{name: "Invalid status", req: &pb.ListTodosRequest{Statuses: []string{"done"}}},
+		{name: "Invalid priority", req: &pb.ListTodosRequest{Priorities: []string{"urgent"}}},
+		{name: "Invalid sort", req: &pb.ListTodosRequest{Sort: "title"}},
+		{name: "Invalid order", req: &pb.ListTodosRequest{Order: "up"}},
+		{name: "Invalid page token", req: &pb.ListTodosRequest{PageToken: "garbage"}},
+		{
+			name: "Page token of another sort",
+			req: &pb.ListTodosRequest{
+				Sort:      models.TodoSortPriority,
+				PageToken: models.NewTodoCursor(&models.Todo{ID: uuid.New()}, models.TodoSortCreatedAt, true).Encode(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.ListTodos(ctx, tt.req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestTodoServer_ListTodos_TagFilter(t *testing.T) {
+	userID := uuid.New()
+	home, work, archive := uuid.New(), uuid.New(), uuid.New()
+	tag := models.TodoTag{ID: home, Name: "home", Color: "#00ff00"}
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Tags: []models.TodoTag{tag}}
+
+	repo := new(MockTodoRepository)
+	repo.On("List", mock.Anything, userID, models.TodoFilter{
+		TagsAny:  []uuid.UUID{home, work},
+		TagsAll:  []uuid.UUID{home},
+		TagsNone: []uuid.UUID{archive},
+		Limit:    defaultPerPage + 1,
+	}).Return([]*models.Todo{todo}, nil)
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	resp, err := srv.ListTodos(ctx, &pb.ListTodosRequest{
+		TagsAny:  []string{home.String(), work.String()},
+		TagsAll:  []string{home.String()},
+		TagsNone: []string{archive.String()},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTodos(), 1)
+	require.Len(t, resp.GetTodos()[0].GetTags(), 1)
+	assert.Equal(t, "home", resp.GetTodos()[0].GetTags()[0].GetName())
+
+	_, err = srv.ListTodos(ctx, &pb.ListTodosRequest{TagsAll: []string{"invalid-id"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	repo.AssertExpectations(t)
+}
+
+func TestTodoServer_ListTodos_ProjectFilter(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, ProjectID: &projectID, Position: 3}
+
+	repo := new(MockTodoRepository)
+	repo.On("List", mock.Anything, userID, models.TodoFilter{ProjectID: &projectID, Limit: defaultPerPage + 1}).Return([]*models.Todo{todo}, nil)
+	repo.On("GetGroupedTodos", mock.Anything, userID, models.TodoFilter{ProjectID: &projectID}).Return([]models.TodoGroup{{
+		Status: "pending", Priority: "high", Count: 1, Tasks: []*models.Todo{todo},
+	}}, nil)
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	resp, err := srv.ListTodos(ctx, &pb.ListTodosRequest{ProjectId: projectID.String()})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTodos(), 1)
+	assert.Equal(t, projectID.String(), resp.GetTodos()[0].GetProjectId())
+	assert.Equal(t, int32(3), resp.GetTodos()[0].GetPosition())
+
+	grouped, err := srv.GetGroupedTodos(ctx, &pb.GetGroupedTodosRequest{ProjectId: projectID.String()})
+	require.NoError(t, err)
+	require.Len(t, grouped.GetGroups(), 1)
+
+	_, err = srv.ListTodos(ctx, &pb.ListTodosRequest{ProjectId: "invalid-id"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	repo.AssertExpectations(t)
+}
+
+func TestTodoServer_GetGroupedTodos_ByAssignee(t *testing.T) {
+	userID := uuid.New()
+	assigneeID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Assignees: []uuid.UUID{assigneeID}}
+	unassigned := &models.Todo{ID: uuid.New(), UserID: userID}
+
+	repo := new(MockTodoRepository)
+	repo.On("GetGroupedTodos", mock.Anything, userID, models.TodoFilter{GroupBy: models.TodoGroupByAssignee}).Return([]models.TodoGroup{
+		{Assignee: &assigneeID, Count: 1, Tasks: []*models.Todo{todo}},
+		{Count: 1, Tasks: []*models.Todo{unassigned}},
+	}, nil)
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	grouped, err := srv.GetGroupedTodos(ctx, &pb.GetGroupedTodosRequest{GroupBy: models.TodoGroupByAssignee})
+	require.NoError(t, err)
+	require.Len(t, grouped.GetGroups(), 2)
+	assert.Equal(t, assigneeID.String(), grouped.GetGroups()[0].GetAssigneeId())
+	assert.Equal(t, []string{assigneeID.String()}, grouped.GetGroups()[0].GetTodos()[0].GetAssigneeIds())
+	assert.Empty(t, grouped.GetGroups()[1].GetAssigneeId())
+
+	_, err = srv.GetGroupedTodos(ctx, &pb.GetGroupedTodosRequest{GroupBy: "owner"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	repo.AssertExpectations(t)
+}
+
+func TestTodoServer_MoveTodo(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+	ownTodo := &models.Todo{ID: uuid.New(), UserID: userID}
+	foreignTodo := &models.Todo{ID: uuid.New(), UserID: uuid.New()}
+	archivedProjectTodo := &models.Todo{ID: uuid.New(), UserID: userID}
+
+	tests := []struct {
+		name      string
+		req       *pb.MoveTodoRequest
+		setupMock func(repo *MockTodoRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name: "успешный перенос",
+			req:  &pb.MoveTodoRequest{Id: ownTodo.ID.String(), ProjectId: projectID.String()},
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, ownTodo.ID).Return(ownTodo, nil)
+				moved := *ownTodo
+				moved.ProjectID = &projectID
+				repo.On("MoveToProject", mock.Anything, ownTodo.ID, projectID, mock.Anything).Return([]*models.Todo{&moved}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "чужая задача",
+			req:  &pb.MoveTodoRequest{Id: foreignTodo.ID.String(), ProjectId: projectID.String()},
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, foreignTodo.ID).Return(foreignTodo, nil)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "архивный или чужой проект",
+			req:  &pb.MoveTodoRequest{Id: archivedProjectTodo.ID.String(), ProjectId: projectID.String()},
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, archivedProjectTodo.ID).Return(archivedProjectTodo, nil)
+				repo.On("MoveToProject", mock.Anything, archivedProjectTodo.ID, projectID, mock.Anything).Return(nil, repository.ErrInvalidProject)
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:      "без проекта",
+			req:       &pb.MoveTodoRequest{Id: ownTodo.ID.String()},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTodoRepository)
+			tt.setupMock(repo)
+			srv := newTodoServer(repo)
+
+			resp, err := srv.MoveTodo(authContext(t, userID), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, projectID.String(), resp.GetProjectId())
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTodoServer_GetGroupedTodos_TagCounts(t *testing.T) {
+	userID := uuid.New()
+	tag := models.TodoTag{ID: uuid.New(), Name: "home", Color: "#00ff00"}
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Status: "pending", Priority: "high", Tags: []models.TodoTag{tag}}
+
+	repo := new(MockTodoRepository)
+	repo.On("GetGroupedTodos", mock.Anything, userID, models.TodoFilter{}).Return([]models.TodoGroup{{
+		Status:    "pending",
+		Priority:  "high",
+		Count:     1,
+		Tasks:     []*models.Todo{todo},
+		TagCounts: []models.TagCount{{Tag: tag, Count: 1}},
+	}}, nil)
+	srv := newTodoServer(repo)
+
+	resp, err := srv.GetGroupedTodos(authContext(t, userID), &pb.GetGroupedTodosRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetGroups(), 1)
+	require.Len(t, resp.GetGroups()[0].GetTagCounts(), 1)
+	assert.Equal(t, tag.ID.String(), resp.GetGroups()[0].GetTagCounts()[0].GetTag().GetId())
+	assert.Equal(t, int32(1), resp.GetGroups()[0].GetTagCounts()[0].GetCount())
+}
+
+func TestTodoServer_GetSubtasks(t *testing.T) {
+	userID := uuid.New()
+	root := &models.Todo{ID: uuid.New(), UserID: userID, Progress: &models.TodoProgress{Done: 1, Total: 2}}
+	child := &models.Todo{ID: uuid.New(), UserID: userID, ParentID: &root.ID, Status: "completed"}
+	other := &models.Todo{ID: uuid.New(), UserID: userID, ParentID: &root.ID}
+	grandchild := &models.Todo{ID: uuid.New(), UserID: userID, ParentID: &other.ID}
+
+	repo := new(MockTodoRepository)
+	repo.On("GetByID", mock.Anything, root.ID).Return(root, nil)
+	repo.On("GetSubtree", mock.Anything, root.ID).Return([]*models.Todo{root, child, other, grandchild}, nil)
+	srv := newTodoServer(repo)
+
+	tree, err := srv.GetSubtasks(authContext(t, userID), &pb.GetSubtasksRequest{Id: root.ID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, root.ID.String(), tree.GetTodo().GetId())
+	assert.Equal(t, int32(1), tree.GetTodo().GetProgress().GetDone())
+	assert.Equal(t, int32(2), tree.GetTodo().GetProgress().GetTotal())
+	require.Len(t, tree.GetSubtasks(), 2)
+	assert.Equal(t, root.ID.String(), tree.GetSubtasks()[0].GetTodo().GetParentId())
+	require.Len(t, tree.GetSubtasks()[1].GetSubtasks(), 1)
+	assert.Equal(t, grandchild.ID.String(), tree.GetSubtasks()[1].GetSubtasks()[0].GetTodo().GetId())
+
+	_, err = srv.GetSubtasks(authContext(t, uuid.New()), &pb.GetSubtasksRequest{Id: root.ID.String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTodoServer_CreateTodo_InvalidParent(t *testing.T) {
+	userID := uuid.New()
+	foreignParent := &models.Todo{ID: uuid.New(), UserID: uuid.New()}
+	missingParentID := uuid.New()
+
+	repo := new(MockTodoRepository)
+	repo.On("GetByID", mock.Anything, foreignParent.ID).Return(foreignParent, nil)
+	repo.On("GetByID", mock.Anything, missingParentID).Return(nil, repository.ErrTodoNotFound)
+	policies := models.SubtaskPolicies{Complete: models.SubtaskPolicyBlock, Delete: models.SubtaskPolicyBlock}
+	srv := newTodoServer(repository.NewSubtaskTodoRepository(repo, nil, directTransactor{}, policies))
+	ctx := authContext(t, userID)
+
+	for _, parentID := range []string{foreignParent.ID.String(), missingParentID.String()} {
+		_, err := srv.CreateTodo(ctx, &pb.CreateTodoRequest{Title: "Подзадача", ParentId: parentID})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	_, err := srv.CreateTodo(ctx, &pb.CreateTodoRequest{Title: "Подзадача", ParentId: "invalid-id"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestTodoServer_UpdateTodo_ExpectedVersion(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name            string
+		expectedVersion int64
+		updateErr       error
+		wantCode        codes.Code
+		wantUpdate      bool
+	}{
+		{name: "без проверки версии", expectedVersion: 0, wantCode: codes.OK, wantUpdate: true},
+		{name: "совпадающая версия", expectedVersion: 3, wantCode: codes.OK, wantUpdate: true},
+		{name: "устаревшая версия", expectedVersion: 2, wantCode: codes.Aborted},
+		{
+			name:            "изменение после чтения",
+			expectedVersion: 3,
+			updateErr:       repository.ErrTodoVersionMismatch,
+			wantCode:        codes.Aborted,
+			wantUpdate:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Отчет", Status: "pending", Version: 3}
+			repo := new(MockTodoRepository)
+			repo.On("GetByID", mock.Anything, todo.ID).Return(todo, nil)
+			repo.On("Update", mock.Anything, mock.MatchedBy(func(updated *models.Todo) bool {
+				return updated.Version == 3
+			})).Return(tt.updateErr)
+			srv := newTodoServer(repo)
+
+			resp, err := srv.UpdateTodo(authContext(t, userID), &pb.UpdateTodoRequest{
+				Id:              todo.ID.String(),
+				Title:           "Квартальный отчет",
+				ExpectedVersion: tt.expectedVersion,
+			})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, int64(3), resp.GetVersion())
+			}
+			if tt.wantUpdate {
+				repo.AssertCalled(t, "Update", mock.Anything, mock.Anything)
+			} else {
+				repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestTodoServer_UpdateTodo_UpdateMask(t *testing.T) {
+	userID := uuid.New()
+	parentID := uuid.New()
+	dueDate := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		req      *pb.UpdateTodoRequest
+		wantCode codes.Code
+		check    func(t *testing.T, todo *models.Todo)
+	}{
+		{
+			name: "пустые значения из маски очищают поля",
+			req: &pb.UpdateTodoRequest{
+				Description: "",
+				UpdateMask:  &fieldmaskpb.FieldMask{Paths: []string{"description", "due_date", "parent_id"}},
+			},
+			wantCode: codes.OK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Empty(t, todo.Description)
+				assert.True(t, todo.DueDate.IsZero())
+				assert.Nil(t, todo.ParentID)
+				assert.Equal(t, "Отчет", todo.Title)
+				require.NotNil(t, todo.Recurrence)
+			},
+		},
+		{
+			name: "поля вне маски не меняются",
+			req: &pb.UpdateTodoRequest{
+				Title:      "Квартальный отчет",
+				Priority:   "high",
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"priority"}},
+			},
+			wantCode: codes.OK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Equal(t, "Отчет", todo.Title)
+				assert.Equal(t, "high", todo.Priority)
+				assert.True(t, dueDate.Equal(todo.DueDate))
+			},
+		},
+		{
+			name: "пустое правило завершает серию",
+			req: &pb.UpdateTodoRequest{
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"recurrence"}},
+			},
+			wantCode: codes.OK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Nil(t, todo.Recurrence)
+			},
+		},
+		{
+			name: "часовой пояс сохраняет правило",
+			req: &pb.UpdateTodoRequest{
+				Timezone:   "UTC",
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"timezone"}},
+			},
+			wantCode: codes.OK,
+			check: func(t *testing.T, todo *models.Todo) {
+				require.NotNil(t, todo.Recurrence)
+				assert.Equal(t, "FREQ=WEEKLY", todo.Recurrence.Rule)
+				assert.Equal(t, "UTC", todo.Recurrence.Timezone)
+			},
+		},
+		{
+			name:     "пустое название",
+			req:      &pb.UpdateTodoRequest{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "неизвестное поле",
+			req:      &pb.UpdateTodoRequest{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"user_id"}}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := &models.Todo{
+				ID:          uuid.New(),
+				UserID:      userID,
+				Title:       "Отчет",
+				Description: "Квартальный",
+				Status:      "pending",
+				Priority:    "medium",
+				DueDate:     dueDate,
+				ParentID:    &parentID,
+				Recurrence:  &models.TodoRecurrence{Rule: "FREQ=WEEKLY", Timezone: "Europe/Moscow", SeriesID: uuid.New()},
+			}
+			repo := new(MockTodoRepository)
+			repo.On("GetByID", mock.Anything, todo.ID).Return(todo, nil)
+			var updated *models.Todo
+			repo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				updated = args.Get(1).(*models.Todo)
+			}).Return(nil)
+			srv := newTodoServer(repo)
+
+			tt.req.Id = todo.ID.String()
+			_, err := srv.UpdateTodo(authContext(t, userID), tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			require.NotNil(t, updated)
+			tt.check(t, updated)
+		})
+	}
+}
+
+func TestTodoServer_UpdateTodo_MoveUnderDescendant(t *testing.T) {
+	userID := uuid.New()
+	parent := &models.Todo{ID: uuid.New(), UserID: userID, Status: "pending"}
+	child := &models.Todo{ID: uuid.New(), UserID: userID, ParentID: &parent.ID, Status: "pending"}
+
+	repo := new(MockTodoRepository)
+	repo.On("GetByID", mock.Anything, parent.ID).Return(parent, nil)
+	repo.On("GetByID", mock.Anything, child.ID).Return(child, nil)
+	policies := models.SubtaskPolicies{Complete: models.SubtaskPolicyBlock, Delete: models.SubtaskPolicyBlock}
+	srv := newTodoServer(repository.NewSubtaskTodoRepository(repo, nil, directTransactor{}, policies))
+
+	_, err := srv.UpdateTodo(authContext(t, userID), &pb.UpdateTodoRequest{Id: parent.ID.String(), ParentId: child.ID.String()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// txKey ключ контекста, которым recordingTransactor помечает транзакцию
+type txKey struct{}
+
+// recordingTransactor помечает контекст транзакции и запоминает ее откат
+type recordingTransactor struct {
+	rolledBack bool
+}
+
+func (t *recordingTransactor) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(context.WithValue(ctx, txKey{}, true))
+	t.rolledBack = err != nil
+	return err
+}
+
+// inTx проверяет, что вызов выполняется в транзакции recordingTransactor
+func inTx(ctx context.Context) bool {
+	return ctx.Value(txKey{}) != nil
+}
+
+func TestTodoServer_SubtaskPolicies(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		policy    models.SubtaskPolicy
+		call      func(srv *TodoServer, ctx context.Context, id uuid.UUID) error
+		setupMock func(repo *MockTodoRepository, id uuid.UUID)
+		wantCode  codes.Code
+		// rolledBack транзакция каскадного завершения откатилась
+		rolledBack bool
+	}{
+		{
+			name:   "завершение с открытыми подзадачами запрещено",
+			policy: models.SubtaskPolicyBlock,
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) error {
+				_, err := srv.UpdateTodo(ctx, &pb.UpdateTodoRequest{Id: id.String(), Status: "completed"})
+				return err
+			},
+			setupMock: func(repo *MockTodoRepository, id uuid.UUID) {
+				repo.On("CountOpenSubtasks", mock.Anything, id).Return(2, nil)
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:   "каскадное завершение",
+			policy: models.SubtaskPolicyCascade,
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) error {
+				_, err := srv.UpdateTodo(ctx, &pb.UpdateTodoRequest{Id: id.String(), Status: "completed"})
+				return err
+			},
+			setupMock: func(repo *MockTodoRepository, id uuid.UUID) {
+				repo.On("CountOpenSubtasks", mock.Anything, id).Return(2, nil)
+				repo.On("CompleteSubtasks", mock.MatchedBy(inTx), id, mock.Anything).Return([]*models.Todo{}, nil)
+				repo.On("Update", mock.MatchedBy(inTx), mock.Anything).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:   "сбой обновления задачи откатывает завершение подзадач",
+			policy: models.SubtaskPolicyCascade,
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) error {
+				_, err := srv.UpdateTodo(ctx, &pb.UpdateTodoRequest{Id: id.String(), Status: "completed"})
+				return err
+			},
+			setupMock: func(repo *MockTodoRepository, id uuid.UUID) {
+				repo.On("CountOpenSubtasks", mock.Anything, id).Return(2, nil)
+				repo.On("CompleteSubtasks", mock.MatchedBy(inTx), id, mock.Anything).Return([]*models.Todo{}, nil)
+				repo.On("Update", mock.MatchedBy(inTx), mock.Anything).Return(repository.ErrTodoVersionMismatch)
+			},
+			wantCode:   codes.Aborted,
+			rolledBack: true,
+		},
+		{
+			name:   "удаление с подзадачами запрещено",
+			policy: models.SubtaskPolicyBlock,
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) error {
+				_, err := srv.DeleteTodo(ctx, &pb.DeleteTodoRequest{Id: id.String()})
+				return err
+			},
+			setupMock: func(repo *MockTodoRepository, id uuid.UUID) {
+				repo.On("GetSubtree", mock.Anything, id).Return([]*models.Todo{{ID: id}, {ID: uuid.New(), ParentID: &id}}, nil)
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:   "каскадное удаление",
+			policy: models.SubtaskPolicyCascade,
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) error {
+				_, err := srv.DeleteTodo(ctx, &pb.DeleteTodoRequest{Id: id.String()})
+				return err
+			},
+			setupMock: func(repo *MockTodoRepository, id uuid.UUID) {
+				repo.On("Delete", mock.Anything, id, 0).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := &models.Todo{ID: uuid.New(), UserID: userID, Status: "in_progress"}
+			repo := new(MockTodoRepository)
+			repo.On("GetByID", mock.Anything, todo.ID).Return(todo, nil)
+			tt.setupMock(repo, todo.ID)
+			policies := models.SubtaskPolicies{Complete: tt.policy, Delete: tt.policy}
+			tx := &recordingTransactor{}
+			srv := newTodoServer(repository.NewSubtaskTodoRepository(repo, nil, tx, policies))
+
+			err := tt.call(srv, authContext(t, userID), todo.ID)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.rolledBack, tx.rolledBack)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTodoServer_CreateTodo_Recurrence(t *testing.T) {
+	userID := uuid.New()
+	dueDate := time.Date(2026, 3, 27, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		req       *pb.CreateTodoRequest
+		setupMock func(repo *MockTodoRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name: "новая серия",
+			req: &pb.CreateTodoRequest{
+				Title:      "Планерка",
+				Recurrence: "FREQ=WEEKLY;BYDAY=FR",
+				Timezone:   "Europe/Moscow",
+				DueDate:    timestamppb.New(dueDate),
+			},
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("CreateSeries", mock.Anything, mock.MatchedBy(func(series *models.TodoSeries) bool {
+					return series.Rule == "FREQ=WEEKLY;BYDAY=FR" && series.Timezone == "Europe/Moscow" && series.Start.Equal(dueDate)
+				})).Return(nil)
+				repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:      "некорректное правило",
+			req:       &pb.CreateTodoRequest{Title: "Планерка", Recurrence: "FREQ=HOURLY", DueDate: timestamppb.New(dueDate)},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:      "без срока",
+			req:       &pb.CreateTodoRequest{Title: "Планерка", Recurrence: "FREQ=DAILY"},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:      "неизвестный часовой пояс",
+			req:       &pb.CreateTodoRequest{Title: "Планерка", Recurrence: "FREQ=DAILY", Timezone: "Mars/Olympus", DueDate: timestamppb.New(dueDate)},
+			setupMock: func(repo *MockTodoRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTodoRepository)
+			tt.setupMock(repo)
+			srv := newTodoServer(repository.NewRecurringTodoRepository(repo))
+
+			resp, err := srv.CreateTodo(authContext(t, userID), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				require.NotNil(t, resp.GetRecurrence())
+				assert.Equal(t, dueDate, resp.GetRecurrence().GetOccurrenceDate().AsTime())
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTodoServer_RecurringCompletion(t *testing.T) {
+	userID := uuid.New()
+	loc, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	series := &models.TodoSeries{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Rule:     "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+		Timezone: "Europe/Moscow",
+		Start:    time.Date(2026, 1, 31, 10, 0, 0, 0, loc),
+		Title:    "Отчет",
+		Priority: "high",
+	}
+	// Повторения: 31 января, 31 марта, 31 мая
+	occurrence := func(date time.Time) *models.Todo {
+		return &models.Todo{
+			ID:      uuid.New(),
+			UserID:  userID,
+			Title:   series.Title,
+			Status:  "pending",
+			DueDate: date,
+			Recurrence: &models.TodoRecurrence{
+				SeriesID:       series.ID,
+				Rule:           series.Rule,
+				Timezone:       series.Timezone,
+				Start:          series.Start,
+				OccurrenceDate: date,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		todo     *models.Todo
+		call     func(srv *TodoServer, ctx context.Context, id uuid.UUID) (*pb.TodoResponse, error)
+		wantNext time.Time
+	}{
+		{
+			name: "завершение создает следующее повторение",
+			todo: occurrence(series.Start),
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) (*pb.TodoResponse, error) {
+				return srv.UpdateTodo(ctx, &pb.UpdateTodoRequest{Id: id.String(), Status: "completed"})
+			},
+			wantNext: time.Date(2026, 3, 31, 10, 0, 0, 0, loc),
+		},
+		{
+			name: "пропуск создает следующее повторение",
+			todo: occurrence(time.Date(2026, 3, 31, 10, 0, 0, 0, loc)),
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) (*pb.TodoResponse, error) {
+				return srv.SkipOccurrence(ctx, &pb.SkipOccurrenceRequest{Id: id.String()})
+			},
+			wantNext: time.Date(2026, 5, 31, 10, 0, 0, 0, loc),
+		},
+		{
+			name: "последнее повторение",
+			todo: occurrence(time.Date(2026, 5, 31, 10, 0, 0, 0, loc)),
+			call: func(srv *TodoServer, ctx context.Context, id uuid.UUID) (*pb.TodoResponse, error) {
+				return srv.UpdateTodo(ctx, &pb.UpdateTodoRequest{Id: id.String(), Status: "completed"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTodoRepository)
+			repo.On("GetByID", mock.Anything, tt.todo.ID).Return(tt.todo, nil)
+			repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+			repo.On("GetSeries", mock.Anything, series.ID).Return(series, nil)
+			if !tt.wantNext.IsZero() {
+				repo.On("CreateOccurrence", mock.Anything, mock.Anything, mock.MatchedBy(func(next *models.Todo) bool {
+					return next.Status == "pending" && next.Title == series.Title && next.Priority == series.Priority &&
+						next.DueDate.Equal(tt.wantNext) && next.Recurrence.OccurrenceDate.Equal(tt.wantNext)
+				})).Return(true, nil)
+			}
+			srv := newTodoServer(repository.NewRecurringTodoRepository(repo))
+
+			resp, err := tt.call(srv, authContext(t, userID), tt.todo.ID)
+			require.NoError(t, err)
+			assert.NotEqual(t, "pending", resp.GetStatus())
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTodoServer_ListOccurrences(t *testing.T) {
+	userID := uuid.New()
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	todo := &models.Todo{
+		ID:     uuid.New(),
+		UserID: userID,
+		Recurrence: &models.TodoRecurrence{
+			SeriesID:       uuid.New(),
+			Rule:           "FREQ=WEEKLY;INTERVAL=2",
+			Start:          start,
+			OccurrenceDate: start,
+		},
+	}
+	plainTodo := &models.Todo{ID: uuid.New(), UserID: userID}
+
+	repo := new(MockTodoRepository)
+	repo.On("GetByID", mock.Anything, todo.ID).Return(todo, nil)
+	repo.On("GetByID", mock.Anything, plainTodo.ID).Return(plainTodo, nil)
+	srv := newTodoServer(repo)
+	ctx := authContext(t, userID)
+
+	resp, err := srv.ListOccurrences(ctx, &pb.ListOccurrencesRequest{Id: todo.ID.String(), Count: 3})
+	require.NoError(t, err)
+	require.Len(t, resp.GetOccurrences(), 3)
+	assert.Equal(t, start.AddDate(0, 0, 14), resp.GetOccurrences()[0].AsTime())
+	assert.Equal(t, start.AddDate(0, 0, 42), resp.GetOccurrences()[2].AsTime())
+
+	_, err = srv.ListOccurrences(ctx, &pb.ListOccurrencesRequest{Id: plainTodo.ID.String()})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = srv.ListOccurrences(ctx, &pb.ListOccurrencesRequest{Id: todo.ID.String(), Count: maxOccurrencesCount + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+type watchTodosStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.TodoEvent
+}
+
+func (s *watchTodosStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchTodosStream) Send(event *pb.TodoEvent) error {
+	s.events <- event
+	return nil
+}
+
+func TestTodoServer_WatchTodos(t *testing.T) {
+	userID := uuid.New()
+	broker := events.NewBroker(nil, events.DefaultConfig())
+	srv := NewTodoServer(new(MockTodoRepository), nil, broker, nil)
+
+	ctx, cancel := context.WithCancel(authContext(t, userID))
+	stream := &watchTodosStream{ctx: ctx, events: make(chan *pb.TodoEvent, 1)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.WatchTodos(&pb.WatchTodosRequest{}, stream)
+	}()
+
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Задача"}
+	require.Eventually(t, func() bool {
+		// Публикуем, пока стрим не подпишется и не получит событие
+		_ = broker.Publish(context.Background(), models.NewTodoEvent(models.TodoEventCreated, todo))
+		select {
+		case event := <-stream.events:
+			assert.Equal(t, pb.TodoEventType_TODO_EVENT_TYPE_CREATED, event.GetType())
+			assert.Equal(t, todo.ID.String(), event.GetTodo().GetId())
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestTodoServer_WatchTodos_ResumeWindowExceeded(t *testing.T) {
+	broker := events.NewBroker(nil, events.DefaultConfig())
+	srv := NewTodoServer(new(MockTodoRepository), nil, broker, nil)
+	stream := &watchTodosStream{ctx: authContext(t, uuid.New())}
+
+	err := srv.WatchTodos(&pb.WatchTodosRequest{Since: timestamppb.New(time.Now().Add(-24 * time.Hour))}, stream)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
+
+func TestToTodoEvent_Comment(t *testing.T) {
+	userID := uuid.New()
+	parentID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Задача", CommentCount: 2}
+	comment := &models.Comment{
+		ID:       uuid.New(),
+		TodoID:   todo.ID,
+		UserID:   userID,
+		ParentID: &parentID,
+		Body:     "Готово, @anna",
+		Mentions: []uuid.UUID{uuid.New()},
+	}
+
+	event := toTodoEvent(models.NewCommentEvent(models.TodoEventCommentCreated, userID, todo, comment))
+	assert.Equal(t, pb.TodoEventType_TODO_EVENT_TYPE_COMMENT_CREATED, event.GetType())
+	assert.Equal(t, int32(2), event.GetTodo().GetCommentCount())
+	assert.Equal(t, comment.ID.String(), event.GetComment().GetId())
+	assert.Equal(t, parentID.String(), event.GetComment().GetParentId())
+	assert.Equal(t, []string{comment.Mentions[0].String()}, event.GetComment().GetMentionIds())
+	assert.Nil(t, event.GetComment().GetDeletedAt())
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	pb "github.com/R-eSPeCT/todo-list/api/proto/user"
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/grpc/interceptor"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// minPasswordLength минимальная длина пароля
+	minPasswordLength = 8
+	// maxUsernameLength максимальная длина имени пользователя
+	maxUsernameLength = 50
+	// tokenType тип выдаваемого access токена
+	tokenType = "Bearer"
+)
+
+// UserPublicMethods методы UserService, доступные без access токена
+var UserPublicMethods = []string{
+	pb.UserService_Register_FullMethodName,
+	pb.UserService_Login_FullMethodName,
+	pb.UserService_RefreshToken_FullMethodName,
+}
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// UserServer реализует gRPC сервис UserService.
+// Вход выдает короткоживущий access токен и refresh токен, который меняется при каждом обновлении.
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	repo          repository.UserRepository
+	jwtManager    *interceptor.JWTManager
+	refreshTokens *auth.RefreshTokenManager
+}
+
+// NewUserServer создает новый экземпляр UserServer
+func NewUserServer(repo repository.UserRepository, jwtManager *interceptor.JWTManager, refreshTokens *auth.RefreshTokenManager) *UserServer {
+	return &UserServer{
+		repo:          repo,
+		jwtManager:    jwtManager,
+		refreshTokens: refreshTokens,
+	}
+}
+
+// Register регистрирует нового пользователя
+func (s *UserServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.UserResponse, error) {
+	username := strings.TrimSpace(req.GetUsername())
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	if !emailRegexp.MatchString(req.GetEmail()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid email format")
+	}
+	if len(req.GetPassword()) < minPasswordLength {
+		return nil, status.Errorf(codes.InvalidArgument, "password must be at least %d characters long", minPasswordLength)
+	}
+
+	_, err := s.repo.GetByEmail(ctx, req.GetEmail())
+	if err == nil {
+		return nil, status.Error(codes.AlreadyExists, "user already exists")
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, status.Error(codes.Internal, "failed to check user")
+	}
+	if username != "" {
+		_, err := s.repo.GetByUsername(ctx, username)
+		if err == nil {
+			return nil, status.Error(codes.AlreadyExists, "username is already taken")
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, status.Error(codes.Internal, "failed to check user")
+		}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.GetPassword()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to hash password")
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:        uuid.New(),
+		Username:  username,
+		Email:     req.GetEmail(),
+		Password:  string(hashedPassword),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	// Email или имя могли занять одновременно с этим запросом
+	err = s.repo.Create(ctx, user)
+	if errors.Is(err, repository.ErrUserExists) {
+		return nil, status.Error(codes.AlreadyExists, "user already exists")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to create user")
+	}
+
+	return toUserResponse(user), nil
+}
+
+// Login проверяет учетные данные и выдает пару токенов, открывающую новое семейство refresh токенов
+func (s *UserServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.AuthResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	user, err := s.repo.GetByEmail(ctx, req.GetEmail())
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.GetPassword())); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+	}
+
+	refreshToken, err := s.refreshTokens.Issue(ctx, user.ID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to issue refresh token")
+	}
+
+	return s.authResponse(user, refreshToken)
+}
+
+// GetUser возвращает профиль текущего пользователя. Пустой id означает текущего пользователя.
+func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserResponse, error) {
+	userID, err := selfUserID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, userRepositoryError(err, "failed to get user")
+	}
+
+	return toUserResponse(user), nil
+}
+
+// UpdateUser обновляет имя и email текущего пользователя. Пустые поля не изменяются.
+func (s *UserServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error) {
+	userID, err := selfUserID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, userRepositoryError(err, "failed to get user")
+	}
+
+	if username := strings.TrimSpace(req.GetUsername()); username != "" && username != user.Username {
+		if err := validateUsername(username); err != nil {
+			return nil, err
+		}
+		_, err := s.repo.GetByUsername(ctx, username)
+		if err == nil {
+			return nil, status.Error(codes.AlreadyExists, "username is already taken")
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, status.Error(codes.Internal, "failed to check user")
+		}
+		user.Username = username
+	}
+
+	if email := req.GetEmail(); email != "" && email != user.Email {
+		if !emailRegexp.MatchString(email) {
+			return nil, status.Error(codes.InvalidArgument, "invalid email format")
+		}
+		_, err := s.repo.GetByEmail(ctx, email)
+		if err == nil {
+			return nil, status.Error(codes.AlreadyExists, "email is already in use")
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, status.Error(codes.Internal, "failed to check user")
+		}
+		user.Email = email
+	}
+
+	// Email или имя могли занять одновременно с этим запросом
+	user.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, userRepositoryError(err, "failed to update user")
+	}
+
+	return toUserResponse(user), nil
+}
+
+// DeleteUser удаляет текущего пользователя вместе с его refresh токенами
+func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	userID, err := selfUserID(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		return nil, userRepositoryError(err, "failed to delete user")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// RefreshToken обменивает refresh токен на новую пару токенов.
+// Повторное предъявление уже замененного токена отзывает все токены этого входа.
+func (s *UserServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.AuthResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	refreshToken, userID, err := s.refreshTokens.Rotate(ctx, req.GetRefreshToken())
+	switch {
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		return nil, status.Error(codes.Unauthenticated, "refresh token has already been used, please log in again")
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		return nil, status.Error(codes.Unauthenticated, "refresh token is invalid or expired")
+	case err != nil:
+		return nil, status.Error(codes.Internal, "failed to refresh token")
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, status.Error(codes.Unauthenticated, "user no longer exists")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+
+	return s.authResponse(user, refreshToken)
+}
+
+// authResponse выдает access токен и собирает ответ с парой токенов
+func (s *UserServer) authResponse(user *models.User, refreshToken string) (*pb.AuthResponse, error) {
+	accessToken, err := s.jwtManager.Generate(user.ID.String())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate access token")
+	}
+
+	return &pb.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenType,
+		ExpiresIn:    int64(s.jwtManager.TokenDuration().Seconds()),
+		User:         toUserResponse(user),
+	}, nil
+}
+
+// selfUserID возвращает ID текущего пользователя и проверяет, что запрос касается его самого
+func selfUserID(ctx context.Context, id string) (uuid.UUID, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if id == "" {
+		return userID, nil
+	}
+
+	requestedID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid user ID format")
+	}
+	if requestedID != userID {
+		return uuid.Nil, status.Error(codes.PermissionDenied, "access to another user is denied")
+	}
+
+	return userID, nil
+}
+
+// userRepositoryError преобразует ошибку репозитория пользователей в gRPC статус
+func userRepositoryError(err error, msg string) error {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return status.Error(codes.NotFound, "user not found")
+	}
+	if errors.Is(err, repository.ErrUserExists) {
+		return status.Error(codes.AlreadyExists, "user already exists")
+	}
+	return status.Error(codes.Internal, msg)
+}
+
+// validateUsername проверяет имя пользователя; пустое имя допустимо
+func validateUsername(username string) error {
+	if len(username) > maxUsernameLength {
+		return status.Errorf(codes.InvalidArgument, "username must be at most %d characters long", maxUsernameLength)
+	}
+	return nil
+}
+
+// toUserResponse преобразует модель пользователя в gRPC сообщение
+func toUserResponse(user *models.User) *pb.UserResponse {
+	return &pb.UserResponse{
+		Id:        user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
@@ -0,0 +1,359 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/R-eSPeCT/todo-list/api/proto/user"
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/grpc/interceptor"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, id uuid.UUID, next *models.RefreshToken) error {
+	args := m.Called(ctx, id, next)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func newTestUserServer(userRepo *MockUserRepository, tokenRepo *MockRefreshTokenRepository) *UserServer {
+	return NewUserServer(
+		userRepo,
+		interceptor.NewJWTManager(testSecret, time.Minute),
+		auth.NewRefreshTokenManager(tokenRepo, time.Hour),
+	)
+}
+
+func TestUserServer_Register(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *pb.RegisterRequest
+		mockSetup func(*MockUserRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name: "успешная регистрация",
+			req:  &pb.RegisterRequest{Username: "john", Email: "john@example.com", Password: "password123"},
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "john@example.com").Return(nil, repository.ErrUserNotFound)
+				repo.On("GetByUsername", mock.Anything, "john").Return(nil, repository.ErrUserNotFound)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "имя пользователя занято",
+			req:  &pb.RegisterRequest{Username: "john", Email: "john@example.com", Password: "password123"},
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "john@example.com").Return(nil, repository.ErrUserNotFound)
+				repo.On("GetByUsername", mock.Anything, "john").Return(&models.User{ID: uuid.New(), Username: "John"}, nil)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name: "имя заняли одновременно",
+			req:  &pb.RegisterRequest{Username: "john", Email: "john@example.com", Password: "password123"},
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "john@example.com").Return(nil, repository.ErrUserNotFound)
+				repo.On("GetByUsername", mock.Anything, "john").Return(nil, repository.ErrUserNotFound)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(repository.ErrUserExists)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name:      "неверный email",
+			req:       &pb.RegisterRequest{Email: "invalid-email", Password: "password123"},
+			mockSetup: func(repo *MockUserRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:      "короткий пароль",
+			req:       &pb.RegisterRequest{Email: "john@example.com", Password: "short"},
+			mockSetup: func(repo *MockUserRepository) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name: "пользователь уже существует",
+			req:  &pb.RegisterRequest{Email: "john@example.com", Password: "password123"},
+			mockSetup: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "john@example.com").Return(&models.User{ID: uuid.New()}, nil)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(MockUserRepository)
+			tt.mockSetup(userRepo)
+			s := newTestUserServer(userRepo, new(MockRefreshTokenRepository))
+
+			resp, err := s.Register(context.Background(), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, tt.req.Username, resp.Username)
+				assert.Equal(t, tt.req.Email, resp.Email)
+			}
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServer_Login(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Password: string(hashedPassword)}
+
+	t.Run("успешный вход", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.UserID == user.ID && token.FamilyID != uuid.Nil && token.TokenHash != ""
+		})).Return(nil)
+
+		resp, err := newTestUserServer(userRepo, tokenRepo).Login(context.Background(),
+			&pb.LoginRequest{Email: user.Email, Password: "password123"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.AccessToken)
+		assert.NotEmpty(t, resp.RefreshToken)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.Equal(t, int64(60), resp.ExpiresIn)
+		assert.Equal(t, user.ID.String(), resp.User.Id)
+
+		// Сохраняется только хеш refresh токена
+		saved := tokenRepo.Calls[0].Arguments.Get(1).(*models.RefreshToken)
+		assert.NotEqual(t, resp.RefreshToken, saved.TokenHash)
+	})
+
+	t.Run("неверный пароль", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		_, err := newTestUserServer(userRepo, new(MockRefreshTokenRepository)).Login(context.Background(),
+			&pb.LoginRequest{Email: user.Email, Password: "wrong-password"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestUserServer_RefreshToken(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+	current := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	userRepo := new(MockUserRepository)
+	tokenRepo := new(MockRefreshTokenRepository)
+	tokenRepo.On("GetByHash", mock.Anything, mock.Anything).Return(current, nil)
+	tokenRepo.On("Rotate", mock.Anything, current.ID, mock.MatchedBy(func(next *models.RefreshToken) bool {
+		return next.FamilyID == current.FamilyID && next.UserID == user.ID && next.ID != current.ID
+	})).Return(nil)
+	userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	resp, err := newTestUserServer(userRepo, tokenRepo).RefreshToken(context.Background(),
+		&pb.RefreshTokenRequest{RefreshToken: "old-refresh-token"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.NotEqual(t, "old-refresh-token", resp.RefreshToken)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestUserServer_RefreshToken_ReuseRevokesFamily(t *testing.T) {
+	rotatedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		mockSetup func(*MockRefreshTokenRepository, *models.RefreshToken)
+	}{
+		{
+			name: "токен уже заменен",
+			mockSetup: func(repo *MockRefreshTokenRepository, token *models.RefreshToken) {
+				rotated := *token
+				rotated.RotatedAt = &rotatedAt
+				repo.On("GetByHash", mock.Anything, mock.Anything).Return(&rotated, nil)
+			},
+		},
+		{
+			name: "токен заменен параллельным запросом",
+			mockSetup: func(repo *MockRefreshTokenRepository, token *models.RefreshToken) {
+				repo.On("GetByHash", mock.Anything, mock.Anything).Return(token, nil)
+				repo.On("Rotate", mock.Anything, token.ID, mock.Anything).Return(repository.ErrRefreshTokenInactive)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &models.RefreshToken{
+				ID:        uuid.New(),
+				UserID:    uuid.New(),
+				FamilyID:  uuid.New(),
+				ExpiresAt: time.Now().Add(time.Hour),
+			}
+			tokenRepo := new(MockRefreshTokenRepository)
+			tt.mockSetup(tokenRepo, token)
+			tokenRepo.On("RevokeFamily", mock.Anything, token.FamilyID).Return(nil)
+
+			_, err := newTestUserServer(new(MockUserRepository), tokenRepo).RefreshToken(context.Background(),
+				&pb.RefreshTokenRequest{RefreshToken: "stolen-refresh-token"})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+			tokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServer_GetUser_OtherUser(t *testing.T) {
+	s := newTestUserServer(new(MockUserRepository), new(MockRefreshTokenRepository))
+
+	_, err := s.GetUser(authContext(t, uuid.New()), &pb.GetUserRequest{Id: uuid.New().String()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUserServer_UpdateUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		req       *pb.UpdateUserRequest
+		setupMock func(repo *MockUserRepository)
+		wantCode  codes.Code
+	}{
+		{
+			name: "новое имя",
+			req:  &pb.UpdateUserRequest{Username: "anna"},
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByUsername", mock.Anything, "anna").Return(nil, repository.ErrUserNotFound)
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "текущее имя не проверяется",
+			req:  &pb.UpdateUserRequest{Username: "boris"},
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "имя занято",
+			req:  &pb.UpdateUserRequest{Username: "anna"},
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByUsername", mock.Anything, "anna").Return(&models.User{ID: uuid.New(), Username: "anna"}, nil)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name: "имя заняли одновременно с запросом",
+			req:  &pb.UpdateUserRequest{Username: "anna"},
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByUsername", mock.Anything, "anna").Return(nil, repository.ErrUserNotFound)
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(repository.ErrUserExists)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name: "email заняли одновременно с запросом",
+			req:  &pb.UpdateUserRequest{Email: "anna@example.com"},
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "anna@example.com").Return(nil, repository.ErrUserNotFound)
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(repository.ErrUserExists)
+			},
+			wantCode: codes.AlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockUserRepository)
+			repo.On("GetByID", mock.Anything, userID).Return(&models.User{ID: userID, Username: "boris", Email: "boris@example.com"}, nil)
+			tt.setupMock(repo)
+			s := newTestUserServer(repo, new(MockRefreshTokenRepository))
+
+			_, err := s.UpdateUser(authContext(t, userID), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxAssigneesPerRequest максимальное число исполнителей, назначаемых одним запросом
+const maxAssigneesPerRequest = 20
+
+// AssignmentHandler обрабатывает HTTP-запросы к исполнителям задач. Должен вызываться после AuthMiddleware.
+type AssignmentHandler struct {
+	assignments services.AssignmentService
+}
+
+// NewAssignmentHandler создает новый экземпляр AssignmentHandler
+func NewAssignmentHandler(assignments services.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{assignments: assignments}
+}
+
+// AssignTodo назначает исполнителями задачи :id пользователей user_ids и возвращает задачу
+func (h *AssignmentHandler) AssignTodo(c *fiber.Ctx) error {
+	userID, todoID, ok, err := todoRequestIDs(c)
+	if !ok {
+		return err
+	}
+
+	var input struct {
+		UserIDs []uuid.UUID `json:"user_ids"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if len(input.UserIDs) == 0 || len(input.UserIDs) > maxAssigneesPerRequest {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Expected from 1 to 20 user IDs",
+		})
+	}
+
+	todo, err := h.assignments.Assign(c.Context(), userID, todoID, input.UserIDs)
+	if err != nil {
+		return assignmentServiceError(c, err, "Failed to assign todo")
+	}
+	return c.JSON(todo)
+}
+
+// UnassignTodo снимает исполнителя :userId с задачи :id и возвращает задачу.
+// Исполнитель может снять себя сам.
+func (h *AssignmentHandler) UnassignTodo(c *fiber.Ctx) error {
+	userID, todoID, ok, err := todoRequestIDs(c)
+	if !ok {
+		return err
+	}
+
+	assigneeID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID format",
+		})
+	}
+
+	todo, err := h.assignments.Unassign(c.Context(), userID, todoID, assigneeID)
+	if err != nil {
+		return assignmentServiceError(c, err, "Failed to unassign todo")
+	}
+	return c.JSON(todo)
+}
+
+// todoRequestIDs возвращает ID текущего пользователя и задачи :id.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func todoRequestIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool, error) {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+	return userID, todoID, true, nil
+}
+
+// assignmentServiceError преобразует ошибку AssignmentService в HTTP-ответ
+func assignmentServiceError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, services.ErrAssigneeNoAccess):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Assignee has no access to the todo",
+		})
+	case errors.Is(err, repository.ErrAssigneeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Assignee not found",
+		})
+	}
+	return todoRepositoryError(c, err, msg)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assignmentServiceStub запоминает назначенных и снятых исполнителей
+type assignmentServiceStub struct {
+	err        error
+	assigned   []uuid.UUID
+	unassigned []uuid.UUID
+}
+
+func (s *assignmentServiceStub) Assign(ctx context.Context, userID, todoID uuid.UUID, assigneeIDs []uuid.UUID) (*models.Todo, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.assigned = append(s.assigned, assigneeIDs...)
+	return &models.Todo{ID: todoID, Assignees: assigneeIDs}, nil
+}
+
+func (s *assignmentServiceStub) Unassign(ctx context.Context, userID, todoID, assigneeID uuid.UUID) (*models.Todo, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.unassigned = append(s.unassigned, assigneeID)
+	return &models.Todo{ID: todoID}, nil
+}
+
+var _ services.AssignmentService = (*assignmentServiceStub)(nil)
+
+func setupAssignmentApp(assignments *assignmentServiceStub) *fiber.App {
+	h := NewAssignmentHandler(assignments)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New().String())
+		return c.Next()
+	})
+	app.Post("/api/todos/:id/assignees", h.AssignTodo)
+	app.Delete("/api/todos/:id/assignees/:userId", h.UnassignTodo)
+	return app
+}
+
+func TestAssignmentHandler_AssignTodo(t *testing.T) {
+	assigneeID := uuid.New()
+
+	tests := []struct {
+		name       string
+		todoID     string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "назначение исполнителя",
+			todoID:     uuid.New().String(),
+			body:       `{"user_ids":["` + assigneeID.String() + `"]}`,
+			wantStatus: fiber.StatusOK,
+		},
+		{
+			name:       "неверный ID задачи",
+			todoID:     "abc",
+			body:       `{"user_ids":["` + assigneeID.String() + `"]}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "пустой список исполнителей",
+			todoID:     uuid.New().String(),
+			body:       `{"user_ids":[]}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "неверный ID исполнителя",
+			todoID:     uuid.New().String(),
+			body:       `{"user_ids":["abc"]}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "исполнитель без доступа",
+			todoID:     uuid.New().String(),
+			body:       `{"user_ids":["` + assigneeID.String() + `"]}`,
+			err:        services.ErrAssigneeNoAccess,
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:       "нет права изменять задачу",
+			todoID:     uuid.New().String(),
+			body:       `{"user_ids":["` + assigneeID.String() + `"]}`,
+			err:        repository.ErrTodoNotFound,
+			wantStatus: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments := &assignmentServiceStub{err: tt.err}
+			app := setupAssignmentApp(assignments)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/"+tt.todoID+"/assignees", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus == fiber.StatusOK {
+				assert.Equal(t, []uuid.UUID{assigneeID}, assignments.assigned)
+			}
+		})
+	}
+}
+
+func TestAssignmentHandler_UnassignTodo(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		err        error
+		wantStatus int
+	}{
+		{name: "снятие исполнителя", userID: uuid.New().String(), wantStatus: fiber.StatusOK},
+		{name: "неверный ID исполнителя", userID: "abc", wantStatus: fiber.StatusBadRequest},
+		{name: "исполнитель не назначен", userID: uuid.New().String(), err: repository.ErrAssigneeNotFound, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupAssignmentApp(&assignmentServiceStub{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/todos/"+uuid.New().String()+"/assignees/"+tt.userID, nil)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// uploadBodyOverhead запас на заголовки и границы multipart/form-data сверх размера файла
+const uploadBodyOverhead = 1024 * 1024
+
+// AttachmentHandler обрабатывает HTTP-запросы к вложениям задач. Должен вызываться после AuthMiddleware.
+type AttachmentHandler struct {
+	attachments services.AttachmentService
+}
+
+// NewAttachmentHandler создает новый экземпляр AttachmentHandler
+func NewAttachmentHandler(attachments services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachments: attachments}
+}
+
+// AttachmentUploadBodyLimit возвращает обработчик fasthttp.Server.HeaderReceived, который разрешает
+// загрузке вложения POST /api/todos/:id/attachments тело с файлом до maxSize байт. Тело остальных
+// запросов ограничено BodyLimit приложения.
+func AttachmentUploadBodyLimit(maxSize int) func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	return func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		if !header.IsPost() || !isAttachmentUploadPath(string(header.RequestURI())) {
+			return fasthttp.RequestConfig{}
+		}
+		return fasthttp.RequestConfig{MaxRequestBodySize: maxSize + uploadBodyOverhead}
+	}
+}
+
+// isAttachmentUploadPath проверяет, что запрос направлен на /api/todos/:id/attachments
+func isAttachmentUploadPath(uri string) bool {
+	path, _, _ := strings.Cut(uri, "?")
+	id, ok := strings.CutPrefix(strings.TrimSuffix(path, "/"), "/api/todos/")
+	if !ok {
+		return false
+	}
+	id, ok = strings.CutSuffix(id, "/attachments")
+	return ok && id != "" && !strings.Contains(id, "/")
+}
+
+// UploadAttachment прикрепляет к задаче :id файл из поля file multipart/form-data запроса
+func (h *AttachmentHandler) UploadAttachment(c *fiber.Ctx) error {
+	userID, todoID, ok, err := todoRequestIDs(c)
+	if !ok {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File is required",
+		})
+	}
+	if header.Size == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File is empty",
+		})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read file",
+		})
+	}
+	defer file.Close()
+
+	attachment, err := h.attachments.Upload(c.Context(), userID, todoID, header.Filename, header.Size, file)
+	if err != nil {
+		return attachmentServiceError(c, err, "Failed to upload attachment")
+	}
+	return c.Status(fiber.StatusCreated).JSON(attachment)
+}
+
+// GetAttachments возвращает вложения задачи :id со ссылками на скачивание
+func (h *AttachmentHandler) GetAttachments(c *fiber.Ctx) error {
+	userID, todoID, ok, err := todoRequestIDs(c)
+	if !ok {
+		return err
+	}
+
+	attachments, err := h.attachments.List(c.Context(), userID, todoID)
+	if err != nil {
+		return attachmentServiceError(c, err, "Failed to get attachments")
+	}
+	return c.JSON(attachments)
+}
+
+// DownloadAttachment перенаправляет на новую подписанную ссылку на скачивание вложения :id
+func (h *AttachmentHandler) DownloadAttachment(c *fiber.Ctx) error {
+	userID, attachmentID, ok, err := attachmentIDs(c)
+	if !ok {
+		return err
+	}
+
+	attachment, err := h.attachments.Get(c.Context(), userID, attachmentID)
+	if err != nil {
+		return attachmentServiceError(c, err, "Failed to get attachment")
+	}
+	return c.Redirect(attachment.URL, fiber.StatusFound)
+}
+
+// DeleteAttachment удаляет вложение :id вместе с содержимым
+func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	userID, attachmentID, ok, err := attachmentIDs(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.attachments.Delete(c.Context(), userID, attachmentID); err != nil {
+		return attachmentServiceError(c, err, "Failed to delete attachment")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// FileHandler отдает файлы локального хранилища по подписанным ссылкам. Подпись заменяет
+// аутентификацию, поэтому обработчик вызывается без AuthMiddleware.
+type FileHandler struct {
+	store *storage.LocalStore
+}
+
+// NewFileHandler создает новый экземпляр FileHandler
+func NewFileHandler(store *storage.LocalStore) *FileHandler {
+	return &FileHandler{store: store}
+}
+
+// DownloadFile отдает файл с ключом из пути после storage.LocalFilesPath, если подпись ссылки
+// верна и срок ее действия не истек
+func (h *FileHandler) DownloadFile(c *fiber.Ctx) error {
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Invalid or expired link",
+		})
+	}
+
+	file, opts, err := h.store.Open(c.Params("*"), query)
+	if errors.Is(err, storage.ErrInvalidSignature) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Invalid or expired link",
+		})
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "File not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read file",
+		})
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read file",
+		})
+	}
+
+	// Файл всегда скачивается, а не открывается в браузере, чтобы загруженный HTML не выполнялся
+	// в origin API
+	c.Set(fiber.HeaderContentType, opts.ContentType)
+	c.Set(fiber.HeaderContentDisposition, storage.ContentDisposition(opts.Filename))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.SendStream(file, int(info.Size()))
+}
+
+// attachmentIDs возвращает ID текущего пользователя и вложения :id.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func attachmentIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool, error) {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	attachmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid attachment ID format",
+		})
+	}
+	return userID, attachmentID, true, nil
+}
+
+// attachmentServiceError преобразует ошибку AttachmentService в HTTP-ответ
+func attachmentServiceError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrAttachmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Attachment not found",
+		})
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "File is too large",
+		})
+	case errors.Is(err, services.ErrAttachmentTypeNotAllowed):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "File type is not allowed",
+		})
+	case errors.Is(err, repository.ErrStorageQuotaExceeded):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Storage quota exceeded",
+		})
+	}
+	return todoRepositoryError(c, err, msg)
+}
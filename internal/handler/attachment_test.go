@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// attachmentServiceStub запоминает загруженные файлы
+type attachmentServiceStub struct {
+	err      error
+	uploaded map[string]string
+}
+
+func (s *attachmentServiceStub) Upload(ctx context.Context, userID, todoID uuid.UUID, filename string, size int64, content io.Reader) (*models.Attachment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	s.uploaded[filename] = string(body)
+	return &models.Attachment{ID: uuid.New(), TodoID: todoID, UserID: userID, Filename: filename, Size: size}, nil
+}
+
+func (s *attachmentServiceStub) List(ctx context.Context, userID, todoID uuid.UUID) ([]*models.Attachment, error) {
+	return []*models.Attachment{}, s.err
+}
+
+func (s *attachmentServiceStub) Get(ctx context.Context, userID, id uuid.UUID) (*models.Attachment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Attachment{ID: id, URL: "https://files.example.com/attachments/" + id.String()}, nil
+}
+
+func (s *attachmentServiceStub) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	return s.err
+}
+
+var _ services.AttachmentService = (*attachmentServiceStub)(nil)
+
+func setupAttachmentApp(attachments *attachmentServiceStub) *fiber.App {
+	h := NewAttachmentHandler(attachments)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New().String())
+		return c.Next()
+	})
+	app.Post("/api/todos/:id/attachments", h.UploadAttachment)
+	app.Get("/api/attachments/:id/download", h.DownloadAttachment)
+	app.Delete("/api/attachments/:id", h.DeleteAttachment)
+	return app
+}
+
+// multipartBody формирует тело multipart/form-data с файлом в поле field
+func multipartBody(t *testing.T, field, filename, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestAttachmentHandler_UploadAttachment(t *testing.T) {
+	tests := []struct {
+		name       string
+		todoID     string
+		field      string
+		content    string
+		err        error
+		wantStatus int
+	}{
+		{name: "загрузка файла", todoID: uuid.New().String(), field: "file", content: "отчет", wantStatus: fiber.StatusCreated},
+		{name: "нет поля file", todoID: uuid.New().String(), field: "document", content: "отчет", wantStatus: fiber.StatusBadRequest},
+		{name: "пустой файл", todoID: uuid.New().String(), field: "file", wantStatus: fiber.StatusBadRequest},
+		{name: "неверный ID задачи", todoID: "abc", field: "file", content: "отчет", wantStatus: fiber.StatusBadRequest},
+		{name: "файл слишком большой", todoID: uuid.New().String(), field: "file", content: "отчет", err: services.ErrAttachmentTooLarge, wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "недопустимый тип", todoID: uuid.New().String(), field: "file", content: "отчет", err: services.ErrAttachmentTypeNotAllowed, wantStatus: fiber.StatusUnsupportedMediaType},
+		{name: "квота исчерпана", todoID: uuid.New().String(), field: "file", content: "отчет", err: repository.ErrStorageQuotaExceeded, wantStatus: fiber.StatusForbidden},
+		{name: "задача недоступна", todoID: uuid.New().String(), field: "file", content: "отчет", err: repository.ErrTodoNotFound, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attachments := &attachmentServiceStub{err: tt.err, uploaded: map[string]string{}}
+			app := setupAttachmentApp(attachments)
+
+			body, contentType := multipartBody(t, tt.field, "report.txt", tt.content)
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/"+tt.todoID+"/attachments", body)
+			req.Header.Set("Content-Type", contentType)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus == fiber.StatusCreated {
+				assert.Equal(t, map[string]string{"report.txt": tt.content}, attachments.uploaded)
+			}
+		})
+	}
+}
+
+func TestAttachmentUploadBodyLimit(t *testing.T) {
+	const maxSize = 8 * 1024
+	todoPath := "/api/todos/" + uuid.New().String()
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		size         int
+		wantTooLarge bool
+	}{
+		{name: "вложение больше лимита приложения", method: http.MethodPost, path: todoPath + "/attachments", size: maxSize},
+		{name: "вложение больше своего лимита", method: http.MethodPost, path: todoPath + "/attachments", size: maxSize + uploadBodyOverhead, wantTooLarge: true},
+		{name: "другой запрос ограничен лимитом приложения", method: http.MethodPut, path: todoPath, size: maxSize, wantTooLarge: true},
+		{name: "путь вложения другой задачи", method: http.MethodPost, path: todoPath + "/subtasks/attachments", size: maxSize, wantTooLarge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attachments := &attachmentServiceStub{uploaded: map[string]string{}}
+			app := fiber.New(fiber.Config{BodyLimit: 1024})
+			app.Server().HeaderReceived = AttachmentUploadBodyLimit(maxSize)
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("userID", uuid.New().String())
+				return c.Next()
+			})
+			app.Post("/api/todos/:id/attachments", NewAttachmentHandler(attachments).UploadAttachment)
+			app.All("/api/*", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNoContent)
+			})
+
+			body, contentType := multipartBody(t, "file", "report.txt", strings.Repeat("a", tt.size))
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			req.Header.Set("Content-Type", contentType)
+			resp, err := app.Test(req)
+			// Слишком большое тело отклоняет сервер до вызова обработчиков
+			if tt.wantTooLarge {
+				assert.ErrorIs(t, err, fasthttp.ErrBodyTooLarge)
+				assert.Empty(t, attachments.uploaded)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		})
+	}
+}
+
+func TestAttachmentHandler_DownloadAndDelete(t *testing.T) {
+	attachmentID := uuid.New().String()
+
+	tests := []struct {
+		name         string
+		method       string
+		attachmentID string
+		err          error
+		wantStatus   int
+	}{
+		{name: "перенаправление на ссылку", method: http.MethodGet, attachmentID: attachmentID, wantStatus: fiber.StatusFound},
+		{name: "вложение недоступно", method: http.MethodGet, attachmentID: attachmentID, err: repository.ErrAttachmentNotFound, wantStatus: fiber.StatusNotFound},
+		{name: "неверный ID вложения", method: http.MethodGet, attachmentID: "abc", wantStatus: fiber.StatusBadRequest},
+		{name: "удаление", method: http.MethodDelete, attachmentID: attachmentID, wantStatus: fiber.StatusNoContent},
+		{name: "удаление недоступного вложения", method: http.MethodDelete, attachmentID: attachmentID, err: repository.ErrAttachmentNotFound, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setupAttachmentApp(&attachmentServiceStub{err: tt.err})
+
+			path := "/api/attachments/" + tt.attachmentID
+			if tt.method == http.MethodGet {
+				path += "/download"
+			}
+			resp, err := app.Test(httptest.NewRequest(tt.method, path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus == fiber.StatusFound {
+				assert.Equal(t, "https://files.example.com/attachments/"+attachmentID, resp.Header.Get("Location"))
+			}
+		})
+	}
+}
+
+func TestFileHandler_DownloadFile(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost:3000", "secret")
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "attachments/a/b", strings.NewReader("<html>x</html>"), 14, "text/plain"))
+
+	app := fiber.New()
+	app.Get(storage.LocalFilesPath+"*", NewFileHandler(store).DownloadFile)
+
+	link, err := store.SignedURL(ctx, "attachments/a/b", storage.DownloadOptions{
+		Filename: "page.html", ContentType: "text/plain", Expires: time.Minute,
+	})
+	require.NoError(t, err)
+	missing, err := store.SignedURL(ctx, "attachments/a/c", storage.DownloadOptions{Expires: time.Minute})
+	require.NoError(t, err)
+	tampered := strings.Replace(link, "page.html", "page.htm", 1)
+
+	tests := []struct {
+		name       string
+		link       string
+		wantStatus int
+	}{
+		{name: "действующая ссылка", link: link, wantStatus: fiber.StatusOK},
+		{name: "измененная ссылка", link: tampered, wantStatus: fiber.StatusForbidden},
+		{name: "файл удален", link: missing, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.link)
+			require.NoError(t, err)
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus == fiber.StatusOK {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "<html>x</html>", string(body))
+				assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+				assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+				assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Disposition"), "attachment"))
+			}
+		})
+	}
+}
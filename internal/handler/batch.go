@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxBatchOperations максимальное число операций в пакете
+const maxBatchOperations = 100
+
+// Режимы выполнения пакета
+const (
+	// batchModeAtomic ошибка любой операции отменяет весь пакет
+	batchModeAtomic = "atomic"
+	// batchModeBestEffort ошибка операции отменяет только ее
+	batchModeBestEffort = "best_effort"
+)
+
+// Операции пакета
+const (
+	batchOpCreate = "create"
+	batchOpUpdate = "update"
+	batchOpDelete = "delete"
+	batchOpMove   = "move"
+)
+
+// BatchHandler выполняет пакеты операций над задачами в одной транзакции.
+// Должен вызываться после AuthMiddleware.
+type BatchHandler struct {
+	todos services.TodoService
+	tx    repository.Transactor
+}
+
+// NewBatchHandler создает новый экземпляр BatchHandler
+func NewBatchHandler(todos services.TodoService, tx repository.Transactor) *BatchHandler {
+	return &BatchHandler{todos: todos, tx: tx}
+}
+
+// batchOperation операция пакета
+type batchOperation struct {
+	Op string `json:"op"`
+	// ID задача операций update, delete и move
+	ID uuid.UUID `json:"id"`
+	// Todo новая задача операции create
+	Todo *models.CreateTodoRequest `json:"todo"`
+	// Changes изменяемые поля операции update; неуказанные поля не меняются
+	Changes *models.UpdateTodoRequest `json:"changes"`
+	// ProjectID проект назначения операции move
+	ProjectID uuid.UUID `json:"project_id"`
+	// ExpectedVersion ожидаемая версия задачи операций update, delete и move; 0 — без проверки
+	ExpectedVersion int `json:"expected_version"`
+}
+
+// batchResult результат операции пакета
+type batchResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	Status string       `json:"status"`
+	Todo   *models.Todo `json:"todo,omitempty"`
+	// Code HTTP-код ошибки операции
+	Code  int    `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchTodos обрабатывает POST-запрос с пакетом операций create, update, delete и move,
+// которые выполняются по порядку в одной транзакции. В режиме atomic (по умолчанию) ошибка
+// операции отменяет весь пакет и возвращается код 422; в режиме best_effort отменяется
+// только ошибочная операция. Результат каждой операции возвращается в results.
+func (h *BatchHandler) BatchTodos(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	var input struct {
+		Mode       string           `json:"mode"`
+		Operations []batchOperation `json:"operations"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if input.Mode == "" {
+		input.Mode = batchModeAtomic
+	}
+	if input.Mode != batchModeAtomic && input.Mode != batchModeBestEffort {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid mode, expected atomic or best_effort",
+		})
+	}
+	if len(input.Operations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Operations are required",
+		})
+	}
+	if len(input.Operations) > maxBatchOperations {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Too many operations",
+		})
+	}
+
+	todos := make([]*models.Todo, len(input.Operations))
+	outcomes, committed, err := repository.RunBatch(c.Context(), h.tx, len(input.Operations), input.Mode == batchModeAtomic,
+		func(ctx context.Context, i int) error {
+			var err error
+			todos[i], err = h.apply(ctx, userID, input.Operations[i])
+			return err
+		})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run batch",
+		})
+	}
+
+	results := make([]batchResult, len(outcomes))
+	for i, outcome := range outcomes {
+		results[i] = batchResult{Index: i, Op: input.Operations[i].Op, Status: outcome.Status}
+		switch outcome.Status {
+		case repository.BatchStatusOK:
+			results[i].Todo = todos[i]
+		case repository.BatchStatusFailed:
+			results[i].Code, results[i].Error = batchOperationError(outcome.Err)
+		}
+	}
+
+	code := fiber.StatusOK
+	if !committed {
+		code = fiber.StatusUnprocessableEntity
+	}
+	return c.Status(code).JSON(fiber.Map{
+		"committed": committed,
+		"results":   results,
+	})
+}
+
+// apply выполняет операцию пакета и возвращает созданную или измененную задачу
+func (h *BatchHandler) apply(ctx context.Context, userID uuid.UUID, op batchOperation) (*models.Todo, error) {
+	if op.Op == batchOpCreate {
+		return h.create(ctx, userID, op.Todo)
+	}
+	if op.Op != batchOpUpdate && op.Op != batchOpDelete && op.Op != batchOpMove {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Unknown operation, expected create, update, delete or move")
+	}
+
+	todo, err := h.todos.GetByID(ctx, userID, op.ID)
+	if err != nil {
+		return nil, err
+	}
+	if op.ExpectedVersion != 0 && op.ExpectedVersion != todo.Version {
+		return nil, repository.ErrTodoVersionMismatch
+	}
+
+	switch op.Op {
+	case batchOpUpdate:
+		if op.Changes == nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Changes are required")
+		}
+		if err := applyTodoChanges(todo, op.Changes); err != nil {
+			return nil, err
+		}
+		if err := h.todos.Update(ctx, userID, todo); err != nil {
+			return nil, err
+		}
+		return todo, nil
+	case batchOpDelete:
+		return nil, h.todos.Delete(ctx, userID, todo.ID, op.ExpectedVersion)
+	default:
+		if op.ProjectID == uuid.Nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Project ID is required")
+		}
+		moved, err := h.todos.MoveToProject(ctx, userID, todo.ID, op.ProjectID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return moved[0], nil
+	}
+}
+
+// create создает задачу операции create. Пустые статус и приоритет заменяются на pending и medium.
+func (h *BatchHandler) create(ctx context.Context, userID uuid.UUID, input *models.CreateTodoRequest) (*models.Todo, error) {
+	if input == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Todo is required")
+	}
+	if strings.TrimSpace(input.Title) == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Title is required")
+	}
+	if input.Status == "" {
+		input.Status = models.TodoStatusPending
+	}
+	if !isValidStatus(input.Status) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+	}
+	if input.Priority == "" {
+		input.Priority = "medium"
+	}
+	if !isValidPriority(input.Priority) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid priority")
+	}
+
+	now := time.Now()
+	todo := &models.Todo{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Title:       input.Title,
+		Description: input.Description,
+		DueDate:     input.DueDate,
+		Status:      input.Status,
+		Priority:    input.Priority,
+		ParentID:    input.ParentID,
+		ProjectID:   input.ProjectID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if input.Recurrence != "" {
+		todo.Recurrence = &models.TodoRecurrence{Rule: input.Recurrence, Timezone: input.Timezone}
+	}
+
+	if err := h.todos.Create(ctx, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// applyTodoChanges меняет поля задачи, указанные в changes. Новое правило повторения
+// или часовой пояс начинают новую серию, как в UpdateTodo.
+func applyTodoChanges(todo *models.Todo, changes *models.UpdateTodoRequest) error {
+	if changes.Title != nil {
+		if strings.TrimSpace(*changes.Title) == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Title is required")
+		}
+		todo.Title = *changes.Title
+	}
+	if changes.Description != nil {
+		todo.Description = *changes.Description
+	}
+	if changes.Status != nil {
+		if !isValidStatus(*changes.Status) {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+		}
+		todo.Status = *changes.Status
+	}
+	if changes.Priority != nil {
+		if !isValidPriority(*changes.Priority) {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid priority")
+		}
+		todo.Priority = *changes.Priority
+	}
+	if changes.DueDate != nil {
+		todo.DueDate = *changes.DueDate
+	}
+	if changes.ParentID != nil {
+		todo.ParentID = changes.ParentID
+	}
+
+	rule, timezone := changes.Recurrence, ""
+	if todo.Recurrence != nil {
+		timezone = todo.Recurrence.Timezone
+	}
+	if changes.Timezone != nil {
+		timezone = *changes.Timezone
+		if rule == nil && todo.Recurrence != nil {
+			current := todo.Recurrence.Rule
+			rule = &current
+		}
+	}
+	applyRecurrenceChange(todo, recurrenceScopeThis, rule, timezone)
+	todo.UpdatedAt = time.Now()
+	return nil
+}
+
+// batchOperationError возвращает HTTP-код и сообщение ошибки операции пакета
+func batchOperationError(err error) (int, string) {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code, fiberErr.Message
+	}
+	if code, message, ok := todoErrorResponse(err); ok {
+		return code, message
+	}
+	log.Printf("Batch operation failed: %v", err)
+	return fiber.StatusInternalServerError, "Operation failed"
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// directTransactor выполняет функцию без транзакции
+type directTransactor struct{}
+
+func (directTransactor) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// todoStore реализует операции репозитория задач пакета над задачами в памяти
+type todoStore struct {
+	todoLookup
+	deleted []uuid.UUID
+}
+
+func (s *todoStore) Create(ctx context.Context, todo *models.Todo) error {
+	todo.Version = 1
+	s.todos[todo.ID] = todo
+	return nil
+}
+
+func (s *todoStore) Update(ctx context.Context, todo *models.Todo) error {
+	todo.Version++
+	s.todos[todo.ID] = todo
+	return nil
+}
+
+func (s *todoStore) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	s.deleted = append(s.deleted, id)
+	delete(s.todos, id)
+	return nil
+}
+
+func (s *todoStore) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	todo := s.todos[id]
+	todo.ProjectID = &projectID
+	return []*models.Todo{todo}, nil
+}
+
+func TestBatchHandler_BatchTodos(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+
+	type result struct {
+		Status string       `json:"status"`
+		Code   int          `json:"code"`
+		Todo   *models.Todo `json:"todo"`
+	}
+	tests := []struct {
+		name          string
+		body          func(own, other *models.Todo) string
+		wantStatus    int
+		wantCommitted bool
+		wantResults   []string
+		wantCodes     []int
+	}{
+		{
+			name: "все операции",
+			body: func(own, other *models.Todo) string {
+				return `{"operations":[
+					{"op":"create","todo":{"title":"Report"}},
+					{"op":"update","id":"` + own.ID.String() + `","changes":{"status":"completed"},"expected_version":1},
+					{"op":"move","id":"` + own.ID.String() + `","project_id":"` + projectID.String() + `"},
+					{"op":"delete","id":"` + own.ID.String() + `"}]}`
+			},
+			wantStatus:    http.StatusOK,
+			wantCommitted: true,
+			wantResults:   []string{"ok", "ok", "ok", "ok"},
+			wantCodes:     []int{0, 0, 0, 0},
+		},
+		{
+			name: "ошибка отменяет пакет",
+			body: func(own, other *models.Todo) string {
+				return `{"operations":[
+					{"op":"update","id":"` + own.ID.String() + `","changes":{"status":"completed"}},
+					{"op":"update","id":"` + other.ID.String() + `","changes":{"status":"completed"}},
+					{"op":"create","todo":{"title":"Report"}}]}`
+			},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantResults: []string{"rolled_back", "failed", "skipped"},
+			wantCodes:   []int{0, http.StatusNotFound, 0},
+		},
+		{
+			name: "ошибки отдельных операций",
+			body: func(own, other *models.Todo) string {
+				return `{"mode":"best_effort","operations":[
+					{"op":"create","todo":{"title":" "}},
+					{"op":"update","id":"` + own.ID.String() + `","changes":{"priority":"urgent"}},
+					{"op":"update","id":"` + own.ID.String() + `","changes":{"title":"Plan"},"expected_version":5},
+					{"op":"archive","id":"` + own.ID.String() + `"},
+					{"op":"update","id":"` + own.ID.String() + `","changes":{"title":"Plan"}}]}`
+			},
+			wantStatus:    http.StatusOK,
+			wantCommitted: true,
+			wantResults:   []string{"failed", "failed", "failed", "failed", "ok"},
+			wantCodes:     []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusPreconditionFailed, http.StatusBadRequest, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			own := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Own", Status: "pending", Priority: "medium", Version: 1}
+			other := &models.Todo{ID: uuid.New(), UserID: uuid.New(), Title: "Other", Status: "pending", Priority: "medium", Version: 1}
+			store := &todoStore{todoLookup: todoLookup{todos: map[uuid.UUID]*models.Todo{own.ID: own, other.ID: other}}}
+
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("userID", userID.String())
+				return c.Next()
+			})
+			app.Post("/api/todos/batch", NewBatchHandler(services.NewTodoService(store, nil, nil), directTransactor{}).BatchTodos)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/batch", strings.NewReader(tt.body(own, other)))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			var body struct {
+				Committed bool     `json:"committed"`
+				Results   []result `json:"results"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantCommitted, body.Committed)
+			require.Len(t, body.Results, len(tt.wantResults))
+			for i, result := range body.Results {
+				assert.Equal(t, tt.wantResults[i], result.Status, "operation %d", i)
+				assert.Equal(t, tt.wantCodes[i], result.Code, "operation %d", i)
+			}
+			assert.Equal(t, "Other", other.Title)
+			assert.Equal(t, "pending", other.Status)
+		})
+	}
+}
+
+func TestBatchHandler_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "нет операций", body: `{"operations":[]}`},
+		{name: "неизвестный режим", body: `{"mode":"parallel","operations":[{"op":"delete"}]}`},
+		{name: "слишком много операций", body: `{"operations":[` + strings.Repeat(`{"op":"delete"},`, maxBatchOperations) + `{"op":"delete"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("userID", uuid.New().String())
+				return c.Next()
+			})
+			store := &todoStore{todoLookup: todoLookup{todos: map[uuid.UUID]*models.Todo{}}}
+			app.Post("/api/todos/batch", NewBatchHandler(services.NewTodoService(store, nil, nil), directTransactor{}).BatchTodos)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/batch", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
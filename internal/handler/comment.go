@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CommentHandler обрабатывает HTTP-запросы к комментариям задач. Должен вызываться после AuthMiddleware.
+type CommentHandler struct {
+	comments services.CommentService
+}
+
+// NewCommentHandler создает новый экземпляр CommentHandler
+func NewCommentHandler(comments services.CommentService) *CommentHandler {
+	return &CommentHandler{comments: comments}
+}
+
+// GetComments возвращает ветки обсуждения задачи :id: комментарии верхнего уровня
+// с вложенными ответами в порядке создания
+func (h *CommentHandler) GetComments(c *fiber.Ctx) error {
+	userID, todoID, ok, err := todoRequestIDs(c)
+	if !ok {
+		return err
+	}
+
+	threads, err := h.comments.List(c.Context(), userID, todoID)
+	if err != nil {
+		return commentServiceError(c, err, "Failed to get comments")
+	}
+	return c.JSON(threads)
+}
+
+// CreateComment добавляет комментарий к задаче :id или, если задан parent_id, ответ на комментарий
+func (h *CommentHandler) CreateComment(c *fiber.Ctx) error {
+	userID, todoID, ok, err := todoRequestIDs(c)
+	if !ok {
+		return err
+	}
+
+	var input models.CommentRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if input.Body, err = normalizeCommentBody(input.Body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	comment, err := h.comments.Create(c.Context(), userID, todoID, input)
+	if err != nil {
+		return commentServiceError(c, err, "Failed to create comment")
+	}
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
+
+// UpdateComment заменяет текст комментария :id. Прежний текст сохраняется в истории изменений.
+func (h *CommentHandler) UpdateComment(c *fiber.Ctx) error {
+	userID, commentID, ok, err := commentIDs(c)
+	if !ok {
+		return err
+	}
+
+	var input struct {
+		Body string `json:"body"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if input.Body, err = normalizeCommentBody(input.Body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	comment, err := h.comments.Update(c.Context(), userID, commentID, input.Body)
+	if err != nil {
+		return commentServiceError(c, err, "Failed to update comment")
+	}
+	return c.JSON(comment)
+}
+
+// DeleteComment удаляет комментарий :id. Ответы на него остаются в ветке обсуждения.
+func (h *CommentHandler) DeleteComment(c *fiber.Ctx) error {
+	userID, commentID, ok, err := commentIDs(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.comments.Delete(c.Context(), userID, commentID); err != nil {
+		return commentServiceError(c, err, "Failed to delete comment")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetCommentHistory возвращает прежние версии текста комментария :id
+func (h *CommentHandler) GetCommentHistory(c *fiber.Ctx) error {
+	userID, commentID, ok, err := commentIDs(c)
+	if !ok {
+		return err
+	}
+
+	revisions, err := h.comments.ListRevisions(c.Context(), userID, commentID)
+	if err != nil {
+		return commentServiceError(c, err, "Failed to get comment history")
+	}
+	return c.JSON(revisions)
+}
+
+// normalizeCommentBody обрезает пробелы вокруг текста комментария и проверяет его длину;
+// текст ошибки предназначен для клиента
+func normalizeCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", errors.New("Comment body is required")
+	}
+	if len([]rune(body)) > models.MaxCommentLength {
+		return "", fmt.Errorf("Comment body must not exceed %d characters", models.MaxCommentLength)
+	}
+	return body, nil
+}
+
+// commentIDs возвращает ID текущего пользователя и комментария :id.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func commentIDs(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool, error) {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID format",
+		})
+	}
+	return userID, commentID, true, nil
+}
+
+// commentServiceError преобразует ошибку CommentService в HTTP-ответ
+func commentServiceError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrCommentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+	case errors.Is(err, services.ErrInvalidCommentParent):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid parent comment",
+		})
+	}
+	return todoRepositoryError(c, err, msg)
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commentServiceStub запоминает созданные и измененные комментарии
+type commentServiceStub struct {
+	err     error
+	created []models.CommentRequest
+	updated []string
+	deleted []uuid.UUID
+}
+
+func (s *commentServiceStub) Create(ctx context.Context, userID, todoID uuid.UUID, req models.CommentRequest) (*models.Comment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.created = append(s.created, req)
+	return &models.Comment{ID: uuid.New(), TodoID: todoID, UserID: userID, ParentID: req.ParentID, Body: req.Body}, nil
+}
+
+func (s *commentServiceStub) List(ctx context.Context, userID, todoID uuid.UUID) ([]*models.CommentNode, error) {
+	return []*models.CommentNode{}, s.err
+}
+
+func (s *commentServiceStub) Update(ctx context.Context, userID, id uuid.UUID, body string) (*models.Comment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.updated = append(s.updated, body)
+	return &models.Comment{ID: id, UserID: userID, Body: body}, nil
+}
+
+func (s *commentServiceStub) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func (s *commentServiceStub) ListRevisions(ctx context.Context, userID, id uuid.UUID) ([]*models.CommentRevision, error) {
+	return []*models.CommentRevision{}, s.err
+}
+
+var _ services.CommentService = (*commentServiceStub)(nil)
+
+func setupCommentApp(comments *commentServiceStub) *fiber.App {
+	h := NewCommentHandler(comments)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New().String())
+		return c.Next()
+	})
+	app.Post("/api/todos/:id/comments", h.CreateComment)
+	app.Put("/api/comments/:id", h.UpdateComment)
+	app.Delete("/api/comments/:id", h.DeleteComment)
+	return app
+}
+
+func TestCommentHandler_CreateComment(t *testing.T) {
+	parentID := uuid.New()
+
+	tests := []struct {
+		name       string
+		todoID     string
+		body       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "комментарий к задаче",
+			todoID:     uuid.New().String(),
+			body:       `{"body":"  Готово, @anna  "}`,
+			wantStatus: fiber.StatusCreated,
+			wantBody:   "Готово, @anna",
+		},
+		{
+			name:       "ответ на комментарий",
+			todoID:     uuid.New().String(),
+			body:       `{"body":"Согласен","parent_id":"` + parentID.String() + `"}`,
+			wantStatus: fiber.StatusCreated,
+			wantBody:   "Согласен",
+		},
+		{
+			name:       "пустой текст",
+			todoID:     uuid.New().String(),
+			body:       `{"body":"   "}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "слишком длинный текст",
+			todoID:     uuid.New().String(),
+			body:       `{"body":"` + strings.Repeat("a", models.MaxCommentLength+1) + `"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "неверный ID задачи",
+			todoID:     "abc",
+			body:       `{"body":"Привет"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "неверный родительский комментарий",
+			todoID:     uuid.New().String(),
+			body:       `{"body":"Ответ","parent_id":"` + parentID.String() + `"}`,
+			err:        services.ErrInvalidCommentParent,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "задача недоступна",
+			todoID:     uuid.New().String(),
+			body:       `{"body":"Привет"}`,
+			err:        repository.ErrTodoNotFound,
+			wantStatus: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := &commentServiceStub{err: tt.err}
+			app := setupCommentApp(comments)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/"+tt.todoID+"/comments", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus == fiber.StatusCreated {
+				require.Len(t, comments.created, 1)
+				assert.Equal(t, tt.wantBody, comments.created[0].Body)
+			}
+		})
+	}
+}
+
+func TestCommentHandler_UpdateAndDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		commentID  string
+		err        error
+		wantStatus int
+	}{
+		{name: "изменение текста", method: http.MethodPut, commentID: uuid.New().String(), wantStatus: fiber.StatusOK},
+		{name: "чужой комментарий", method: http.MethodPut, commentID: uuid.New().String(), err: repository.ErrCommentNotFound, wantStatus: fiber.StatusNotFound},
+		{name: "неверный ID комментария", method: http.MethodPut, commentID: "abc", wantStatus: fiber.StatusBadRequest},
+		{name: "удаление", method: http.MethodDelete, commentID: uuid.New().String(), wantStatus: fiber.StatusNoContent},
+		{name: "удаление недоступного комментария", method: http.MethodDelete, commentID: uuid.New().String(), err: repository.ErrCommentNotFound, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := &commentServiceStub{err: tt.err}
+			app := setupCommentApp(comments)
+
+			req := httptest.NewRequest(tt.method, "/api/comments/"+tt.commentID, strings.NewReader(`{"body":"Итог"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			switch tt.wantStatus {
+			case fiber.StatusOK:
+				assert.Equal(t, []string{"Итог"}, comments.updated)
+			case fiber.StatusNoContent:
+				assert.Len(t, comments.deleted, 1)
+			}
+		})
+	}
+}
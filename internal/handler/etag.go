@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// todoETag возвращает ETag задачи — ее версию в кавычках
+func todoETag(todo *models.Todo) string {
+	return `"` + strconv.Itoa(todo.Version) + `"`
+}
+
+// setTodoETag записывает ETag задачи в заголовок ответа
+func setTodoETag(c *fiber.Ctx, todo *models.Todo) {
+	c.Set(fiber.HeaderETag, todoETag(todo))
+}
+
+// checkIfMatch проверяет заголовок If-Match запроса, изменяющего задачу: если он задан
+// и не содержит ETag текущей версии задачи (или *), записывает ответ 412.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func checkIfMatch(c *fiber.Ctx, todo *models.Todo) (bool, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" || etagListContains(header, todoETag(todo), false) {
+		return true, nil
+	}
+	return false, c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+		"error": "Todo has been modified",
+	})
+}
+
+// notModified сообщает, что заголовок If-None-Match содержит ETag текущей версии задачи
+// и вместо нее можно ответить 304
+func notModified(c *fiber.Ctx, todo *models.Todo) bool {
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	return header != "" && etagListContains(header, todoETag(todo), true)
+}
+
+// etagListContains проверяет, есть ли etag в списке ETag заголовка If-Match или
+// If-None-Match. * совпадает с любым ETag. При слабом сравнении (If-None-Match)
+// префикс W/ не учитывается, при сильном (If-Match) слабые ETag не совпадают.
+func etagListContains(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
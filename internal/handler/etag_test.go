@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtagListContains(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{name: "совпадение", header: `"3"`, want: true},
+		{name: "другая версия", header: `"2"`, want: false},
+		{name: "список", header: `"1", "3"`, want: true},
+		{name: "звездочка", header: `*`, want: true},
+		{name: "слабый при сильном сравнении", header: `W/"3"`, want: false},
+		{name: "слабый при слабом сравнении", header: `W/"3"`, weak: true, want: true},
+		{name: "без кавычек", header: `3`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagListContains(tt.header, `"3"`, tt.weak))
+		})
+	}
+}
+
+func TestTodoETagPreconditions(t *testing.T) {
+	todo := &models.Todo{Title: "Report", Version: 3}
+	app := fiber.New()
+	app.Get("/todo", func(c *fiber.Ctx) error {
+		setTodoETag(c, todo)
+		if notModified(c, todo) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return c.JSON(todo)
+	})
+	app.Put("/todo", func(c *fiber.Ctx) error {
+		if ok, err := checkIfMatch(c, todo); !ok {
+			return err
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{name: "чтение", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "чтение без изменений", method: http.MethodGet, header: fiber.HeaderIfNoneMatch, value: `"3"`, wantStatus: http.StatusNotModified},
+		{name: "чтение измененной", method: http.MethodGet, header: fiber.HeaderIfNoneMatch, value: `"2"`, wantStatus: http.StatusOK},
+		{name: "изменение без условия", method: http.MethodPut, wantStatus: http.StatusNoContent},
+		{name: "изменение текущей версии", method: http.MethodPut, header: fiber.HeaderIfMatch, value: `"3"`, wantStatus: http.StatusNoContent},
+		{name: "изменение устаревшей версии", method: http.MethodPut, header: fiber.HeaderIfMatch, value: `"2"`, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todo", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.method == http.MethodGet {
+				assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+			}
+		})
+	}
+}
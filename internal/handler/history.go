@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HistoryHandler обрабатывает HTTP-запросы к истории изменений задач.
+// Должен вызываться после AuthMiddleware.
+type HistoryHandler struct {
+	todos   services.TodoService
+	history repository.TodoHistoryRepository
+}
+
+// NewHistoryHandler создает новый экземпляр HistoryHandler
+func NewHistoryHandler(todos services.TodoService, history repository.TodoHistoryRepository) *HistoryHandler {
+	return &HistoryHandler{todos: todos, history: history}
+}
+
+// GetTodoHistory возвращает ревизии задачи от первой к последней: действие, автора,
+// источник изменения и старые и новые значения измененных полей
+func (h *HistoryHandler) GetTodoHistory(c *fiber.Ctx) error {
+	todo, ok, err := ownTodo(c, h.todos)
+	if !ok {
+		return err
+	}
+
+	entries, err := h.history.GetByTodoID(c.Context(), todo.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get todo history",
+		})
+	}
+	return c.JSON(entries)
+}
+
+// RevertTodo возвращает поля задачи к состоянию после ревизии :revision. Откат сохраняется
+// обычным обновлением задачи и записывается в историю новой ревизией.
+func (h *HistoryHandler) RevertTodo(c *fiber.Ctx) error {
+	revision, err := c.ParamsInt("revision")
+	if err != nil || revision < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid revision",
+		})
+	}
+
+	todo, ok, err := ownTodo(c, h.todos)
+	if !ok {
+		return err
+	}
+
+	entries, err := h.history.GetByTodoID(c.Context(), todo.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get todo history",
+		})
+	}
+
+	reverted, err := models.RevertTodo(todo, entries, revision)
+	if errors.Is(err, models.ErrRevisionNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Revision not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revert todo",
+		})
+	}
+	reverted.UpdatedAt = time.Now()
+
+	// ownTodo уже проверил ID пользователя
+	userID, _ := uuid.Parse(localUserID(c.Locals("userID")))
+	if err := h.todos.Update(models.WithRevert(c.Context(), revision), userID, reverted); err != nil {
+		return todoRepositoryError(c, err, "Failed to revert todo")
+	}
+	return c.JSON(reverted)
+}
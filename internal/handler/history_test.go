@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTodoHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockTodoHistoryRepository) Create(ctx context.Context, entry *models.TodoHistoryEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockTodoHistoryRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.TodoHistoryEntry, error) {
+	args := m.Called(ctx, todoID)
+	return args.Get(0).([]*models.TodoHistoryEntry), args.Error(1)
+}
+
+// todoReverter реализует GetByID и Update репозитория задач и запоминает обновления
+// вместе с ревизией отката из контекста
+type todoReverter struct {
+	todoLookup
+	updated   []*models.Todo
+	revisions []int
+}
+
+func (r *todoReverter) Update(ctx context.Context, todo *models.Todo) error {
+	revision, _ := models.RevertFromContext(ctx)
+	r.updated = append(r.updated, todo)
+	r.revisions = append(r.revisions, revision)
+	return nil
+}
+
+// todoRevisions возвращает ревизии, последовательно переводящие задачу через states
+func todoRevisions(states ...*models.Todo) []*models.TodoHistoryEntry {
+	var entries []*models.TodoHistoryEntry
+	var previous *models.Todo
+	for i, state := range states {
+		action := models.TodoHistoryUpdated
+		if previous == nil {
+			action = models.TodoHistoryCreated
+		}
+		entries = append(entries, &models.TodoHistoryEntry{
+			ID:       uuid.New(),
+			TodoID:   state.ID,
+			Revision: i + 1,
+			Action:   action,
+			Source:   models.ChangeSourceREST,
+			Changes:  models.DiffTodos(previous, state),
+		})
+		previous = state
+	}
+	return entries
+}
+
+func setupHistoryApp(todos *todoReverter, history *MockTodoHistoryRepository, userID uuid.UUID) *fiber.App {
+	h := NewHistoryHandler(services.NewTodoService(todos, nil, nil), history)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID.String())
+		return c.Next()
+	})
+	app.Get("/api/todos/:id/history", h.GetTodoHistory)
+	app.Post("/api/todos/:id/history/:revision/revert", h.RevertTodo)
+	return app
+}
+
+func TestHistoryHandler_GetTodoHistory(t *testing.T) {
+	userID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Report", Status: "pending", Priority: "medium"}
+	foreign := &models.Todo{ID: uuid.New(), UserID: uuid.New(), Title: "Other"}
+
+	todos := &todoReverter{todoLookup: todoLookup{todos: map[uuid.UUID]*models.Todo{todo.ID: todo, foreign.ID: foreign}}}
+	history := new(MockTodoHistoryRepository)
+	history.On("GetByTodoID", mock.Anything, todo.ID).Return(todoRevisions(todo), nil)
+	app := setupHistoryApp(todos, history, userID)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/todos/"+todo.ID.String()+"/history", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries []models.TodoHistoryEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.TodoHistoryCreated, entries[0].Action)
+	assert.Equal(t, "title", entries[0].Changes[0].Field)
+	assert.JSONEq(t, `"Report"`, string(entries[0].Changes[0].New))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/todos/"+foreign.ID.String()+"/history", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	history.AssertNotCalled(t, "GetByTodoID", mock.Anything, foreign.ID)
+}
+
+func TestHistoryHandler_RevertTodo(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+	due := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+
+	created := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Report", Status: "pending", Priority: "medium", ProjectID: &projectID}
+	scheduled := *created
+	scheduled.DueDate = due
+	scheduled.Priority = "high"
+	movedProjectID := uuid.New()
+	renamed := scheduled
+	renamed.Title = "Quarterly report"
+	renamed.Status = "in_progress"
+	renamed.ProjectID = &movedProjectID
+	entries := todoRevisions(created, &scheduled, &renamed)
+
+	tests := []struct {
+		name       string
+		revision   string
+		wantStatus int
+		check      func(t *testing.T, todo *models.Todo)
+	}{
+		{
+			name:       "предыдущая ревизия",
+			revision:   "2",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Equal(t, "Report", todo.Title)
+				assert.Equal(t, "pending", todo.Status)
+				assert.Equal(t, "high", todo.Priority)
+				assert.True(t, due.Equal(todo.DueDate))
+			},
+		},
+		{
+			name:       "первая ревизия",
+			revision:   "1",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Equal(t, "Report", todo.Title)
+				assert.Equal(t, "medium", todo.Priority)
+				assert.True(t, todo.DueDate.IsZero())
+				assert.Equal(t, &movedProjectID, todo.ProjectID, "перенос в другой проект не откатывается")
+			},
+		},
+		{name: "несуществующая ревизия", revision: "7", wantStatus: http.StatusNotFound},
+		{name: "неверная ревизия", revision: "zero", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := renamed
+			todos := &todoReverter{todoLookup: todoLookup{todos: map[uuid.UUID]*models.Todo{current.ID: &current}}}
+			history := new(MockTodoHistoryRepository)
+			history.On("GetByTodoID", mock.Anything, current.ID).Return(entries, nil)
+			app := setupHistoryApp(todos, history, userID)
+
+			url := "/api/todos/" + current.ID.String() + "/history/" + tt.revision + "/revert"
+			resp, err := app.Test(httptest.NewRequest(http.MethodPost, url, nil))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.check == nil {
+				assert.Empty(t, todos.updated)
+				return
+			}
+
+			require.Len(t, todos.updated, 1)
+			tt.check(t, todos.updated[0])
+			revision, _ := strconv.Atoi(tt.revision)
+			assert.Equal(t, []int{revision}, todos.revisions)
+			assert.Equal(t, "Quarterly report", current.Title, "текущая задача не должна меняться до сохранения")
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKSHandler публикует открытые ключи проверки токенов
+type JWKSHandler struct {
+	keys *auth.KeySet
+	// maxAge время кэширования ответа клиентами
+	maxAge time.Duration
+}
+
+// NewJWKSHandler создает новый экземпляр JWKSHandler
+func NewJWKSHandler(keys *auth.KeySet, maxAge time.Duration) *JWKSHandler {
+	return &JWKSHandler{
+		keys:   keys,
+		maxAge: maxAge,
+	}
+}
+
+// GetJWKS возвращает открытые ключи в формате JWKS.
+// После ротации токены сразу подписываются новым ключом, поэтому сервисы, получившие
+// токен с неизвестным kid, должны запросить набор заново, не дожидаясь истечения кэша.
+func (h *JWKSHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	return c.JSON(h.keys.JWKS())
+}
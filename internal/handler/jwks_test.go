@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJWKS(t *testing.T, keys *auth.KeySet) (*http.Response, auth.JWKS) {
+	app := fiber.New()
+	app.Get("/.well-known/jwks.json", NewJWKSHandler(keys, 5*time.Minute).GetJWKS)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.NoError(t, err)
+
+	var jwks auth.JWKS
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+	return resp, jwks
+}
+
+func TestJWKSHandler_GetJWKS(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		kty       string
+	}{
+		{name: "EdDSA", algorithm: auth.AlgorithmEdDSA, kty: "OKP"},
+		{name: "RS256", algorithm: auth.AlgorithmRS256, kty: "RSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := auth.LoadKeySet(t.TempDir(), tt.algorithm)
+			require.NoError(t, err)
+
+			resp, jwks := getJWKS(t, keys)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "public, max-age=300", resp.Header.Get(fiber.HeaderCacheControl))
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.algorithm, jwks.Keys[0].Alg)
+			assert.Equal(t, "sig", jwks.Keys[0].Use)
+			assert.NotEmpty(t, jwks.Keys[0].Kid)
+		})
+	}
+}
+
+func TestJWKSHandler_HMACKeysNotPublished(t *testing.T) {
+	_, jwks := getJWKS(t, auth.NewHMACKeySet([]byte("test_secret")))
+	assert.Empty(t, jwks.Keys)
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := auth.LoadKeySet(dir, auth.AlgorithmEdDSA)
+	require.NoError(t, err)
+	jwtManager := auth.NewJWTManagerWithKeys(keys, time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	oldToken, err := jwtManager.Generate(user)
+	require.NoError(t, err)
+
+	require.NoError(t, keys.Rotate())
+	newToken, err := jwtManager.Generate(user)
+	require.NoError(t, err)
+
+	// Токены, подписанные замененным ключом, остаются действительными
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := jwtManager.Validate(token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), claims.UserID)
+	}
+
+	// Другой экземпляр сервера с тем же каталогом проверяет токены обоих ключей
+	other, err := auth.LoadKeySet(dir, auth.AlgorithmEdDSA)
+	require.NoError(t, err)
+	otherManager := auth.NewJWTManagerWithKeys(other, time.Hour)
+	for _, token := range []string{oldToken, newToken} {
+		_, err := otherManager.Validate(token)
+		assert.NoError(t, err)
+	}
+
+	_, jwks := getJWKS(t, keys)
+	assert.Len(t, jwks.Keys, 2)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestKeySet_RejectsForeignTokens(t *testing.T) {
+	keys, err := auth.LoadKeySet(t.TempDir(), auth.AlgorithmEdDSA)
+	require.NoError(t, err)
+	jwtManager := auth.NewJWTManagerWithKeys(keys, time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	foreignKeys, err := auth.LoadKeySet(t.TempDir(), auth.AlgorithmEdDSA)
+	require.NoError(t, err)
+	foreignToken, err := auth.NewJWTManagerWithKeys(foreignKeys, time.Hour).Generate(user)
+	require.NoError(t, err)
+
+	hmacToken, err := auth.NewJWTManager([]byte("test_secret")).Generate(user)
+	require.NoError(t, err)
+
+	for _, token := range []string{foreignToken, hmacToken} {
+		_, err := jwtManager.Validate(token)
+		assert.Error(t, err)
+	}
+}
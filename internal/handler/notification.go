@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// NotificationHandler обрабатывает HTTP-запросы к входящим уведомлениям пользователя.
+// Должен вызываться после AuthMiddleware.
+type NotificationHandler struct {
+	repo repository.NotificationRepository
+}
+
+// NewNotificationHandler создает новый экземпляр NotificationHandler
+func NewNotificationHandler(repo repository.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{repo: repo}
+}
+
+// GetNotifications возвращает последние уведомления пользователя, начиная с новых.
+// Параметр unread=true оставляет только непрочитанные.
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	notifications, err := h.repo.GetByUserID(c.Context(), userID, c.QueryBool("unread"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get notifications",
+		})
+	}
+	return c.JSON(notifications)
+}
+
+// MarkNotificationRead отмечает уведомление прочитанным
+func (h *NotificationHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	notificationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid notification ID format",
+		})
+	}
+
+	if err := h.repo.MarkRead(c.Context(), notificationID, userID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Notification not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to mark notification as read",
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
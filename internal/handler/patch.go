@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/jsonpatch"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/recurrence"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Типы содержимого PATCH-запроса
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+// todoPatchDocument изменяемые поля задачи: документ, к которому применяется PATCH.
+// null в поле очищает его: срок, родителя (задача становится корневой) или правило
+// повторения (серия завершается на этой задаче).
+type todoPatchDocument struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"due_date"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Recurrence  *string    `json:"recurrence"`
+	Timezone    *string    `json:"timezone"`
+}
+
+// newTodoPatchDocument возвращает документ с текущими значениями полей задачи
+func newTodoPatchDocument(todo *models.Todo) *todoPatchDocument {
+	doc := &todoPatchDocument{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Status:      todo.Status,
+		Priority:    todo.Priority,
+		ParentID:    todo.ParentID,
+	}
+	if !todo.DueDate.IsZero() {
+		dueDate := todo.DueDate
+		doc.DueDate = &dueDate
+	}
+	if todo.Recurrence != nil {
+		rule, timezone := todo.Recurrence.Rule, todo.Recurrence.Timezone
+		doc.Recurrence = &rule
+		doc.Timezone = &timezone
+	}
+	return doc
+}
+
+// decodeTodoPatchDocument разбирает документ после применения изменения и проверяет поля.
+// Отсутствующее поле равносильно null. Ошибки возвращаются по каждому полю.
+func decodeTodoPatchDocument(data []byte) (*todoPatchDocument, []ValidationError) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, []ValidationError{{Field: "", Message: "Patched todo must be a JSON object"}}
+	}
+
+	doc := &todoPatchDocument{}
+	targets := map[string]interface{}{
+		"title":       &doc.Title,
+		"description": &doc.Description,
+		"status":      &doc.Status,
+		"priority":    &doc.Priority,
+		"due_date":    &doc.DueDate,
+		"parent_id":   &doc.ParentID,
+		"recurrence":  &doc.Recurrence,
+		"timezone":    &doc.Timezone,
+	}
+
+	var errs []ValidationError
+	invalid := map[string]bool{}
+	for name, raw := range fields {
+		target, ok := targets[name]
+		if !ok {
+			errs = append(errs, ValidationError{Field: name, Message: "Field cannot be changed"})
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			errs = append(errs, ValidationError{Field: name, Message: "Invalid value"})
+			invalid[name] = true
+		}
+	}
+
+	check := func(field string, valid bool, message string) {
+		if !invalid[field] && !valid {
+			errs = append(errs, ValidationError{Field: field, Message: message})
+		}
+	}
+	check("title", strings.TrimSpace(doc.Title) != "", "Title is required")
+	check("status", isValidStatus(doc.Status), "Invalid status")
+	check("priority", isValidPriority(doc.Priority), "Invalid priority")
+	if doc.Recurrence != nil && *doc.Recurrence != "" {
+		_, err := recurrence.Parse(*doc.Recurrence)
+		check("recurrence", err == nil, "Invalid recurrence rule")
+	}
+	if doc.Timezone != nil {
+		_, err := time.LoadLocation(*doc.Timezone)
+		check("timezone", err == nil, "Invalid timezone")
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return doc, errs
+}
+
+// PatchTodo обрабатывает PATCH-запрос для частичного изменения задачи. Изменение
+// применяется к документу из полей title, description, status, priority, due_date,
+// parent_id, recurrence и timezone в формате, заданном Content-Type:
+//   - application/merge-patch+json (и application/json) — JSON Merge Patch (RFC 7396);
+//   - application/json-patch+json — JSON Patch (RFC 6902).
+//
+// Неуказанные поля не меняются, null очищает поле. Ошибки полей возвращаются списком
+// в формате NewValidationErrorResponse. Параметр scope и заголовок If-Match действуют,
+// как в UpdateTodo.
+func (h *TodoHandler) PatchTodo(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	scope := c.Query("scope", recurrenceScopeThis)
+	if scope != recurrenceScopeThis && scope != recurrenceScopeFuture {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid scope, expected this or future",
+		})
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType {
+	case mimeMergePatch, fiber.MIMEApplicationJSON:
+		apply = jsonpatch.MergePatch
+	case mimeJSONPatch:
+		apply = jsonpatch.Apply
+	default:
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Unsupported patch format, expected " + mimeMergePatch + " or " + mimeJSONPatch,
+		})
+	}
+
+	todo, err := h.todos.GetByID(c.Context(), userID, todoID)
+	if err != nil {
+		return todoRepositoryError(c, err, "Failed to get todo")
+	}
+	if ok, err := checkIfMatch(c, todo); !ok {
+		return err
+	}
+
+	current := newTodoPatchDocument(todo)
+	data, err := json.Marshal(current)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update todo",
+		})
+	}
+	patched, err := apply(data, c.Body())
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Patch test operation failed",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid patch: " + err.Error(),
+		})
+	}
+
+	doc, errs := decodeTodoPatchDocument(patched)
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(NewValidationErrorResponse(errs))
+	}
+
+	todo.Title = doc.Title
+	todo.Description = doc.Description
+	todo.Status = doc.Status
+	todo.Priority = doc.Priority
+	todo.ParentID = doc.ParentID
+	todo.DueDate = time.Time{}
+	if doc.DueDate != nil {
+		todo.DueDate = *doc.DueDate
+	}
+
+	// Правило передается, только если изменилось оно или часовой пояс: иначе повторение
+	// остается прежним
+	var rule *string
+	timezone := ""
+	if doc.Timezone != nil {
+		timezone = *doc.Timezone
+	}
+	if stringValue(doc.Recurrence) != stringValue(current.Recurrence) ||
+		(doc.Recurrence != nil && timezone != stringValue(current.Timezone)) {
+		value := stringValue(doc.Recurrence)
+		rule = &value
+	}
+	if !applyRecurrenceChange(todo, scope, rule, timezone) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Todo is not recurring",
+		})
+	}
+	todo.UpdatedAt = time.Now()
+
+	if err := h.todos.Update(c.Context(), userID, todo); err != nil {
+		return todoRepositoryError(c, err, "Failed to update todo")
+	}
+
+	setTodoETag(c, todo)
+	return c.JSON(todo)
+}
+
+// stringValue возвращает строку или пустую строку для nil
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoHandler_PatchTodo(t *testing.T) {
+	userID := uuid.New()
+	parentID := uuid.New()
+	dueDate := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+	newTodo := func() *models.Todo {
+		return &models.Todo{
+			ID:          uuid.New(),
+			UserID:      userID,
+			Title:       "Report",
+			Description: "Quarterly",
+			Status:      "pending",
+			Priority:    "medium",
+			DueDate:     dueDate,
+			ParentID:    &parentID,
+			Version:     3,
+			Recurrence:  &models.TodoRecurrence{Rule: "FREQ=WEEKLY", Timezone: "Europe/Moscow", SeriesID: uuid.New()},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		foreign     bool
+		body        string
+		wantStatus  int
+		wantFields  []string
+		check       func(t *testing.T, todo *models.Todo)
+	}{
+		{
+			name:        "merge patch меняет только указанные поля",
+			contentType: mimeMergePatch,
+			body:        `{"title":"Annual report","priority":"high"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Equal(t, "Annual report", todo.Title)
+				assert.Equal(t, "high", todo.Priority)
+				assert.Equal(t, "Quarterly", todo.Description)
+				assert.True(t, dueDate.Equal(todo.DueDate))
+				assert.Equal(t, &parentID, todo.ParentID)
+				require.NotNil(t, todo.Recurrence)
+				assert.NotEqual(t, uuid.Nil, todo.Recurrence.SeriesID)
+			},
+		},
+		{
+			name:        "null очищает поля",
+			contentType: mimeMergePatch,
+			body:        `{"due_date":null,"parent_id":null,"description":null,"recurrence":null}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.True(t, todo.DueDate.IsZero())
+				assert.Nil(t, todo.ParentID)
+				assert.Empty(t, todo.Description)
+				assert.Nil(t, todo.Recurrence)
+				assert.Equal(t, "Report", todo.Title)
+			},
+		},
+		{
+			name:        "application/json понимается как merge patch",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"status":"completed"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Equal(t, "completed", todo.Status)
+			},
+		},
+		{
+			name:        "json patch",
+			contentType: mimeJSONPatch,
+			body: `[{"op":"test","path":"/status","value":"pending"},
+				{"op":"replace","path":"/status","value":"in_progress"},
+				{"op":"remove","path":"/due_date"}]`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				assert.Equal(t, "in_progress", todo.Status)
+				assert.True(t, todo.DueDate.IsZero())
+			},
+		},
+		{
+			name:        "смена часового пояса начинает новую серию",
+			contentType: mimeMergePatch,
+			body:        `{"timezone":"UTC"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, todo *models.Todo) {
+				require.NotNil(t, todo.Recurrence)
+				assert.Equal(t, "FREQ=WEEKLY", todo.Recurrence.Rule)
+				assert.Equal(t, "UTC", todo.Recurrence.Timezone)
+				assert.Equal(t, uuid.Nil, todo.Recurrence.SeriesID)
+			},
+		},
+		{
+			name:        "ошибки полей",
+			contentType: mimeMergePatch,
+			body:        `{"title":null,"status":"done","due_date":"tomorrow","id":"x"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantFields:  []string{"due_date", "id", "status", "title"},
+		},
+		{
+			name:        "неверное правило повторения",
+			contentType: mimeMergePatch,
+			body:        `{"recurrence":"FREQ=HOURLY"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantFields:  []string{"recurrence"},
+		},
+		{
+			name:        "test не совпал",
+			contentType: mimeJSONPatch,
+			body:        `[{"op":"test","path":"/title","value":"Other"}]`,
+			wantStatus:  http.StatusConflict,
+		},
+		{
+			name:        "неверный json patch",
+			contentType: mimeJSONPatch,
+			body:        `[{"op":"replace","path":"/tags","value":[]}]`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "неподдерживаемый формат",
+			contentType: fiber.MIMETextPlain,
+			body:        `title=Other`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "устаревшая версия",
+			contentType: mimeMergePatch,
+			ifMatch:     `"2"`,
+			body:        `{"title":"Other"}`,
+			wantStatus:  http.StatusPreconditionFailed,
+		},
+		{
+			name:        "чужая задача",
+			contentType: mimeMergePatch,
+			foreign:     true,
+			body:        `{"title":"Other"}`,
+			wantStatus:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := newTodo()
+			if tt.foreign {
+				todo.UserID = uuid.New()
+			}
+			todos := &todoReverter{todoLookup: todoLookup{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}}
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("userID", userID.String())
+				return c.Next()
+			})
+			app.Patch("/api/todos/:id", NewTodoHandler(todos, services.NewTodoService(todos, nil, nil), nil).PatchTodo)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/todos/"+todo.ID.String(), strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus != http.StatusOK {
+				assert.Empty(t, todos.updated)
+				if tt.wantFields != nil {
+					var result struct {
+						Data []ValidationError `json:"data"`
+					}
+					require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+					var fields []string
+					for _, validationError := range result.Data {
+						fields = append(fields, validationError.Field)
+					}
+					assert.Equal(t, tt.wantFields, fields)
+				}
+				return
+			}
+			require.Len(t, todos.updated, 1)
+			assert.Equal(t, `"3"`, resp.Header.Get(fiber.HeaderETag))
+			tt.check(t, todos.updated[0])
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxProjectNameLength максимальная длина названия проекта
+const maxProjectNameLength = 100
+
+// ProjectHandler обрабатывает HTTP-запросы для работы с проектами пользователя и их задачами.
+// Должен вызываться после AuthMiddleware.
+type ProjectHandler struct {
+	repo  repository.ProjectRepository
+	todos repository.TodoRepository
+}
+
+// NewProjectHandler создает новый экземпляр ProjectHandler
+func NewProjectHandler(repo repository.ProjectRepository, todos repository.TodoRepository) *ProjectHandler {
+	return &ProjectHandler{
+		repo:  repo,
+		todos: todos,
+	}
+}
+
+// GetProjects возвращает проекты пользователя: сначала Inbox, затем остальные по названию.
+// Архивные проекты возвращаются только с параметром archived=true.
+func (h *ProjectHandler) GetProjects(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	projects, err := h.repo.GetByUserID(c.Context(), userID, c.QueryBool("archived"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get projects",
+		})
+	}
+	return c.JSON(projects)
+}
+
+// CreateProject создает новый проект
+func (h *ProjectHandler) CreateProject(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	var input models.ProjectRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := normalizeProjectRequest(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	now := time.Now()
+	project := &models.Project{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      input.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.repo.Create(c.Context(), project); err != nil {
+		return projectRepositoryError(c, err, "Failed to create project")
+	}
+	return c.Status(fiber.StatusCreated).JSON(project)
+}
+
+// UpdateProject переименовывает проект
+func (h *ProjectHandler) UpdateProject(c *fiber.Ctx) error {
+	var input models.ProjectRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := normalizeProjectRequest(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	project, ok, err := h.ownProject(c)
+	if !ok {
+		return err
+	}
+
+	project.Name = input.Name
+	project.UpdatedAt = time.Now()
+
+	if err := h.repo.Update(c.Context(), project); err != nil {
+		return projectRepositoryError(c, err, "Failed to update project")
+	}
+	return c.JSON(project)
+}
+
+// DeleteProject удаляет проект; его задачи переносятся в Inbox
+func (h *ProjectHandler) DeleteProject(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	projectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid project ID format",
+		})
+	}
+
+	if err := h.repo.Delete(c.Context(), projectID, userID); err != nil {
+		return projectRepositoryError(c, err, "Failed to delete project")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ArchiveProject перемещает проект в архив: его задачи не попадают в общие списки задач
+func (h *ProjectHandler) ArchiveProject(c *fiber.Ctx) error {
+	now := time.Now()
+	return h.setArchived(c, &now)
+}
+
+// UnarchiveProject возвращает проект из архива
+func (h *ProjectHandler) UnarchiveProject(c *fiber.Ctx) error {
+	return h.setArchived(c, nil)
+}
+
+// setArchived разбирает параметр :id и устанавливает время архивации проекта
+func (h *ProjectHandler) setArchived(c *fiber.Ctx, archivedAt *time.Time) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	projectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid project ID format",
+		})
+	}
+
+	project, err := h.repo.SetArchived(c.Context(), projectID, userID, archivedAt)
+	if err != nil {
+		return projectRepositoryError(c, err, "Failed to update project")
+	}
+	return c.JSON(project)
+}
+
+// GetProjectTodos возвращает задачи проекта в порядке проекта.
+// Принимает те же фильтры по тегам, что и список задач.
+func (h *ProjectHandler) GetProjectTodos(c *fiber.Ctx) error {
+	project, filter, ok, err := h.projectFilter(c)
+	if !ok {
+		return err
+	}
+
+	todos, err := h.todos.List(c.Context(), project.UserID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get todos",
+		})
+	}
+	return c.JSON(todos)
+}
+
+// GetGroupedProjectTodos возвращает задачи проекта, сгруппированные по статусу и приоритету
+// или, с параметром group_by=assignee, по исполнителю, с числом задач каждого тега в группе
+func (h *ProjectHandler) GetGroupedProjectTodos(c *fiber.Ctx) error {
+	groupBy, err := parseGroupBy(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	project, filter, ok, err := h.projectFilter(c)
+	if !ok {
+		return err
+	}
+
+	filter.GroupBy = groupBy
+	groups, err := h.todos.GetGroupedTodos(c.Context(), project.UserID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get todos",
+		})
+	}
+	return c.JSON(todoGroups(groups))
+}
+
+// ReorderTodos задает порядок задач проекта. Задачи из todo_ids располагаются в начале
+// в указанном порядке, остальные задачи проекта следуют за ними.
+func (h *ProjectHandler) ReorderTodos(c *fiber.Ctx) error {
+	var input struct {
+		TodoIDs []uuid.UUID `json:"todo_ids"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	project, ok, err := h.ownProject(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.repo.ReorderTodos(c.Context(), project.UserID, project.ID, input.TodoIDs); err != nil {
+		return projectRepositoryError(c, err, "Failed to reorder todos")
+	}
+
+	todos, err := h.todos.List(c.Context(), project.UserID, models.TodoFilter{ProjectID: &project.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get todos",
+		})
+	}
+	return c.JSON(todos)
+}
+
+// projectFilter загружает проект пользователя и фильтр его задач из параметров запроса.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func (h *ProjectHandler) projectFilter(c *fiber.Ctx) (*models.Project, models.TodoFilter, bool, error) {
+	filter, err := parseTodoFilter(c)
+	if err != nil {
+		return nil, filter, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	project, ok, err := h.ownProject(c)
+	if !ok {
+		return nil, filter, false, err
+	}
+
+	filter.ProjectID = &project.ID
+	return project, filter, true, nil
+}
+
+// ownProject загружает проект из параметра :id, принадлежащий пользователю.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func (h *ProjectHandler) ownProject(c *fiber.Ctx) (*models.Project, bool, error) {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	projectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid project ID format",
+		})
+	}
+
+	project, err := h.repo.GetByID(c.Context(), projectID)
+	if err != nil || project.UserID != userID {
+		return nil, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Project not found",
+		})
+	}
+	return project, true, nil
+}
+
+// normalizeProjectRequest проверяет запрос проекта
+func normalizeProjectRequest(input *models.ProjectRequest) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return errors.New("Project name is required")
+	}
+	if len([]rune(input.Name)) > maxProjectNameLength {
+		return errors.New("Project name is too long")
+	}
+	return nil
+}
+
+// projectRepositoryError преобразует ошибку репозитория проектов в HTTP-ответ
+func projectRepositoryError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Project not found",
+		})
+	case errors.Is(err, repository.ErrInboxProject):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Inbox cannot be deleted or archived",
+		})
+	case errors.Is(err, repository.ErrInvalidTodoOrder):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Todo order must contain distinct todos of the project",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": msg,
+	})
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) GetByUserID(ctx context.Context, userID uuid.UUID, includeArchived bool) ([]*models.Project, error) {
+	args := m.Called(ctx, userID, includeArchived)
+	return args.Get(0).([]*models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) SetArchived(ctx context.Context, id, userID uuid.UUID, archivedAt *time.Time) (*models.Project, error) {
+	args := m.Called(ctx, id, userID, archivedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) EnsureInbox(ctx context.Context, userID uuid.UUID) (*models.Project, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) ReorderTodos(ctx context.Context, userID, projectID uuid.UUID, todoIDs []uuid.UUID) error {
+	args := m.Called(ctx, userID, projectID, todoIDs)
+	return args.Error(0)
+}
+
+func setupProjectApp(repo *MockProjectRepository, userID uuid.UUID) *fiber.App {
+	h := NewProjectHandler(repo, nil)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID.String())
+		return c.Next()
+	})
+	app.Get("/api/projects", h.GetProjects)
+	app.Post("/api/projects", h.CreateProject)
+	app.Delete("/api/projects/:id", h.DeleteProject)
+	app.Post("/api/projects/:id/archive", h.ArchiveProject)
+	app.Get("/api/projects/:id/todos", h.GetProjectTodos)
+	app.Put("/api/projects/:id/todos/order", h.ReorderTodos)
+	return app
+}
+
+func TestProjectHandler_CreateProject(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      map[string]interface{}
+		setupMock  func(repo *MockProjectRepository)
+		wantStatus int
+	}{
+		{
+			name:  "успешное создание",
+			input: map[string]interface{}{"name": "  Работа  "},
+			setupMock: func(repo *MockProjectRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(project *models.Project) bool {
+					return project.UserID == userID && project.Name == "Работа" && !project.IsInbox
+				})).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "пустое название",
+			input:      map[string]interface{}{"name": " "},
+			setupMock:  func(repo *MockProjectRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockProjectRepository)
+			tt.setupMock(repo)
+			app := setupProjectApp(repo, userID)
+
+			body, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPost, "/api/projects", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestProjectHandler_GetProjects_Archived(t *testing.T) {
+	userID := uuid.New()
+	archivedAt := time.Now()
+	projects := []*models.Project{
+		{ID: uuid.New(), UserID: userID, Name: models.InboxProjectName, IsInbox: true},
+		{ID: uuid.New(), UserID: userID, Name: "Старый", ArchivedAt: &archivedAt},
+	}
+
+	repo := new(MockProjectRepository)
+	repo.On("GetByUserID", mock.Anything, userID, true).Return(projects, nil)
+	app := setupProjectApp(repo, userID)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/projects?archived=true", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got []models.Project
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 2)
+	assert.True(t, got[0].IsInbox)
+	assert.True(t, got[1].IsArchived())
+}
+
+func TestProjectHandler_InboxProtected(t *testing.T) {
+	userID := uuid.New()
+	inboxID := uuid.New()
+
+	repo := new(MockProjectRepository)
+	repo.On("Delete", mock.Anything, inboxID, userID).Return(repository.ErrInboxProject)
+	repo.On("SetArchived", mock.Anything, inboxID, userID, mock.Anything).Return(nil, repository.ErrInboxProject)
+	app := setupProjectApp(repo, userID)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/projects/"+inboxID.String(), nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, "/api/projects/"+inboxID.String()+"/archive", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestProjectHandler_ForeignProject(t *testing.T) {
+	userID := uuid.New()
+	foreignProject := &models.Project{ID: uuid.New(), UserID: uuid.New(), Name: "Чужой"}
+
+	repo := new(MockProjectRepository)
+	repo.On("GetByID", mock.Anything, foreignProject.ID).Return(foreignProject, nil)
+	app := setupProjectApp(repo, userID)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/projects/"+foreignProject.ID.String()+"/todos", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	body, _ := json.Marshal(map[string]interface{}{"todo_ids": []uuid.UUID{uuid.New()}})
+	req := httptest.NewRequest(http.MethodPut, "/api/projects/"+foreignProject.ID.String()+"/todos/order", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	repo.AssertNotCalled(t, "ReorderTodos", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProjectHandler_ReorderTodos_InvalidOrder(t *testing.T) {
+	userID := uuid.New()
+	project := &models.Project{ID: uuid.New(), UserID: userID, Name: "Работа"}
+	todoID := uuid.New()
+
+	repo := new(MockProjectRepository)
+	repo.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+	repo.On("ReorderTodos", mock.Anything, userID, project.ID, []uuid.UUID{todoID, todoID}).Return(repository.ErrInvalidTodoOrder)
+	app := setupProjectApp(repo, userID)
+
+	body, _ := json.Marshal(map[string]interface{}{"todo_ids": []uuid.UUID{todoID, todoID}})
+	req := httptest.NewRequest(http.MethodPut, "/api/projects/"+project.ID.String()+"/todos/order", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestProjectHandler_GetGroupedProjectTodos(t *testing.T) {
+	userID := uuid.New()
+	project := &models.Project{ID: uuid.New(), UserID: userID, Name: "Работа"}
+	tag := models.TodoTag{ID: uuid.New(), Name: "срочно"}
+	groups := []models.TodoGroup{{
+		Status:    "pending",
+		Priority:  "medium",
+		Count:     1,
+		Tasks:     []*models.Todo{{ID: uuid.New(), UserID: userID, ProjectID: &project.ID, Tags: []models.TodoTag{tag}}},
+		TagCounts: []models.TagCount{{Tag: tag, Count: 1}},
+	}}
+
+	repo := new(MockProjectRepository)
+	repo.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+	todos := new(MockTodoRepository)
+	filter := models.TodoFilter{ProjectID: &project.ID, GroupBy: models.TodoGroupByStatus}
+	todos.On("GetGroupedTodos", mock.Anything, userID, filter).Return(groups, nil)
+
+	h := NewProjectHandler(repo, todos)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID.String())
+		return c.Next()
+	})
+	app.Get("/api/projects/:id/todos/grouped", h.GetGroupedProjectTodos)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/projects/"+project.ID.String()+"/todos/grouped", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	todos.AssertExpectations(t)
+
+	var got []models.TodoGroup
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 1)
+	assert.Equal(t, 1, got[0].Count)
+	assert.Equal(t, groups[0].TagCounts, got[0].TagCounts)
+}
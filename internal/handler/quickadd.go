@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/quickadd"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// QuickAddHandler обрабатывает быстрое добавление задач одной строкой.
+// Должен вызываться после AuthMiddleware.
+type QuickAddHandler struct {
+	todos repository.TodoRepository
+	tags  repository.TagRepository
+}
+
+// NewQuickAddHandler создает новый экземпляр QuickAddHandler
+func NewQuickAddHandler(todos repository.TodoRepository, tags repository.TagRepository) *QuickAddHandler {
+	return &QuickAddHandler{todos: todos, tags: tags}
+}
+
+// QuickAddTodo разбирает строку text (quickadd.Parse), понимая даты в часовом поясе timezone,
+// и создает задачу с полученными названием, сроком, приоритетом, тегами и правилом повторения.
+// Недостающие теги создаются. С preview задача не создается и возвращается только результат
+// разбора, в том числе нераспознанные фрагменты.
+func (h *QuickAddHandler) QuickAddTodo(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	var input struct {
+		Text string `json:"text"`
+		// Timezone часовой пояс IANA, в котором понимаются даты и вычисляются повторения; по умолчанию UTC
+		Timezone string `json:"timezone"`
+		// ProjectID проект задачи; по умолчанию Inbox
+		ProjectID *uuid.UUID `json:"project_id"`
+		// Preview только разобрать строку, не создавая задачу
+		Preview bool `json:"preview"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	input.Text = strings.TrimSpace(input.Text)
+	if input.Text == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Text is required",
+		})
+	}
+	if input.Timezone == "" {
+		input.Timezone = models.DefaultRecurrenceTimezone
+	}
+	loc, err := time.LoadLocation(input.Timezone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid timezone",
+		})
+	}
+
+	parsed := quickadd.Parse(input.Text, time.Now().In(loc))
+	for _, name := range parsed.Tags {
+		if len([]rune(name)) > maxTagNameLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Tag name is too long",
+			})
+		}
+	}
+	if input.Preview {
+		return c.JSON(fiber.Map{"parsed": parsed})
+	}
+	if parsed.Title == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Title is required",
+		})
+	}
+
+	tags, err := h.resolveTags(c.Context(), userID, parsed.Tags)
+	if err != nil {
+		return tagRepositoryError(c, err, "Failed to create tags")
+	}
+
+	now := time.Now()
+	todo := &models.Todo{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     parsed.Title,
+		Status:    "pending",
+		Priority:  parsed.Priority,
+		ProjectID: input.ProjectID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if todo.Priority == "" {
+		todo.Priority = "medium"
+	}
+	if parsed.DueDate != nil {
+		todo.DueDate = *parsed.DueDate
+	}
+	if parsed.Recurrence != "" {
+		todo.Recurrence = &models.TodoRecurrence{Rule: parsed.Recurrence, Timezone: input.Timezone}
+	}
+
+	if err := h.todos.Create(c.Context(), todo); err != nil {
+		return todoRepositoryError(c, err, "Failed to create todo")
+	}
+	for _, tag := range tags {
+		if err := h.tags.AttachToTodo(c.Context(), userID, todo.ID, tag.ID); err != nil {
+			return tagRepositoryError(c, err, "Failed to attach tag")
+		}
+		todo.Tags = append(todo.Tags, models.TodoTag{ID: tag.ID, Name: tag.Name, Color: tag.Color})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"todo":   todo,
+		"parsed": parsed,
+	})
+}
+
+// resolveTags возвращает теги пользователя с названиями names без учета регистра,
+// создавая недостающие с цветом по умолчанию
+func (h *QuickAddHandler) resolveTags(ctx context.Context, userID uuid.UUID, names []string) ([]*models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	existing, err := h.tags.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.Tag, len(existing))
+	for _, tag := range existing {
+		byName[strings.ToLower(tag.Name)] = tag
+	}
+
+	tags := make([]*models.Tag, 0, len(names))
+	for _, name := range names {
+		tag, ok := byName[strings.ToLower(name)]
+		if !ok {
+			now := time.Now()
+			tag = &models.Tag{
+				ID:        uuid.New(),
+				UserID:    userID,
+				Name:      name,
+				Color:     models.DefaultTagColor,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := h.tags.Create(ctx, tag); err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
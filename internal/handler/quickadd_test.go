@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// todoRecorder реализует только Create репозитория задач и запоминает созданные задачи
+type todoRecorder struct {
+	repository.TodoRepository
+	created []*models.Todo
+}
+
+func (r *todoRecorder) Create(ctx context.Context, todo *models.Todo) error {
+	r.created = append(r.created, todo)
+	return nil
+}
+
+func setupQuickAddApp(todos *todoRecorder, tags *MockTagRepository, userID uuid.UUID) *fiber.App {
+	h := NewQuickAddHandler(todos, tags)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID.String())
+		return c.Next()
+	})
+	app.Post("/api/todos/quick", h.QuickAddTodo)
+	return app
+}
+
+func postQuickAdd(t *testing.T, app *fiber.App, input map[string]interface{}) (*http.Response, map[string]json.RawMessage) {
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/api/todos/quick", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var result map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return resp, result
+}
+
+func TestQuickAddHandler_QuickAddTodo(t *testing.T) {
+	userID := uuid.New()
+	home := &models.Tag{ID: uuid.New(), UserID: userID, Name: "Home", Color: "#00ff00"}
+
+	todos := &todoRecorder{}
+	tags := new(MockTagRepository)
+	tags.On("GetByUserID", mock.Anything, userID).Return([]*models.Tag{home}, nil)
+	tags.On("Create", mock.Anything, mock.MatchedBy(func(tag *models.Tag) bool {
+		return tag.Name == "bills" && tag.Color == models.DefaultTagColor
+	})).Return(nil)
+	tags.On("AttachToTodo", mock.Anything, userID, mock.Anything, mock.Anything).Return(nil)
+	app := setupQuickAddApp(todos, tags, userID)
+
+	resp, result := postQuickAdd(t, app, map[string]interface{}{
+		"text":     "Pay rent tomorrow 9am !high #home #bills every month",
+		"timezone": "UTC",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Len(t, todos.created, 1)
+
+	todo := todos.created[0]
+	assert.Equal(t, "Pay rent", todo.Title)
+	assert.Equal(t, "high", todo.Priority)
+	assert.Equal(t, "pending", todo.Status)
+	assert.Equal(t, 9, todo.DueDate.Hour())
+	require.NotNil(t, todo.Recurrence)
+	assert.Equal(t, "FREQ=MONTHLY", todo.Recurrence.Rule)
+	assert.Equal(t, "UTC", todo.Recurrence.Timezone)
+	require.Len(t, todo.Tags, 2)
+	assert.Equal(t, home.ID, todo.Tags[0].ID)
+	assert.Equal(t, "bills", todo.Tags[1].Name)
+	tags.AssertNumberOfCalls(t, "Create", 1)
+	tags.AssertNumberOfCalls(t, "AttachToTodo", 2)
+	assert.Contains(t, result, "parsed")
+}
+
+func TestQuickAddHandler_Preview(t *testing.T) {
+	userID := uuid.New()
+	todos := &todoRecorder{}
+	tags := new(MockTagRepository)
+	app := setupQuickAddApp(todos, tags, userID)
+
+	resp, result := postQuickAdd(t, app, map[string]interface{}{
+		"text":    "Отчет в пятницу !срочно #работа",
+		"preview": true,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed struct {
+		Title        string   `json:"title"`
+		DueDate      string   `json:"due_date"`
+		Tags         []string `json:"tags"`
+		Unrecognized []string `json:"unrecognized"`
+	}
+	require.NoError(t, json.Unmarshal(result["parsed"], &parsed))
+	assert.Equal(t, "Отчет !срочно", parsed.Title)
+	assert.NotEmpty(t, parsed.DueDate)
+	assert.Equal(t, []string{"работа"}, parsed.Tags)
+	assert.Equal(t, []string{"!срочно"}, parsed.Unrecognized)
+	assert.Empty(t, todos.created)
+	tags.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestQuickAddHandler_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+	}{
+		{name: "пустая строка", input: map[string]interface{}{"text": "  "}},
+		{name: "неизвестный часовой пояс", input: map[string]interface{}{"text": "Report", "timezone": "Mars/Olympus"}},
+		{name: "нет названия", input: map[string]interface{}{"text": "tomorrow !high"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos := &todoRecorder{}
+			app := setupQuickAddApp(todos, new(MockTagRepository), uuid.New())
+
+			resp, _ := postQuickAdd(t, app, tt.input)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Empty(t, todos.created)
+		})
+	}
+}
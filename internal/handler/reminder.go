@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ReminderHandler обрабатывает HTTP-запросы для работы с напоминаниями о задачах.
+// Напоминания отправляет планировщик cmd/scheduler. Должен вызываться после AuthMiddleware.
+type ReminderHandler struct {
+	repo  repository.ReminderRepository
+	todos services.TodoService
+}
+
+// NewReminderHandler создает новый экземпляр ReminderHandler
+func NewReminderHandler(repo repository.ReminderRepository, todos services.TodoService) *ReminderHandler {
+	return &ReminderHandler{repo: repo, todos: todos}
+}
+
+// GetReminders возвращает напоминания задачи
+func (h *ReminderHandler) GetReminders(c *fiber.Ctx) error {
+	todo, ok, err := h.ownTodo(c)
+	if !ok {
+		return err
+	}
+
+	reminders, err := h.repo.GetByTodoID(c.Context(), todo.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get reminders",
+		})
+	}
+	return c.JSON(reminders)
+}
+
+// CreateReminder создает напоминание о задаче: в абсолютное время remind_at или
+// за offset_minutes минут до срока задачи
+func (h *ReminderHandler) CreateReminder(c *fiber.Ctx) error {
+	var input models.ReminderRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := normalizeReminderRequest(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	todo, ok, err := h.ownTodo(c)
+	if !ok {
+		return err
+	}
+	if input.OffsetMinutes != nil && todo.DueDate.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Todo has no due date",
+		})
+	}
+
+	reminder := &models.Reminder{
+		ID:            uuid.New(),
+		TodoID:        todo.ID,
+		UserID:        todo.UserID,
+		RemindAt:      input.RemindAt,
+		OffsetMinutes: input.OffsetMinutes,
+		Channel:       input.Channel,
+		Target:        input.Target,
+		CreatedAt:     time.Now(),
+	}
+	if err := h.repo.Create(c.Context(), reminder); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create reminder",
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(reminder)
+}
+
+// DeleteReminder удаляет напоминание задачи
+func (h *ReminderHandler) DeleteReminder(c *fiber.Ctx) error {
+	reminder, ok, err := h.ownReminder(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.repo.Delete(c.Context(), reminder.ID, reminder.UserID); err != nil {
+		return reminderRepositoryError(c, err, "Failed to delete reminder")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetReminderDeliveries возвращает попытки доставки напоминания в порядке времени
+func (h *ReminderHandler) GetReminderDeliveries(c *fiber.Ctx) error {
+	reminder, ok, err := h.ownReminder(c)
+	if !ok {
+		return err
+	}
+
+	deliveries, err := h.repo.ListDeliveries(c.Context(), reminder.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get reminder deliveries",
+		})
+	}
+	return c.JSON(deliveries)
+}
+
+// ownTodo загружает задачу из параметра :id, доступную пользователю.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func (h *ReminderHandler) ownTodo(c *fiber.Ctx) (*models.Todo, bool, error) {
+	return ownTodo(c, h.todos)
+}
+
+// ownTodo загружает из todos задачу из параметра :id, доступную пользователю
+// из c.Locals("userID"). Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func ownTodo(c *fiber.Ctx, todos services.TodoService) (*models.Todo, bool, error) {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	todo, err := todos.GetByID(c.Context(), userID, todoID)
+	if err != nil {
+		return nil, false, todoRepositoryError(c, err, "Failed to get todo")
+	}
+	return todo, true, nil
+}
+
+// ownReminder загружает напоминание :reminderId задачи :id, принадлежащей пользователю.
+// Если ok == false, ответ уже записан и err нужно вернуть из обработчика.
+func (h *ReminderHandler) ownReminder(c *fiber.Ctx) (*models.Reminder, bool, error) {
+	reminderID, err := uuid.Parse(c.Params("reminderId"))
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid reminder ID format",
+		})
+	}
+
+	todo, ok, err := h.ownTodo(c)
+	if !ok {
+		return nil, false, err
+	}
+
+	reminders, err := h.repo.GetByTodoID(c.Context(), todo.ID)
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get reminders",
+		})
+	}
+	for _, reminder := range reminders {
+		if reminder.ID == reminderID {
+			return reminder, true, nil
+		}
+	}
+	return nil, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "Reminder not found",
+	})
+}
+
+// normalizeReminderRequest проверяет запрос напоминания и подставляет канал по умолчанию
+func normalizeReminderRequest(input *models.ReminderRequest) error {
+	if (input.RemindAt == nil) == (input.OffsetMinutes == nil) {
+		return errors.New("Either remind_at or offset_minutes is required")
+	}
+	if input.OffsetMinutes != nil && *input.OffsetMinutes < 0 {
+		return errors.New("offset_minutes must not be negative")
+	}
+
+	input.Target = strings.TrimSpace(input.Target)
+	switch input.Channel {
+	case "":
+		input.Channel = models.ReminderChannelInApp
+		input.Target = ""
+	case models.ReminderChannelInApp:
+		input.Target = ""
+	case models.ReminderChannelEmail:
+		// Без адреса письмо отправляется на email пользователя
+		if input.Target != "" {
+			if _, err := mail.ParseAddress(input.Target); err != nil {
+				return errors.New("Invalid email target")
+			}
+		}
+	case models.ReminderChannelWebhook:
+		target, err := url.Parse(input.Target)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return errors.New("Webhook target must be an http or https URL")
+		}
+	default:
+		return errors.New("Invalid channel, expected email, webhook or in_app")
+	}
+	return nil
+}
+
+// reminderRepositoryError преобразует ошибку репозитория напоминаний в HTTP ответ
+func reminderRepositoryError(c *fiber.Ctx, err error, msg string) error {
+	if errors.Is(err, repository.ErrReminderNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Reminder not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": msg,
+	})
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReminderRepository struct {
+	mock.Mock
+}
+
+func (m *MockReminderRepository) Create(ctx context.Context, reminder *models.Reminder) error {
+	args := m.Called(ctx, reminder)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.Reminder, error) {
+	args := m.Called(ctx, todoID)
+	return args.Get(0).([]*models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.DueReminder, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]*models.DueReminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) RecordDelivery(ctx context.Context, reminder *models.Reminder, delivery *models.ReminderDelivery) error {
+	args := m.Called(ctx, reminder, delivery)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ListDeliveries(ctx context.Context, reminderID uuid.UUID) ([]*models.ReminderDelivery, error) {
+	args := m.Called(ctx, reminderID)
+	return args.Get(0).([]*models.ReminderDelivery), args.Error(1)
+}
+
+// todoLookup реализует только GetByID репозитория задач
+type todoLookup struct {
+	repository.TodoRepository
+	todos map[uuid.UUID]*models.Todo
+}
+
+func (l *todoLookup) GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
+	todo, ok := l.todos[id]
+	if !ok {
+		return nil, repository.ErrTodoNotFound
+	}
+	return todo, nil
+}
+
+func setupReminderApp(repo *MockReminderRepository, userID uuid.UUID, todos ...*models.Todo) *fiber.App {
+	lookup := &todoLookup{todos: make(map[uuid.UUID]*models.Todo)}
+	for _, todo := range todos {
+		lookup.todos[todo.ID] = todo
+	}
+	h := NewReminderHandler(repo, services.NewTodoService(lookup, nil, nil))
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID.String())
+		return c.Next()
+	})
+	app.Post("/api/todos/:id/reminders", h.CreateReminder)
+	app.Delete("/api/todos/:id/reminders/:reminderId", h.DeleteReminder)
+	return app
+}
+
+func TestReminderHandler_CreateReminder(t *testing.T) {
+	userID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID, DueDate: time.Now().Add(24 * time.Hour)}
+	noDueDate := &models.Todo{ID: uuid.New(), UserID: userID}
+	foreign := &models.Todo{ID: uuid.New(), UserID: uuid.New(), DueDate: time.Now()}
+
+	tests := []struct {
+		name       string
+		todo       *models.Todo
+		input      map[string]interface{}
+		setupMock  func(repo *MockReminderRepository)
+		wantStatus int
+	}{
+		{
+			name:  "смещение до срока во входящие",
+			todo:  todo,
+			input: map[string]interface{}{"offset_minutes": 30},
+			setupMock: func(repo *MockReminderRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(reminder *models.Reminder) bool {
+					return reminder.TodoID == todo.ID && reminder.UserID == userID &&
+						*reminder.OffsetMinutes == 30 && reminder.Channel == models.ReminderChannelInApp
+				})).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:  "абсолютное время через webhook",
+			todo:  todo,
+			input: map[string]interface{}{"remind_at": "2026-05-01T09:00:00Z", "channel": "webhook", "target": "https://example.com/hook"},
+			setupMock: func(repo *MockReminderRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(reminder *models.Reminder) bool {
+					return reminder.RemindAt != nil && reminder.Target == "https://example.com/hook"
+				})).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "время и смещение одновременно",
+			todo:       todo,
+			input:      map[string]interface{}{"remind_at": "2026-05-01T09:00:00Z", "offset_minutes": 10},
+			setupMock:  func(repo *MockReminderRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "webhook без URL",
+			todo:       todo,
+			input:      map[string]interface{}{"offset_minutes": 10, "channel": "webhook", "target": "ftp://example.com"},
+			setupMock:  func(repo *MockReminderRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "неизвестный канал",
+			todo:       todo,
+			input:      map[string]interface{}{"offset_minutes": 10, "channel": "sms"},
+			setupMock:  func(repo *MockReminderRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "смещение для задачи без срока",
+			todo:       noDueDate,
+			input:      map[string]interface{}{"offset_minutes": 10},
+			setupMock:  func(repo *MockReminderRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "чужая задача",
+			todo:       foreign,
+			input:      map[string]interface{}{"offset_minutes": 10},
+			setupMock:  func(repo *MockReminderRepository) {},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockReminderRepository)
+			tt.setupMock(repo)
+			app := setupReminderApp(repo, userID, todo, noDueDate, foreign)
+
+			body, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/"+tt.todo.ID.String()+"/reminders", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			repo.AssertNumberOfCalls(t, "Create", len(repo.ExpectedCalls))
+		})
+	}
+}
+
+func TestReminderHandler_DeleteReminder_OtherTodo(t *testing.T) {
+	userID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: userID}
+	reminder := &models.Reminder{ID: uuid.New(), TodoID: uuid.New(), UserID: userID}
+
+	repo := new(MockReminderRepository)
+	repo.On("GetByTodoID", mock.Anything, todo.ID).Return([]*models.Reminder{}, nil)
+	app := setupReminderApp(repo, userID, todo)
+
+	// Напоминание другой задачи не удаляется через эту задачу
+	req := httptest.NewRequest(http.MethodDelete, "/api/todos/"+todo.ID.String()+"/reminders/"+reminder.ID.String(), nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
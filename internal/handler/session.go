@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SessionHandler обрабатывает завершение сессий пользователя
+type SessionHandler struct {
+	denylist      *auth.TokenDenylist
+	refreshTokens *auth.RefreshTokenManager
+}
+
+// NewSessionHandler создает новый экземпляр SessionHandler
+func NewSessionHandler(denylist *auth.TokenDenylist, refreshTokens *auth.RefreshTokenManager) *SessionHandler {
+	return &SessionHandler{
+		denylist:      denylist,
+		refreshTokens: refreshTokens,
+	}
+}
+
+// Logout отзывает токен, с которым пришел запрос.
+// Должен вызываться после AuthMiddleware.
+func (h *SessionHandler) Logout(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Требуется авторизация",
+		})
+	}
+
+	// Токены, выданные до появления jti, можно отозвать только выходом со всех устройств
+	if claims.Id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Токен не поддерживает отзыв, используйте выход со всех устройств",
+		})
+	}
+
+	if err := h.denylist.Revoke(c.Context(), claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Ошибка при отзыве токена",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// LogoutAll завершает все сессии пользователя: отзывает все выданные ранее access токены
+// и все refresh токены. Должен вызываться после AuthMiddleware.
+func (h *SessionHandler) LogoutAll(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Требуется авторизация",
+		})
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Неверный формат ID пользователя",
+		})
+	}
+
+	if err := h.denylist.RevokeIssuedBefore(c.Context(), claims.UserID, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Ошибка при отзыве токенов",
+		})
+	}
+
+	if err := h.refreshTokens.RevokeAll(c.Context(), userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Ошибка при отзыве refresh токенов",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/middleware"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCache реализует cache.Cache в памяти для тестов
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = data
+	return nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *memoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	return ok, nil
+}
+
+func (c *memoryCache) Increment(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (c *memoryCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (c *memoryCache) Close() error {
+	return nil
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, id uuid.UUID, next *models.RefreshToken) error {
+	args := m.Called(ctx, id, next)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func setupSessionApp(jwtManager *auth.JWTManager, tokenRepo *MockRefreshTokenRepository) *fiber.App {
+	denylist := auth.NewTokenDenylist(newMemoryCache(), time.Hour)
+	h := NewSessionHandler(denylist, auth.NewRefreshTokenManager(tokenRepo, time.Hour))
+	authRequired := middleware.AuthMiddleware(jwtManager, denylist)
+
+	app := fiber.New()
+	app.Post("/logout", authRequired, h.Logout)
+	app.Post("/logout-all", authRequired, h.LogoutAll)
+	app.Get("/profile", authRequired, func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	return app
+}
+
+func doWithToken(t *testing.T, app *fiber.App, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestSessionHandler_Logout(t *testing.T) {
+	jwtManager := auth.NewJWTManager([]byte("test_secret"))
+	app := setupSessionApp(jwtManager, new(MockRefreshTokenRepository))
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	token, err := jwtManager.Generate(user)
+	require.NoError(t, err)
+	otherToken, err := jwtManager.Generate(user)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNoContent, doWithToken(t, app, http.MethodPost, "/logout", token))
+	assert.Equal(t, http.StatusUnauthorized, doWithToken(t, app, http.MethodGet, "/profile", token))
+	// Остальные сессии пользователя не затрагиваются
+	assert.Equal(t, http.StatusOK, doWithToken(t, app, http.MethodGet, "/profile", otherToken))
+}
+
+func TestSessionHandler_LogoutAll(t *testing.T) {
+	jwtManager := auth.NewJWTManager([]byte("test_secret"))
+	tokenRepo := new(MockRefreshTokenRepository)
+	app := setupSessionApp(jwtManager, tokenRepo)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	tokenRepo.On("RevokeByUserID", mock.Anything, user.ID).Return(nil)
+
+	token, err := jwtManager.Generate(user)
+	require.NoError(t, err)
+	// Токен другой сессии, выданный секундой раньше
+	oldToken := signTestToken(t, user.ID, time.Now().Add(-time.Second))
+	// Токен другой сессии, выданный в ту же секунду, что и выход
+	sameSecondToken := signTestToken(t, user.ID, time.Now())
+
+	assert.Equal(t, http.StatusNoContent, doWithToken(t, app, http.MethodPost, "/logout-all", token))
+	assert.Equal(t, http.StatusUnauthorized, doWithToken(t, app, http.MethodGet, "/profile", token))
+	assert.Equal(t, http.StatusUnauthorized, doWithToken(t, app, http.MethodGet, "/profile", oldToken))
+	assert.Equal(t, http.StatusUnauthorized, doWithToken(t, app, http.MethodGet, "/profile", sameSecondToken))
+	// AssertExpectations форматирует аргументы вызова, включая fasthttp контекст,
+	// который переиспользуется следующими запросами, поэтому проверяем только количество вызовов
+	tokenRepo.AssertNumberOfCalls(t, "RevokeByUserID", 1)
+
+	// Токены, выданные после выхода, действительны
+	time.Sleep(time.Second)
+	newToken, err := jwtManager.Generate(user)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, doWithToken(t, app, http.MethodGet, "/profile", newToken))
+}
+
+// signTestToken подписывает токен с заданным временем выдачи
+func signTestToken(t *testing.T, userID uuid.UUID, issuedAt time.Time) string {
+	claims := auth.Claims{
+		UserID: userID.String(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test_secret"))
+	require.NoError(t, err)
+	return token
+}
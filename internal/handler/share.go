@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ShareHandler обрабатывает HTTP-запросы к общему доступу к проектам и задачам
+// и приглашениям пользователя. Должен вызываться после AuthMiddleware.
+type ShareHandler struct {
+	shares services.ShareService
+}
+
+// NewShareHandler создает новый экземпляр ShareHandler
+func NewShareHandler(shares services.ShareService) *ShareHandler {
+	return &ShareHandler{shares: shares}
+}
+
+// ShareTodo приглашает пользователя с адресом email к задаче :id с ролью viewer, editor или owner
+func (h *ShareHandler) ShareTodo(c *fiber.Ctx) error {
+	return h.invite(c, "Invalid todo ID format", h.shares.ShareTodo)
+}
+
+// ShareProject приглашает пользователя с адресом email к проекту :id и всем его задачам
+func (h *ShareHandler) ShareProject(c *fiber.Ctx) error {
+	return h.invite(c, "Invalid project ID format", h.shares.ShareProject)
+}
+
+// GetTodoShares возвращает приглашения к задаче :id
+func (h *ShareHandler) GetTodoShares(c *fiber.Ctx) error {
+	return h.list(c, "Invalid todo ID format", h.shares.ListTodoShares)
+}
+
+// GetProjectShares возвращает приглашения к проекту :id
+func (h *ShareHandler) GetProjectShares(c *fiber.Ctx) error {
+	return h.list(c, "Invalid project ID format", h.shares.ListProjectShares)
+}
+
+// UpdateShare меняет роль участника
+func (h *ShareHandler) UpdateShare(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	shareID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid share ID format",
+		})
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if !models.IsValidShareRole(input.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid role, expected viewer, editor or owner",
+		})
+	}
+
+	share, err := h.shares.UpdateRole(c.Context(), userID, shareID, input.Role)
+	if err != nil {
+		return shareServiceError(c, err, "Failed to update share")
+	}
+	return c.JSON(share)
+}
+
+// DeleteShare отзывает приглашение или доступ участника. Участник может отозвать свой доступ сам.
+func (h *ShareHandler) DeleteShare(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	shareID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid share ID format",
+		})
+	}
+
+	if err := h.shares.Revoke(c.Context(), userID, shareID); err != nil {
+		return shareServiceError(c, err, "Failed to delete share")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetInvitations возвращает ожидающие ответа приглашения на адрес пользователя
+func (h *ShareHandler) GetInvitations(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	invitations, err := h.shares.ListInvitations(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get invitations",
+		})
+	}
+	return c.JSON(invitations)
+}
+
+// AcceptInvitation принимает приглашение: проект или задача становятся доступны пользователю
+// и появляются в его списке задач
+func (h *ShareHandler) AcceptInvitation(c *fiber.Ctx) error {
+	return h.respond(c, true)
+}
+
+// DeclineInvitation отклоняет приглашение
+func (h *ShareHandler) DeclineInvitation(c *fiber.Ctx) error {
+	return h.respond(c, false)
+}
+
+func (h *ShareHandler) respond(c *fiber.Ctx, accept bool) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	shareID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invitation ID format",
+		})
+	}
+
+	share, err := h.shares.Respond(c.Context(), userID, shareID, accept)
+	if errors.Is(err, repository.ErrShareNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invitation not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to respond to invitation",
+		})
+	}
+	return c.JSON(share)
+}
+
+// invite создает приглашение к проекту или задаче :id методом share
+func (h *ShareHandler) invite(c *fiber.Ctx, invalidID string,
+	share func(ctx context.Context, userID, id uuid.UUID, req models.ShareRequest) (*models.Share, error)) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": invalidID,
+		})
+	}
+
+	var input models.ShareRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	input.Email = strings.TrimSpace(input.Email)
+	if !isValidEmail(input.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid email format",
+		})
+	}
+	if !models.IsValidShareRole(input.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid role, expected viewer, editor or owner",
+		})
+	}
+
+	created, err := share(c.Context(), userID, id, input)
+	if err != nil {
+		return shareServiceError(c, err, "Failed to create share")
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// list возвращает приглашения к проекту или задаче :id методом list
+func (h *ShareHandler) list(c *fiber.Ctx, invalidID string,
+	list func(ctx context.Context, userID, id uuid.UUID) ([]*models.Share, error)) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": invalidID,
+		})
+	}
+
+	shares, err := list(c.Context(), userID, id)
+	if err != nil {
+		return shareServiceError(c, err, "Failed to get shares")
+	}
+	return c.JSON(shares)
+}
+
+// shareServiceError преобразует ошибку ShareService в HTTP-ответ
+func shareServiceError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrTodoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Todo not found",
+		})
+	case errors.Is(err, repository.ErrProjectNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Project not found",
+		})
+	case errors.Is(err, repository.ErrShareNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Share not found",
+		})
+	case errors.Is(err, repository.ErrShareExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "User is already invited",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": msg,
+	})
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shareServiceStub реализует приглашение к задаче и ответ на приглашение
+type shareServiceStub struct {
+	services.ShareService
+	err      error
+	requests []models.ShareRequest
+	accepted []bool
+}
+
+func (s *shareServiceStub) ShareTodo(ctx context.Context, userID, todoID uuid.UUID, req models.ShareRequest) (*models.Share, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.requests = append(s.requests, req)
+	return &models.Share{ID: uuid.New(), TodoID: &todoID, InvitedBy: userID, Email: req.Email, Role: req.Role}, nil
+}
+
+func (s *shareServiceStub) Respond(ctx context.Context, userID, id uuid.UUID, accept bool) (*models.Share, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.accepted = append(s.accepted, accept)
+	return &models.Share{ID: id, UserID: &userID}, nil
+}
+
+func setupShareApp(shares *shareServiceStub) *fiber.App {
+	h := NewShareHandler(shares)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New().String())
+		return c.Next()
+	})
+	app.Post("/api/todos/:id/shares", h.ShareTodo)
+	app.Post("/api/invitations/:id/accept", h.AcceptInvitation)
+	app.Post("/api/invitations/:id/decline", h.DeclineInvitation)
+	return app
+}
+
+func TestShareHandler_ShareTodo(t *testing.T) {
+	tests := []struct {
+		name       string
+		todoID     string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "приглашение редактора",
+			todoID:     uuid.New().String(),
+			body:       `{"email":" anna@example.com ","role":"editor"}`,
+			wantStatus: fiber.StatusCreated,
+		},
+		{
+			name:       "неверный ID задачи",
+			todoID:     "abc",
+			body:       `{"email":"anna@example.com","role":"editor"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "неверный email",
+			todoID:     uuid.New().String(),
+			body:       `{"email":"anna","role":"editor"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "неизвестная роль",
+			todoID:     uuid.New().String(),
+			body:       `{"email":"anna@example.com","role":"admin"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "нет права делиться задачей",
+			todoID:     uuid.New().String(),
+			body:       `{"email":"anna@example.com","role":"viewer"}`,
+			err:        repository.ErrTodoNotFound,
+			wantStatus: fiber.StatusNotFound,
+		},
+		{
+			name:       "адрес уже приглашен",
+			todoID:     uuid.New().String(),
+			body:       `{"email":"anna@example.com","role":"viewer"}`,
+			err:        repository.ErrShareExists,
+			wantStatus: fiber.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareServiceStub{err: tt.err}
+			app := setupShareApp(shares)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/todos/"+tt.todoID+"/shares", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus == fiber.StatusCreated {
+				require.Len(t, shares.requests, 1)
+				assert.Equal(t, "anna@example.com", shares.requests[0].Email)
+			}
+		})
+	}
+}
+
+func TestShareHandler_RespondInvitation(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		err        error
+		wantStatus int
+		wantAccept []bool
+	}{
+		{name: "принятие", action: "accept", wantStatus: fiber.StatusOK, wantAccept: []bool{true}},
+		{name: "отклонение", action: "decline", wantStatus: fiber.StatusOK, wantAccept: []bool{false}},
+		{name: "приглашение не найдено", action: "accept", err: repository.ErrShareNotFound, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareServiceStub{err: tt.err}
+			app := setupShareApp(shares)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/invitations/"+uuid.New().String()+"/"+tt.action, nil)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantAccept, shares.accepted)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// streamResetEvent сообщает клиенту, что пропущенные изменения недоступны и задачи нужно перезагрузить
+const streamResetEvent = "reset"
+
+// StreamHandler отправляет изменения задач пользователя в браузер через SSE и WebSocket
+type StreamHandler struct {
+	broker    *events.Broker
+	heartbeat time.Duration
+}
+
+// NewStreamHandler создает новый экземпляр StreamHandler.
+// heartbeat задает интервал служебных сообщений, не дающих прокси закрыть простаивающее соединение.
+func NewStreamHandler(broker *events.Broker, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
+
+// StreamTodos обрабатывает GET-запрос на поток изменений задач в формате Server-Sent Events.
+// Для возобновления используется заголовок Last-Event-ID или параметр since (RFC 3339).
+func (h *StreamHandler) StreamTodos(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	resumeFrom := c.Get("Last-Event-ID")
+	if resumeFrom == "" {
+		resumeFrom = c.Query("since")
+	}
+	since, err := parseSince(resumeFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid since format",
+		})
+	}
+
+	sub, reset, err := h.subscribe(userID, since)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to subscribe to todo changes",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.writeSSE(w, sub, reset)
+	})
+	return nil
+}
+
+// WebSocketUpgrade пропускает дальше только запросы на установку WebSocket соединения
+func (h *StreamHandler) WebSocketUpgrade(c *fiber.Ctx) error {
+	if websocket.IsWebSocketUpgrade(c) {
+		return c.Next()
+	}
+	return fiber.ErrUpgradeRequired
+}
+
+// StreamTodosWebSocket отправляет изменения задач через WebSocket.
+// Каждое сообщение — JSON события; для возобновления используется параметр since.
+func (h *StreamHandler) StreamTodosWebSocket(conn *websocket.Conn) {
+	defer conn.Close()
+
+	userID, err := uuid.Parse(localUserID(conn.Locals("userID")))
+	if err != nil {
+		closeWebSocket(conn, websocket.ClosePolicyViolation, "invalid user ID in token")
+		return
+	}
+
+	since, err := parseSince(conn.Query("since"))
+	if err != nil {
+		closeWebSocket(conn, websocket.CloseUnsupportedData, "invalid since format")
+		return
+	}
+
+	sub, reset, err := h.subscribe(userID, since)
+	if err != nil {
+		closeWebSocket(conn, websocket.CloseInternalServerErr, "failed to subscribe to todo changes")
+		return
+	}
+	defer sub.Close()
+
+	if reset {
+		if err := conn.WriteJSON(fiber.Map{"type": streamResetEvent}); err != nil {
+			return
+		}
+	}
+
+	// Клиент ничего не отправляет, но чтение нужно для обработки pong и закрытия соединения
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				closeWebSocket(conn, websocket.CloseTryAgainLater, "subscription closed, reconnect with since")
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.heartbeat)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe подписывается на изменения задач. Если пропущенные события уже недоступны,
+// подписка начинается с текущего момента и reset равен true.
+func (h *StreamHandler) subscribe(userID uuid.UUID, since time.Time) (sub *events.Subscription, reset bool, err error) {
+	sub, err = h.broker.Subscribe(userID, since)
+	if errors.Is(err, events.ErrResumeWindowExceeded) {
+		sub, err = h.broker.Subscribe(userID, time.Time{})
+		return sub, true, err
+	}
+	return sub, false, err
+}
+
+// writeSSE пишет события подписки в поток SSE, пока подписка активна и клиент подключен
+func (h *StreamHandler) writeSSE(w *bufio.Writer, sub *events.Subscription, reset bool) {
+	defer sub.Close()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	if reset {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", streamResetEvent)
+	}
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Соединение закрывается, EventSource переподключится с Last-Event-ID
+				return
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSEEvent записывает событие в формате SSE. В качестве id используется время
+// события, чтобы переданный браузером Last-Event-ID можно было использовать как since.
+func writeSSEEvent(w *bufio.Writer, event models.TodoEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n",
+		event.OccurredAt.Format(time.RFC3339Nano), event.Type, data)
+	return err
+}
+
+// parseSince разбирает время возобновления подписки. Пустая строка означает подписку с текущего момента.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// localUserID возвращает ID пользователя, сохраненный AuthMiddleware
+func localUserID(value interface{}) string {
+	userID, _ := value.(string)
+	return userID
+}
+
+// closeWebSocket отправляет клиенту кадр закрытия с кодом и причиной
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamHandler_WriteSSE(t *testing.T) {
+	broker := events.NewBroker(nil, events.DefaultConfig())
+	h := NewStreamHandler(broker, time.Hour)
+	userID := uuid.New()
+
+	sub, err := broker.Subscribe(userID, time.Time{})
+	require.NoError(t, err)
+
+	event := models.NewTodoEvent(models.TodoEventCreated, &models.Todo{ID: uuid.New(), UserID: userID})
+	require.NoError(t, broker.Publish(context.Background(), event))
+	broker.Close()
+
+	var buf bytes.Buffer
+	h.writeSSE(bufio.NewWriter(&buf), sub, false)
+
+	body := buf.String()
+	assert.Contains(t, body, "retry: 3000\n\n")
+	assert.Contains(t, body, "id: "+event.OccurredAt.Format(time.RFC3339Nano)+"\n")
+	assert.Contains(t, body, "event: created\n")
+	assert.Contains(t, body, event.Todo.ID.String())
+}
+
+func TestStreamHandler_WriteSSE_Reset(t *testing.T) {
+	broker := events.NewBroker(nil, events.DefaultConfig())
+	h := NewStreamHandler(broker, time.Hour)
+	userID := uuid.New()
+
+	sub, reset, err := h.subscribe(userID, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reset)
+	broker.Close()
+
+	var buf bytes.Buffer
+	h.writeSSE(bufio.NewWriter(&buf), sub, reset)
+	assert.Contains(t, buf.String(), "event: reset\n")
+}
+
+func TestStreamHandler_StreamTodos(t *testing.T) {
+	h := NewStreamHandler(events.NewBroker(nil, events.DefaultConfig()), time.Hour)
+
+	tests := []struct {
+		name       string
+		userID     string
+		query      string
+		wantStatus int
+	}{
+		{
+			name:       "неверный ID пользователя",
+			userID:     "invalid-id",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "неверный формат since",
+			userID:     uuid.New().String(),
+			query:      "?since=yesterday",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/api/todos/stream", func(c *fiber.Ctx) error {
+				c.Locals("userID", tt.userID)
+				return c.Next()
+			}, h.StreamTodos)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/todos/stream"+tt.query, nil)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestStreamHandler_WebSocketUpgrade(t *testing.T) {
+	h := NewStreamHandler(events.NewBroker(nil, events.DefaultConfig()), time.Hour)
+	app := fiber.New()
+	app.Get("/api/todos/stream/ws", h.WebSocketUpgrade, func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/todos/stream/ws", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+}
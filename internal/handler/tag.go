@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxTagNameLength максимальная длина названия тега
+const maxTagNameLength = 50
+
+// tagColorRegexp допустимый формат цвета тега: #RRGGBB
+var tagColorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// TagHandler обрабатывает HTTP-запросы для работы с тегами пользователя.
+// Должен вызываться после AuthMiddleware.
+type TagHandler struct {
+	repo repository.TagRepository
+}
+
+// NewTagHandler создает новый экземпляр TagHandler
+func NewTagHandler(repo repository.TagRepository) *TagHandler {
+	return &TagHandler{repo: repo}
+}
+
+// GetTags возвращает теги пользователя, отсортированные по названию
+func (h *TagHandler) GetTags(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	tags, err := h.repo.GetByUserID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get tags",
+		})
+	}
+	return c.JSON(tags)
+}
+
+// CreateTag создает новый тег
+func (h *TagHandler) CreateTag(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	var input models.TagRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := normalizeTagRequest(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	now := time.Now()
+	tag := &models.Tag{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      input.Name,
+		Color:     input.Color,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.repo.Create(c.Context(), tag); err != nil {
+		return tagRepositoryError(c, err, "Failed to create tag")
+	}
+	return c.Status(fiber.StatusCreated).JSON(tag)
+}
+
+// UpdateTag изменяет название и цвет тега
+func (h *TagHandler) UpdateTag(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	tagID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID format",
+		})
+	}
+
+	var input models.TagRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := normalizeTagRequest(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	tag, err := h.repo.GetByID(c.Context(), tagID)
+	if err != nil || tag.UserID != userID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tag not found",
+		})
+	}
+
+	tag.Name = input.Name
+	tag.Color = input.Color
+	tag.UpdatedAt = time.Now()
+
+	if err := h.repo.Update(c.Context(), tag); err != nil {
+		return tagRepositoryError(c, err, "Failed to update tag")
+	}
+	return c.JSON(tag)
+}
+
+// DeleteTag удаляет тег и снимает его со всех задач
+func (h *TagHandler) DeleteTag(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	tagID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID format",
+		})
+	}
+
+	if err := h.repo.Delete(c.Context(), tagID, userID); err != nil {
+		return tagRepositoryError(c, err, "Failed to delete tag")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AttachTag назначает тег задаче
+func (h *TagHandler) AttachTag(c *fiber.Ctx) error {
+	return h.changeTodoTag(c, h.repo.AttachToTodo, "Failed to attach tag")
+}
+
+// DetachTag снимает тег с задачи
+func (h *TagHandler) DetachTag(c *fiber.Ctx) error {
+	return h.changeTodoTag(c, h.repo.DetachFromTodo, "Failed to detach tag")
+}
+
+// changeTodoTag разбирает параметры :id и :tagId и выполняет операцию с тегом задачи
+func (h *TagHandler) changeTodoTag(c *fiber.Ctx, change func(ctx context.Context, userID, todoID, tagID uuid.UUID) error, msg string) error {
+	userID, err := uuid.Parse(localUserID(c.Locals("userID")))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID in token",
+		})
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	tagID, err := uuid.Parse(c.Params("tagId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID format",
+		})
+	}
+
+	if err := change(c.Context(), userID, todoID, tagID); err != nil {
+		return tagRepositoryError(c, err, msg)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// normalizeTagRequest проверяет запрос тега и подставляет цвет по умолчанию
+func normalizeTagRequest(input *models.TagRequest) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return errors.New("Tag name is required")
+	}
+	if len([]rune(input.Name)) > maxTagNameLength {
+		return errors.New("Tag name is too long")
+	}
+
+	if input.Color == "" {
+		input.Color = models.DefaultTagColor
+	}
+	if !tagColorRegexp.MatchString(input.Color) {
+		return errors.New("Invalid tag color, expected #RRGGBB")
+	}
+	input.Color = strings.ToLower(input.Color)
+	return nil
+}
+
+// tagRepositoryError преобразует ошибку репозитория тегов в HTTP-ответ
+func tagRepositoryError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrTagNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tag not found",
+		})
+	case errors.Is(err, repository.ErrTodoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Todo not found",
+		})
+	case errors.Is(err, repository.ErrTagExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Tag with this name already exists",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": msg,
+	})
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tag, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Tag, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) AttachToTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	args := m.Called(ctx, userID, todoID, tagID)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) DetachFromTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	args := m.Called(ctx, userID, todoID, tagID)
+	return args.Error(0)
+}
+
+func setupTagApp(repo *MockTagRepository, userID uuid.UUID) *fiber.App {
+	h := NewTagHandler(repo)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID.String())
+		return c.Next()
+	})
+	app.Get("/api/tags", h.GetTags)
+	app.Post("/api/tags", h.CreateTag)
+	app.Put("/api/tags/:id", h.UpdateTag)
+	app.Delete("/api/tags/:id", h.DeleteTag)
+	app.Post("/api/todos/:id/tags/:tagId", h.AttachTag)
+	app.Delete("/api/todos/:id/tags/:tagId", h.DetachTag)
+	return app
+}
+
+func TestTagHandler_CreateTag(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name       string
+		input      map[string]interface{}
+		setupMock  func(repo *MockTagRepository)
+		wantStatus int
+		wantColor  string
+	}{
+		{
+			name:  "успешное создание с цветом по умолчанию",
+			input: map[string]interface{}{"name": "  Дом  "},
+			setupMock: func(repo *MockTagRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(tag *models.Tag) bool {
+					return tag.UserID == userID && tag.Name == "Дом"
+				})).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantColor:  models.DefaultTagColor,
+		},
+		{
+			name:  "цвет приводится к нижнему регистру",
+			input: map[string]interface{}{"name": "Работа", "color": "#FF00AA"},
+			setupMock: func(repo *MockTagRepository) {
+				repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantColor:  "#ff00aa",
+		},
+		{
+			name:       "пустое название",
+			input:      map[string]interface{}{"name": " "},
+			setupMock:  func(repo *MockTagRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "неверный цвет",
+			input:      map[string]interface{}{"name": "Дом", "color": "red"},
+			setupMock:  func(repo *MockTagRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "тег уже существует",
+			input: map[string]interface{}{"name": "Дом"},
+			setupMock: func(repo *MockTagRepository) {
+				repo.On("Create", mock.Anything, mock.Anything).Return(repository.ErrTagExists)
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTagRepository)
+			tt.setupMock(repo)
+			app := setupTagApp(repo, userID)
+
+			body, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPost, "/api/tags", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantColor != "" {
+				var tag models.Tag
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&tag))
+				assert.Equal(t, tt.wantColor, tag.Color)
+			}
+		})
+	}
+}
+
+func TestTagHandler_UpdateTag_ForeignTag(t *testing.T) {
+	userID := uuid.New()
+	foreignTag := &models.Tag{ID: uuid.New(), UserID: uuid.New(), Name: "Чужой"}
+
+	repo := new(MockTagRepository)
+	repo.On("GetByID", mock.Anything, foreignTag.ID).Return(foreignTag, nil)
+	app := setupTagApp(repo, userID)
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Мой"})
+	req := httptest.NewRequest(http.MethodPut, "/api/tags/"+foreignTag.ID.String(), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestTagHandler_AttachTag(t *testing.T) {
+	userID := uuid.New()
+	todoID := uuid.New()
+	tagID := uuid.New()
+
+	tests := []struct {
+		name       string
+		path       string
+		repoErr    error
+		wantStatus int
+	}{
+		{
+			name:       "успешное назначение",
+			path:       "/api/todos/" + todoID.String() + "/tags/" + tagID.String(),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "задача не найдена",
+			path:       "/api/todos/" + todoID.String() + "/tags/" + tagID.String(),
+			repoErr:    repository.ErrTodoNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "тег не найден",
+			path:       "/api/todos/" + todoID.String() + "/tags/" + tagID.String(),
+			repoErr:    repository.ErrTagNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "неверный ID тега",
+			path:       "/api/todos/" + todoID.String() + "/tags/invalid-id",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockTagRepository)
+			repo.On("AttachToTodo", mock.Anything, userID, todoID, tagID).Return(tt.repoErr)
+			app := setupTagApp(repo, userID)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodPost, tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+// todoTagStore назначает теги задачам todoLookup, увеличивая их версию
+type todoTagStore struct {
+	repository.TagRepository
+	todos *todoLookup
+	tags  map[uuid.UUID]models.TodoTag
+}
+
+func (s *todoTagStore) AttachToTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	todo := *s.todos.todos[todoID]
+	for _, tag := range todo.Tags {
+		if tag.ID == tagID {
+			return nil
+		}
+	}
+	todo.Tags = append(append([]models.TodoTag{}, todo.Tags...), s.tags[tagID])
+	todo.Version++
+	s.todos.todos[todoID] = &todo
+	return nil
+}
+
+func (s *todoTagStore) DetachFromTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	todo := *s.todos.todos[todoID]
+	var tags []models.TodoTag
+	for _, tag := range todo.Tags {
+		if tag.ID != tagID {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == len(todo.Tags) {
+		return nil
+	}
+	todo.Tags = tags
+	todo.Version++
+	s.todos.todos[todoID] = &todo
+	return nil
+}
+
+// eventLog запоминает опубликованные события
+type eventLog struct {
+	events []models.TodoEvent
+}
+
+func (l *eventLog) Publish(ctx context.Context, event models.TodoEvent) error {
+	l.events = append(l.events, event)
+	return nil
+}
+
+func TestTagHandler_TodoTagsHistoryAndEvents(t *testing.T) {
+	userID := uuid.New()
+	tag := models.TodoTag{ID: uuid.New(), Name: "работа", Color: "#00ff00"}
+
+	tests := []struct {
+		name        string
+		method      string
+		tags        []models.TodoTag
+		wantChanges bool
+	}{
+		{name: "назначение тега", method: http.MethodPost, wantChanges: true},
+		{name: "повторное назначение", method: http.MethodPost, tags: []models.TodoTag{tag}},
+		{name: "снятие тега", method: http.MethodDelete, tags: []models.TodoTag{tag}, wantChanges: true},
+		{name: "снятие отсутствующего тега", method: http.MethodDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := &models.Todo{ID: uuid.New(), UserID: userID, Title: "Отчет", Tags: tt.tags, Version: 1}
+			todos := &todoLookup{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}
+			history := new(MockTodoHistoryRepository)
+			history.On("Create", mock.Anything, mock.AnythingOfType("*models.TodoHistoryEntry")).Return(nil)
+			published := &eventLog{}
+			store := &todoTagStore{todos: todos, tags: map[uuid.UUID]models.TodoTag{tag.ID: tag}}
+			tags := repository.NewEventTagRepository(repository.NewHistoryTagRepository(store, todos, history), todos, nil, published)
+
+			h := NewTagHandler(tags)
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("userID", userID.String())
+				return c.Next()
+			})
+			app.Post("/api/todos/:id/tags/:tagId", h.AttachTag)
+			app.Delete("/api/todos/:id/tags/:tagId", h.DetachTag)
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, "/api/todos/"+todo.ID.String()+"/tags/"+tag.ID.String(), nil))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+			if !tt.wantChanges {
+				history.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				assert.Empty(t, published.events)
+				return
+			}
+
+			require.Len(t, history.Calls, 1)
+			entry := history.Calls[0].Arguments.Get(1).(*models.TodoHistoryEntry)
+			assert.Equal(t, models.TodoHistoryUpdated, entry.Action)
+			require.Len(t, entry.Changes, 1)
+			assert.Equal(t, "tags", entry.Changes[0].Field)
+
+			require.Len(t, published.events, 1)
+			assert.Equal(t, models.TodoEventUpdated, published.events[0].Type)
+			assert.Equal(t, userID, published.events[0].UserID)
+		})
+	}
+}
@@ -1,55 +1,135 @@
 package handler
 
 import (
-	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/R-eSPeCT/todo-list/internal/auth"
 	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+const (
+	// recurrenceScopeThis изменение только текущего повторения
+	recurrenceScopeThis = "this"
+	// recurrenceScopeFuture изменение текущего и последующих повторений
+	recurrenceScopeFuture = "future"
+	// defaultOccurrencesCount число дат предпросмотра повторений по умолчанию
+	defaultOccurrencesCount = 10
+	// maxOccurrencesCount максимальное число дат предпросмотра повторений
+	maxOccurrencesCount = 100
+	// defaultTodosLimit размер страницы GetTodos по умолчанию
+	defaultTodosLimit = 50
+	// maxTodosLimit максимальный размер страницы GetTodos
+	maxTodosLimit = 200
+	// defaultSearchLimit число результатов поиска по умолчанию
+	defaultSearchLimit = 20
+	// maxSearchLimit максимальное число результатов поиска
+	maxSearchLimit = 100
+)
+
+// TodoPage представляет страницу списка задач
+type TodoPage struct {
+	Todos []*models.Todo `json:"todos"`
+	// NextCursor курсор следующей страницы; пусто, если страница последняя
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 // TodoHandler представляет собой обработчик HTTP-запросов для работы с задачами (Todo).
 type TodoHandler struct {
-	repo       repository.TodoRepository
+	repo repository.TodoRepository
+	// todos проверяет доступ пользователя к задаче, запрошенной по ID
+	todos      services.TodoService
 	jwtManager *auth.JWTManager
 }
 
-// NewTodoHandler создает новый экземпляр TodoHandler с использованием репозитория и менеджера JWT.
-func NewTodoHandler(repo repository.TodoRepository, jwtManager *auth.JWTManager) *TodoHandler {
+// NewTodoHandler создает новый экземпляр TodoHandler с использованием репозитория, сервиса задач
+// и менеджера JWT.
+func NewTodoHandler(repo repository.TodoRepository, todos services.TodoService, jwtManager *auth.JWTManager) *TodoHandler {
 	return &TodoHandler{
 		repo:       repo,
+		todos:      todos,
 		jwtManager: jwtManager,
 	}
 }
 
-// GetTodos обрабатывает GET-запрос для получения списка всех задач пользователя.
+// GetTodos обрабатывает GET-запрос для получения страницы задач пользователя.
+// Принимает фильтры parseTodoFilter, поле сортировки sort (created_at, updated_at, due_date,
+// priority, position), направление order (asc или desc), размер страницы limit и курсор cursor,
+// полученный в next_cursor предыдущей страницы. Задачи архивных проектов не возвращаются.
 func (h *TodoHandler) GetTodos(c *fiber.Ctx) error {
 	userID, err := h.getUserIDFromToken(c)
 	if err != nil {
 		return err
 	}
+	return h.listTodos(c, userID, nil)
+}
+
+// GetAssignedTodos обрабатывает GET-запрос для получения задач, назначенных текущему пользователю,
+// включая задачи общего доступа. Принимает те же фильтры и параметры страницы, что и GetTodos.
+func (h *TodoHandler) GetAssignedTodos(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+	return h.listTodos(c, userID, &userID)
+}
+
+// listTodos возвращает страницу задач пользователя, подходящих под фильтры из параметров
+// запроса; если assigneeID задан, только задачи, назначенные этому пользователю
+func (h *TodoHandler) listTodos(c *fiber.Ctx, userID uuid.UUID, assigneeID *uuid.UUID) error {
+	filter, err := parseTodoFilter(c)
+	if err == nil {
+		filter.AssigneeID = assigneeID
+		err = parseTodoPage(c, &filter)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	ctx := context.Background()
-	todos, err := h.repo.GetByUserID(ctx, userID)
+	// Лишняя задача показывает, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	todos, err := h.repo.List(c.Context(), userID, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get todos",
 		})
 	}
-	return c.JSON(todos)
+
+	page := TodoPage{Todos: todos}
+	if len(todos) > limit {
+		page.Todos = todos[:limit]
+		sort, desc := filter.SortOrder()
+		page.NextCursor = models.NewTodoCursor(page.Todos[limit-1], sort, desc).Encode()
+	}
+	if page.Todos == nil {
+		page.Todos = []*models.Todo{}
+	}
+	return c.JSON(page)
 }
 
 // CreateTodo обрабатывает POST-запрос для создания новой задачи.
 func (h *TodoHandler) CreateTodo(c *fiber.Ctx) error {
 	var input struct {
-		Title       string    `json:"title"`
-		Description string    `json:"description"`
-		DueDate     time.Time `json:"due_date"`
-		Status      string    `json:"status"`
-		Priority    string    `json:"priority"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		DueDate     time.Time  `json:"due_date"`
+		Status      string     `json:"status"`
+		Priority    string     `json:"priority"`
+		ParentID    *uuid.UUID `json:"parent_id"`
+		// ProjectID проект задачи; по умолчанию проект родителя или Inbox
+		ProjectID *uuid.UUID `json:"project_id"`
+		// Recurrence правило повторения RRULE; срок задачи становится началом серии
+		Recurrence string `json:"recurrence"`
+		// Timezone часовой пояс IANA, в котором вычисляются повторения; по умолчанию UTC
+		Timezone string `json:"timezone"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
@@ -83,14 +163,17 @@ func (h *TodoHandler) CreateTodo(c *fiber.Ctx) error {
 		DueDate:     input.DueDate,
 		Status:      input.Status,
 		Priority:    input.Priority,
+		ParentID:    input.ParentID,
+		ProjectID:   input.ProjectID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	if input.Recurrence != "" {
+		todo.Recurrence = &models.TodoRecurrence{Rule: input.Recurrence, Timezone: input.Timezone}
+	}
 
-	if err := h.repo.Create(c.Context(), todo); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create todo",
-		})
+	if err := h.todos.Create(c.Context(), todo); err != nil {
+		return todoRepositoryError(c, err, "Failed to create todo")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(todo)
@@ -119,7 +202,16 @@ func isValidPriority(priority string) bool {
 }
 
 // UpdateTodo обрабатывает PUT-запрос для обновления существующей задачи.
+// Для повторяющейся задачи параметр scope=future применяет изменения к этому и всем последующим
+// повторениям: задача начинает новую серию. По умолчанию (scope=this) меняется только это повторение.
+// Изменение правила recurrence всегда действует на последующие повторения, пустое правило
+// завершает серию на этой задаче.
 func (h *TodoHandler) UpdateTodo(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
 	todoID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -133,6 +225,18 @@ func (h *TodoHandler) UpdateTodo(c *fiber.Ctx) error {
 		DueDate     time.Time `json:"due_date"`
 		Status      string    `json:"status"`
 		Priority    string    `json:"priority"`
+		// ParentID перемещает задачу к другому родителю; если не указан, родитель не меняется
+		ParentID *uuid.UUID `json:"parent_id"`
+		// Recurrence новое правило повторения; если не указано, правило не меняется
+		Recurrence *string `json:"recurrence"`
+		Timezone   string  `json:"timezone"`
+	}
+
+	scope := c.Query("scope", recurrenceScopeThis)
+	if scope != recurrenceScopeThis && scope != recurrenceScopeFuture {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid scope, expected this or future",
+		})
 	}
 
 	if err := c.BodyParser(&input); err != nil {
@@ -141,11 +245,14 @@ func (h *TodoHandler) UpdateTodo(c *fiber.Ctx) error {
 		})
 	}
 
-	todo, err := h.repo.GetByID(c.Context(), todoID)
+	todo, err := h.todos.GetByID(c.Context(), userID, todoID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Todo not found",
-		})
+		return todoRepositoryError(c, err, "Failed to get todo")
+	}
+	// Версия прочитанной задачи проверяется и при сохранении: изменение, сделанное
+	// после проверки If-Match, тоже приводит к 412
+	if ok, err := checkIfMatch(c, todo); !ok {
+		return err
 	}
 
 	todo.Title = input.Title
@@ -153,19 +260,60 @@ func (h *TodoHandler) UpdateTodo(c *fiber.Ctx) error {
 	todo.DueDate = input.DueDate
 	todo.Status = input.Status
 	todo.Priority = input.Priority
+	if input.ParentID != nil {
+		todo.ParentID = input.ParentID
+	}
+	if !applyRecurrenceChange(todo, scope, input.Recurrence, input.Timezone) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Todo is not recurring",
+		})
+	}
 	todo.UpdatedAt = time.Now()
 
-	if err := h.repo.Update(c.Context(), todo); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update todo",
-		})
+	if err := h.todos.Update(c.Context(), userID, todo); err != nil {
+		return todoRepositoryError(c, err, "Failed to update todo")
 	}
 
+	setTodoETag(c, todo)
 	return c.JSON(todo)
 }
 
-// DeleteTodo обрабатывает DELETE-запрос для удаления задачи.
+// applyRecurrenceChange меняет повторение задачи при обновлении с областью scope: rule — новое
+// правило (nil — правило не меняется, пустое — серия завершается на этой задаче), timezone —
+// часовой пояс правила. Возвращает false, если scope=future указан для неповторяющейся задачи.
+func applyRecurrenceChange(todo *models.Todo, scope string, rule *string, timezone string) bool {
+	if scope != recurrenceScopeFuture && rule == nil {
+		return true
+	}
+
+	newRule := ""
+	if todo.Recurrence != nil {
+		newRule = todo.Recurrence.Rule
+	}
+	if rule != nil {
+		newRule = *rule
+	}
+	if scope == recurrenceScopeFuture && newRule == "" && todo.Recurrence == nil {
+		return false
+	}
+
+	// Нулевой SeriesID запрашивает новую серию, начинающуюся с этой задачи
+	todo.Recurrence = nil
+	if newRule != "" {
+		todo.Recurrence = &models.TodoRecurrence{Rule: newRule, Timezone: timezone}
+	}
+	return true
+}
+
+// DeleteTodo обрабатывает DELETE-запрос для удаления задачи. Задача вместе с подзадачами
+// перемещается в корзину, откуда ее можно восстановить до окончательного удаления.
+// С заголовком If-Match задача удаляется, только если ее версия не изменилась.
 func (h *TodoHandler) DeleteTodo(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
 	todoID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -173,17 +321,123 @@ func (h *TodoHandler) DeleteTodo(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.repo.Delete(c.Context(), todoID); err != nil {
+	// Версия проверенной задачи передается в Delete: изменение, сделанное после
+	// проверки If-Match, тоже приводит к 412
+	var version int
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		todo, err := h.todos.GetByID(c.Context(), userID, todoID)
+		if err != nil {
+			return todoRepositoryError(c, err, "Failed to delete todo")
+		}
+		if ok, err := checkIfMatch(c, todo); !ok {
+			return err
+		}
+		version = todo.Version
+	}
+
+	if err := h.todos.Delete(c.Context(), userID, todoID, version); err != nil {
+		return todoRepositoryError(c, err, "Failed to delete todo")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetTrash обрабатывает GET-запрос для получения задач в корзине, начиная с удаленных
+// последними. Подзадачи, удаленные вместе с родителем, возвращаются при его восстановлении.
+func (h *TodoHandler) GetTrash(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	todos, err := h.repo.ListTrash(c.Context(), userID)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete todo",
+			"error": "Failed to get trash",
+		})
+	}
+	if todos == nil {
+		todos = []*models.Todo{}
+	}
+	return c.JSON(todos)
+}
+
+// RestoreTodo обрабатывает POST-запрос для восстановления задачи из корзины вместе
+// с подзадачами, удаленными одновременно с ней. Возвращает восстановленную задачу.
+func (h *TodoHandler) RestoreTodo(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	restored, err := h.repo.Restore(c.Context(), todoID, userID)
+	if err != nil {
+		return todoRepositoryError(c, err, "Failed to restore todo")
+	}
+	return c.JSON(restored[0])
+}
+
+// PurgeTodo обрабатывает DELETE-запрос для окончательного удаления задачи из корзины
+// вместе с подзадачами.
+func (h *TodoHandler) PurgeTodo(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
 		})
 	}
 
+	if err := h.repo.Purge(c.Context(), todoID, userID); err != nil {
+		return todoRepositoryError(c, err, "Failed to delete todo")
+	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // GetTodoByID обрабатывает GET-запрос для получения задачи по её ID.
 func (h *TodoHandler) GetTodoByID(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	todo, err := h.todos.GetByID(c.Context(), userID, todoID)
+	if err != nil {
+		return todoRepositoryError(c, err, "Failed to get todo")
+	}
+
+	setTodoETag(c, todo)
+	if notModified(c, todo) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(todo)
+}
+
+// GetSubtasks обрабатывает GET-запрос для получения задачи вместе со всеми подзадачами.
+func (h *TodoHandler) GetSubtasks(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
 	todoID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -191,40 +445,370 @@ func (h *TodoHandler) GetTodoByID(c *fiber.Ctx) error {
 		})
 	}
 
-	todo, err := h.repo.GetByID(c.Context(), todoID)
+	subtree, err := h.todos.GetSubtree(c.Context(), userID, todoID)
 	if err != nil {
+		return todoRepositoryError(c, err, "Failed to get subtasks")
+	}
+
+	root := models.BuildTodoTree(subtree, todoID)
+	if root == nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Todo not found",
 		})
 	}
 
+	return c.JSON(root)
+}
+
+// MoveTodo обрабатывает POST-запрос для переноса задачи вместе с подзадачами в другой проект.
+// Задача становится задачей верхнего уровня и располагается в конце проекта.
+func (h *TodoHandler) MoveTodo(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	var input struct {
+		ProjectID uuid.UUID `json:"project_id"`
+	}
+	if err := c.BodyParser(&input); err != nil || input.ProjectID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	moved, err := h.todos.MoveToProject(c.Context(), userID, todoID, input.ProjectID, time.Now())
+	if err != nil {
+		return todoRepositoryError(c, err, "Failed to move todo")
+	}
+
+	return c.JSON(moved[0])
+}
+
+// SkipOccurrence обрабатывает POST-запрос для пропуска повторения: повторение отменяется,
+// и создается следующее повторение серии.
+func (h *TodoHandler) SkipOccurrence(c *fiber.Ctx) error {
+	userID, todo, err := h.ownRecurringTodo(c)
+	if todo == nil {
+		return err
+	}
+
+	todo.Status = models.TodoStatusCancelled
+	todo.UpdatedAt = time.Now()
+	if err := h.todos.Update(c.Context(), userID, todo); err != nil {
+		return todoRepositoryError(c, err, "Failed to skip occurrence")
+	}
+
 	return c.JSON(todo)
 }
 
-// GetGroupedTodos обрабатывает GET-запрос для получения сгруппированных задач.
+// GetOccurrences обрабатывает GET-запрос для предпросмотра плановых дат следующих повторений.
+// Параметр count задает число дат (по умолчанию 10, не больше 100).
+func (h *TodoHandler) GetOccurrences(c *fiber.Ctx) error {
+	count := c.QueryInt("count", defaultOccurrencesCount)
+	if count < 1 || count > maxOccurrencesCount {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid count",
+		})
+	}
+
+	_, todo, err := h.ownRecurringTodo(c)
+	if todo == nil {
+		return err
+	}
+
+	occurrences, err := todo.Recurrence.Upcoming(count)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get occurrences",
+		})
+	}
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+
+	return c.JSON(occurrences)
+}
+
+// ownRecurringTodo загружает повторяющуюся задачу из параметра :id, доступную пользователю,
+// и возвращает ее вместе с ID пользователя. Если задача не возвращена, ответ уже записан
+// и ошибку нужно вернуть из обработчика.
+func (h *TodoHandler) ownRecurringTodo(c *fiber.Ctx) (uuid.UUID, *models.Todo, error) {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	todoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid todo ID format",
+		})
+	}
+
+	todo, err := h.todos.GetByID(c.Context(), userID, todoID)
+	if err != nil {
+		return uuid.Nil, nil, todoRepositoryError(c, err, "Failed to get todo")
+	}
+	if todo.Recurrence == nil {
+		return uuid.Nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Todo is not recurring",
+		})
+	}
+
+	return userID, todo, nil
+}
+
+// SearchTodos обрабатывает GET-запрос полнотекстового поиска задач по названию и описанию.
+// Запрос передается в параметре q, число результатов — в limit; фильтры parseTodoFilter
+// ограничивают область поиска. Результаты упорядочены по релевантности.
+func (h *TodoHandler) SearchTodos(c *fiber.Ctx) error {
+	userID, err := h.getUserIDFromToken(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseTodoFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	// Параметр q здесь задает поисковый запрос, а не фильтр по подстроке
+	query := filter.Text
+	filter.Text = ""
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Search query is required",
+		})
+	}
+
+	filter.Limit = c.QueryInt("limit", defaultSearchLimit)
+	if filter.Limit < 1 || filter.Limit > maxSearchLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid limit",
+		})
+	}
+
+	results, err := h.repo.Search(c.Context(), userID, query, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search todos",
+		})
+	}
+	return c.JSON(fiber.Map{"results": results})
+}
+
+// GetGroupedTodos обрабатывает GET-запрос для получения задач, сгруппированных по статусу
+// и приоритету или, с параметром group_by=assignee, по исполнителю, с числом задач каждого
+// тега в группе. Принимает те же фильтры, что и GetTodos.
 func (h *TodoHandler) GetGroupedTodos(c *fiber.Ctx) error {
 	userID, err := h.getUserIDFromToken(c)
 	if err != nil {
 		return err
 	}
 
-	todos, err := h.repo.GetByUserID(c.Context(), userID)
+	filter, err := parseTodoFilter(c)
+	if err == nil {
+		filter.GroupBy, err = parseGroupBy(c)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	groups, err := h.repo.GetGroupedTodos(c.Context(), userID, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get todos",
 		})
 	}
+	return c.JSON(todoGroups(groups))
+}
 
-	// Группируем задачи по статусу
-	grouped := make(map[string][]*models.Todo)
-	for _, todo := range todos {
-		grouped[todo.Status] = append(grouped[todo.Status], todo)
+// todoGroups заменяет отсутствие групп пустым списком, чтобы ответ был JSON-массивом
+func todoGroups(groups []models.TodoGroup) []models.TodoGroup {
+	if groups == nil {
+		return []models.TodoGroup{}
 	}
+	return groups
+}
 
-	return c.JSON(grouped)
+// parseGroupBy разбирает способ группировки задач из параметра group_by; по умолчанию по статусу
+func parseGroupBy(c *fiber.Ctx) (string, error) {
+	groupBy := c.Query("group_by", models.TodoGroupByStatus)
+	if !models.IsValidTodoGroupBy(groupBy) {
+		return "", errors.New("Invalid group_by, expected status or assignee")
+	}
+	return groupBy, nil
 }
 
+// parseTodoFilter разбирает фильтры задач из параметров запроса; текст ошибки предназначен для клиента.
+// Параметры tags_any, tags_all и tags_none принимают ID тегов через запятую и оставляют задачи
+// хотя бы с одним из тегов, со всеми тегами и без указанных тегов соответственно; status и priority
+// принимают списки значений через запятую; due_after, due_before, created_after и created_before
+// задают полуинтервалы дат в формате RFC 3339; q ищет строку в названии и описании.
+func parseTodoFilter(c *fiber.Ctx) (models.TodoFilter, error) {
+	var filter models.TodoFilter
+	for _, param := range []struct {
+		name   string
+		target *[]uuid.UUID
+	}{
+		{"tags_any", &filter.TagsAny},
+		{"tags_all", &filter.TagsAll},
+		{"tags_none", &filter.TagsNone},
+	} {
+		ids, err := parseUUIDList(c.Query(param.name))
+		if err != nil {
+			return filter, errors.New("Invalid tag ID in " + param.name)
+		}
+		*param.target = ids
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *[]string
+		valid  func(string) bool
+	}{
+		{"status", &filter.Statuses, isValidStatus},
+		{"priority", &filter.Priorities, isValidPriority},
+	} {
+		for _, value := range parseStringList(c.Query(param.name)) {
+			if !param.valid(value) {
+				return filter, errors.New("Invalid " + param.name + " " + value)
+			}
+			*param.target = append(*param.target, value)
+		}
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{"due_after", &filter.DueAfter},
+		{"due_before", &filter.DueBefore},
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("Invalid " + param.name + ", expected RFC 3339 time")
+		}
+		*param.target = &t
+	}
+
+	filter.Text = strings.TrimSpace(c.Query("q"))
+	return filter, nil
+}
+
+// parseTodoPage разбирает сортировку (models.TodoFilter.SetSort) и страницу списка задач
+// из параметров запроса
+func parseTodoPage(c *fiber.Ctx, filter *models.TodoFilter) error {
+	switch err := filter.SetSort(c.Query("sort"), c.Query("order")); {
+	case errors.Is(err, models.ErrInvalidSort):
+		return errors.New("Invalid sort, expected created_at, updated_at, due_date, priority or position")
+	case err != nil:
+		return errors.New("Invalid order, expected asc or desc")
+	}
+
+	filter.Limit = c.QueryInt("limit", defaultTodosLimit)
+	if filter.Limit < 1 || filter.Limit > maxTodosLimit {
+		return errors.New("Invalid limit")
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		sort, desc := filter.SortOrder()
+		after, err := models.DecodeTodoCursor(cursor, sort, desc)
+		if err != nil {
+			return errors.New("Invalid cursor")
+		}
+		filter.After = after
+	}
+	return nil
+}
+
+// parseStringList разбирает список значений, разделенных запятыми
+func parseStringList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseUUIDList разбирает список ID, разделенных запятыми; пустая строка дает пустой список
+func parseUUIDList(value string) ([]uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	for _, part := range strings.Split(value, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// todoRepositoryError преобразует ошибку репозитория задач в HTTP-ответ
+func todoRepositoryError(c *fiber.Ctx, err error, msg string) error {
+	if code, message, ok := todoErrorResponse(err); ok {
+		return c.Status(code).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": msg,
+	})
+}
+
+// todoErrorResponse возвращает код и сообщение ответа для известной ошибки репозитория задач
+func todoErrorResponse(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, repository.ErrTodoNotFound):
+		return fiber.StatusNotFound, "Todo not found", true
+	case errors.Is(err, repository.ErrInvalidParent):
+		return fiber.StatusBadRequest, "Invalid parent todo", true
+	case errors.Is(err, repository.ErrInvalidProject):
+		return fiber.StatusBadRequest, "Invalid project", true
+	case errors.Is(err, repository.ErrInvalidRecurrence):
+		return fiber.StatusBadRequest, "Invalid recurrence rule, timezone or due date", true
+	case errors.Is(err, repository.ErrTodoHasSubtasks):
+		return fiber.StatusConflict, "Todo has subtasks", true
+	case errors.Is(err, repository.ErrTodoHasOpenSubtasks):
+		return fiber.StatusConflict, "Todo has open subtasks", true
+	case errors.Is(err, repository.ErrTodoVersionMismatch):
+		return fiber.StatusPreconditionFailed, "Todo has been modified", true
+	case errors.Is(err, repository.ErrTodoParentDeleted):
+		return fiber.StatusConflict, "Parent todo is in trash", true
+	}
+	return 0, "", false
+}
+
+// getUserIDFromToken возвращает ID пользователя, сохраненный AuthMiddleware, или извлекает
+// его из токена заголовка Authorization, если обработчик вызван без AuthMiddleware.
 func (h *TodoHandler) getUserIDFromToken(c *fiber.Ctx) (uuid.UUID, error) {
+	if userID, err := uuid.Parse(localUserID(c.Locals("userID"))); err == nil {
+		return userID, nil
+	}
+
 	token := c.Get("Authorization")
 	if token == "" {
 		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Missing token")
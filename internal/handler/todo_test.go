@@ -7,29 +7,36 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/R-eSPeCT/todo-list/internal/auth"
 	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockTodoRepository struct {
 	mock.Mock
 }
 
+// mockTodos возвращает список задач из аргумента i, допуская nil
+func mockTodos(args mock.Arguments, i int) []*models.Todo {
+	if args.Get(i) == nil {
+		return nil
+	}
+	return args.Get(i).([]*models.Todo)
+}
+
 func (m *MockTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
 	args := m.Called(ctx, todo)
 	return args.Error(0)
 }
 
-func (m *MockTodoRepository) GetAll(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).([]*models.Todo), args.Error(1)
-}
-
 func (m *MockTodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -38,38 +45,124 @@ func (m *MockTodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return args.Get(0).(*models.Todo), args.Error(1)
 }
 
+func (m *MockTodoRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
+	args := m.Called(ctx, userID)
+	return mockTodos(args, 0), args.Error(1)
+}
+
+func (m *MockTodoRepository) List(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]*models.Todo, error) {
+	args := m.Called(ctx, userID, filter)
+	return mockTodos(args, 0), args.Error(1)
+}
+
 func (m *MockTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
 	args := m.Called(ctx, todo)
 	return args.Error(0)
 }
 
-func (m *MockTodoRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockTodoRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) GetGroupedTodos(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]models.TodoGroup, error) {
+	args := m.Called(ctx, userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TodoGroup), args.Error(1)
+}
+
+func (m *MockTodoRepository) GetSubtree(ctx context.Context, id uuid.UUID) ([]*models.Todo, error) {
 	args := m.Called(ctx, id)
+	return mockTodos(args, 0), args.Error(1)
+}
+
+func (m *MockTodoRepository) CountOpenSubtasks(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTodoRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	args := m.Called(ctx, id, updatedAt)
+	return mockTodos(args, 0), args.Error(1)
+}
+
+func (m *MockTodoRepository) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	args := m.Called(ctx, id, projectID, updatedAt)
+	return mockTodos(args, 0), args.Error(1)
+}
+
+func (m *MockTodoRepository) CreateSeries(ctx context.Context, series *models.TodoSeries) error {
+	args := m.Called(ctx, series)
 	return args.Error(0)
 }
 
-func (m *MockTodoRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).([]*models.Todo), args.Error(1)
+func (m *MockTodoRepository) GetSeries(ctx context.Context, id uuid.UUID) (*models.TodoSeries, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TodoSeries), args.Error(1)
+}
+
+func (m *MockTodoRepository) CreateOccurrence(ctx context.Context, previous, next *models.Todo) (bool, error) {
+	args := m.Called(ctx, previous, next)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockTodoRepository) GetGroupedTodos(ctx context.Context, userID uuid.UUID) ([]models.TodoGroup, error) {
+func (m *MockTodoRepository) Search(ctx context.Context, userID uuid.UUID, query string, filter models.TodoFilter) ([]*models.TodoSearchResult, error) {
+	args := m.Called(ctx, userID, query, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TodoSearchResult), args.Error(1)
+}
+
+func (m *MockTodoRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
 	args := m.Called(ctx, userID)
-	return args.Get(0).([]models.TodoGroup), args.Error(1)
+	return mockTodos(args, 0), args.Error(1)
 }
 
-func TestTodoHandler_Create(t *testing.T) {
-	repo := new(MockTodoRepository)
-	jwtManager := auth.NewJWTManager([]byte("test-key"))
-	handler := NewTodoHandler(repo, jwtManager)
+func (m *MockTodoRepository) Restore(ctx context.Context, id, userID uuid.UUID) ([]*models.Todo, error) {
+	args := m.Called(ctx, id, userID)
+	return mockTodos(args, 0), args.Error(1)
+}
+
+func (m *MockTodoRepository) Purge(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Int(0), args.Error(1)
+}
+
+var _ repository.TodoRepository = (*MockTodoRepository)(nil)
+
+// todoRequest выполняет запрос к приложению с обработчиком задач, созданным для repo.
+// Приложение создается для каждого запроса: mock сохраняет контекст fasthttp, который
+// после форматирования в mock нельзя использовать повторно.
+func todoRequest(t *testing.T, repo *MockTodoRepository, route func(app *fiber.App, h *TodoHandler), req *http.Request) *http.Response {
+	h := NewTodoHandler(repo, services.NewTodoService(repo, nil, nil), testJWTManager)
 	app := fiber.New()
-	app.Post("/api/todos", handler.CreateTodo)
+	route(app, h)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+var testJWTManager = auth.NewJWTManager([]byte("test-key"))
+
+func TestTodoHandler_Create(t *testing.T) {
+	userID := uuid.New()
 
 	tests := []struct {
 		name       string
 		input      map[string]interface{}
 		token      string
-		setupMock  func()
+		setupMock  func(repo *MockTodoRepository)
 		wantStatus int
 	}{
 		{
@@ -77,55 +170,54 @@ func TestTodoHandler_Create(t *testing.T) {
 			input: map[string]interface{}{
 				"title":       "Тестовая задача",
 				"description": "Описание тестовой задачи",
+				"status":      "pending",
+				"priority":    "medium",
 			},
-			token: createTestToken(t, jwtManager),
-			setupMock: func() {
-				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil)
+			token: createUserToken(t, userID),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+					return todo.UserID == userID && todo.Title == "Тестовая задача"
+				})).Return(nil)
 			},
 			wantStatus: http.StatusCreated,
 		},
 		{
-			name:       "неверный формат запроса",
-			input:      map[string]interface{}{},
-			token:      createTestToken(t, jwtManager),
-			setupMock:  func() {},
+			name:       "неверный статус",
+			input:      map[string]interface{}{"title": "Задача", "status": "unknown", "priority": "low"},
+			token:      createUserToken(t, userID),
 			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "отсутствует токен",
-			input:      map[string]interface{}{},
-			token:      "",
-			setupMock:  func() {},
+			input:      map[string]interface{}{"title": "Задача", "status": "pending", "priority": "low"},
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
+			repo := new(MockTodoRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
 
 			body, _ := json.Marshal(tt.input)
 			req := httptest.NewRequest(http.MethodPost, "/api/todos", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			if tt.token != "" {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			assert.NoError(t, err)
+			resp := todoRequest(t, repo, func(app *fiber.App, h *TodoHandler) {
+				app.Post("/api/todos", h.CreateTodo)
+			}, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-
 			repo.AssertExpectations(t)
 		})
 	}
 }
 
-func TestTodoHandler_GetAll(t *testing.T) {
-	repo := new(MockTodoRepository)
-	jwtManager := auth.NewJWTManager([]byte("test-key"))
-	handler := NewTodoHandler(repo, jwtManager)
-	app := fiber.New()
-	app.Get("/api/todos", handler.GetAll)
-
+func TestTodoHandler_GetTodos(t *testing.T) {
 	userID := uuid.New()
 	todos := []*models.Todo{
 		{
@@ -139,50 +231,52 @@ func TestTodoHandler_GetAll(t *testing.T) {
 	tests := []struct {
 		name       string
 		token      string
-		setupMock  func()
+		setupMock  func(repo *MockTodoRepository)
 		wantStatus int
 	}{
 		{
 			name:  "успешное получение",
-			token: createTestToken(t, jwtManager),
-			setupMock: func() {
-				repo.On("GetAll", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(todos, nil)
+			token: createUserToken(t, userID),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("List", mock.Anything, userID, mock.AnythingOfType("models.TodoFilter")).Return(todos, nil)
 			},
 			wantStatus: http.StatusOK,
 		},
 		{
 			name:       "отсутствует токен",
-			token:      "",
-			setupMock:  func() {},
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
+			repo := new(MockTodoRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
 
 			req := httptest.NewRequest(http.MethodGet, "/api/todos", nil)
 			if tt.token != "" {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			assert.NoError(t, err)
+			resp := todoRequest(t, repo, func(app *fiber.App, h *TodoHandler) {
+				app.Get("/api/todos", h.GetTodos)
+			}, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-
 			repo.AssertExpectations(t)
+
+			if tt.wantStatus == http.StatusOK {
+				var page TodoPage
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+				require.Len(t, page.Todos, 1)
+				assert.Equal(t, todos[0].ID, page.Todos[0].ID)
+			}
 		})
 	}
 }
 
-func TestTodoHandler_GetByID(t *testing.T) {
-	repo := new(MockTodoRepository)
-	jwtManager := auth.NewJWTManager([]byte("test-key"))
-	handler := NewTodoHandler(repo, jwtManager)
-	app := fiber.New()
-	app.Get("/api/todos/:id", handler.GetByID)
-
+func TestTodoHandler_GetTodoByID(t *testing.T) {
 	todoID := uuid.New()
 	userID := uuid.New()
 	todo := &models.Todo{
@@ -196,134 +290,146 @@ func TestTodoHandler_GetByID(t *testing.T) {
 		name       string
 		todoID     string
 		token      string
-		setupMock  func()
+		setupMock  func(repo *MockTodoRepository)
 		wantStatus int
 	}{
 		{
 			name:   "успешное получение",
 			todoID: todoID.String(),
-			token:  createTestToken(t, jwtManager),
-			setupMock: func() {
+			token:  createUserToken(t, userID),
+			setupMock: func(repo *MockTodoRepository) {
 				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:   "чужая задача",
+			todoID: todoID.String(),
+			token:  createUserToken(t, uuid.New()),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
+			},
+			wantStatus: http.StatusNotFound,
+		},
 		{
 			name:       "неверный ID",
 			todoID:     "invalid-id",
-			token:      createTestToken(t, jwtManager),
-			setupMock:  func() {},
+			token:      createUserToken(t, userID),
 			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "отсутствует токен",
 			todoID:     todoID.String(),
-			token:      "",
-			setupMock:  func() {},
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
+			repo := new(MockTodoRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
 
 			req := httptest.NewRequest(http.MethodGet, "/api/todos/"+tt.todoID, nil)
 			if tt.token != "" {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			assert.NoError(t, err)
+			resp := todoRequest(t, repo, func(app *fiber.App, h *TodoHandler) {
+				app.Get("/api/todos/:id", h.GetTodoByID)
+			}, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-
 			repo.AssertExpectations(t)
 		})
 	}
 }
 
 func TestTodoHandler_Update(t *testing.T) {
-	repo := new(MockTodoRepository)
-	jwtManager := auth.NewJWTManager([]byte("test-key"))
-	handler := NewTodoHandler(repo, jwtManager)
-	app := fiber.New()
-	app.Put("/api/todos/:id", handler.UpdateTodo)
-
 	todoID := uuid.New()
 	userID := uuid.New()
-	todo := &models.Todo{
-		ID:          todoID,
-		Title:       "Тестовая задача",
-		Description: "Описание задачи",
-		UserID:      userID,
+	newTodo := func() *models.Todo {
+		return &models.Todo{
+			ID:          todoID,
+			Title:       "Тестовая задача",
+			Description: "Описание задачи",
+			Status:      "pending",
+			Priority:    "medium",
+			UserID:      userID,
+		}
+	}
+	input := map[string]interface{}{
+		"title":       "Обновленная задача",
+		"description": "Обновленное описание",
+		"status":      "in_progress",
+		"priority":    "high",
 	}
 
 	tests := []struct {
 		name       string
 		todoID     string
-		input      map[string]interface{}
 		token      string
-		setupMock  func()
+		setupMock  func(repo *MockTodoRepository)
 		wantStatus int
 	}{
 		{
 			name:   "успешное обновление",
 			todoID: todoID.String(),
-			input: map[string]interface{}{
-				"title":       "Обновленная задача",
-				"description": "Обновленное описание",
-			},
-			token: createTestToken(t, jwtManager),
-			setupMock: func() {
-				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
-				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(nil)
+			token:  createUserToken(t, userID),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(newTodo(), nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+					return todo.Title == "Обновленная задача" && todo.Status == "in_progress"
+				})).Return(nil)
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:   "чужая задача",
+			todoID: todoID.String(),
+			token:  createUserToken(t, uuid.New()),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(newTodo(), nil)
+			},
+			wantStatus: http.StatusNotFound,
+		},
 		{
 			name:       "неверный ID",
 			todoID:     "invalid-id",
-			input:      map[string]interface{}{},
-			token:      createTestToken(t, jwtManager),
-			setupMock:  func() {},
+			token:      createUserToken(t, userID),
 			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "отсутствует токен",
 			todoID:     todoID.String(),
-			input:      map[string]interface{}{},
-			token:      "",
-			setupMock:  func() {},
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
+			repo := new(MockTodoRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
 
-			body, _ := json.Marshal(tt.input)
+			body, _ := json.Marshal(input)
 			req := httptest.NewRequest(http.MethodPut, "/api/todos/"+tt.todoID, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			if tt.token != "" {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			assert.NoError(t, err)
+			resp := todoRequest(t, repo, func(app *fiber.App, h *TodoHandler) {
+				app.Put("/api/todos/:id", h.UpdateTodo)
+			}, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-
 			repo.AssertExpectations(t)
 		})
 	}
 }
 
 func TestTodoHandler_Delete(t *testing.T) {
-	repo := new(MockTodoRepository)
-	jwtManager := auth.NewJWTManager([]byte("test-key"))
-	handler := NewTodoHandler(repo, jwtManager)
-	app := fiber.New()
-	app.Delete("/api/todos/:id", handler.DeleteTodo)
-
 	todoID := uuid.New()
 	userID := uuid.New()
 	todo := &models.Todo{
@@ -331,140 +437,194 @@ func TestTodoHandler_Delete(t *testing.T) {
 		Title:       "Тестовая задача",
 		Description: "Описание задачи",
 		UserID:      userID,
+		Version:     3,
 	}
 
 	tests := []struct {
 		name       string
 		todoID     string
 		token      string
-		setupMock  func()
+		ifMatch    string
+		setupMock  func(repo *MockTodoRepository)
 		wantStatus int
 	}{
 		{
 			name:   "успешное удаление",
 			todoID: todoID.String(),
-			token:  createTestToken(t, jwtManager),
-			setupMock: func() {
+			token:  createUserToken(t, userID),
+			setupMock: func(repo *MockTodoRepository) {
 				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
-				repo.On("Delete", mock.Anything, todoID).Return(nil)
+				repo.On("Delete", mock.Anything, todoID, 0).Return(nil)
 			},
-			wantStatus: http.StatusOK,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:    "удаление проверенной версии",
+			todoID:  todoID.String(),
+			token:   createUserToken(t, userID),
+			ifMatch: `"3"`,
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
+				repo.On("Delete", mock.Anything, todoID, 3).Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:    "версия не совпадает с If-Match",
+			todoID:  todoID.String(),
+			token:   createUserToken(t, userID),
+			ifMatch: `"2"`,
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "задачу изменили после проверки If-Match",
+			todoID:  todoID.String(),
+			token:   createUserToken(t, userID),
+			ifMatch: `"3"`,
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
+				repo.On("Delete", mock.Anything, todoID, 3).Return(repository.ErrTodoVersionMismatch)
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "чужая задача",
+			todoID: todoID.String(),
+			token:  createUserToken(t, uuid.New()),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetByID", mock.Anything, todoID).Return(todo, nil)
+			},
+			wantStatus: http.StatusNotFound,
 		},
 		{
 			name:       "неверный ID",
 			todoID:     "invalid-id",
-			token:      createTestToken(t, jwtManager),
-			setupMock:  func() {},
+			token:      createUserToken(t, userID),
 			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "отсутствует токен",
 			todoID:     todoID.String(),
-			token:      "",
-			setupMock:  func() {},
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
+			repo := new(MockTodoRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
 
 			req := httptest.NewRequest(http.MethodDelete, "/api/todos/"+tt.todoID, nil)
 			if tt.token != "" {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
-			resp, err := app.Test(req)
-			assert.NoError(t, err)
+			resp := todoRequest(t, repo, func(app *fiber.App, h *TodoHandler) {
+				app.Delete("/api/todos/:id", h.DeleteTodo)
+			}, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-
 			repo.AssertExpectations(t)
 		})
 	}
 }
 
 func TestTodoHandler_GetGroupedTodos(t *testing.T) {
-	repo := new(MockTodoRepository)
-	jwtManager := auth.NewJWTManager([]byte("test-key"))
-	handler := NewTodoHandler(repo, jwtManager)
-	app := fiber.New()
-	app.Get("/api/todos/grouped", handler.GetGroupedTodos)
-
 	userID := uuid.New()
-	groupedTodos := []models.TodoGroup{
-		{
-			Status: "pending",
-			Tasks: []*models.Todo{
-				{
-					ID:          uuid.New(),
-					Title:       "Задача 1",
-					Description: "Описание 1",
-					UserID:      userID,
-					Status:      "pending",
-				},
-			},
-		},
-		{
-			Status: "completed",
-			Tasks: []*models.Todo{
-				{
-					ID:          uuid.New(),
-					Title:       "Задача 2",
-					Description: "Описание 2",
-					UserID:      userID,
-					Status:      "completed",
-				},
-			},
-		},
-	}
+	work := models.TodoTag{ID: uuid.New(), Name: "работа", Color: "#ff0000"}
+	todo := &models.Todo{ID: uuid.New(), Title: "Задача 1", UserID: userID, Status: "pending", Priority: "high", Tags: []models.TodoTag{work}}
+	groups := []models.TodoGroup{{
+		Status:    "pending",
+		Priority:  "high",
+		Count:     1,
+		Tasks:     []*models.Todo{todo},
+		TagCounts: []models.TagCount{{Tag: work, Count: 1}},
+	}}
 
 	tests := []struct {
 		name       string
 		token      string
-		setupMock  func()
+		query      string
+		setupMock  func(repo *MockTodoRepository)
 		wantStatus int
+		wantGroups []models.TodoGroup
 	}{
 		{
-			name:  "успешное получение",
-			token: createTestToken(t, jwtManager),
-			setupMock: func() {
-				repo.On("GetGroupedTodos", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(groupedTodos, nil)
+			name:  "группы с числом задач по тегам",
+			token: createUserToken(t, userID),
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetGroupedTodos", mock.Anything, userID, models.TodoFilter{GroupBy: models.TodoGroupByStatus}).Return(groups, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantGroups: groups,
+		},
+		{
+			name:  "группировка по исполнителю без задач",
+			token: createUserToken(t, userID),
+			query: "?group_by=assignee",
+			setupMock: func(repo *MockTodoRepository) {
+				repo.On("GetGroupedTodos", mock.Anything, userID, models.TodoFilter{GroupBy: models.TodoGroupByAssignee}).Return(nil, nil)
 			},
 			wantStatus: http.StatusOK,
+			wantGroups: []models.TodoGroup{},
+		},
+		{
+			name:       "неверная группировка",
+			token:      createUserToken(t, userID),
+			query:      "?group_by=priority",
+			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "отсутствует токен",
-			token:      "",
-			setupMock:  func() {},
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMock()
+			repo := new(MockTodoRepository)
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
 
-			req := httptest.NewRequest(http.MethodGet, "/api/todos/grouped", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/todos/grouped"+tt.query, nil)
 			if tt.token != "" {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			assert.NoError(t, err)
+			resp := todoRequest(t, repo, func(app *fiber.App, h *TodoHandler) {
+				app.Get("/api/todos/grouped", h.GetGroupedTodos)
+			}, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-
 			repo.AssertExpectations(t)
+
+			if tt.wantGroups != nil {
+				var got []models.TodoGroup
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+				require.Len(t, got, len(tt.wantGroups))
+				for i, group := range got {
+					want := tt.wantGroups[i]
+					assert.Equal(t, want.Status, group.Status)
+					assert.Equal(t, want.Count, group.Count)
+					require.Len(t, group.Tasks, len(want.Tasks))
+					assert.Equal(t, want.Tasks[0].ID, group.Tasks[0].ID)
+					assert.Equal(t, want.TagCounts, group.TagCounts)
+				}
+			}
 		})
 	}
 }
 
-func createTestToken(t *testing.T, jwtManager *auth.JWTManager) string {
-	user := &models.User{
-		ID:    uuid.New(),
-		Email: "test@example.com",
-	}
-	token, err := jwtManager.Generate(user)
-	assert.NoError(t, err)
+// createUserToken выдает токен пользователю userID
+func createUserToken(t *testing.T, userID uuid.UUID) string {
+	token, err := testJWTManager.Generate(&models.User{ID: userID, Email: "test@example.com"})
+	require.NoError(t, err)
 	return token
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"github.com/R-eSPeCT/todo-list/internal/auth"
 	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/R-eSPeCT/todo-list/internal/repository"
@@ -237,6 +238,5 @@ func isValidEmail(email string) bool {
 
 // isUniqueViolation проверяет, является ли ошибка нарушением уникального ограничения.
 func isUniqueViolation(err error) bool {
-	// Реализация зависит от используемой базы данных
-	return false
+	return errors.Is(err, repository.ErrUserExists)
 }
@@ -9,12 +9,15 @@ import (
 	"testing"
 
 	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/middleware"
 	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type MockUserRepository struct {
@@ -42,6 +45,14 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
@@ -52,27 +63,29 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
-func setupTestApp(t *testing.T, repo *MockUserRepository) *fiber.App {
+// userRequest выполняет запрос к новому приложению с обработчиком пользователей для repo.
+// Профиль доступен через AuthMiddleware, как в main.go.
+func userRequest(t *testing.T, repo *MockUserRepository, req *http.Request) *http.Response {
 	jwtManager := auth.NewJWTManager([]byte("test_secret"))
 	h := NewUserHandler(repo, jwtManager)
 
 	app := fiber.New()
 	app.Post("/register", h.Register)
 	app.Post("/login", h.Login)
-	app.Get("/profile", h.GetProfile)
-	app.Put("/profile", h.UpdateProfile)
-	return app
+	app.Get("/profile", middleware.AuthMiddleware(jwtManager, nil), h.GetProfile)
+	app.Put("/profile", middleware.AuthMiddleware(jwtManager, nil), h.UpdateProfile)
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
 }
 
 func TestUserHandler_Register(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	app := setupTestApp(t, mockRepo)
-
 	tests := []struct {
 		name       string
 		input      map[string]string
 		wantStatus int
-		setupMock  func()
+		setupMock  func(repo *MockUserRepository)
 	}{
 		{
 			name: "успешная регистрация",
@@ -81,8 +94,20 @@ func TestUserHandler_Register(t *testing.T) {
 				"password": "password123",
 			},
 			wantStatus: http.StatusCreated,
-			setupMock: func() {
-				mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, repository.ErrUserNotFound)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+			},
+		},
+		{
+			name: "email уже занят",
+			input: map[string]string{
+				"email":    "test@example.com",
+				"password": "password123",
+			},
+			wantStatus: http.StatusConflict,
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "test@example.com").Return(&models.User{ID: uuid.New()}, nil)
 			},
 		},
 		{
@@ -105,16 +130,16 @@ func TestUserHandler_Register(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
 			if tt.setupMock != nil {
-				tt.setupMock()
+				tt.setupMock(mockRepo)
 			}
 
 			jsonBody, _ := json.Marshal(tt.input)
 			req := httptest.NewRequest("POST", "/register", bytes.NewReader(jsonBody))
 			req.Header.Set("Content-Type", "application/json")
 
-			resp, err := app.Test(req)
-			require.NoError(t, err)
+			resp := userRequest(t, mockRepo, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
 
 			mockRepo.AssertExpectations(t)
@@ -123,19 +148,22 @@ func TestUserHandler_Register(t *testing.T) {
 }
 
 func TestUserHandler_Login(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	app := setupTestApp(t, mockRepo)
-
 	userID := uuid.New()
 	email := "test@example.com"
 	password := "password123"
-	hashedPassword := "$2a$10$..." // Replace with actual hashed password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{
+		ID:       userID,
+		Email:    email,
+		Password: string(hashedPassword),
+	}
 
 	tests := []struct {
 		name       string
 		input      map[string]string
 		wantStatus int
-		setupMock  func()
+		setupMock  func(repo *MockUserRepository)
 	}{
 		{
 			name: "успешный вход",
@@ -144,13 +172,8 @@ func TestUserHandler_Login(t *testing.T) {
 				"password": password,
 			},
 			wantStatus: http.StatusOK,
-			setupMock: func() {
-				user := &models.User{
-					ID:       userID,
-					Email:    email,
-					Password: hashedPassword,
-				}
-				mockRepo.On("GetByEmail", mock.Anything, email).Return(user, nil)
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, email).Return(user, nil)
 			},
 		},
 		{
@@ -160,8 +183,8 @@ func TestUserHandler_Login(t *testing.T) {
 				"password": password,
 			},
 			wantStatus: http.StatusUnauthorized,
-			setupMock: func() {
-				mockRepo.On("GetByEmail", mock.Anything, "invalid@example.com").Return(nil, nil)
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, "invalid@example.com").Return(nil, nil)
 			},
 		},
 		{
@@ -171,29 +194,22 @@ func TestUserHandler_Login(t *testing.T) {
 				"password": "wrongpassword",
 			},
 			wantStatus: http.StatusUnauthorized,
-			setupMock: func() {
-				user := &models.User{
-					ID:       userID,
-					Email:    email,
-					Password: hashedPassword,
-				}
-				mockRepo.On("GetByEmail", mock.Anything, email).Return(user, nil)
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", mock.Anything, email).Return(user, nil)
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.setupMock != nil {
-				tt.setupMock()
-			}
+			mockRepo := new(MockUserRepository)
+			tt.setupMock(mockRepo)
 
 			jsonBody, _ := json.Marshal(tt.input)
 			req := httptest.NewRequest("POST", "/login", bytes.NewReader(jsonBody))
 			req.Header.Set("Content-Type", "application/json")
 
-			resp, err := app.Test(req)
-			require.NoError(t, err)
+			resp := userRequest(t, mockRepo, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
 
 			mockRepo.AssertExpectations(t)
@@ -202,9 +218,6 @@ func TestUserHandler_Login(t *testing.T) {
 }
 
 func TestUserHandler_GetProfile(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	app := setupTestApp(t, mockRepo)
-
 	userID := uuid.New()
 	testUser := &models.User{
 		ID:    userID,
@@ -219,14 +232,14 @@ func TestUserHandler_GetProfile(t *testing.T) {
 		name       string
 		token      string
 		wantStatus int
-		setupMock  func()
+		setupMock  func(repo *MockUserRepository)
 	}{
 		{
 			name:       "успешное получение профиля",
 			token:      token,
 			wantStatus: http.StatusOK,
-			setupMock: func() {
-				mockRepo.On("GetByID", mock.Anything, userID).Return(testUser, nil)
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByID", mock.Anything, userID).Return(testUser, nil)
 			},
 		},
 		{
@@ -243,8 +256,9 @@ func TestUserHandler_GetProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
 			if tt.setupMock != nil {
-				tt.setupMock()
+				tt.setupMock(mockRepo)
 			}
 
 			req := httptest.NewRequest("GET", "/profile", nil)
@@ -252,8 +266,7 @@ func TestUserHandler_GetProfile(t *testing.T) {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			require.NoError(t, err)
+			resp := userRequest(t, mockRepo, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
 
 			mockRepo.AssertExpectations(t)
@@ -262,17 +275,16 @@ func TestUserHandler_GetProfile(t *testing.T) {
 }
 
 func TestUserHandler_UpdateProfile(t *testing.T) {
-	mockRepo := new(MockUserRepository)
-	app := setupTestApp(t, mockRepo)
-
 	userID := uuid.New()
-	testUser := &models.User{
-		ID:    userID,
-		Email: "test@example.com",
+	newUser := func() *models.User {
+		return &models.User{
+			ID:    userID,
+			Email: "test@example.com",
+		}
 	}
 
 	jwtManager := auth.NewJWTManager([]byte("test_secret"))
-	token, err := jwtManager.Generate(testUser)
+	token, err := jwtManager.Generate(newUser())
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -280,7 +292,7 @@ func TestUserHandler_UpdateProfile(t *testing.T) {
 		token      string
 		input      map[string]string
 		wantStatus int
-		setupMock  func()
+		setupMock  func(repo *MockUserRepository)
 	}{
 		{
 			name:  "успешное обновление профиля",
@@ -289,9 +301,11 @@ func TestUserHandler_UpdateProfile(t *testing.T) {
 				"email": "newemail@example.com",
 			},
 			wantStatus: http.StatusOK,
-			setupMock: func() {
-				mockRepo.On("GetByID", mock.Anything, userID).Return(testUser, nil)
-				mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+			setupMock: func(repo *MockUserRepository) {
+				repo.On("GetByID", mock.Anything, userID).Return(newUser(), nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+					return user.Email == "newemail@example.com"
+				})).Return(nil)
 			},
 		},
 		{
@@ -312,8 +326,9 @@ func TestUserHandler_UpdateProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
 			if tt.setupMock != nil {
-				tt.setupMock()
+				tt.setupMock(mockRepo)
 			}
 
 			jsonBody, _ := json.Marshal(tt.input)
@@ -323,8 +338,7 @@ func TestUserHandler_UpdateProfile(t *testing.T) {
 				req.Header.Set("Authorization", "Bearer "+tt.token)
 			}
 
-			resp, err := app.Test(req)
-			require.NoError(t, err)
+			resp := userRequest(t, mockRepo, req)
 			assert.Equal(t, tt.wantStatus, resp.StatusCode)
 
 			mockRepo.AssertExpectations(t)
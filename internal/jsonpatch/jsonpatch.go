@@ -0,0 +1,395 @@
+// Package jsonpatch применяет изменения к JSON документам в форматах JSON Merge Patch (RFC 7396)
+// и JSON Patch (RFC 6902). Пути JSON Patch задаются указателями JSON Pointer (RFC 6901).
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Операции JSON Patch
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+var (
+	// ErrInvalidPatch возвращается для синтаксически неверного изменения или документа
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPathNotFound возвращается, когда путь операции не существует в документе
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed возвращается, когда значение не совпало в операции test
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Operation представляет операцию JSON Patch
+type Operation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	// From исходный путь операций move и copy
+	From string `json:"from,omitempty"`
+	// Value значение операций add, replace и test; nil, если поле не передано
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MergePatch применяет к документу doc изменение patch по RFC 7396: члены объекта patch
+// заменяют одноименные члены документа, вложенные объекты сливаются рекурсивно,
+// а null удаляет член. Изменение, не являющееся объектом, заменяет документ целиком.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(target, changes))
+}
+
+// mergeValue сливает изменение patch со значением target
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergeValue(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Apply применяет к документу doc операции JSON Patch из patch по RFC 6902. Операции
+// применяются по порядку; если одна из них не выполнена, возвращается ошибка,
+// и документ не меняется.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		target, err = applyOperation(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+// applyOperation выполняет одну операцию и возвращает измененный документ
+func applyOperation(doc interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case OpAdd, OpReplace, OpTest:
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		value, err := decode(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+		switch operation.Op {
+		case OpAdd:
+			return add(doc, path, value)
+		case OpReplace:
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			if len(path) == 0 {
+				return value, nil
+			}
+			doc, _, err = remove(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case OpRemove:
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case OpMove, OpCopy:
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == OpCopy {
+			value, err = deepCopy(value)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+		if isProperPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into its own child", ErrInvalidPatch)
+		}
+		doc, _, err = remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, operation.Op)
+	}
+}
+
+// parsePointer разбирает JSON Pointer на токены. Пустой указатель обозначает весь документ.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// isProperPrefix проверяет, что путь prefix указывает на предка path
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex возвращает индекс элемента массива длины length по токену. С allowEnd
+// допускаются индекс length и токен "-", обозначающие позицию после последнего элемента.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	// Ведущие нули и знаки в индексах запрещены
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > length || (index == length && !allowEnd) {
+		return 0, ErrPathNotFound
+	}
+	return index, nil
+}
+
+// get возвращает значение документа по пути path
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return doc, nil
+}
+
+// add добавляет значение value по пути path: заменяет член объекта или вставляет
+// элемент массива. Родитель path должен существовать.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		updated, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(node), len(rest) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		updated, err := add(node[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+// remove удаляет значение по пути path и возвращает измененный документ и удаленное значение
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	token, rest := path[0], path[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, ErrPathNotFound
+		}
+		if len(rest) == 0 {
+			delete(node, token)
+			return node, child, nil
+		}
+		updated, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = updated
+		return node, removed, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := node[index]
+			return append(node[:index], node[index+1:]...), removed, nil
+		}
+		updated, removed, err := remove(node[index], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[index] = updated
+		return node, removed, nil
+	default:
+		return nil, nil, ErrPathNotFound
+	}
+}
+
+// equal сравнивает значения JSON по RFC 6902: числа сравниваются по значению,
+// объекты без учета порядка членов
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		bNumber, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == bNumber {
+			return true
+		}
+		aValue, aErr := a.Float64()
+		bValue, bErr := bNumber.Float64()
+		return aErr == nil && bErr == nil && aValue == bValue
+	case map[string]interface{}:
+		bObject, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(bObject) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := bObject[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bArray, ok := b.([]interface{})
+		if !ok || len(a) != len(bArray) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], bArray[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// deepCopy возвращает независимую копию значения
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+// decode разбирает JSON, сохраняя числа без потери точности
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON value", ErrInvalidPatch)
+	}
+	return value, nil
+}
@@ -0,0 +1,174 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		patch  string
+		result string
+	}{
+		{name: "замена члена", doc: `{"a":"b"}`, patch: `{"a":"c"}`, result: `{"a":"c"}`},
+		{name: "добавление члена", doc: `{"a":"b"}`, patch: `{"b":"c"}`, result: `{"a":"b","b":"c"}`},
+		{name: "null удаляет член", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, result: `{"b":"c"}`},
+		{name: "массив заменяется целиком", doc: `{"a":[1,2]}`, patch: `{"a":[3]}`, result: `{"a":[3]}`},
+		{name: "вложенный объект", doc: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"d":null,"f":1}}`, result: `{"a":{"b":"c","f":1}}`},
+		{name: "объект вместо значения", doc: `{"a":"b"}`, patch: `{"a":{"c":null,"d":1}}`, result: `{"a":{"d":1}}`},
+		{name: "не объект заменяет документ", doc: `{"a":"b"}`, patch: `["c"]`, result: `["c"]`},
+		{name: "пустое изменение", doc: `{"a":1.50}`, patch: `{}`, result: `{"a":1.50}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.result, string(result))
+		})
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		patch  string
+		result string
+		err    error
+	}{
+		{
+			name:   "add члена",
+			doc:    `{"a":1}`,
+			patch:  `[{"op":"add","path":"/b","value":null}]`,
+			result: `{"a":1,"b":null}`,
+		},
+		{
+			name:   "add в массив",
+			doc:    `{"a":[1,3]}`,
+			patch:  `[{"op":"add","path":"/a/1","value":2},{"op":"add","path":"/a/-","value":4}]`,
+			result: `{"a":[1,2,3,4]}`,
+		},
+		{
+			name:   "remove",
+			doc:    `{"a":1,"b":[1,2,3]}`,
+			patch:  `[{"op":"remove","path":"/a"},{"op":"remove","path":"/b/0"}]`,
+			result: `{"b":[2,3]}`,
+		},
+		{
+			name:   "replace",
+			doc:    `{"a":{"b":"c"}}`,
+			patch:  `[{"op":"replace","path":"/a/b","value":"d"}]`,
+			result: `{"a":{"b":"d"}}`,
+		},
+		{
+			name:   "move",
+			doc:    `{"a":{"b":"c"},"d":{}}`,
+			patch:  `[{"op":"move","from":"/a/b","path":"/d/e"}]`,
+			result: `{"a":{},"d":{"e":"c"}}`,
+		},
+		{
+			name:   "copy",
+			doc:    `{"a":{"b":[1]}}`,
+			patch:  `[{"op":"copy","from":"/a/b","path":"/c"},{"op":"add","path":"/c/-","value":2}]`,
+			result: `{"a":{"b":[1]},"c":[1,2]}`,
+		},
+		{
+			name:   "test с равными числами",
+			doc:    `{"a":1,"b":{"c":[true]}}`,
+			patch:  `[{"op":"test","path":"/a","value":1.0},{"op":"test","path":"/b","value":{"c":[true]}}]`,
+			result: `{"a":1,"b":{"c":[true]}}`,
+		},
+		{
+			name:   "экранирование в указателе",
+			doc:    `{"a/b":1,"c~d":2}`,
+			patch:  `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/c~0d"}]`,
+			result: `{"a/b":3}`,
+		},
+		{
+			name:   "замена документа",
+			doc:    `{"a":1}`,
+			patch:  `[{"op":"replace","path":"","value":{"b":2}}]`,
+			result: `{"b":2}`,
+		},
+		{
+			name:  "test не совпал",
+			doc:   `{"a":"b"}`,
+			patch: `[{"op":"replace","path":"/a","value":"c"},{"op":"test","path":"/a","value":"b"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "replace отсутствующего члена",
+			doc:   `{}`,
+			patch: `[{"op":"replace","path":"/a","value":1}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "add без родителя",
+			doc:   `{}`,
+			patch: `[{"op":"add","path":"/a/b","value":1}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "индекс за концом массива",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"add","path":"/a/2","value":1}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "индекс с ведущим нулем",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"remove","path":"/a/01"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move в собственного потомка",
+			doc:   `{"a":{"b":{}}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "нет value",
+			doc:   `{}`,
+			patch: `[{"op":"add","path":"/a"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "неизвестная операция",
+			doc:   `{}`,
+			patch: `[{"op":"merge","path":"/a","value":1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "указатель без слэша",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"a"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "изменение не массив",
+			doc:   `{}`,
+			patch: `{"op":"remove","path":"/a"}`,
+			err:   ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.result, string(result))
+		})
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"strings"
 
 	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // AuthMiddleware создает middleware для проверки JWT токена в заголовке Authorization.
 // Извлекает токен из заголовка, проверяет его валидность и добавляет ID пользователя в контекст.
-func AuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
+// Если задан denylist, отозванные токены отклоняются.
+func AuthMiddleware(jwtManager *auth.JWTManager, denylist *auth.TokenDenylist) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -33,8 +36,43 @@ func AuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
 			})
 		}
 
-		// Добавляем ID пользователя в контекст для использования в следующих обработчиках
+		if denylist != nil {
+			revoked, err := denylist.IsRevoked(c.Context(), claims.Id, claims.UserID, claims.IssuedAt)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Ошибка при проверке токена",
+				})
+			}
+			if revoked {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Токен отозван",
+				})
+			}
+		}
+
+		// Добавляем ID пользователя и claims в контекст для использования в следующих обработчиках
 		c.Locals("userID", claims.UserID)
+		c.Locals("claims", claims)
+
+		// Автор изменений задач для истории; репозитории получают контекст fasthttp с Locals
+		actor := models.Actor{Source: models.ChangeSourceREST}
+		if id, err := uuid.Parse(claims.UserID); err == nil {
+			actor.UserID = &id
+		}
+		c.Locals(models.ActorContextKey, actor)
+		return c.Next()
+	}
+}
+
+// TokenFromQuery переносит токен из параметра запроса в заголовок Authorization, если заголовок не задан.
+// Нужен перед AuthMiddleware для EventSource и WebSocket в браузере, которые не позволяют задать заголовки.
+func TokenFromQuery(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			if token := c.Query(param); token != "" {
+				c.Request().Header.Set("Authorization", "Bearer "+token)
+			}
+		}
 		return c.Next()
 	}
 }
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/R-eSPeCT/todo-list/internal/auth"
 	"github.com/R-eSPeCT/todo-list/internal/models"
@@ -16,7 +19,8 @@ import (
 func TestAuthMiddleware(t *testing.T) {
 	app := fiber.New()
 	jwtManager := auth.NewJWTManager([]byte("test-secret-key"))
-	app.Use(AuthMiddleware(jwtManager))
+	denylist := auth.NewTokenDenylist(newMemoryCache(), time.Hour)
+	app.Use(AuthMiddleware(jwtManager, denylist))
 
 	// Генерируем валидный токен
 	userID := uuid.New()
@@ -27,33 +31,51 @@ func TestAuthMiddleware(t *testing.T) {
 	validToken, err := jwtManager.Generate(user)
 	require.NoError(t, err)
 
+	// Отозванный токен другого пользователя
+	revokedToken, err := jwtManager.Generate(&models.User{ID: uuid.New(), Email: "revoked@example.com"})
+	require.NoError(t, err)
+	claims, err := jwtManager.Validate(revokedToken)
+	require.NoError(t, err)
+	require.NoError(t, denylist.Revoke(context.Background(), claims.Id, time.Unix(claims.ExpiresAt, 0)))
+
 	// Тестовый обработчик
 	app.Get("/test", func(c *fiber.Ctx) error {
-		ctxUserID := c.Locals("userID")
-		assert.NotNil(t, ctxUserID)
-		assert.IsType(t, uuid.UUID{}, ctxUserID)
-		assert.Equal(t, userID, ctxUserID)
+		assert.Equal(t, userID.String(), c.Locals("userID"))
+		actor, ok := c.Locals(models.ActorContextKey).(models.Actor)
+		require.True(t, ok)
+		require.NotNil(t, actor.UserID)
+		assert.Equal(t, userID, *actor.UserID)
 		return c.SendStatus(http.StatusOK)
 	})
 
 	tests := []struct {
 		name       string
-		token      string
+		header     string
 		wantStatus int
 	}{
 		{
 			name:       "valid token",
-			token:      validToken,
+			header:     "Bearer " + validToken,
 			wantStatus: http.StatusOK,
 		},
 		{
 			name:       "missing token",
-			token:      "",
+			header:     "",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid header format",
+			header:     "Token " + validToken,
 			wantStatus: http.StatusUnauthorized,
 		},
 		{
 			name:       "invalid token format",
-			token:      "invalid-token-format",
+			header:     "Bearer invalid-token-format",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "revoked token",
+			header:     "Bearer " + revokedToken,
 			wantStatus: http.StatusUnauthorized,
 		},
 	}
@@ -61,8 +83,8 @@ func TestAuthMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/test", nil)
-			if tt.token != "" {
-				req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
 			}
 
 			resp, err := app.Test(req)
@@ -72,91 +94,36 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
-func TestCorsMiddleware(t *testing.T) {
+func TestTokenFromQuery(t *testing.T) {
 	app := fiber.New()
-	app.Use(CorsMiddleware())
-
-	// Тестовый обработчик
+	app.Use(TokenFromQuery("access_token"))
 	app.Get("/test", func(c *fiber.Ctx) error {
-		return c.SendStatus(http.StatusOK)
+		return c.SendString(c.Get("Authorization"))
 	})
 
 	tests := []struct {
-		name             string
-		origin           string
-		method           string
-		wantAllowOrigin  string
-		wantAllowMethods string
-		wantAllowHeaders string
-		wantStatus       int
+		name   string
+		url    string
+		header string
+		want   string
 	}{
-		{
-			name:             "allowed origin",
-			origin:           "http://localhost:3000",
-			method:           http.MethodGet,
-			wantAllowOrigin:  "http://localhost:3000",
-			wantAllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-			wantAllowHeaders: "Origin,Content-Type,Accept,Authorization",
-			wantStatus:       http.StatusOK,
-		},
-		{
-			name:             "disallowed origin",
-			origin:           "http://malicious.com",
-			method:           http.MethodGet,
-			wantAllowOrigin:  "",
-			wantAllowMethods: "",
-			wantAllowHeaders: "",
-			wantStatus:       http.StatusOK,
-		},
-		{
-			name:             "preflight request",
-			origin:           "http://localhost:3000",
-			method:           http.MethodOptions,
-			wantAllowOrigin:  "http://localhost:3000",
-			wantAllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-			wantAllowHeaders: "Origin,Content-Type,Accept,Authorization",
-			wantStatus:       http.StatusOK,
-		},
+		{name: "token in query", url: "/test?access_token=abc", want: "Bearer abc"},
+		{name: "header takes precedence", url: "/test?access_token=abc", header: "Bearer xyz", want: "Bearer xyz"},
+		{name: "no token", url: "/test", want: ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(tt.method, "/test", nil)
-			if tt.origin != "" {
-				req.Header.Set("Origin", tt.origin)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
 			}
 
 			resp, err := app.Test(req)
 			require.NoError(t, err)
-			assert.Equal(t, tt.wantStatus, resp.StatusCode)
-			assert.Equal(t, tt.wantAllowOrigin, resp.Header.Get("Access-Control-Allow-Origin"))
-			assert.Equal(t, tt.wantAllowMethods, resp.Header.Get("Access-Control-Allow-Methods"))
-			assert.Equal(t, tt.wantAllowHeaders, resp.Header.Get("Access-Control-Allow-Headers"))
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(body))
 		})
 	}
 }
-
-func TestLoggerMiddleware(t *testing.T) {
-	app := fiber.New()
-	app.Use(LoggerMiddleware())
-
-	// Тестовый обработчик
-	app.Get("/test", func(c *fiber.Ctx) error {
-		return c.SendStatus(http.StatusOK)
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	resp, err := app.Test(req)
-	require.NoError(t, err)
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-
-	// Проверяем, что логгер не падает при ошибке
-	app.Get("/error", func(c *fiber.Ctx) error {
-		return fiber.NewError(http.StatusInternalServerError, "test error")
-	})
-
-	req = httptest.NewRequest(http.MethodGet, "/error", nil)
-	resp, err = app.Test(req)
-	require.NoError(t, err)
-	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
-}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// HeaderIdempotencyKey заголовок с ключом идемпотентности запроса
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed заголовок ответа, повторенного по ключу идемпотентности
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength максимальная длина ключа идемпотентности
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders заголовки ответа, которые сохраняются и повторяются вместе с телом
+var replayedHeaders = []string{fiber.HeaderETag, fiber.HeaderLocation}
+
+// IdempotencyConfig конфигурация для ключей идемпотентности
+type IdempotencyConfig struct {
+	// TTL время хранения ответа на запрос с ключом
+	TTL time.Duration
+	// LockTimeout время, после которого незавершенный запрос с ключом перестает
+	// блокировать повторы (например, если экземпляр сервера упал во время обработки)
+	LockTimeout time.Duration
+	// Префикс для ключей в Redis
+	KeyPrefix string
+}
+
+// idempotencyRecord запрос с ключом идемпотентности и, после его завершения, ответ
+type idempotencyRecord struct {
+	// Fingerprint хэш метода, пути и тела запроса
+	Fingerprint string            `json:"fingerprint"`
+	Completed   bool              `json:"completed"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// Idempotency создает middleware, выполняющее POST, PATCH и DELETE запросы с заголовком
+// Idempotency-Key не более одного раза. Первый ответ на ключ пользователя сохраняется
+// в кэше на config.TTL, и повторы запроса получают его без выполнения обработчика.
+// Пока первый запрос выполняется, повторы получают 409, а запрос с тем же ключом,
+// но другими методом, путем или телом — 422. Ответы 5xx не сохраняются, чтобы запрос
+// можно было повторить. Должно вызываться после AuthMiddleware.
+func Idempotency(cache cache.Cache, config IdempotencyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		method := c.Method()
+		if method != fiber.MethodPost && method != fiber.MethodPatch && method != fiber.MethodDelete {
+			return c.Next()
+		}
+		idempotencyKey := c.Get(HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			return c.Next()
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency-Key is too long",
+			})
+		}
+		userID, _ := c.Locals("userID").(string)
+		if userID == "" {
+			return c.Next()
+		}
+
+		key := fmt.Sprintf("%s:%s:%s", config.KeyPrefix, userID, idempotencyKey)
+		fingerprint := requestFingerprint(c)
+
+		acquired, err := cache.SetNX(c.Context(), key, idempotencyRecord{Fingerprint: fingerprint}, config.LockTimeout)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Idempotency check failed",
+			})
+		}
+		if !acquired {
+			return replayIdempotent(c, cache, key, fingerprint)
+		}
+
+		if err := c.Next(); err != nil {
+			// Ошибку обработает ErrorHandler приложения; повтор запроса выполнится заново
+			_ = cache.Delete(c.Context(), key)
+			return err
+		}
+
+		response := c.Response()
+		if response.StatusCode() >= fiber.StatusInternalServerError {
+			_ = cache.Delete(c.Context(), key)
+			return nil
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  response.StatusCode(),
+			ContentType: string(response.Header.ContentType()),
+			Headers:     make(map[string]string),
+			Body:        append([]byte(nil), response.Body()...),
+		}
+		for _, header := range replayedHeaders {
+			if value := c.GetRespHeader(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+		// Ответ уже сформирован: ошибка сохранения означает только, что повтор выполнится заново
+		_ = cache.Set(c.Context(), key, record, config.TTL)
+		return nil
+	}
+}
+
+// replayIdempotent отвечает на повтор запроса с уже занятым ключом key
+func replayIdempotent(c *fiber.Ctx, cache cache.Cache, key, fingerprint string) error {
+	data, err := cache.Get(c.Context(), key)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Idempotency check failed",
+		})
+	}
+
+	var record idempotencyRecord
+	// Запись, исчезнувшая между SetNX и Get, считается выполняющимся запросом: клиент повторит его позже
+	if data != nil {
+		if err := json.Unmarshal(data, &record); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Idempotency check failed",
+			})
+		}
+		if record.Fingerprint != fingerprint {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Idempotency-Key has already been used for a different request",
+			})
+		}
+	}
+	if !record.Completed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A request with this Idempotency-Key is still in progress",
+		})
+	}
+
+	for header, value := range record.Headers {
+		c.Set(header, value)
+	}
+	c.Set(HeaderIdempotentReplayed, "true")
+	if record.ContentType != "" {
+		c.Set(fiber.HeaderContentType, record.ContentType)
+	}
+	return c.Status(record.StatusCode).Send(record.Body)
+}
+
+// requestFingerprint возвращает хэш метода, пути с параметрами и тела запроса
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCache хранит значения кэша в памяти; время жизни не учитывается
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string][]byte)}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.values[key], nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = data
+	return nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.values[key]
+	return ok, nil
+}
+
+func (m *memoryCache) Increment(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if exists, _ := m.Exists(ctx, key); exists {
+		return false, nil
+	}
+	return true, m.Set(ctx, key, value, ttl)
+}
+
+func (m *memoryCache) Close() error {
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	store := newMemoryCache()
+	created := 0
+	release := make(chan struct{})
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", c.Get("X-User"))
+		return c.Next()
+	})
+	app.Use(Idempotency(store, IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute, KeyPrefix: "idempotency"}))
+	app.Post("/todos", func(c *fiber.Ctx) error {
+		created++
+		c.Set(fiber.HeaderETag, `"1"`)
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"number": created})
+	})
+	app.Post("/slow", func(c *fiber.Ctx) error {
+		<-release
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Post("/failing", func(c *fiber.Ctx) error {
+		created++
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	send := func(path, user, key, body string) (*http.Response, string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(data)
+	}
+
+	t.Run("повтор получает первый ответ", func(t *testing.T) {
+		first, firstBody := send("/todos", "alice", "key-1", `{"title":"Report"}`)
+		require.Equal(t, http.StatusCreated, first.StatusCode)
+
+		retry, retryBody := send("/todos", "alice", "key-1", `{"title":"Report"}`)
+		assert.Equal(t, http.StatusCreated, retry.StatusCode)
+		assert.Equal(t, firstBody, retryBody)
+		assert.Equal(t, `"1"`, retry.Header.Get(fiber.HeaderETag))
+		assert.Equal(t, fiber.MIMEApplicationJSON, retry.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, "true", retry.Header.Get(HeaderIdempotentReplayed))
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("ключи разных пользователей независимы", func(t *testing.T) {
+		resp, body := send("/todos", "bob", "key-1", `{"title":"Report"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.JSONEq(t, `{"number":2}`, body)
+		assert.Empty(t, resp.Header.Get(HeaderIdempotentReplayed))
+	})
+
+	t.Run("другое тело с тем же ключом", func(t *testing.T) {
+		resp, _ := send("/todos", "alice", "key-1", `{"title":"Other"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("другой путь с тем же ключом", func(t *testing.T) {
+		resp, _ := send("/failing", "alice", "key-1", `{"title":"Report"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("без ключа запрос выполняется каждый раз", func(t *testing.T) {
+		before := created
+		send("/todos", "alice", "", `{}`)
+		send("/todos", "alice", "", `{}`)
+		assert.Equal(t, before+2, created)
+	})
+
+	t.Run("ошибка сервера не сохраняется", func(t *testing.T) {
+		before := created
+		first, _ := send("/failing", "alice", "key-2", `{}`)
+		retry, _ := send("/failing", "alice", "key-2", `{}`)
+		assert.Equal(t, http.StatusInternalServerError, first.StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, retry.StatusCode)
+		assert.Equal(t, before+2, created)
+	})
+
+	t.Run("повтор выполняющегося запроса", func(t *testing.T) {
+		done := make(chan *http.Response)
+		go func() {
+			resp, _ := send("/slow", "alice", "key-3", `{}`)
+			done <- resp
+		}()
+		require.Eventually(t, func() bool {
+			exists, _ := store.Exists(context.Background(), "idempotency:alice:key-3")
+			return exists
+		}, time.Second, 10*time.Millisecond)
+
+		resp, _ := send("/slow", "alice", "key-3", `{}`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		close(release)
+		assert.Equal(t, http.StatusNoContent, (<-done).StatusCode)
+		resp, _ = send("/slow", "alice", "key-3", `{}`)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get(HeaderIdempotentReplayed))
+	})
+}
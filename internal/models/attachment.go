@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment представляет файл, прикрепленный к задаче. Содержимое хранится в хранилище
+// файлов под ключом StorageKey и скачивается по подписанной ссылке с ограниченным сроком действия.
+type Attachment struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TodoID uuid.UUID `json:"todo_id" db:"todo_id"`
+	// UserID пользователь, загрузивший файл; размер файла учитывается в его квоте
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	// Size размер файла в байтах
+	Size       int64     `json:"size" db:"size"`
+	StorageKey string    `json:"-" db:"storage_key"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	// URL подписанная ссылка на скачивание; действует до URLExpiresAt
+	URL          string     `json:"url,omitempty" db:"-"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty" db:"-"`
+}
+
+// AttachmentStorageKey возвращает ключ содержимого вложения id задачи todoID в хранилище файлов
+func AttachmentStorageKey(todoID, id uuid.UUID) string {
+	return "attachments/" + todoID.String() + "/" + id.String()
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCommentLength максимальная длина текста комментария в символах
+const MaxCommentLength = 10000
+
+// mentionRegexp находит упоминания @username и @email. Упоминание начинается в начале
+// текста или после символа, который не может входить в имя или адрес.
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@.+-])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// Comment представляет комментарий к задаче. Ответ на комментарий ссылается на него
+// через ParentID; удаленный комментарий остается в ветке без текста, чтобы ответы на него сохранились.
+type Comment struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TodoID uuid.UUID `json:"todo_id" db:"todo_id"`
+	// UserID автор комментария
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	// ParentID комментарий, на который дан ответ; nil для комментариев верхнего уровня
+	ParentID *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	Body     string     `json:"body" db:"body"`
+	// Mentions упомянутые в тексте пользователи, которым видна задача
+	Mentions  []uuid.UUID `json:"mentions" db:"mentions"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+	// EditedAt время последнего изменения текста; nil, если текст не менялся
+	EditedAt *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	// DeletedAt время удаления комментария; nil для неудаленных комментариев
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// CommentRevision представляет прежний текст комментария, замененный при изменении
+type CommentRevision struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	CommentID uuid.UUID `json:"comment_id" db:"comment_id"`
+	Body      string    `json:"body" db:"body"`
+	// ReplacedAt время, когда текст был заменен новым
+	ReplacedAt time.Time `json:"replaced_at" db:"replaced_at"`
+}
+
+// CommentRequest представляет запрос на создание комментария
+type CommentRequest struct {
+	Body     string     `json:"body"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+// CommentNode представляет комментарий вместе с ответами на него
+type CommentNode struct {
+	*Comment
+	Replies []*CommentNode `json:"replies"`
+}
+
+// BuildCommentThreads собирает ветки обсуждения из плоского списка комментариев задачи.
+// Порядок комментариев и ответов сохраняется из исходного списка.
+func BuildCommentThreads(comments []*Comment) []*CommentNode {
+	nodes := make(map[uuid.UUID]*CommentNode, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &CommentNode{Comment: comment, Replies: []*CommentNode{}}
+	}
+
+	threads := []*CommentNode{}
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, nodes[comment.ID])
+				continue
+			}
+		}
+		threads = append(threads, nodes[comment.ID])
+	}
+	return threads
+}
+
+// ParseMentions возвращает упоминания @username и @email из текста без повторов
+// в порядке появления. Точка в конце упоминания считается концом предложения.
+func ParseMentions(body string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		mention := strings.TrimRight(match[1], ".")
+		key := strings.ToLower(mention)
+		if mention == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		mentions = append(mentions, mention)
+	}
+	return mentions
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы изменений задач
+const (
+	TodoEventCreated = "created"
+	TodoEventUpdated = "updated"
+	TodoEventDeleted = "deleted"
+	// События комментариев к задаче
+	TodoEventCommentCreated = "comment_created"
+	TodoEventCommentUpdated = "comment_updated"
+	TodoEventCommentDeleted = "comment_deleted"
+)
+
+// TodoEvent представляет изменение задачи, рассылаемое подписчикам
+type TodoEvent struct {
+	ID     uuid.UUID `json:"id"`
+	Type   string    `json:"type"`
+	UserID uuid.UUID `json:"user_id"`
+	Todo   *Todo     `json:"todo"`
+	// Comment комментарий события комментария
+	Comment    *Comment  `json:"comment,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// NewTodoEvent создает событие изменения задачи для ее владельца
+func NewTodoEvent(eventType string, todo *Todo) TodoEvent {
+	return TodoEvent{
+		ID:         uuid.New(),
+		Type:       eventType,
+		UserID:     todo.UserID,
+		Todo:       todo,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// NewCommentEvent создает событие комментария к задаче для пользователя userID
+func NewCommentEvent(eventType string, userID uuid.UUID, todo *Todo, comment *Comment) TodoEvent {
+	event := NewTodoEvent(eventType, todo)
+	event.UserID = userID
+	event.Comment = comment
+	return event
+}
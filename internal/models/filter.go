@@ -0,0 +1,183 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Поля сортировки задач
+const (
+	TodoSortCreatedAt = "created_at"
+	TodoSortUpdatedAt = "updated_at"
+	TodoSortDueDate   = "due_date"
+	TodoSortPriority  = "priority"
+	// TodoSortPosition порядок задач в проекте
+	TodoSortPosition = "position"
+)
+
+// NoDueDateSortKey ключ сортировки задач без срока: они следуют за задачами со сроком
+var NoDueDateSortKey = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrInvalidSort возвращается для неподдерживаемого поля сортировки
+	ErrInvalidSort = errors.New("invalid sort")
+	// ErrInvalidSortOrder возвращается для направления сортировки, отличного от asc и desc
+	ErrInvalidSortOrder = errors.New("invalid sort order")
+	// ErrInvalidCursor возвращается для поврежденного курсора или курсора другой сортировки
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// TodoFilter задает условия выборки задач пользователя, их порядок и страницу
+type TodoFilter struct {
+	// ProjectID оставляет задачи проекта и упорядочивает их по позиции в проекте.
+	// Без проекта задачи архивных проектов не выбираются.
+	ProjectID *uuid.UUID
+	// TagsAny оставляет задачи хотя бы с одним из тегов
+	TagsAny []uuid.UUID
+	// TagsAll оставляет задачи со всеми тегами
+	TagsAll []uuid.UUID
+	// TagsNone исключает задачи с любым из тегов
+	TagsNone []uuid.UUID
+	// Statuses оставляет задачи с одним из статусов
+	Statuses []string
+	// Priorities оставляет задачи с одним из приоритетов
+	Priorities []string
+	// DueAfter и DueBefore оставляют задачи со сроком в полуинтервале [DueAfter, DueBefore)
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	// CreatedAfter и CreatedBefore оставляют задачи, созданные в полуинтервале [CreatedAfter, CreatedBefore)
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Text оставляет задачи, название или описание которых содержит строку без учета регистра
+	Text string
+	// AssigneeID оставляет задачи, назначенные пользователю
+	AssigneeID *uuid.UUID
+
+	// Sort поле сортировки (TodoSort*). Без поля задачи упорядочиваются по убыванию
+	// времени создания, а задачи проекта — по позиции в проекте.
+	Sort string
+	// Desc сортировка по убыванию; учитывается только вместе с Sort
+	Desc bool
+	// Limit максимальное число задач; 0 — без ограничения
+	Limit int
+	// After курсор последней задачи предыдущей страницы
+	After *TodoCursor
+	// GroupBy способ группировки задач (TodoGroupBy*); по умолчанию по статусу и приоритету
+	GroupBy string
+}
+
+// SortOrder возвращает поле и направление сортировки с учетом порядка по умолчанию
+func (f TodoFilter) SortOrder() (string, bool) {
+	switch {
+	case f.Sort != "":
+		return f.Sort, f.Desc
+	case f.ProjectID != nil:
+		return TodoSortPosition, false
+	}
+	return TodoSortCreatedAt, true
+}
+
+// SetSort задает сортировку по полю sort в направлении order ("asc" или "desc").
+// Пустое поле оставляет сортировку по умолчанию, а без направления задачи сортируются
+// по убыванию для created_at и updated_at и по возрастанию для остальных полей.
+func (f *TodoFilter) SetSort(sort, order string) error {
+	if sort != "" {
+		if !IsValidTodoSort(sort) {
+			return ErrInvalidSort
+		}
+		f.Sort = sort
+		f.Desc = sort == TodoSortCreatedAt || sort == TodoSortUpdatedAt
+	}
+
+	switch order {
+	case "":
+	case "asc", "desc":
+		f.Sort, _ = f.SortOrder()
+		f.Desc = order == "desc"
+	default:
+		return ErrInvalidSortOrder
+	}
+	return nil
+}
+
+// IsValidTodoSort проверяет, поддерживается ли сортировка задач по полю
+func IsValidTodoSort(sort string) bool {
+	switch sort {
+	case TodoSortCreatedAt, TodoSortUpdatedAt, TodoSortDueDate, TodoSortPriority, TodoSortPosition:
+		return true
+	}
+	return false
+}
+
+// PriorityRank возвращает порядковый номер приоритета для сортировки: low < medium < high
+func PriorityRank(priority string) int {
+	switch priority {
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	}
+	return 0
+}
+
+// TodoCursor указывает позицию задачи в отсортированной выборке. Следующая страница
+// начинается сразу после этой позиции, поэтому задачи, добавленные между запросами,
+// не сдвигают страницы и не приводят к повторам.
+type TodoCursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	// Time ключ сортировки по времени
+	Time time.Time `json:"t,omitempty"`
+	// Rank ключ сортировки по приоритету или позиции
+	Rank int       `json:"r,omitempty"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// NewTodoCursor создает курсор задачи для сортировки по полю sort
+func NewTodoCursor(todo *Todo, sort string, desc bool) *TodoCursor {
+	cursor := &TodoCursor{Sort: sort, Desc: desc, ID: todo.ID}
+	switch sort {
+	case TodoSortUpdatedAt:
+		cursor.Time = todo.UpdatedAt
+	case TodoSortDueDate:
+		cursor.Time = todo.DueDate
+		if todo.DueDate.IsZero() {
+			cursor.Time = NoDueDateSortKey
+		}
+	case TodoSortPriority:
+		cursor.Rank = PriorityRank(todo.Priority)
+	case TodoSortPosition:
+		cursor.Rank = todo.Position
+	default:
+		cursor.Time = todo.CreatedAt
+	}
+	return cursor
+}
+
+// Encode возвращает непрозрачное строковое представление курсора
+func (c *TodoCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTodoCursor разбирает курсор и проверяет, что он получен для той же сортировки
+func DecodeTodoCursor(value, sort string, desc bool) (*TodoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor TodoCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.Desc != desc || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
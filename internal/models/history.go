@@ -0,0 +1,212 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Действия, записываемые в историю задачи
+const (
+	TodoHistoryCreated  = "created"
+	TodoHistoryUpdated  = "updated"
+	TodoHistoryDeleted  = "deleted"
+	TodoHistoryRestored = "restored"
+	// TodoHistoryReverted обновление, возвращающее задачу к одной из прежних ревизий
+	TodoHistoryReverted = "reverted"
+)
+
+// Источники изменений задач
+const (
+	ChangeSourceREST = "rest"
+	ChangeSourceGRPC = "grpc"
+	// ChangeSourceAutomation изменения фоновых процессов и изменения без автора в контексте
+	ChangeSourceAutomation = "automation"
+)
+
+// ErrRevisionNotFound возвращается при откате к ревизии, которой нет в истории задачи
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// TodoHistoryEntry представляет ревизию задачи: одно изменение с автором и измененными полями
+type TodoHistoryEntry struct {
+	ID     uuid.UUID `json:"id"`
+	TodoID uuid.UUID `json:"todo_id"`
+	// Revision номер ревизии задачи, начиная с 1
+	Revision int    `json:"revision"`
+	Action   string `json:"action"`
+	// ActorID автор изменения; nil для автоматических изменений
+	ActorID *uuid.UUID        `json:"actor_id,omitempty"`
+	Source  string            `json:"source"`
+	Changes []TodoFieldChange `json:"changes"`
+	// RevertedTo ревизия, к которой вернулась задача, для действия reverted
+	RevertedTo *int      `json:"reverted_to,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TodoFieldChange представляет изменение поля задачи. Значения записаны в JSON
+// так же, как поле в ответе API.
+type TodoFieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// todoHistoryState поля задачи, изменения которых записываются в историю
+type todoHistoryState struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     time.Time  `json:"due_date"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	ProjectID   *uuid.UUID `json:"project_id"`
+}
+
+// todoHistoryFields порядок полей в изменениях ревизии
+var todoHistoryFields = []string{"title", "description", "status", "priority", "due_date", "parent_id", "project_id"}
+
+// historyState возвращает отслеживаемые поля задачи в JSON. Срок приводится к UTC
+// с точностью базы данных, чтобы один и тот же момент не считался изменением.
+func historyState(todo *Todo) map[string]json.RawMessage {
+	data, _ := json.Marshal(todoHistoryState{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Status:      todo.Status,
+		Priority:    todo.Priority,
+		DueDate:     todo.DueDate.UTC().Truncate(time.Microsecond),
+		ParentID:    todo.ParentID,
+		ProjectID:   todo.ProjectID,
+	})
+	var state map[string]json.RawMessage
+	_ = json.Unmarshal(data, &state)
+	return state
+}
+
+// DiffTodos возвращает изменения отслеживаемых полей задачи от before к after.
+// Для новой задачи before равен nil, и в изменения попадают все заполненные поля.
+func DiffTodos(before, after *Todo) []TodoFieldChange {
+	if before == nil {
+		before = &Todo{}
+	}
+	oldState, newState := historyState(before), historyState(after)
+
+	changes := []TodoFieldChange{}
+	for _, field := range todoHistoryFields {
+		if string(oldState[field]) == string(newState[field]) {
+			continue
+		}
+		changes = append(changes, TodoFieldChange{Field: field, Old: oldState[field], New: newState[field]})
+	}
+	return changes
+}
+
+// DiffTodoTags возвращает изменение тегов задачи от before к after в поле tags или пустой
+// список, если теги не изменились. Теги записываются в историю, но не откатываются RevertTodo.
+func DiffTodoTags(before, after *Todo) []TodoFieldChange {
+	oldTags, newTags := historyTags(before.Tags), historyTags(after.Tags)
+	if string(oldTags) == string(newTags) {
+		return []TodoFieldChange{}
+	}
+	return []TodoFieldChange{{Field: "tags", Old: oldTags, New: newTags}}
+}
+
+// historyTags возвращает теги задачи в JSON; отсутствие тегов записывается пустым списком
+func historyTags(tags []TodoTag) json.RawMessage {
+	if tags == nil {
+		tags = []TodoTag{}
+	}
+	data, _ := json.Marshal(tags)
+	return data
+}
+
+// RevertTodo возвращает копию задачи todo с отслеживаемыми полями, какими они были после
+// ревизии revision: изменения более поздних ревизий из entries отменяются от последней
+// к ранней. Проект задачи не откатывается: перенос между проектами перемещает и подзадачи,
+// поэтому выполняется только явным переносом. Остальные поля задачи не меняются.
+func RevertTodo(todo *Todo, entries []*TodoHistoryEntry, revision int) (*Todo, error) {
+	sorted := make([]*TodoHistoryEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Revision > sorted[j].Revision })
+
+	state := historyState(todo)
+	found := false
+	for _, entry := range sorted {
+		if entry.Revision <= revision {
+			found = entry.Revision == revision
+			break
+		}
+		for _, change := range entry.Changes {
+			state[change.Field] = change.Old
+		}
+	}
+	if !found {
+		return nil, ErrRevisionNotFound
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var reverted todoHistoryState
+	if err := json.Unmarshal(data, &reverted); err != nil {
+		return nil, err
+	}
+
+	result := *todo
+	result.Title = reverted.Title
+	result.Description = reverted.Description
+	result.Status = reverted.Status
+	result.Priority = reverted.Priority
+	result.DueDate = reverted.DueDate
+	result.ParentID = reverted.ParentID
+	return &result, nil
+}
+
+// Actor представляет автора изменения задачи
+type Actor struct {
+	// UserID пользователь, выполнивший изменение; nil для автоматических изменений
+	UserID *uuid.UUID
+	Source string
+}
+
+// actorContextKey тип ключа контекста с автором изменения
+type actorContextKey struct{}
+
+// revertContextKey тип ключа контекста с ревизией, к которой откатывается задача
+type revertContextKey struct{}
+
+// ActorContextKey ключ контекста, под которым хранится автор изменения. Обработчики REST
+// передают в репозитории контекст fasthttp, поэтому автор кладется в него через
+// c.Locals(models.ActorContextKey, actor).
+var ActorContextKey = actorContextKey{}
+
+// WithActor возвращает контекст с автором изменений
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, ActorContextKey, actor)
+}
+
+// ActorFromContext возвращает автора изменений из контекста. Изменения без автора
+// считаются автоматическими.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(ActorContextKey).(Actor); ok {
+		return actor
+	}
+	return Actor{Source: ChangeSourceAutomation}
+}
+
+// WithRevert возвращает контекст, в котором обновление задачи записывается в историю
+// как откат к ревизии revision
+func WithRevert(ctx context.Context, revision int) context.Context {
+	return context.WithValue(ctx, revertContextKey{}, revision)
+}
+
+// RevertFromContext возвращает ревизию, к которой откатывается задача, если обновление
+// выполняется в контексте WithRevert
+func RevertFromContext(ctx context.Context) (int, bool) {
+	revision, ok := ctx.Value(revertContextKey{}).(int)
+	return revision, ok
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InboxProjectName название проекта по умолчанию
+const InboxProjectName = "Inbox"
+
+// Project представляет список (проект), в котором находятся задачи пользователя.
+// У каждого пользователя есть Inbox, куда попадают задачи без явно указанного проекта.
+type Project struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	IsInbox    bool       `json:"is_inbox" db:"is_inbox"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsArchived сообщает, находится ли проект в архиве
+func (p *Project) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+// ProjectRequest представляет запрос на создание или переименование проекта
+type ProjectRequest struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/recurrence"
+	"github.com/google/uuid"
+)
+
+// DefaultRecurrenceTimezone часовой пояс правил повторения по умолчанию
+const DefaultRecurrenceTimezone = "UTC"
+
+// TodoSeries представляет серию повторяющихся задач. Следующее повторение создается
+// по правилу серии из ее шаблона (название, описание, приоритет), когда текущее
+// повторение завершается или отменяется.
+type TodoSeries struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Rule        string    `json:"rule" db:"rrule"`
+	Timezone    string    `json:"timezone" db:"timezone"`
+	Start       time.Time `json:"start" db:"dtstart"`
+	Title       string    `json:"title" db:"title"`
+	Description string    `json:"description" db:"description"`
+	Priority    string    `json:"priority" db:"priority"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// TodoRecurrence описывает принадлежность задачи к серии повторений.
+// Нулевой SeriesID в создаваемой или обновляемой задаче означает запрос новой серии
+// с правилом Rule, начинающейся со срока задачи: так задаются повторения новой задачи
+// и изменения "это и последующие повторения".
+type TodoRecurrence struct {
+	SeriesID uuid.UUID `json:"series_id"`
+	// Rule правило повторения в формате RRULE
+	Rule     string    `json:"rule"`
+	Timezone string    `json:"timezone"`
+	Start    time.Time `json:"start"`
+	// OccurrenceDate плановая дата повторения по правилу серии; не меняется при переносе срока задачи
+	OccurrenceDate time.Time `json:"occurrence_date"`
+}
+
+// Location возвращает часовой пояс, в котором вычисляются повторения
+func (r *TodoRecurrence) Location() (*time.Location, error) {
+	if r.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(r.Timezone)
+}
+
+// Upcoming возвращает не более n плановых дат повторений серии после текущего повторения
+func (r *TodoRecurrence) Upcoming(n int) ([]time.Time, error) {
+	rule, err := recurrence.Parse(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := r.Location()
+	if err != nil {
+		return nil, err
+	}
+	return rule.After(r.Start.In(loc), r.OccurrenceDate, n), nil
+}
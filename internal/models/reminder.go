@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Каналы доставки напоминаний
+const (
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+	ReminderChannelInApp   = "in_app"
+)
+
+// Reminder представляет напоминание о задаче. Время срабатывания задается либо абсолютно
+// (RemindAt), либо смещением до срока задачи (OffsetMinutes) и тогда следует за переносом срока.
+type Reminder struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TodoID uuid.UUID `json:"todo_id" db:"todo_id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	// RemindAt абсолютное время напоминания
+	RemindAt *time.Time `json:"remind_at,omitempty" db:"remind_at"`
+	// OffsetMinutes за сколько минут до срока задачи сработает напоминание
+	OffsetMinutes *int   `json:"offset_minutes,omitempty" db:"offset_minutes"`
+	Channel       string `json:"channel" db:"channel"`
+	// Target адрес доставки: email или URL webhook; для email по умолчанию адрес пользователя
+	Target string `json:"target,omitempty" db:"target"`
+	// Attempts число неудачных попыток доставки
+	Attempts int `json:"attempts" db:"attempts"`
+	// NextAttemptAt время повторной попытки после неудачной доставки
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	// FailedAt время, когда попытки доставки исчерпаны
+	FailedAt  *time.Time `json:"failed_at,omitempty" db:"failed_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// FireAt возвращает время срабатывания напоминания для задачи со сроком dueDate.
+// Напоминание со смещением не срабатывает, пока у задачи нет срока.
+func (r *Reminder) FireAt(dueDate time.Time) (time.Time, bool) {
+	if r.RemindAt != nil {
+		return *r.RemindAt, true
+	}
+	if r.OffsetMinutes == nil || dueDate.IsZero() {
+		return time.Time{}, false
+	}
+	return dueDate.Add(-time.Duration(*r.OffsetMinutes) * time.Minute), true
+}
+
+// ReminderRequest представляет запрос на создание напоминания
+type ReminderRequest struct {
+	RemindAt      *time.Time `json:"remind_at"`
+	OffsetMinutes *int       `json:"offset_minutes"`
+	Channel       string     `json:"channel"`
+	Target        string     `json:"target"`
+}
+
+// DueReminder представляет напоминание, которое пора доставить, вместе с задачей
+// и адресом владельца
+type DueReminder struct {
+	Reminder  *Reminder
+	Todo      *Todo
+	UserEmail string
+}
+
+// ReminderDelivery представляет попытку доставки напоминания
+type ReminderDelivery struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	ReminderID  uuid.UUID `json:"reminder_id" db:"reminder_id"`
+	Channel     string    `json:"channel" db:"channel"`
+	Success     bool      `json:"success" db:"success"`
+	Error       string    `json:"error,omitempty" db:"error"`
+	AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// Notification представляет уведомление во входящих пользователя
+type Notification struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	TodoID     *uuid.UUID `json:"todo_id,omitempty" db:"todo_id"`
+	ReminderID *uuid.UUID `json:"reminder_id,omitempty" db:"reminder_id"`
+	Title      string     `json:"title" db:"title"`
+	Body       string     `json:"body" db:"body"`
+	ReadAt     *time.Time `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
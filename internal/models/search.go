@@ -0,0 +1,21 @@
+package models
+
+// Метки, которыми выделяются найденные слова в результатах поиска
+const (
+	SearchHighlightStart = "<mark>"
+	SearchHighlightStop  = "</mark>"
+)
+
+// TodoSearchResult представляет задачу, найденную поиском. Выделенные фрагменты
+// содержат экранированный HTML, в котором найденные слова обрамлены метками <mark>.
+type TodoSearchResult struct {
+	Todo *Todo `json:"todo"`
+	// Rank релевантность задачи запросу; результаты упорядочены по ее убыванию
+	Rank float64 `json:"rank"`
+	// TitleHighlight название задачи с выделенными найденными словами
+	TitleHighlight string `json:"title_highlight"`
+	// Snippet фрагменты описания с найденными словами
+	Snippet string `json:"snippet,omitempty"`
+	// Fuzzy задача найдена по похожему написанию слов, а не по самим словам запроса
+	Fuzzy bool `json:"fuzzy,omitempty"`
+}
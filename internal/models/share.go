@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Роли участников общего доступа
+const (
+	// ShareRoleViewer может только просматривать задачи
+	ShareRoleViewer = "viewer"
+	// ShareRoleEditor может изменять, переносить и удалять задачи
+	ShareRoleEditor = "editor"
+	// ShareRoleOwner дополнительно управляет общим доступом
+	ShareRoleOwner = "owner"
+)
+
+// Статусы приглашений
+const (
+	ShareStatusPending  = "pending"
+	ShareStatusAccepted = "accepted"
+	ShareStatusDeclined = "declined"
+)
+
+// Share представляет общий доступ к проекту или отдельной задаче. Доступ выдается
+// приглашением на email и действует после того, как пользователь с этим адресом его примет.
+// Доступ к проекту распространяется на все его задачи.
+type Share struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	TodoID    *uuid.UUID `json:"todo_id,omitempty" db:"todo_id"`
+	// InvitedBy пользователь, отправивший приглашение
+	InvitedBy uuid.UUID `json:"invited_by" db:"invited_by"`
+	Email     string    `json:"email" db:"email"`
+	// UserID пользователь, принявший или отклонивший приглашение
+	UserID      *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	Role        string     `json:"role" db:"role"`
+	Status      string     `json:"status" db:"status"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty" db:"responded_at"`
+}
+
+// ShareRequest представляет запрос на приглашение пользователя
+type ShareRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// IsValidShareRole проверяет роль участника
+func IsValidShareRole(role string) bool {
+	return ShareRoleRank(role) > 0
+}
+
+// ShareRoleRank возвращает уровень прав роли: чем больше, тем больше прав; 0 — неизвестная роль
+func ShareRoleRank(role string) int {
+	switch role {
+	case ShareRoleViewer:
+		return 1
+	case ShareRoleEditor:
+		return 2
+	case ShareRoleOwner:
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTagColor цвет тега, если он не указан
+const DefaultTagColor = "#808080"
+
+// Tag представляет тег пользователя для классификации задач
+type Tag struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Color     string    `json:"color" db:"color"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TodoTag представляет тег, назначенный задаче
+type TodoTag struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Color string    `json:"color"`
+}
+
+// TagCount представляет число задач группы с тегом
+type TagCount struct {
+	Tag   TodoTag `json:"tag"`
+	Count int     `json:"count"`
+}
+
+// TagRequest представляет запрос на создание или обновление тега
+type TagRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
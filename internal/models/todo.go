@@ -6,6 +6,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// Статусы задач
+const (
+	TodoStatusPending    = "pending"
+	TodoStatusInProgress = "in_progress"
+	TodoStatusCompleted  = "completed"
+	TodoStatusCancelled  = "cancelled"
+)
+
 // Todo представляет задачу в системе
 type Todo struct {
 	ID          uuid.UUID `json:"id" db:"id"`
@@ -15,17 +23,98 @@ type Todo struct {
 	Priority    string    `json:"priority" db:"priority"`
 	DueDate     time.Time `json:"due_date" db:"due_date"`
 	UserID      uuid.UUID `json:"user_id" db:"user_id"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	// ParentID родительская задача; nil для задач верхнего уровня
+	ParentID *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	// ProjectID проект задачи; при создании без проекта задача попадает в Inbox
+	ProjectID *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	// Position порядок задачи внутри проекта
+	Position int `json:"position" db:"position"`
+	// Recurrence серия повторений задачи; nil для неповторяющихся задач
+	Recurrence *TodoRecurrence `json:"recurrence,omitempty" db:"-"`
+	// Progress прогресс прямых подзадач; nil, если подзадач нет
+	Progress *TodoProgress `json:"progress,omitempty" db:"-"`
+	// Tags теги задачи, отсортированные по названию
+	Tags []TodoTag `json:"tags,omitempty" db:"-"`
+	// Assignees исполнители задачи в порядке назначения
+	Assignees []uuid.UUID `json:"assignees,omitempty" db:"-"`
+	// CommentCount число неудаленных комментариев к задаче
+	CommentCount int       `json:"comment_count" db:"-"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt время перемещения задачи в корзину; nil для задач вне корзины
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Version увеличивается при каждом изменении задачи, в том числе ее тегов, исполнителей
+	// и положения в проекте. Используется для оптимистичной блокировки и в заголовке ETag.
+	Version int `json:"version" db:"version"`
+}
+
+// TodoProgress представляет прогресс выполнения подзадач.
+// Отмененные подзадачи не учитываются.
+type TodoProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// IsClosed сообщает, завершена или отменена ли задача
+func (t *Todo) IsClosed() bool {
+	return t.Status == TodoStatusCompleted || t.Status == TodoStatusCancelled
+}
+
+// TodoNode представляет задачу вместе с ее подзадачами
+type TodoNode struct {
+	*Todo
+	Subtasks []*TodoNode `json:"subtasks"`
+}
+
+// BuildTodoTree собирает дерево подзадач задачи rootID из плоского списка задач поддерева.
+// Возвращает nil, если корневой задачи нет в списке.
+func BuildTodoTree(todos []*Todo, rootID uuid.UUID) *TodoNode {
+	nodes := make(map[uuid.UUID]*TodoNode, len(todos))
+	for _, todo := range todos {
+		nodes[todo.ID] = &TodoNode{Todo: todo, Subtasks: []*TodoNode{}}
+	}
+
+	// Порядок подзадач сохраняется из исходного списка
+	for _, todo := range todos {
+		if todo.ID == rootID || todo.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*todo.ParentID]; ok {
+			parent.Subtasks = append(parent.Subtasks, nodes[todo.ID])
+		}
+	}
+	return nodes[rootID]
+}
+
+// SubtaskPolicy определяет поведение при завершении или удалении задачи с подзадачами
+type SubtaskPolicy string
+
+const (
+	// SubtaskPolicyBlock запрещает операцию, пока у задачи есть подзадачи
+	// (для завершения — незавершенные подзадачи)
+	SubtaskPolicyBlock SubtaskPolicy = "block"
+	// SubtaskPolicyCascade применяет операцию ко всем подзадачам
+	SubtaskPolicyCascade SubtaskPolicy = "cascade"
+)
+
+// SubtaskPolicies задает поведение при завершении и удалении родительских задач
+type SubtaskPolicies struct {
+	Complete SubtaskPolicy
+	Delete   SubtaskPolicy
 }
 
 // CreateTodoRequest представляет запрос на создание задачи
 type CreateTodoRequest struct {
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	Priority    string    `json:"priority"`
-	DueDate     time.Time `json:"due_date"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     time.Time  `json:"due_date"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	// Recurrence правило повторения в формате RRULE; срок задачи становится началом серии
+	Recurrence string `json:"recurrence,omitempty"`
+	Timezone   string `json:"timezone,omitempty"`
 }
 
 // UpdateTodoRequest представляет запрос на обновление задачи
@@ -35,12 +124,35 @@ type UpdateTodoRequest struct {
 	Status      *string    `json:"status,omitempty"`
 	Priority    *string    `json:"priority,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	// Recurrence новое правило повторения; пустое правило завершает серию на этой задаче
+	Recurrence *string `json:"recurrence,omitempty"`
+	// Timezone новый часовой пояс правила повторения
+	Timezone *string `json:"timezone,omitempty"`
+}
+
+// Способы группировки задач
+const (
+	// TodoGroupByStatus группировка по статусу и приоритету
+	TodoGroupByStatus = "status"
+	// TodoGroupByAssignee группировка по исполнителю: задача с несколькими исполнителями
+	// входит в группу каждого из них, задачи без исполнителя — в группу без Assignee
+	TodoGroupByAssignee = "assignee"
+)
+
+// IsValidTodoGroupBy проверяет способ группировки задач
+func IsValidTodoGroupBy(groupBy string) bool {
+	return groupBy == TodoGroupByStatus || groupBy == TodoGroupByAssignee
 }
 
 // TodoGroup представляет группировку задач
 type TodoGroup struct {
-	Status   string  `json:"status"`
-	Priority string  `json:"priority"`
-	Count    int     `json:"count"`
-	Tasks    []*Todo `json:"tasks"`
+	Status   string `json:"status,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	// Assignee исполнитель задач группы при группировке по исполнителю
+	Assignee *uuid.UUID `json:"assignee,omitempty"`
+	Count    int        `json:"count"`
+	Tasks    []*Todo    `json:"tasks"`
+	// TagCounts число задач группы с каждым тегом, отсортированное по названию тега
+	TagCounts []TagCount `json:"tag_counts"`
 }
@@ -9,6 +9,7 @@ import (
 // User представляет пользователя в системе
 type User struct {
 	ID        uuid.UUID `json:"id" db:"id"`
+	Username  string    `json:"username,omitempty" db:"username"`
 	Email     string    `json:"email" db:"email"`
 	Password  string    `json:"-" db:"password"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+)
+
+// InboxNotifier сохраняет напоминания во входящие пользователя внутри приложения
+type InboxNotifier struct {
+	repo repository.NotificationRepository
+}
+
+// NewInboxNotifier создает новый экземпляр InboxNotifier
+func NewInboxNotifier(repo repository.NotificationRepository) *InboxNotifier {
+	return &InboxNotifier{repo: repo}
+}
+
+func (n *InboxNotifier) Notify(ctx context.Context, msg Message) error {
+	notification := &models.Notification{
+		ID:         uuid.New(),
+		UserID:     msg.UserID,
+		TodoID:     &msg.TodoID,
+		ReminderID: &msg.ReminderID,
+		Title:      msg.Title,
+		Body:       msg.Body(),
+		CreatedAt:  time.Now(),
+	}
+	return n.repo.Create(ctx, notification)
+}
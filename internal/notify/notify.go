@@ -0,0 +1,55 @@
+// Package notify доставляет напоминания о задачах по каналам уведомлений
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+// Message представляет напоминание, подготовленное к доставке
+type Message struct {
+	ReminderID uuid.UUID
+	TodoID     uuid.UUID
+	UserID     uuid.UUID
+	// Email адрес владельца задачи
+	Email string
+	// Target адрес доставки из напоминания: email или URL webhook
+	Target string
+	Title  string
+	// DueDate срок задачи; нулевой, если срок не задан
+	DueDate time.Time
+	// FireAt плановое время срабатывания напоминания
+	FireAt time.Time
+}
+
+// NewMessage собирает сообщение о напоминании, которое пора доставить
+func NewMessage(due *models.DueReminder, fireAt time.Time) Message {
+	return Message{
+		ReminderID: due.Reminder.ID,
+		TodoID:     due.Todo.ID,
+		UserID:     due.Reminder.UserID,
+		Email:      due.UserEmail,
+		Target:     due.Reminder.Target,
+		Title:      due.Todo.Title,
+		DueDate:    due.Todo.DueDate,
+		FireAt:     fireAt,
+	}
+}
+
+// Body возвращает текст напоминания
+func (m Message) Body() string {
+	if m.DueDate.IsZero() {
+		return fmt.Sprintf("Reminder: %s", m.Title)
+	}
+	return fmt.Sprintf("Reminder: %s is due %s", m.Title, m.DueDate.UTC().Format(time.RFC1123))
+}
+
+// Notifier доставляет напоминание по одному каналу. Ошибка означает, что доставка
+// не удалась и ее можно повторить.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
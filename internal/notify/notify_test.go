@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage(target string) Message {
+	return Message{
+		ReminderID: uuid.New(),
+		TodoID:     uuid.New(),
+		UserID:     uuid.New(),
+		Email:      "user@example.com",
+		Target:     target,
+		Title:      "Сдать отчет",
+		DueDate:    time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+		FireAt:     time.Date(2026, 5, 1, 11, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var body []byte
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	msg := testMessage(srv.URL)
+	notifier := NewWebhookNotifier(srv.Client(), "secret")
+	require.NoError(t, notifier.Notify(context.Background(), msg))
+
+	assert.Equal(t, "sha256="+Sign([]byte("secret"), body), signature)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "reminder", payload["event"])
+	assert.Equal(t, msg.ReminderID.String(), payload["reminder_id"])
+	assert.Equal(t, msg.Title, payload["title"])
+}
+
+func TestWebhookNotifier_Notify_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	notifier := NewWebhookNotifier(srv.Client(), "")
+	err := notifier.Notify(context.Background(), testMessage(srv.URL))
+	assert.ErrorContains(t, err, "502")
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		title  string
+		wantTo string
+	}{
+		{
+			name:   "адрес пользователя по умолчанию",
+			title:  "Сдать отчет",
+			wantTo: "user@example.com",
+		},
+		{
+			name:   "адрес из напоминания",
+			target: "team@example.com",
+			title:  "Сдать отчет",
+			wantTo: "team@example.com",
+		},
+		{
+			name:   "перевод строки в названии не добавляет заголовки",
+			title:  "Отчет\r\nBcc: attacker@example.com",
+			wantTo: "user@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTo []string
+			var gotMsg string
+			notifier := NewSMTPNotifier(SMTPConfig{Addr: "smtp.example.com:587", From: "todo@example.com"})
+			notifier.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				gotTo = to
+				gotMsg = string(msg)
+				return nil
+			}
+
+			msg := testMessage(tt.target)
+			msg.Title = tt.title
+			require.NoError(t, notifier.Notify(context.Background(), msg))
+			assert.Equal(t, []string{tt.wantTo}, gotTo)
+
+			headers, _, _ := strings.Cut(gotMsg, "\r\n\r\n")
+			assert.NotContains(t, headers, "\r\nBcc:")
+			assert.Contains(t, headers, "To: "+tt.wantTo)
+		})
+	}
+}
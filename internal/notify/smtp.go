@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPConfig содержит настройки отправки писем
+type SMTPConfig struct {
+	// Addr адрес SMTP сервера в формате host:port
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPNotifier отправляет напоминания письмом на адрес из напоминания
+// или, если он не указан, на адрес пользователя
+type SMTPNotifier struct {
+	cfg  SMTPConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifier создает новый экземпляр SMTPNotifier
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg, send: smtp.SendMail}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	to := msg.Target
+	if to == "" {
+		to = msg.Email
+	}
+	if to == "" {
+		return errors.New("no email address")
+	}
+
+	var auth smtp.Auth
+	if n.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(n.cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address: %w", err)
+		}
+		auth = smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, host)
+	}
+
+	body := strings.Join([]string{
+		"From: " + n.cfg.From,
+		"To: " + to,
+		"Subject: " + headerValue("Reminder: "+msg.Title),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body(),
+	}, "\r\n")
+	return n.send(n.cfg.Addr, auth, n.cfg.From, []string{to}, []byte(body))
+}
+
+// headerValue убирает переводы строк, чтобы название задачи не добавило заголовки письма
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignatureHeader заголовок с HMAC-SHA256 подписью тела запроса webhook
+const SignatureHeader = "X-Todo-Signature"
+
+// webhookPayload тело запроса webhook
+type webhookPayload struct {
+	Event      string     `json:"event"`
+	ReminderID uuid.UUID  `json:"reminder_id"`
+	TodoID     uuid.UUID  `json:"todo_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Title      string     `json:"title"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+	FireAt     time.Time  `json:"fire_at"`
+}
+
+// WebhookNotifier отправляет напоминания POST запросом с JSON телом на URL из напоминания.
+// Если задан секрет, тело подписывается в заголовке X-Todo-Signature: sha256=<hex>.
+type WebhookNotifier struct {
+	client *http.Client
+	secret []byte
+}
+
+// NewWebhookNotifier создает новый экземпляр WebhookNotifier
+func NewWebhookNotifier(client *http.Client, secret string) *WebhookNotifier {
+	return &WebhookNotifier{client: client, secret: []byte(secret)}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.Target == "" {
+		return errors.New("no webhook URL")
+	}
+
+	payload := webhookPayload{
+		Event:      "reminder",
+		ReminderID: msg.ReminderID,
+		TodoID:     msg.TodoID,
+		UserID:     msg.UserID,
+		Title:      msg.Title,
+		FireAt:     msg.FireAt,
+	}
+	if !msg.DueDate.IsZero() {
+		payload.DueDate = &msg.DueDate
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign возвращает HMAC-SHA256 подпись тела в шестнадцатеричном виде
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
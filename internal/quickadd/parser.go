@@ -0,0 +1,621 @@
+// Package quickadd разбирает строку быстрого добавления задачи, например
+// "Pay rent tomorrow 9am !high #home every month", на название, срок, приоритет, теги
+// и правило повторения. Ключевые слова понимаются на английском и русском языках.
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/R-eSPeCT/todo-list/internal/recurrence"
+)
+
+// Result результат разбора строки быстрого добавления
+type Result struct {
+	// Title слова строки, не распознанные как срок, приоритет, тег или повторение
+	Title string `json:"title"`
+	// DueDate срок задачи; nil, если в строке нет даты или времени
+	DueDate    *time.Time `json:"due_date,omitempty"`
+	Priority   string     `json:"priority,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+	// Unrecognized фрагменты, похожие на команды, но не разобранные: неизвестный приоритет,
+	// повторный срок, неверная дата. Они остаются в названии задачи.
+	Unrecognized []string `json:"unrecognized,omitempty"`
+}
+
+// unit единица измерения относительного срока и интервала повторения
+type unit int
+
+const (
+	unitMinute unit = iota + 1
+	unitHour
+	unitDay
+	unitWeek
+	unitMonth
+	unitYear
+)
+
+var units = map[string]unit{
+	"minute": unitMinute, "minutes": unitMinute, "min": unitMinute, "mins": unitMinute,
+	"минуту": unitMinute, "минуты": unitMinute, "минут": unitMinute,
+	"hour": unitHour, "hours": unitHour, "час": unitHour, "часа": unitHour, "часов": unitHour,
+	"day": unitDay, "days": unitDay, "день": unitDay, "дня": unitDay, "дней": unitDay,
+	"week": unitWeek, "weeks": unitWeek, "неделю": unitWeek, "недели": unitWeek, "недель": unitWeek,
+	"month": unitMonth, "months": unitMonth, "месяц": unitMonth, "месяца": unitMonth, "месяцев": unitMonth,
+	"year": unitYear, "years": unitYear, "год": unitYear, "года": unitYear, "лет": unitYear,
+}
+
+// Сокращения sat и sun не поддерживаются: это обычные английские слова
+var weekdays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday, "понедельник": time.Monday, "пн": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday, "вторник": time.Tuesday, "вт": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday, "четверг": time.Thursday, "чт": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+	"saturday": time.Saturday, "суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+	"sunday": time.Sunday, "воскресенье": time.Sunday, "вс": time.Sunday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January, "января": time.January,
+	"february": time.February, "feb": time.February, "февраля": time.February,
+	"march": time.March, "mar": time.March, "марта": time.March,
+	"april": time.April, "apr": time.April, "апреля": time.April,
+	"may": time.May, "мая": time.May,
+	"june": time.June, "jun": time.June, "июня": time.June,
+	"july": time.July, "jul": time.July, "июля": time.July,
+	"august": time.August, "aug": time.August, "августа": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September, "сентября": time.September,
+	"october": time.October, "oct": time.October, "октября": time.October,
+	"november": time.November, "nov": time.November, "ноября": time.November,
+	"december": time.December, "dec": time.December, "декабря": time.December,
+}
+
+var priorities = map[string]string{
+	"high": "high", "высокий": "high",
+	"medium": "medium", "средний": "medium",
+	"low": "low", "низкий": "low",
+}
+
+// Слова, начинающие правило повторения: every 2 weeks, каждый понедельник
+var everyWords = map[string]bool{"every": true, "каждый": true, "каждую": true, "каждое": true, "каждые": true}
+
+// Правила повторения из одного слова
+var repeatWords = map[string]string{
+	"daily": recurrence.Daily, "ежедневно": recurrence.Daily,
+	"weekly": recurrence.Weekly, "еженедельно": recurrence.Weekly,
+	"monthly": recurrence.Monthly, "ежемесячно": recurrence.Monthly,
+	"yearly": recurrence.Yearly, "annually": recurrence.Yearly, "ежегодно": recurrence.Yearly,
+}
+
+var (
+	isoDateRegexp = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	// dotDateRegexp дата 31.12 или 31.12.2024; месяц из двух цифр отличает ее от дробного числа
+	dotDateRegexp = regexp.MustCompile(`^(\d{1,2})\.(\d{2})(?:\.(\d{4}))?$`)
+	// clockRegexp время 9am, 9:30pm или 21:00
+	clockRegexp  = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	dayNumRegexp = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+// Parse разбирает строку text. Относительные даты вычисляются от now в его часовом поясе.
+// Дата без времени означает начало дня, время без даты — ближайший такой момент после now.
+// Повторяющаяся задача без даты начинается сегодня или в ближайший день повторения.
+func Parse(text string, now time.Time) *Result {
+	p := &parser{now: now, tokens: strings.Fields(text)}
+	p.parse()
+	return p.result()
+}
+
+// parser хранит состояние разбора строки
+type parser struct {
+	now    time.Time
+	tokens []string
+
+	title        []string
+	unrecognized []string
+	priority     string
+	tags         []string
+	rule         *recurrence.Rule
+	// date начало дня срока; нулевое, если дата не указана
+	date time.Time
+	// hasClock указано ли время clock (минуты от начала дня)
+	hasClock bool
+	clock    int
+	// instant точный срок относительно now: in 2 hours
+	instant time.Time
+}
+
+func (p *parser) parse() {
+	matchers := []func(i int) int{
+		p.matchTag, p.matchPriority, p.matchRepeat, p.matchRelative,
+		p.matchDay, p.matchWeekday, p.matchDate, p.matchClock,
+	}
+	for i := 0; i < len(p.tokens); {
+		n := 0
+		for _, match := range matchers {
+			if n = match(i); n > 0 {
+				break
+			}
+		}
+		if n == 0 {
+			p.title = append(p.title, p.tokens[i])
+			n = 1
+		}
+		i += n
+	}
+}
+
+func (p *parser) result() *Result {
+	result := &Result{
+		Title:        strings.Join(p.title, " "),
+		Priority:     p.priority,
+		Tags:         p.tags,
+		Unrecognized: p.unrecognized,
+	}
+	if p.rule != nil {
+		result.Recurrence = p.rule.String()
+	}
+	if due, ok := p.dueDate(); ok {
+		result.DueDate = &due
+	}
+	return result
+}
+
+// dueDate собирает срок из разобранных даты, времени и правила повторения
+func (p *parser) dueDate() (time.Time, bool) {
+	if !p.instant.IsZero() {
+		return p.instant, true
+	}
+
+	today := startOfDay(p.now)
+	switch {
+	case !p.date.IsZero():
+		return p.at(p.date), true
+	case p.rule != nil:
+		// Срок — начало серии, поэтому это ближайшее повторение, еще не наступившее к now
+		days := 0
+		if len(p.rule.ByDay) > 0 {
+			days = 7
+			for _, day := range p.rule.ByDay {
+				days = min(days, daysUntil(today, day.Weekday))
+			}
+		}
+		start := p.at(today.AddDate(0, 0, days))
+		if start.Before(p.now) && p.hasClock {
+			if next, ok := p.rule.Next(start, p.now); ok {
+				start = next
+			}
+		}
+		return start, true
+	case p.hasClock:
+		due := p.at(today)
+		if !due.After(p.now) {
+			due = p.at(today.AddDate(0, 0, 1))
+		}
+		return due, true
+	}
+	return time.Time{}, false
+}
+
+// at возвращает момент разобранного времени в день date
+func (p *parser) at(date time.Time) time.Time {
+	if !p.hasClock {
+		return date
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), p.clock/60, p.clock%60, 0, 0, date.Location())
+}
+
+// word возвращает слово i в нижнем регистре без завершающих знаков препинания
+func (p *parser) word(i int) string {
+	if i < 0 || i >= len(p.tokens) {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(p.tokens[i], ",.;:!?"))
+}
+
+// reject оставляет n слов начиная с i в названии и отмечает их как нераспознанный фрагмент
+func (p *parser) reject(i, n int) int {
+	p.title = append(p.title, p.tokens[i:i+n]...)
+	p.unrecognized = append(p.unrecognized, strings.Join(p.tokens[i:i+n], " "))
+	return n
+}
+
+// hasDue указан ли уже срок
+func (p *parser) hasDue() bool {
+	return !p.date.IsZero() || !p.instant.IsZero()
+}
+
+// setDate задает дату срока из n слов начиная с i; вторая дата не распознается
+func (p *parser) setDate(i, n int, date time.Time) int {
+	if p.hasDue() {
+		return p.reject(i, n)
+	}
+	p.date = date
+	return n
+}
+
+// matchTag разбирает тег #name
+func (p *parser) matchTag(i int) int {
+	token := strings.TrimRight(p.tokens[i], ",.;:!?")
+	if !strings.HasPrefix(token, "#") || len(token) == 1 {
+		return 0
+	}
+	name := token[1:]
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return 0
+		}
+	}
+	for _, tag := range p.tags {
+		if strings.EqualFold(tag, name) {
+			return 1
+		}
+	}
+	p.tags = append(p.tags, name)
+	return 1
+}
+
+// matchPriority разбирает приоритет !high или !высокий
+func (p *parser) matchPriority(i int) int {
+	word := p.word(i)
+	if !strings.HasPrefix(word, "!") || len(word) == 1 {
+		return 0
+	}
+	priority, ok := priorities[word[1:]]
+	if !ok || p.priority != "" {
+		return p.reject(i, 1)
+	}
+	p.priority = priority
+	return 1
+}
+
+// matchRepeat разбирает правило повторения: daily, every month, every 2 weeks,
+// every monday, every weekday, каждые 3 дня, каждую среду, по будням
+func (p *parser) matchRepeat(i int) int {
+	word := p.word(i)
+	if freq, ok := repeatWords[word]; ok {
+		return p.setRule(i, 1, &recurrence.Rule{Freq: freq})
+	}
+	if word == "по" && p.word(i+1) == "будням" {
+		return p.setRule(i, 2, workdaysRule())
+	}
+	if !everyWords[word] {
+		return 0
+	}
+
+	next := p.word(i + 1)
+	switch {
+	case next == "weekday" || next == "workday":
+		return p.setRule(i, 2, workdaysRule())
+	case next == "будний" && p.word(i+2) == "день":
+		return p.setRule(i, 3, workdaysRule())
+	}
+	if weekday, ok := weekdays[next]; ok {
+		return p.setRule(i, 2, &recurrence.Rule{
+			Freq:  recurrence.Weekly,
+			ByDay: []recurrence.WeekdayNum{{Weekday: weekday}},
+		})
+	}
+
+	interval, n := 1, 1
+	if value, err := strconv.Atoi(next); err == nil {
+		interval, n = value, 2
+	} else if next == "other" {
+		interval, n = 2, 2
+	}
+	var freq string
+	switch units[p.word(i+n)] {
+	case unitDay:
+		freq = recurrence.Daily
+	case unitWeek:
+		freq = recurrence.Weekly
+	case unitMonth:
+		freq = recurrence.Monthly
+	case unitYear:
+		freq = recurrence.Yearly
+	}
+	if freq == "" || interval < 1 {
+		return p.reject(i, min(n+1, len(p.tokens)-i))
+	}
+	return p.setRule(i, n+1, &recurrence.Rule{Freq: freq, Interval: interval})
+}
+
+// setRule задает правило повторения из n слов начиная с i; второе правило не распознается
+func (p *parser) setRule(i, n int, rule *recurrence.Rule) int {
+	if p.rule != nil {
+		return p.reject(i, n)
+	}
+	p.rule = rule
+	return n
+}
+
+// workdaysRule правило повторения по будним дням
+func workdaysRule() *recurrence.Rule {
+	rule := &recurrence.Rule{Freq: recurrence.Weekly}
+	for day := time.Monday; day <= time.Friday; day++ {
+		rule.ByDay = append(rule.ByDay, recurrence.WeekdayNum{Weekday: day})
+	}
+	return rule
+}
+
+// matchRelative разбирает относительный срок: in 3 days, in an hour, через 2 недели, через час
+func (p *parser) matchRelative(i int) int {
+	word := p.word(i)
+	if word != "in" && word != "через" {
+		return 0
+	}
+
+	amount, n := 1, 1
+	next := p.word(i + 1)
+	if value, err := strconv.Atoi(next); err == nil {
+		amount, n = value, 2
+	} else if word == "in" {
+		// В английском количество обязательно: in 3 days, in a week
+		if next != "a" && next != "an" {
+			return 0
+		}
+		n = 2
+	}
+	u, ok := units[p.word(i+n)]
+	if !ok {
+		return 0
+	}
+	n++
+
+	switch u {
+	case unitMinute, unitHour:
+		if p.hasDue() || p.hasClock {
+			return p.reject(i, n)
+		}
+		step := time.Minute
+		if u == unitHour {
+			step = time.Hour
+		}
+		p.instant = p.now.Add(time.Duration(amount) * step)
+		return n
+	case unitWeek:
+		return p.setDate(i, n, startOfDay(p.now).AddDate(0, 0, 7*amount))
+	case unitMonth:
+		return p.setDate(i, n, startOfDay(p.now).AddDate(0, amount, 0))
+	case unitYear:
+		return p.setDate(i, n, startOfDay(p.now).AddDate(amount, 0, 0))
+	}
+	return p.setDate(i, n, startOfDay(p.now).AddDate(0, 0, amount))
+}
+
+// matchDay разбирает today, tomorrow, day after tomorrow, next week, next month
+// и их русские соответствия
+func (p *parser) matchDay(i int) int {
+	today := startOfDay(p.now)
+	switch p.word(i) {
+	case "today", "сегодня":
+		return p.setDate(i, 1, today)
+	case "tomorrow", "завтра":
+		return p.setDate(i, 1, today.AddDate(0, 0, 1))
+	case "послезавтра":
+		return p.setDate(i, 1, today.AddDate(0, 0, 2))
+	case "day":
+		if p.word(i+1) == "after" && p.word(i+2) == "tomorrow" {
+			return p.setDate(i, 3, today.AddDate(0, 0, 2))
+		}
+	case "next":
+		switch p.word(i + 1) {
+		case "week":
+			return p.setDate(i, 2, nextWeekday(today, time.Monday))
+		case "month":
+			return p.setDate(i, 2, firstOfMonth(today).AddDate(0, 1, 0))
+		}
+	case "на":
+		if p.word(i+1) == "следующей" && p.word(i+2) == "неделе" {
+			return p.setDate(i, 3, nextWeekday(today, time.Monday))
+		}
+	case "в":
+		if p.word(i+1) == "следующем" && p.word(i+2) == "месяце" {
+			return p.setDate(i, 3, firstOfMonth(today).AddDate(0, 1, 0))
+		}
+	}
+	return 0
+}
+
+// matchWeekday разбирает ближайший день недели: friday, on fri, next monday, в пятницу
+func (p *parser) matchWeekday(i int) int {
+	n := 0
+	switch p.word(i) {
+	case "on", "next", "в", "во":
+		n = 1
+	}
+	weekday, ok := weekdays[p.word(i+n)]
+	if !ok {
+		return 0
+	}
+	return p.setDate(i, n+1, nextWeekday(startOfDay(p.now), weekday))
+}
+
+// matchDate разбирает дату: 2024-05-01, 01.05, 01.05.2024, May 1, 1 May, 1 мая 2025, on May 1st
+func (p *parser) matchDate(i int) int {
+	n := 0
+	if p.word(i) == "on" {
+		n = 1
+	}
+	word := p.word(i + n)
+
+	if m := isoDateRegexp.FindStringSubmatch(word); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		date, ok := p.calendarDate(year, time.Month(month), day)
+		if !ok {
+			return p.reject(i, n+1)
+		}
+		return p.setDate(i, n+1, date)
+	}
+	if m := dotDateRegexp.FindStringSubmatch(word); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		year := 0
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+		}
+		date, ok := p.calendarDate(year, time.Month(month), day)
+		if !ok {
+			return 0
+		}
+		return p.setDate(i, n+1, date)
+	}
+
+	// Месяц словом до или после числа
+	day, month := 0, time.Month(0)
+	if value, ok := months[word]; ok {
+		if m := dayNumRegexp.FindStringSubmatch(p.word(i + n + 1)); m != nil {
+			day, _ = strconv.Atoi(m[1])
+			month = value
+		}
+	} else if m := dayNumRegexp.FindStringSubmatch(word); m != nil {
+		if value, ok := months[p.word(i+n+1)]; ok {
+			day, _ = strconv.Atoi(m[1])
+			month = value
+		}
+	}
+	if month == 0 {
+		return 0
+	}
+	n += 2
+
+	year := 0
+	if value, err := strconv.Atoi(p.word(i + n)); err == nil && value >= 1000 && value <= 9999 {
+		year = value
+		n++
+	}
+	date, ok := p.calendarDate(year, month, day)
+	if !ok {
+		return p.reject(i, n)
+	}
+	return p.setDate(i, n, date)
+}
+
+// calendarDate возвращает начало дня даты. Без года (year == 0) выбирается ближайшая
+// такая дата не раньше сегодняшней.
+func (p *parser) calendarDate(year int, month time.Month, day int) (time.Time, bool) {
+	today := startOfDay(p.now)
+	upcoming := year == 0
+	if upcoming {
+		year = today.Year()
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, p.now.Location())
+	if date.Month() != month || date.Day() != day {
+		return time.Time{}, false
+	}
+	if upcoming && date.Before(today) {
+		// 29 февраля следующего года может не существовать
+		date = time.Date(year+1, month, day, 0, 0, 0, 0, p.now.Location())
+	}
+	return date, date.Day() == day
+}
+
+// matchClock разбирает время: 9am, 9:30 pm, 21:00, at 9, at 18:30, в 21:00, в 9 утра, в 7 вечера
+func (p *parser) matchClock(i int) int {
+	n := 0
+	preposition := p.word(i)
+	if preposition == "at" || preposition == "в" {
+		n = 1
+	}
+	m := clockRegexp.FindStringSubmatch(p.word(i + n))
+	if m == nil {
+		return 0
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	n++
+
+	meridiem := m[3]
+	switch next := p.word(i + n); {
+	case meridiem == "" && (next == "am" || next == "pm"):
+		meridiem = next
+		n++
+	case meridiem == "" && preposition == "в" && isDayPeriod(next):
+		meridiem = next
+		n++
+	}
+
+	switch {
+	case meridiem != "":
+		if hour < 1 || hour > 12 {
+			return p.reject(i, n)
+		}
+		hour = toDayHour(hour, meridiem)
+	case m[2] == "" && preposition != "at":
+		// Одно число без двоеточия, am/pm или предлога — не время
+		return 0
+	}
+	if hour > 23 || minute > 59 {
+		return p.reject(i, n)
+	}
+
+	if p.hasClock || !p.instant.IsZero() {
+		return p.reject(i, n)
+	}
+	p.hasClock = true
+	p.clock = hour*60 + minute
+	return n
+}
+
+// isDayPeriod проверяет, уточняет ли слово время суток: в 9 утра, в 2 дня
+func isDayPeriod(word string) bool {
+	switch word {
+	case "утра", "дня", "вечера", "ночи":
+		return true
+	}
+	return false
+}
+
+// toDayHour переводит час 1–12 с уточнением am/pm или времени суток в час 0–23
+func toDayHour(hour int, meridiem string) int {
+	switch meridiem {
+	case "pm", "вечера":
+		if hour < 12 {
+			hour += 12
+		}
+	case "дня":
+		// в 2 дня — 14:00, в 11 и 12 дня — до полудня и полдень
+		if hour <= 6 {
+			hour += 12
+		}
+	default:
+		// am, утра, ночи: 12am и 12 ночи — полночь
+		if hour == 12 {
+			hour = 0
+		}
+	}
+	return hour
+}
+
+// daysUntil возвращает число дней от today до ближайшего дня недели weekday, 0 — сегодня
+func daysUntil(today time.Time, weekday time.Weekday) int {
+	return (int(weekday) - int(today.Weekday()) + 7) % 7
+}
+
+// nextWeekday возвращает ближайший день недели weekday после today
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	days := daysUntil(today, weekday)
+	if days == 0 {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// startOfDay возвращает начало дня t в его часовом поясе
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// firstOfMonth возвращает начало первого дня месяца t
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,139 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	// Среда
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, msk)
+	at := func(month time.Month, day, hour, minute int) *time.Time {
+		due := time.Date(2024, month, day, hour, minute, 0, 0, msk)
+		return &due
+	}
+
+	tests := []struct {
+		name         string
+		text         string
+		title        string
+		due          *time.Time
+		priority     string
+		tags         []string
+		recurrence   string
+		unrecognized []string
+	}{
+		{
+			name:       "все поля",
+			text:       "Pay rent tomorrow 9am !high #home every month",
+			title:      "Pay rent",
+			due:        at(time.May, 16, 9, 0),
+			priority:   "high",
+			tags:       []string{"home"},
+			recurrence: "FREQ=MONTHLY",
+		},
+		{
+			name:     "по-русски",
+			text:     "Позвонить маме завтра в 19:00 !высокий #семья",
+			title:    "Позвонить маме",
+			due:      at(time.May, 16, 19, 0),
+			priority: "high",
+			tags:     []string{"семья"},
+		},
+		{name: "день недели", text: "Report friday", title: "Report", due: at(time.May, 17, 0, 0)},
+		{name: "сегодняшний день недели", text: "Report on wed", title: "Report", due: at(time.May, 22, 0, 0)},
+		{name: "в пятницу", text: "Отчет в пятницу в 9 утра", title: "Отчет", due: at(time.May, 17, 9, 0)},
+		{name: "через часы", text: "Call in 2 hours", title: "Call", due: at(time.May, 15, 12, 0)},
+		{name: "через дни", text: "Отчет через 3 дня", title: "Отчет", due: at(time.May, 18, 0, 0)},
+		{name: "через неделю", text: "Отчет через неделю", title: "Отчет", due: at(time.May, 22, 0, 0)},
+		{name: "следующая неделя", text: "Plan next week", title: "Plan", due: at(time.May, 20, 0, 0)},
+		{name: "ISO дата", text: "Taxes 2024-06-01", title: "Taxes", due: at(time.June, 1, 0, 0)},
+		{name: "месяц словом", text: "Meeting May 20th 3pm", title: "Meeting", due: at(time.May, 20, 15, 0)},
+		{name: "дата через точку", text: "Отпуск 01.07", title: "Отпуск", due: at(time.July, 1, 0, 0)},
+		{
+			name:  "прошедшая дата без года",
+			text:  "День рождения 1 мая",
+			title: "День рождения",
+			due:   func() *time.Time { due := time.Date(2025, 5, 1, 0, 0, 0, 0, msk); return &due }(),
+		},
+		{name: "время сегодня", text: "Lunch at 13", title: "Lunch", due: at(time.May, 15, 13, 0)},
+		{name: "прошедшее время", text: "Ужин в 7 утра", title: "Ужин", due: at(time.May, 16, 7, 0)},
+		{name: "время суток", text: "Ужин в 7 вечера", title: "Ужин", due: at(time.May, 15, 19, 0)},
+		{
+			name:       "по будням",
+			text:       "Standup every weekday 10:30",
+			title:      "Standup",
+			due:        at(time.May, 15, 10, 30),
+			recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		},
+		{
+			name:       "каждый день недели",
+			text:       "Gym every monday 7am",
+			title:      "Gym",
+			due:        at(time.May, 20, 7, 0),
+			recurrence: "FREQ=WEEKLY;BYDAY=MO",
+		},
+		{
+			name:       "прошедшее время повторения",
+			text:       "Water plants every day 9am",
+			title:      "Water plants",
+			due:        at(time.May, 16, 9, 0),
+			recurrence: "FREQ=DAILY",
+		},
+		{
+			name:       "интервал",
+			text:       "каждые 2 недели отчет",
+			title:      "отчет",
+			due:        at(time.May, 15, 0, 0),
+			recurrence: "FREQ=WEEKLY;INTERVAL=2",
+		},
+		{name: "повторяющиеся теги", text: "#work task #Work", title: "task", tags: []string{"work"}},
+		{name: "число без единиц", text: "Buy 3 apples", title: "Buy 3 apples"},
+		{
+			name:         "неизвестный приоритет",
+			text:         "Fix bug !urgent",
+			title:        "Fix bug !urgent",
+			unrecognized: []string{"!urgent"},
+		},
+		{
+			name:         "второй срок",
+			text:         "Plan today tomorrow",
+			title:        "Plan tomorrow",
+			due:          at(time.May, 15, 0, 0),
+			unrecognized: []string{"tomorrow"},
+		},
+		{
+			name:         "неизвестное повторение",
+			text:         "Check every box",
+			title:        "Check every box",
+			unrecognized: []string{"every box"},
+		},
+		{
+			name:         "несуществующая дата",
+			text:         "Report 2024-02-30",
+			title:        "Report 2024-02-30",
+			unrecognized: []string{"2024-02-30"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Parse(tt.text, now)
+			assert.Equal(t, tt.title, result.Title)
+			if tt.due == nil {
+				assert.Nil(t, result.DueDate)
+			} else {
+				require.NotNil(t, result.DueDate)
+				assert.True(t, tt.due.Equal(*result.DueDate), "due date %s, want %s", result.DueDate, tt.due)
+			}
+			assert.Equal(t, tt.priority, result.Priority)
+			assert.Equal(t, tt.tags, result.Tags)
+			assert.Equal(t, tt.recurrence, result.Recurrence)
+			assert.Equal(t, tt.unrecognized, result.Unrecognized)
+		})
+	}
+}
@@ -0,0 +1,480 @@
+// Package recurrence реализует разбор и развертывание правил повторения iCalendar (RFC 5545, RRULE).
+// Поддерживаются FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY и BYMONTH.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Частота повторения
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+const (
+	// untilLayout формат UTC значения UNTIL
+	untilLayout = "20060102T150405Z"
+	// untilDateLayout формат UNTIL в виде даты
+	untilDateLayout = "20060102"
+	// maxEmptyPeriods число подряд идущих периодов без повторений, после которого
+	// правило считается исчерпанным (например, 30 февраля)
+	maxEmptyPeriods = 5000
+)
+
+// ErrInvalidRule возвращается для некорректных или неподдерживаемых правил
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum день недели BYDAY с необязательным порядковым номером: 1MO — первый понедельник,
+// -1FR — последняя пятница месяца (или года). N == 0 означает каждый такой день.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	day := strings.ToUpper(w.Weekday.String()[:2])
+	if w.N == 0 {
+		return day
+	}
+	return strconv.Itoa(w.N) + day
+}
+
+// Rule правило повторения
+type Rule struct {
+	Freq     string
+	Interval int
+	// Count общее число повторений, включая первое; 0 — без ограничения
+	Count int
+	// Until последний допустимый момент повторения; нулевое значение — без ограничения
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+}
+
+// Parse разбирает правило в формате RRULE, например "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10".
+// Префикс "RRULE:" необязателен.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch val {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = val
+			default:
+				err = fmt.Errorf("unsupported FREQ %s", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(val)
+		case "COUNT":
+			rule.Count, err = parsePositive(val)
+		case "UNTIL":
+			rule.Until, err = parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, 1, 31, true)
+		case "BYMONTH":
+			rule.ByMonth, err = parseIntList(val, 1, 12, false)
+		case "WKST":
+			if val != "MO" {
+				err = fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be used together", ErrInvalidRule)
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("%w: numbered BYDAY is allowed only with MONTHLY or YEARLY", ErrInvalidRule)
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY is not allowed with WEEKLY", ErrInvalidRule)
+	}
+	return rule, nil
+}
+
+// String возвращает правило в каноническом виде RRULE без префикса
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterate последовательно вызывает yield для повторений правила, начинающегося в start,
+// пока yield возвращает true. Как и в RFC 5545, start всегда считается первым повторением.
+// Повторения вычисляются в часовом поясе start.
+func (r *Rule) Iterate(start time.Time, yield func(time.Time) bool) {
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		if r.Count > 0 && emitted >= r.Count {
+			return false
+		}
+		emitted++
+		return yield(t)
+	}
+
+	if !emit(start) {
+		return
+	}
+	for period, empty := 0, 0; empty < maxEmptyPeriods; period++ {
+		candidates := r.expand(start, period)
+		if len(candidates) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+		for _, t := range candidates {
+			if !t.After(start) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// After возвращает не более n повторений, следующих строго после after
+func (r *Rule) After(start, after time.Time, n int) []time.Time {
+	var result []time.Time
+	if n <= 0 {
+		return result
+	}
+	r.Iterate(start, func(t time.Time) bool {
+		if t.After(after) {
+			result = append(result, t)
+		}
+		return len(result) < n
+	})
+	return result
+}
+
+// Next возвращает первое повторение строго после after
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	next := r.After(start, after, 1)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// CountBefore возвращает число повторений строго раньше before
+func (r *Rule) CountBefore(start, before time.Time) int {
+	count := 0
+	r.Iterate(start, func(t time.Time) bool {
+		if !t.Before(before) {
+			return false
+		}
+		count++
+		return true
+	})
+	return count
+}
+
+// expand возвращает отсортированные кандидаты в повторения для периода с номером period
+func (r *Rule) expand(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		day := dateOf(start).AddDate(0, 0, step)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		// Неделя начинается с понедельника (WKST=MO)
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := dateOf(start).AddDate(0, 0, step*7-offset)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != start.Weekday() {
+				continue
+			}
+			if r.matchesWeekday(day) && r.matchesMonth(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := firstOfMonth(start).AddDate(0, step, 0)
+		if r.matchesMonth(month) {
+			days = r.expandMonth(month, start)
+		}
+	case Yearly:
+		year := start.Year() + step
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+			// BYDAY без BYMONTH в годовом правиле выбирает дни всего года
+			days = expandWeekdays(r.ByDay, time.Date(year, time.January, 1, 0, 0, 0, 0, start.Location()),
+				time.Date(year+1, time.January, 1, 0, 0, 0, 0, start.Location()))
+			break
+		}
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+		for _, m := range months {
+			days = append(days, r.expandMonth(time.Date(year, time.Month(m), 1, 0, 0, 0, 0, start.Location()), start)...)
+		}
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(),
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location()))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+// expandMonth возвращает дни месяца month, выбранные BYMONTHDAY и BYDAY;
+// без них — день месяца start, если он есть в месяце
+func (r *Rule) expandMonth(month, start time.Time) []time.Time {
+	next := month.AddDate(0, 1, 0)
+	lastDay := next.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if start.Day() > lastDay {
+			return nil
+		}
+		return []time.Time{month.AddDate(0, 0, start.Day()-1)}
+	}
+
+	var days []time.Time
+	if len(r.ByDay) > 0 {
+		days = expandWeekdays(r.ByDay, month, next)
+		if len(r.ByMonthDay) > 0 {
+			filtered := days[:0]
+			for _, day := range days {
+				if r.matchesMonthDay(day) {
+					filtered = append(filtered, day)
+				}
+			}
+			days = filtered
+		}
+		return days
+	}
+
+	for _, d := range r.ByMonthDay {
+		if d < 0 {
+			d = lastDay + d + 1
+		}
+		if d >= 1 && d <= lastDay {
+			days = append(days, month.AddDate(0, 0, d-1))
+		}
+	}
+	return days
+}
+
+// expandWeekdays возвращает дни в интервале [from, to), выбранные BYDAY;
+// порядковые номера отсчитываются от начала или конца интервала
+func expandWeekdays(byDay []WeekdayNum, from, to time.Time) []time.Time {
+	seen := make(map[time.Time]bool)
+	var days []time.Time
+	for _, wd := range byDay {
+		var matching []time.Time
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == wd.Weekday {
+				matching = append(matching, day)
+			}
+		}
+
+		switch {
+		case wd.N == 0:
+		case wd.N > 0 && wd.N <= len(matching):
+			matching = matching[wd.N-1 : wd.N]
+		case wd.N < 0 && -wd.N <= len(matching):
+			matching = matching[len(matching)+wd.N : len(matching)+wd.N+1]
+		default:
+			matching = nil
+		}
+		for _, day := range matching {
+			if !seen[day] {
+				seen[day] = true
+				days = append(days, day)
+			}
+		}
+	}
+	return days
+}
+
+func (r *Rule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if time.Month(m) == day.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	lastDay := firstOfMonth(day).AddDate(0, 1, -1).Day()
+	for _, d := range r.ByMonthDay {
+		if d == day.Day() || (d < 0 && lastDay+d+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday проверяет BYDAY без учета порядковых номеров
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("expected positive number, got %s", value)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse(untilLayout, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(untilDateLayout, value); err == nil {
+		// Дата без времени включает весь день
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %s, expected YYYYMMDD or YYYYMMDDTHHMMSSZ", value)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %s", item)
+		}
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %s", item)
+		}
+		wd := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY %s", item)
+			}
+			wd.N = n
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseIntList(value string, min, max int, allowNegative bool) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		valid := err == nil && ((n >= min && n <= max) || (allowNegative && n <= -min && n >= -max))
+		if !valid {
+			return nil, fmt.Errorf("invalid value %s", item)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
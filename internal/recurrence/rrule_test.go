@@ -0,0 +1,171 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dates(t *testing.T, values ...string) []time.Time {
+	result := make([]time.Time, len(values))
+	for i, value := range values {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		require.NoError(t, err)
+		result[i] = parsed
+	}
+	return result
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{name: "ежедневно", rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{name: "префикс и регистр", rule: "RRULE:freq=weekly;byday=mo,we;count=5", want: "FREQ=WEEKLY;COUNT=5;BYDAY=MO,WE"},
+		{name: "последняя пятница месяца", rule: "FREQ=MONTHLY;BYDAY=-1FR", want: "FREQ=MONTHLY;BYDAY=-1FR"},
+		{name: "until датой", rule: "FREQ=DAILY;UNTIL=20240105", want: "FREQ=DAILY;UNTIL=20240105T235959Z"},
+		{name: "без FREQ", rule: "COUNT=3", wantErr: true},
+		{name: "COUNT вместе с UNTIL", rule: "FREQ=DAILY;COUNT=3;UNTIL=20240105T000000Z", wantErr: true},
+		{name: "неподдерживаемая часть", rule: "FREQ=DAILY;BYSETPOS=1", wantErr: true},
+		{name: "номер дня в недельном правиле", rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{name: "неверный интервал", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "пустое правило", rule: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRule)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.String())
+		})
+	}
+}
+
+func TestRule_After(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		after string
+		n     int
+		want  []string
+	}{
+		{
+			name:  "каждые два дня",
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: "2024-01-01 09:00",
+			after: "2024-01-01 09:00",
+			n:     3,
+			want:  []string{"2024-01-03 09:00", "2024-01-05 09:00", "2024-01-07 09:00"},
+		},
+		{
+			name:  "по понедельникам и средам",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE",
+			start: "2024-01-03 18:30", // среда
+			after: "2024-01-01 00:00",
+			n:     4,
+			want:  []string{"2024-01-03 18:30", "2024-01-08 18:30", "2024-01-10 18:30", "2024-01-15 18:30"},
+		},
+		{
+			name:  "COUNT включает первое повторение",
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			start: "2024-01-01 10:00",
+			after: "2023-12-31 00:00",
+			n:     10,
+			want:  []string{"2024-01-01 10:00", "2024-01-08 10:00", "2024-01-15 10:00"},
+		},
+		{
+			name:  "UNTIL ограничивает повторения",
+			rule:  "FREQ=DAILY;UNTIL=20240103T100000Z",
+			start: "2024-01-01 10:00",
+			after: "2024-01-01 10:00",
+			n:     10,
+			want:  []string{"2024-01-02 10:00", "2024-01-03 10:00"},
+		},
+		{
+			name:  "31 число пропускает короткие месяцы",
+			rule:  "FREQ=MONTHLY",
+			start: "2024-01-31 08:00",
+			after: "2024-01-31 08:00",
+			n:     2,
+			want:  []string{"2024-03-31 08:00", "2024-05-31 08:00"},
+		},
+		{
+			name:  "последний день месяца",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2024-01-31 08:00",
+			after: "2024-01-31 08:00",
+			n:     2,
+			want:  []string{"2024-02-29 08:00", "2024-03-31 08:00"},
+		},
+		{
+			name:  "последняя пятница месяца",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: "2024-01-26 17:00",
+			after: "2024-01-26 17:00",
+			n:     2,
+			want:  []string{"2024-02-23 17:00", "2024-03-29 17:00"},
+		},
+		{
+			name:  "ежегодно 29 февраля",
+			rule:  "FREQ=YEARLY",
+			start: "2024-02-29 12:00",
+			after: "2024-02-29 12:00",
+			n:     1,
+			want:  []string{"2028-02-29 12:00"},
+		},
+		{
+			name:  "второй вторник марта и сентября",
+			rule:  "FREQ=YEARLY;BYMONTH=3,9;BYDAY=2TU",
+			start: "2024-03-12 09:00",
+			after: "2024-03-12 09:00",
+			n:     2,
+			want:  []string{"2024-09-10 09:00", "2025-03-11 09:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			require.NoError(t, err)
+			start := dates(t, tt.start)[0]
+			after := dates(t, tt.after)[0]
+			assert.Equal(t, dates(t, tt.want...), rule.After(start, after, tt.n))
+		})
+	}
+}
+
+func TestRule_TimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=TU")
+	require.NoError(t, err)
+
+	// Вторник 01:00 по Москве — это понедельник по UTC
+	start := time.Date(2024, time.January, 2, 1, 0, 0, 0, loc)
+	next, ok := rule.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.January, 9, 1, 0, 0, 0, loc), next)
+}
+
+func TestRule_CountBefore(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY;COUNT=5")
+	require.NoError(t, err)
+	start := dates(t, "2024-01-01 09:00")[0]
+
+	assert.Equal(t, 0, rule.CountBefore(start, start))
+	assert.Equal(t, 3, rule.CountBefore(start, dates(t, "2024-01-04 09:00")[0]))
+	assert.Equal(t, 5, rule.CountBefore(start, dates(t, "2025-01-01 00:00")[0]))
+
+	_, ok := rule.Next(start, dates(t, "2024-01-05 09:00")[0])
+	assert.False(t, ok)
+}
@@ -0,0 +1,160 @@
+// Package reminder отправляет напоминания о задачах в фоновом процессе
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/notify"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
+	"github.com/google/uuid"
+)
+
+// leaseKeyPrefix префикс ключей аренды напоминаний в кэше
+const leaseKeyPrefix = "reminder_lease"
+
+// Config содержит настройки планировщика напоминаний
+type Config struct {
+	// PollInterval период опроса напоминаний, которые пора отправить
+	PollInterval time.Duration
+	// LeaseTTL время, на которое экземпляр захватывает попытку доставки напоминания
+	LeaseTTL time.Duration
+	// BatchSize максимальное число напоминаний за один опрос
+	BatchSize int
+	// MaxAttempts число попыток доставки, после которого напоминание считается недоставленным
+	MaxAttempts int
+	// RetryBackoff задержка перед первой повторной попыткой; каждая следующая вдвое дольше
+	RetryBackoff time.Duration
+}
+
+// DefaultConfig возвращает настройки планировщика по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 30 * time.Second,
+		LeaseTTL:     5 * time.Minute,
+		BatchSize:    100,
+		MaxAttempts:  5,
+		RetryBackoff: time.Minute,
+	}
+}
+
+// Scheduler периодически выбирает напоминания, которые пора отправить, и доставляет их
+// через Notifier канала напоминания. Несколько экземпляров могут работать одновременно:
+// перед доставкой экземпляр захватывает попытку через SetNX в общем кэше, поэтому каждая
+// попытка выполняется одним экземпляром, а доставленное напоминание больше не выбирается.
+type Scheduler struct {
+	repo      repository.ReminderRepository
+	leases    cache.Cache
+	notifiers map[string]notify.Notifier
+	cfg       Config
+	now       func() time.Time
+}
+
+// NewScheduler создает новый экземпляр Scheduler. notifiers сопоставляет каналам
+// напоминаний (models.ReminderChannel*) способы доставки; напоминания ненастроенных
+// каналов завершаются ошибкой доставки.
+func NewScheduler(repo repository.ReminderRepository, leases cache.Cache, notifiers map[string]notify.Notifier, cfg Config) *Scheduler {
+	return &Scheduler{
+		repo:      repo,
+		leases:    leases,
+		notifiers: notifiers,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// Run отправляет напоминания каждые PollInterval до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to send reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce отправляет напоминания, которые пора доставить, и возвращает число попыток доставки
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	due, err := s.repo.ListDue(ctx, s.now(), s.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due reminders: %w", err)
+	}
+
+	attempted := 0
+	for _, item := range due {
+		acquired, err := s.leases.SetNX(ctx, leaseKey(item.Reminder), s.now().Unix(), s.cfg.LeaseTTL)
+		if err != nil {
+			return attempted, fmt.Errorf("failed to acquire reminder lease: %w", err)
+		}
+		if !acquired {
+			// Эту попытку уже выполняет другой экземпляр
+			continue
+		}
+
+		if err := s.deliver(ctx, item); err != nil {
+			return attempted, fmt.Errorf("failed to record reminder delivery: %w", err)
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// leaseKey возвращает ключ аренды текущей попытки доставки напоминания. Номер попытки
+// входит в ключ, чтобы повторная попытка не ждала истечения аренды предыдущей.
+func leaseKey(reminder *models.Reminder) string {
+	return fmt.Sprintf("%s:%s:%d", leaseKeyPrefix, reminder.ID, reminder.Attempts)
+}
+
+// deliver доставляет напоминание и сохраняет результат попытки
+func (s *Scheduler) deliver(ctx context.Context, item *models.DueReminder) error {
+	reminder := item.Reminder
+	fireAt, _ := reminder.FireAt(item.Todo.DueDate)
+
+	err := s.notify(ctx, reminder.Channel, notify.NewMessage(item, fireAt))
+
+	now := s.now()
+	delivery := &models.ReminderDelivery{
+		ID:          uuid.New(),
+		ReminderID:  reminder.ID,
+		Channel:     reminder.Channel,
+		Success:     err == nil,
+		AttemptedAt: now,
+	}
+	reminder.NextAttemptAt = nil
+	switch {
+	case err == nil:
+		reminder.SentAt = &now
+	case reminder.Attempts+1 >= s.cfg.MaxAttempts:
+		delivery.Error = err.Error()
+		reminder.Attempts++
+		reminder.FailedAt = &now
+	default:
+		delivery.Error = err.Error()
+		next := now.Add(s.cfg.RetryBackoff << reminder.Attempts)
+		reminder.Attempts++
+		reminder.NextAttemptAt = &next
+	}
+
+	return s.repo.RecordDelivery(ctx, reminder, delivery)
+}
+
+// notify отправляет сообщение через Notifier канала
+func (s *Scheduler) notify(ctx context.Context, channel string, msg notify.Message) error {
+	notifier, ok := s.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("channel %s is not configured", channel)
+	}
+	return notifier.Notify(ctx, msg)
+}
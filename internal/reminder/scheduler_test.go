@@ -0,0 +1,278 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/notify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReminderRepository struct {
+	mock.Mock
+}
+
+func (m *MockReminderRepository) Create(ctx context.Context, reminder *models.Reminder) error {
+	args := m.Called(ctx, reminder)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.Reminder, error) {
+	args := m.Called(ctx, todoID)
+	return args.Get(0).([]*models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.DueReminder, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DueReminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) RecordDelivery(ctx context.Context, reminder *models.Reminder, delivery *models.ReminderDelivery) error {
+	args := m.Called(ctx, reminder, delivery)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ListDeliveries(ctx context.Context, reminderID uuid.UUID) ([]*models.ReminderDelivery, error) {
+	args := m.Called(ctx, reminderID)
+	return args.Get(0).([]*models.ReminderDelivery), args.Error(1)
+}
+
+// leaseCache реализует cache.Cache в памяти; SetNX учитывает время жизни ключей
+type leaseCache struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	now     func() time.Time
+}
+
+func newLeaseCache(now func() time.Time) *leaseCache {
+	return &leaseCache{expires: make(map[string]time.Time), now: now}
+}
+
+func (c *leaseCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *leaseCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return nil
+}
+
+func (c *leaseCache) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+func (c *leaseCache) Exists(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func (c *leaseCache) Increment(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (c *leaseCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if expires, ok := c.expires[key]; ok && c.now().Before(expires) {
+		return false, nil
+	}
+	c.expires[key] = c.now().Add(ttl)
+	return true, nil
+}
+
+func (c *leaseCache) Close() error {
+	return nil
+}
+
+// recordingNotifier запоминает доставленные сообщения и возвращает заданную ошибку
+type recordingNotifier struct {
+	mu       sync.Mutex
+	err      error
+	messages []notify.Message
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return n.err
+}
+
+func dueReminder(attempts int) *models.DueReminder {
+	offset := 30
+	userID := uuid.New()
+	return &models.DueReminder{
+		Reminder: &models.Reminder{
+			ID:            uuid.New(),
+			UserID:        userID,
+			OffsetMinutes: &offset,
+			Channel:       models.ReminderChannelWebhook,
+			Target:        "https://example.com/hook",
+			Attempts:      attempts,
+		},
+		Todo: &models.Todo{
+			ID:      uuid.New(),
+			UserID:  userID,
+			Title:   "Сдать отчет",
+			DueDate: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		UserEmail: "user@example.com",
+	}
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	now := time.Date(2026, 5, 1, 11, 30, 0, 0, time.UTC)
+	cfg := DefaultConfig()
+
+	tests := []struct {
+		name        string
+		attempts    int
+		notifyErr   error
+		channel     string
+		wantSuccess bool
+		check       func(t *testing.T, reminder *models.Reminder)
+	}{
+		{
+			name:        "успешная доставка",
+			wantSuccess: true,
+			check: func(t *testing.T, reminder *models.Reminder) {
+				require.NotNil(t, reminder.SentAt)
+				assert.Equal(t, now, *reminder.SentAt)
+				assert.Nil(t, reminder.NextAttemptAt)
+				assert.Equal(t, 0, reminder.Attempts)
+			},
+		},
+		{
+			name:      "повторная попытка с увеличивающейся задержкой",
+			attempts:  2,
+			notifyErr: errors.New("connection refused"),
+			check: func(t *testing.T, reminder *models.Reminder) {
+				assert.Nil(t, reminder.SentAt)
+				assert.Nil(t, reminder.FailedAt)
+				assert.Equal(t, 3, reminder.Attempts)
+				require.NotNil(t, reminder.NextAttemptAt)
+				assert.Equal(t, now.Add(4*cfg.RetryBackoff), *reminder.NextAttemptAt)
+			},
+		},
+		{
+			name:      "попытки исчерпаны",
+			attempts:  cfg.MaxAttempts - 1,
+			notifyErr: errors.New("connection refused"),
+			check: func(t *testing.T, reminder *models.Reminder) {
+				assert.Nil(t, reminder.SentAt)
+				require.NotNil(t, reminder.FailedAt)
+				assert.Nil(t, reminder.NextAttemptAt)
+				assert.Equal(t, cfg.MaxAttempts, reminder.Attempts)
+			},
+		},
+		{
+			name:    "канал не настроен",
+			channel: models.ReminderChannelEmail,
+			check: func(t *testing.T, reminder *models.Reminder) {
+				assert.Nil(t, reminder.SentAt)
+				assert.Equal(t, 1, reminder.Attempts)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := dueReminder(tt.attempts)
+			if tt.channel != "" {
+				item.Reminder.Channel = tt.channel
+			}
+			notifier := &recordingNotifier{err: tt.notifyErr}
+			repo := new(MockReminderRepository)
+			repo.On("ListDue", mock.Anything, now, cfg.BatchSize).Return([]*models.DueReminder{item}, nil)
+			repo.On("RecordDelivery", mock.Anything, item.Reminder, mock.MatchedBy(func(delivery *models.ReminderDelivery) bool {
+				return delivery.ReminderID == item.Reminder.ID && delivery.Success == tt.wantSuccess &&
+					(delivery.Error == "") == tt.wantSuccess && delivery.AttemptedAt.Equal(now)
+			})).Return(nil)
+
+			scheduler := NewScheduler(repo, newLeaseCache(func() time.Time { return now }),
+				map[string]notify.Notifier{models.ReminderChannelWebhook: notifier}, cfg)
+			scheduler.now = func() time.Time { return now }
+
+			attempted, err := scheduler.RunOnce(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, attempted)
+			tt.check(t, item.Reminder)
+			repo.AssertExpectations(t)
+
+			if tt.channel == "" {
+				require.Len(t, notifier.messages, 1)
+				msg := notifier.messages[0]
+				assert.Equal(t, item.Reminder.ID, msg.ReminderID)
+				assert.Equal(t, "https://example.com/hook", msg.Target)
+				assert.Equal(t, item.Todo.DueDate.Add(-30*time.Minute), msg.FireAt)
+			}
+		})
+	}
+}
+
+func TestScheduler_LeasePreventsDuplicateDelivery(t *testing.T) {
+	now := time.Date(2026, 5, 1, 11, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	item := dueReminder(0)
+
+	// Два экземпляра планировщика видят одно и то же напоминание до сохранения результата
+	repo := new(MockReminderRepository)
+	repo.On("ListDue", mock.Anything, now, mock.Anything).Return([]*models.DueReminder{item}, nil)
+	repo.On("RecordDelivery", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	leases := newLeaseCache(clock)
+	notifier := &recordingNotifier{}
+	notifiers := map[string]notify.Notifier{models.ReminderChannelWebhook: notifier}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		scheduler := NewScheduler(repo, leases, notifiers, DefaultConfig())
+		scheduler.now = clock
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := scheduler.RunOnce(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, notifier.messages, 1)
+	repo.AssertNumberOfCalls(t, "RecordDelivery", 1)
+}
+
+func TestScheduler_RetryAcquiresNewLease(t *testing.T) {
+	now := time.Date(2026, 5, 1, 11, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	item := dueReminder(0)
+
+	repo := new(MockReminderRepository)
+	repo.On("ListDue", mock.Anything, mock.Anything, mock.Anything).Return([]*models.DueReminder{item}, nil)
+	repo.On("RecordDelivery", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	notifier := &recordingNotifier{err: errors.New("timeout")}
+	scheduler := NewScheduler(repo, newLeaseCache(clock), map[string]notify.Notifier{models.ReminderChannelWebhook: notifier}, DefaultConfig())
+	scheduler.now = clock
+
+	// Аренда первой попытки еще действует, но повторная попытка захватывает свою
+	_, err := scheduler.RunOnce(context.Background())
+	require.NoError(t, err)
+	_, err = scheduler.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, notifier.messages, 2)
+	assert.Equal(t, 2, item.Reminder.Attempts)
+}
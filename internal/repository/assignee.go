@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrAssigneeNotFound возвращается, когда пользователь не назначен исполнителем задачи
+var ErrAssigneeNotFound = errors.New("assignee not found")
+
+type assigneeRepository struct {
+	db *sql.DB
+}
+
+// NewAssigneeRepository создает новый экземпляр AssigneeRepository
+func NewAssigneeRepository(db *sql.DB) AssigneeRepository {
+	return &assigneeRepository{db: db}
+}
+
+// Assign назначает пользователей userIDs исполнителями задачи и возвращает тех из них,
+// кто не был назначен раньше. Версия задачи увеличивается, только если назначен новый исполнитель.
+func (r *assigneeRepository) Assign(ctx context.Context, todoID, assignedBy uuid.UUID, userIDs []uuid.UUID, assignedAt time.Time) ([]uuid.UUID, error) {
+	query := `
+		WITH added AS (
+			INSERT INTO todo_assignees (todo_id, user_id, assigned_by, assigned_at)
+			SELECT $1, unnest($3::varchar[]), $2, $4
+			ON CONFLICT (todo_id, user_id) DO NOTHING
+			RETURNING user_id
+		), bumped AS (
+			UPDATE todos SET version = version + 1 WHERE id = $1 AND EXISTS (SELECT 1 FROM added)
+		)
+		SELECT user_id FROM added
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, todoID, assignedBy, uuidArray(userIDs), assignedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var added []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		added = append(added, userID)
+	}
+	return added, rows.Err()
+}
+
+// Unassign снимает исполнителя с задачи
+func (r *assigneeRepository) Unassign(ctx context.Context, todoID, userID uuid.UUID) error {
+	query := `
+		WITH removed AS (
+			DELETE FROM todo_assignees WHERE todo_id = $1 AND user_id = $2
+			RETURNING todo_id
+		)
+		UPDATE todos SET version = version + 1 WHERE id IN (SELECT todo_id FROM removed)
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, todoID, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAssigneeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrAttachmentNotFound возвращается, когда вложение не найдено или недоступно пользователю
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrStorageQuotaExceeded возвращается, если файл не помещается в квоту пользователя
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// attachmentColumns колонки вложения a в порядке scanAttachment
+const attachmentColumns = `a.id, a.todo_id, a.user_id, a.filename, a.content_type, a.size, a.storage_key, a.created_at`
+
+// scanAttachment читает вложение, выбранное колонками attachmentColumns
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	err := row.Scan(
+		&attachment.ID, &attachment.TodoID, &attachment.UserID, &attachment.Filename,
+		&attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+type attachmentRepository struct {
+	db *sql.DB
+}
+
+// NewAttachmentRepository создает новый экземпляр AttachmentRepository
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// Create сохраняет вложение. Загрузки одного пользователя сериализуются рекомендательной
+// блокировкой, чтобы одновременные загрузки не превысили квоту вместе.
+func (r *attachmentRepository) Create(ctx context.Context, attachment *models.Attachment, quota int64) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if quota > 0 {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, attachment.UserID.String()); err != nil {
+			return err
+		}
+		var used int64
+		err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`, attachment.UserID).Scan(&used)
+		if err != nil {
+			return err
+		}
+		if used+attachment.Size > quota {
+			return ErrStorageQuotaExceeded
+		}
+	}
+
+	query := `
+		INSERT INTO attachments (id, todo_id, user_id, filename, content_type, size, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = tx.ExecContext(ctx, query,
+		attachment.ID, attachment.TodoID, attachment.UserID, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.StorageKey, attachment.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *attachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments a WHERE a.id = $1`
+	attachment, err := scanAttachment(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+// ListByTodo возвращает вложения задачи в порядке загрузки
+func (r *attachmentRepository) ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments a WHERE a.todo_id = $1 ORDER BY a.created_at, a.id`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// Delete удаляет вложение; ключ его содержимого попадает в очередь удаления триггером
+func (r *attachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM attachments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+func (r *attachmentRepository) UsageByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var used int64
+	query := `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&used)
+	return used, err
+}
+
+// PendingBlobDeletions возвращает ключи из очереди удаления, начиная с самых старых
+func (r *attachmentRepository) PendingBlobDeletions(ctx context.Context, limit int) ([]string, error) {
+	query := `SELECT storage_key FROM blob_deletions ORDER BY created_at, storage_key LIMIT $1`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *attachmentRepository) CompleteBlobDeletions(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM blob_deletions WHERE storage_key = ANY($1)`, pq.StringArray(keys))
+	return err
+}
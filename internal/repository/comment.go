@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrCommentNotFound возвращается, когда комментарий не найден или недоступен пользователю
+var ErrCommentNotFound = errors.New("comment not found")
+
+// commentColumns колонки комментария c в порядке scanComment
+const commentColumns = `c.id, c.todo_id, c.user_id, c.parent_id, c.body, c.mentions, c.created_at, c.edited_at, c.deleted_at`
+
+// scanComment читает комментарий, выбранный колонками commentColumns
+func scanComment(row rowScanner) (*models.Comment, error) {
+	comment := &models.Comment{}
+	var parentID uuid.NullUUID
+	var mentions pq.StringArray
+	var editedAt, deletedAt sql.NullTime
+	err := row.Scan(
+		&comment.ID, &comment.TodoID, &comment.UserID, &parentID, &comment.Body, &mentions,
+		&comment.CreatedAt, &editedAt, &deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		comment.ParentID = &parentID.UUID
+	}
+	if comment.Mentions, err = parseUUIDArray(mentions); err != nil {
+		return nil, fmt.Errorf("failed to decode comment mentions: %w", err)
+	}
+	if comment.Mentions == nil {
+		comment.Mentions = []uuid.UUID{}
+	}
+	comment.EditedAt = nullTimePtr(editedAt)
+	comment.DeletedAt = nullTimePtr(deletedAt)
+	return comment, nil
+}
+
+type commentRepository struct {
+	db *sql.DB
+}
+
+// NewCommentRepository создает новый экземпляр CommentRepository
+func NewCommentRepository(db *sql.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	query := `
+		INSERT INTO comments (id, todo_id, user_id, parent_id, body, mentions, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		comment.ID, comment.TodoID, comment.UserID, comment.ParentID, comment.Body,
+		uuidArray(comment.Mentions), comment.CreatedAt,
+	)
+	return err
+}
+
+func (r *commentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM comments c WHERE c.id = $1`
+	comment, err := scanComment(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// ListByTodo возвращает комментарии задачи, включая удаленные, в порядке создания
+func (r *commentRepository) ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM comments c WHERE c.todo_id = $1 ORDER BY c.created_at, c.id`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// Update сохраняет новый текст, упоминания и время изменения EditedAt комментария,
+// записывая прежний текст в историю изменений. Удаленный комментарий изменить нельзя.
+func (r *commentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	query := `
+		WITH previous AS (
+			SELECT id, body FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+		), revision AS (
+			INSERT INTO comment_revisions (id, comment_id, body, replaced_at)
+			SELECT $5, id, body, $4 FROM previous
+		)
+		UPDATE comments SET body = $2, mentions = $3, edited_at = $4
+		WHERE id IN (SELECT id FROM previous)
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		comment.ID, comment.Body, uuidArray(comment.Mentions), comment.EditedAt, uuid.New(),
+	)
+	if err != nil {
+		return err
+	}
+	return commentRowsAffected(result)
+}
+
+// Delete удаляет текст, упоминания и историю изменений комментария, оставляя его в ветке обсуждения
+func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID, deletedAt time.Time) error {
+	query := `
+		WITH revisions AS (
+			DELETE FROM comment_revisions WHERE comment_id = $1
+		)
+		UPDATE comments SET body = '', mentions = '{}', deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, deletedAt)
+	if err != nil {
+		return err
+	}
+	return commentRowsAffected(result)
+}
+
+// ListRevisions возвращает прежние версии текста комментария в порядке изменения
+func (r *commentRepository) ListRevisions(ctx context.Context, commentID uuid.UUID) ([]*models.CommentRevision, error) {
+	query := `
+		SELECT id, comment_id, body, replaced_at
+		FROM comment_revisions
+		WHERE comment_id = $1
+		ORDER BY replaced_at, id
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.CommentRevision{}
+	for rows.Next() {
+		revision := &models.CommentRevision{}
+		if err := rows.Scan(&revision.ID, &revision.CommentID, &revision.Body, &revision.ReplacedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// commentRowsAffected возвращает ErrCommentNotFound, если запрос не изменил ни одной строки
+func commentRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
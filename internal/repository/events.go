@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+// eventTodoRepository публикует изменения задач после успешных операций репозитория
+type eventTodoRepository struct {
+	TodoRepository
+	shares    ShareRepository
+	publisher events.Publisher
+}
+
+// NewEventTodoRepository оборачивает TodoRepository так, что каждое создание,
+// обновление и удаление задачи публикуется через publisher. Изменение подзадачи
+// также публикуется как обновление родительской задачи, у которой меняется прогресс.
+// События получают владелец задачи и участники общего доступа из shares, если он задан.
+func NewEventTodoRepository(repo TodoRepository, shares ShareRepository, publisher events.Publisher) TodoRepository {
+	return &eventTodoRepository{
+		TodoRepository: repo,
+		shares:         shares,
+		publisher:      publisher,
+	}
+}
+
+func (r *eventTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	if err := r.TodoRepository.Create(ctx, todo); err != nil {
+		return err
+	}
+	r.publish(ctx, models.TodoEventCreated, todo)
+	r.publishParents(ctx, todo.ParentID)
+	return nil
+}
+
+func (r *eventTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	// Прежний родитель нужен, чтобы обновить его прогресс при перемещении задачи
+	var previousParentID *uuid.UUID
+	if previous, err := r.TodoRepository.GetByID(ctx, todo.ID); err == nil {
+		previousParentID = previous.ParentID
+	}
+
+	if err := r.TodoRepository.Update(ctx, todo); err != nil {
+		return err
+	}
+	r.publish(ctx, models.TodoEventUpdated, todo)
+	r.publishParents(ctx, todo.ParentID, previousParentID)
+	return nil
+}
+
+func (r *eventTodoRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	// Загружаем задачу и ее подзадачи заранее: после удаления неизвестно, кому отправлять события
+	subtree, err := r.TodoRepository.GetSubtree(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.TodoRepository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	for _, todo := range subtree {
+		r.publish(ctx, models.TodoEventDeleted, todo)
+	}
+	r.publishParents(ctx, subtree[0].ParentID)
+	return nil
+}
+
+func (r *eventTodoRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	completed, err := r.TodoRepository.CompleteSubtasks(ctx, id, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range completed {
+		r.publish(ctx, models.TodoEventUpdated, todo)
+	}
+	return completed, nil
+}
+
+func (r *eventTodoRepository) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	// Перенесенная задача отделяется от родителя, у которого меняется прогресс
+	var previousParentID *uuid.UUID
+	if previous, err := r.TodoRepository.GetByID(ctx, id); err == nil {
+		previousParentID = previous.ParentID
+	}
+
+	moved, err := r.TodoRepository.MoveToProject(ctx, id, projectID, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range moved {
+		r.publish(ctx, models.TodoEventUpdated, todo)
+	}
+	r.publishParents(ctx, previousParentID)
+	return moved, nil
+}
+
+func (r *eventTodoRepository) CreateOccurrence(ctx context.Context, previous, next *models.Todo) (bool, error) {
+	created, err := r.TodoRepository.CreateOccurrence(ctx, previous, next)
+	if err != nil || !created {
+		return created, err
+	}
+	r.publish(ctx, models.TodoEventCreated, next)
+	r.publishParents(ctx, next.ParentID)
+	return true, nil
+}
+
+// Restore публикует восстановленные из корзины задачи как созданные: при удалении
+// подписчики получили для них событие удаления
+func (r *eventTodoRepository) Restore(ctx context.Context, id, userID uuid.UUID) ([]*models.Todo, error) {
+	restored, err := r.TodoRepository.Restore(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range restored {
+		r.publish(ctx, models.TodoEventCreated, todo)
+	}
+	if len(restored) > 0 {
+		r.publishParents(ctx, restored[0].ParentID)
+	}
+	return restored, nil
+}
+
+// eventTagRepository публикует обновление задач при назначении и снятии тегов
+type eventTagRepository struct {
+	TagRepository
+	todos *eventTodoRepository
+}
+
+// NewEventTagRepository оборачивает TagRepository так, что назначение и снятие тега
+// публикуется через publisher как обновление задачи, загруженной из todos. События
+// получают владелец задачи и участники общего доступа из shares, если он задан.
+func NewEventTagRepository(repo TagRepository, todos TodoRepository, shares ShareRepository, publisher events.Publisher) TagRepository {
+	return &eventTagRepository{
+		TagRepository: repo,
+		todos:         &eventTodoRepository{TodoRepository: todos, shares: shares, publisher: publisher},
+	}
+}
+
+func (r *eventTagRepository) AttachToTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	return r.changeTags(ctx, todoID, func() error {
+		return r.TagRepository.AttachToTodo(ctx, userID, todoID, tagID)
+	})
+}
+
+func (r *eventTagRepository) DetachFromTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	return r.changeTags(ctx, todoID, func() error {
+		return r.TagRepository.DetachFromTodo(ctx, userID, todoID, tagID)
+	})
+}
+
+// changeTags выполняет change и публикует обновление задачи, если ее версия изменилась:
+// повторное назначение и снятие тега задачу не меняют
+func (r *eventTagRepository) changeTags(ctx context.Context, todoID uuid.UUID, change func() error) error {
+	previous, err := r.todos.GetByID(ctx, todoID)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	current, err := r.todos.GetByID(ctx, todoID)
+	if err != nil {
+		log.Printf("Failed to load todo %s for event: %v", todoID, err)
+		return nil
+	}
+	if current.Version != previous.Version {
+		r.todos.publish(ctx, models.TodoEventUpdated, current)
+	}
+	return nil
+}
+
+// publishParents публикует обновление родительских задач, прогресс которых изменился
+func (r *eventTodoRepository) publishParents(ctx context.Context, parentIDs ...*uuid.UUID) {
+	published := make(map[uuid.UUID]bool, len(parentIDs))
+	for _, parentID := range parentIDs {
+		if parentID == nil || published[*parentID] {
+			continue
+		}
+		published[*parentID] = true
+
+		parent, err := r.TodoRepository.GetByID(ctx, *parentID)
+		if err != nil {
+			log.Printf("Failed to load parent todo %s for event: %v", *parentID, err)
+			continue
+		}
+		r.publish(ctx, models.TodoEventUpdated, parent)
+	}
+}
+
+// publish отправляет событие владельцу задачи и участникам общего доступа. Изменение уже
+// сохранено, поэтому ошибка только логируется. Внутри Transactor событие отправляется после
+// фиксации транзакции, а получатели выбираются до нее.
+func (r *eventTodoRepository) publish(ctx context.Context, eventType string, todo *models.Todo) {
+	snapshot := *todo
+	recipients := r.recipients(ctx, todo)
+	afterCommit(ctx, func() {
+		for _, userID := range recipients {
+			event := models.NewTodoEvent(eventType, &snapshot)
+			event.UserID = userID
+			if err := r.publisher.Publish(ctx, event); err != nil {
+				log.Printf("Failed to publish %s event for todo %s: %v", eventType, snapshot.ID, err)
+			}
+		}
+	})
+}
+
+// recipients возвращает владельца задачи и участников общего доступа к ней. Если участников
+// не удалось загрузить, событие получает только владелец.
+func (r *eventTodoRepository) recipients(ctx context.Context, todo *models.Todo) []uuid.UUID {
+	recipients := []uuid.UUID{todo.UserID}
+	if r.shares == nil {
+		return recipients
+	}
+	participants, err := r.shares.ListTodoParticipants(ctx, todo.ID)
+	if err != nil {
+		log.Printf("Failed to load participants of todo %s for event: %v", todo.ID, err)
+		return recipients
+	}
+	for _, userID := range participants {
+		if userID != todo.UserID {
+			recipients = append(recipients, userID)
+		}
+	}
+	return recipients
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+type todoHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewTodoHistoryRepository создает новый экземпляр TodoHistoryRepository
+func NewTodoHistoryRepository(db *sql.DB) TodoHistoryRepository {
+	return &todoHistoryRepository{db: db}
+}
+
+// Create сохраняет запись истории следующей ревизией задачи и записывает ее номер в entry.Revision
+func (r *todoHistoryRepository) Create(ctx context.Context, entry *models.TodoHistoryEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокировка задачи не дает параллельным записям получить один номер ревизии
+	var locked int
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM todos WHERE id = $1 FOR UPDATE`, entry.TodoID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrTodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO todo_history (id, todo_id, revision, action, actor_id, source, changes, reverted_to, created_at)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(revision), 0) + 1 FROM todo_history WHERE todo_id = $2),
+			$3, $4, $5, $6, $7, $8)
+		RETURNING revision
+	`
+	err = tx.QueryRowContext(ctx, query,
+		entry.ID, entry.TodoID, entry.Action, entry.ActorID, entry.Source, changes, entry.RevertedTo, entry.CreatedAt,
+	).Scan(&entry.Revision)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetByTodoID возвращает историю задачи от первой ревизии к последней
+func (r *todoHistoryRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.TodoHistoryEntry, error) {
+	query := `
+		SELECT id, todo_id, revision, action, actor_id, source, changes, reverted_to, created_at
+		FROM todo_history
+		WHERE todo_id = $1
+		ORDER BY revision
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.TodoHistoryEntry{}
+	for rows.Next() {
+		entry := &models.TodoHistoryEntry{}
+		var actorID uuid.NullUUID
+		var revertedTo sql.NullInt64
+		var changes []byte
+		err := rows.Scan(&entry.ID, &entry.TodoID, &entry.Revision, &entry.Action, &actorID,
+			&entry.Source, &changes, &revertedTo, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			entry.ActorID = &actorID.UUID
+		}
+		if revertedTo.Valid {
+			revision := int(revertedTo.Int64)
+			entry.RevertedTo = &revision
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// historyTodoRepository записывает в историю изменения задач после успешных операций репозитория
+type historyTodoRepository struct {
+	TodoRepository
+	history TodoHistoryRepository
+}
+
+// NewHistoryTodoRepository оборачивает TodoRepository так, что каждое изменение задачи
+// записывается в history ревизией с измененными полями, автором и источником из контекста
+// (models.ActorFromContext). Оборачивает репозиторий непосредственно, чтобы в историю
+// попадали и изменения подзадач, выполняемые другими обертками.
+func NewHistoryTodoRepository(repo TodoRepository, history TodoHistoryRepository) TodoRepository {
+	return &historyTodoRepository{
+		TodoRepository: repo,
+		history:        history,
+	}
+}
+
+func (r *historyTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	if err := r.TodoRepository.Create(ctx, todo); err != nil {
+		return err
+	}
+	r.record(ctx, models.TodoHistoryCreated, todo.ID, models.DiffTodos(nil, todo))
+	return nil
+}
+
+func (r *historyTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	previous, err := r.TodoRepository.GetByID(ctx, todo.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.TodoRepository.Update(ctx, todo); err != nil {
+		return err
+	}
+
+	changes := models.DiffTodos(previous, todo)
+	if revision, ok := models.RevertFromContext(ctx); ok {
+		r.recordRevert(ctx, todo.ID, changes, revision)
+		return nil
+	}
+	if len(changes) > 0 {
+		r.record(ctx, models.TodoHistoryUpdated, todo.ID, changes)
+	}
+	return nil
+}
+
+func (r *historyTodoRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	subtree, err := r.TodoRepository.GetSubtree(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.TodoRepository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	for _, todo := range subtree {
+		r.record(ctx, models.TodoHistoryDeleted, todo.ID, nil)
+	}
+	return nil
+}
+
+func (r *historyTodoRepository) Restore(ctx context.Context, id, userID uuid.UUID) ([]*models.Todo, error) {
+	restored, err := r.TodoRepository.Restore(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range restored {
+		r.record(ctx, models.TodoHistoryRestored, todo.ID, nil)
+	}
+	return restored, nil
+}
+
+func (r *historyTodoRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	subtree, err := r.TodoRepository.GetSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := r.TodoRepository.CompleteSubtasks(ctx, id, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.recordChanged(ctx, subtree, completed)
+	return completed, nil
+}
+
+func (r *historyTodoRepository) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	subtree, err := r.TodoRepository.GetSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	moved, err := r.TodoRepository.MoveToProject(ctx, id, projectID, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.recordChanged(ctx, subtree, moved)
+	return moved, nil
+}
+
+func (r *historyTodoRepository) CreateOccurrence(ctx context.Context, previous, next *models.Todo) (bool, error) {
+	created, err := r.TodoRepository.CreateOccurrence(ctx, previous, next)
+	if err != nil || !created {
+		return created, err
+	}
+	r.record(ctx, models.TodoHistoryCreated, next.ID, models.DiffTodos(nil, next))
+	return true, nil
+}
+
+// historyTagRepository записывает в историю задач назначение и снятие тегов
+type historyTagRepository struct {
+	TagRepository
+	todos *historyTodoRepository
+}
+
+// NewHistoryTagRepository оборачивает TagRepository так, что назначение и снятие тега
+// записывается в history ревизией задачи с изменением поля tags. Задачи загружаются из todos.
+func NewHistoryTagRepository(repo TagRepository, todos TodoRepository, history TodoHistoryRepository) TagRepository {
+	return &historyTagRepository{
+		TagRepository: repo,
+		todos:         &historyTodoRepository{TodoRepository: todos, history: history},
+	}
+}
+
+func (r *historyTagRepository) AttachToTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	return r.changeTags(ctx, todoID, func() error {
+		return r.TagRepository.AttachToTodo(ctx, userID, todoID, tagID)
+	})
+}
+
+func (r *historyTagRepository) DetachFromTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	return r.changeTags(ctx, todoID, func() error {
+		return r.TagRepository.DetachFromTodo(ctx, userID, todoID, tagID)
+	})
+}
+
+// changeTags выполняет change и записывает изменение тегов задачи, если они изменились
+func (r *historyTagRepository) changeTags(ctx context.Context, todoID uuid.UUID, change func() error) error {
+	previous, err := r.todos.GetByID(ctx, todoID)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	current, err := r.todos.GetByID(ctx, todoID)
+	if err != nil {
+		log.Printf("Failed to load todo %s for history: %v", todoID, err)
+		return nil
+	}
+	if changes := models.DiffTodoTags(previous, current); len(changes) > 0 {
+		r.todos.record(ctx, models.TodoHistoryUpdated, todoID, changes)
+	}
+	return nil
+}
+
+// recordChanged записывает изменения задач changed относительно их состояния в before
+func (r *historyTodoRepository) recordChanged(ctx context.Context, before, changed []*models.Todo) {
+	previous := make(map[uuid.UUID]*models.Todo, len(before))
+	for _, todo := range before {
+		previous[todo.ID] = todo
+	}
+	for _, todo := range changed {
+		if changes := models.DiffTodos(previous[todo.ID], todo); len(changes) > 0 {
+			r.record(ctx, models.TodoHistoryUpdated, todo.ID, changes)
+		}
+	}
+}
+
+// recordRevert записывает откат задачи к ревизии revision
+func (r *historyTodoRepository) recordRevert(ctx context.Context, todoID uuid.UUID, changes []models.TodoFieldChange, revision int) {
+	entry := newHistoryEntry(ctx, models.TodoHistoryReverted, todoID, changes)
+	entry.RevertedTo = &revision
+	r.save(ctx, entry)
+}
+
+// record записывает действие над задачей
+func (r *historyTodoRepository) record(ctx context.Context, action string, todoID uuid.UUID, changes []models.TodoFieldChange) {
+	r.save(ctx, newHistoryEntry(ctx, action, todoID, changes))
+}
+
+// save сохраняет запись истории. Изменение задачи уже сохранено, поэтому ошибка только логируется.
+func (r *historyTodoRepository) save(ctx context.Context, entry *models.TodoHistoryEntry) {
+	if err := r.history.Create(ctx, entry); err != nil {
+		log.Printf("Failed to record %s history for todo %s: %v", entry.Action, entry.TodoID, err)
+	}
+}
+
+// newHistoryEntry создает запись истории с автором из контекста
+func newHistoryEntry(ctx context.Context, action string, todoID uuid.UUID, changes []models.TodoFieldChange) *models.TodoHistoryEntry {
+	if changes == nil {
+		changes = []models.TodoFieldChange{}
+	}
+	actor := models.ActorFromContext(ctx)
+	return &models.TodoHistoryEntry{
+		ID:        uuid.New(),
+		TodoID:    todoID,
+		Action:    action,
+		ActorID:   actor.UserID,
+		Source:    actor.Source,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+}
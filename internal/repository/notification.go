@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrNotificationNotFound возвращается, когда уведомление не найдено или не принадлежит пользователю
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// maxNotifications максимальное число уведомлений, возвращаемых GetByUserID
+const maxNotifications = 100
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository создает новый экземпляр NotificationRepository
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (id, user_id, todo_id, reminder_id, title, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		notification.ID, notification.UserID, notification.TodoID, notification.ReminderID,
+		notification.Title, notification.Body, notification.CreatedAt,
+	)
+	return err
+}
+
+// GetByUserID возвращает последние уведомления пользователя, начиная с новых
+func (r *notificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]*models.Notification, error) {
+	query := `
+		SELECT id, user_id, todo_id, reminder_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, maxNotifications)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		notification := &models.Notification{}
+		var todoID, reminderID uuid.NullUUID
+		var readAt sql.NullTime
+		err := rows.Scan(&notification.ID, &notification.UserID, &todoID, &reminderID,
+			&notification.Title, &notification.Body, &readAt, &notification.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if todoID.Valid {
+			notification.TodoID = &todoID.UUID
+		}
+		if reminderID.Valid {
+			notification.ReminderID = &reminderID.UUID
+		}
+		notification.ReadAt = nullTimePtr(readAt)
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным; повторная отметка не меняет время прочтения
+func (r *notificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID, readAt time.Time) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $1)
+		WHERE id = $2 AND user_id = $3
+	`
+	result, err := r.db.ExecContext(ctx, query, readAt, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
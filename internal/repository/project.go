@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrProjectNotFound возвращается, когда проект не найден или не принадлежит пользователю
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrInvalidProject возвращается, если проект задачи не найден, недоступен
+	// пользователю для изменения или находится в архиве
+	ErrInvalidProject = errors.New("invalid project")
+	// ErrInboxProject возвращается при попытке удалить или архивировать Inbox
+	ErrInboxProject = errors.New("inbox project cannot be deleted or archived")
+	// ErrInvalidTodoOrder возвращается, если новый порядок содержит задачи другого проекта или повторы
+	ErrInvalidTodoOrder = errors.New("invalid todo order")
+)
+
+// projectColumns колонки проекта в порядке scanProject
+const projectColumns = `id, user_id, name, is_inbox, archived_at, created_at, updated_at`
+
+// scanProject читает проект, выбранный колонками projectColumns
+func scanProject(row rowScanner) (*models.Project, error) {
+	project := &models.Project{}
+	var archivedAt sql.NullTime
+	err := row.Scan(
+		&project.ID, &project.UserID, &project.Name, &project.IsInbox,
+		&archivedAt, &project.CreatedAt, &project.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if archivedAt.Valid {
+		project.ArchivedAt = &archivedAt.Time
+	}
+	return project, nil
+}
+
+// ensureInbox создает Inbox пользователя, если его еще нет, и возвращает его ID
+func ensureInbox(ctx context.Context, q queryer, userID uuid.UUID) (uuid.UUID, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO projects (id, user_id, name, is_inbox, created_at, updated_at)
+		VALUES ($1, $2, $3, TRUE, $4, $4)
+		ON CONFLICT (user_id) WHERE is_inbox DO NOTHING
+	`
+	if _, err := q.ExecContext(ctx, query, uuid.New(), userID, models.InboxProjectName, now); err != nil {
+		return uuid.Nil, err
+	}
+
+	var id uuid.UUID
+	err := q.QueryRowContext(ctx, `SELECT id FROM projects WHERE user_id = $1 AND is_inbox`, userID).Scan(&id)
+	return id, err
+}
+
+// checkProject проверяет, что пользователь может добавлять задачи в проект: проект не в архиве
+// и принадлежит пользователю или открыт ему с ролью editor или owner
+func checkProject(ctx context.Context, q queryer, userID, projectID uuid.UUID) error {
+	var archived bool
+	query := `
+		SELECT p.archived_at IS NOT NULL
+		FROM projects p
+		WHERE p.id = $1 AND (p.user_id = $2 OR EXISTS (
+			SELECT 1 FROM shares s
+			WHERE s.project_id = p.id AND s.user_id = $2 AND s.status = 'accepted'
+				AND s.role IN ('editor', 'owner')
+		))
+	`
+	err := q.QueryRowContext(ctx, query, projectID, userID).Scan(&archived)
+	if err == sql.ErrNoRows || archived {
+		return ErrInvalidProject
+	}
+	return err
+}
+
+type projectRepository struct {
+	db *sql.DB
+}
+
+// NewProjectRepository создает новый экземпляр ProjectRepository
+func NewProjectRepository(db *sql.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *models.Project) error {
+	query := `
+		INSERT INTO projects (id, user_id, name, is_inbox, created_at, updated_at)
+		VALUES ($1, $2, $3, FALSE, $4, $5)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		project.ID, project.UserID, project.Name, project.CreatedAt, project.UpdatedAt,
+	)
+	return err
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+	project, err := scanProject(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// GetByUserID возвращает проекты пользователя: сначала Inbox, затем остальные по названию.
+// Inbox создается, если его еще нет.
+func (r *projectRepository) GetByUserID(ctx context.Context, userID uuid.UUID, includeArchived bool) ([]*models.Project, error) {
+	if _, err := ensureInbox(ctx, r.db, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE user_id = $1 AND ($2 OR archived_at IS NULL)
+		ORDER BY is_inbox DESC, name
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []*models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+func (r *projectRepository) Update(ctx context.Context, project *models.Project) error {
+	query := `
+		UPDATE projects
+		SET name = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		project.Name, project.UpdatedAt, project.ID, project.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	return projectRowsAffected(result)
+}
+
+// SetArchived архивирует проект (archivedAt != nil) или возвращает его из архива
+func (r *projectRepository) SetArchived(ctx context.Context, id, userID uuid.UUID, archivedAt *time.Time) (*models.Project, error) {
+	query := `
+		UPDATE projects
+		SET archived_at = $1, updated_at = now()
+		WHERE id = $2 AND user_id = $3 AND NOT is_inbox
+		RETURNING ` + projectColumns
+	project, err := scanProject(r.db.QueryRowContext(ctx, query, archivedAt, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, r.inboxOrNotFound(ctx, id, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Delete удаляет проект пользователя; его задачи переносятся в конец Inbox
+func (r *projectRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	inboxID, err := ensureInbox(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if inboxID == id {
+		return ErrInboxProject
+	}
+
+	query := `
+		UPDATE todos
+		SET project_id = $1, version = version + 1,
+			position = position + (SELECT COALESCE(MAX(position), 0) FROM todos WHERE project_id = $1)
+		WHERE project_id = $2 AND user_id = $3
+	`
+	if _, err := tx.ExecContext(ctx, query, inboxID, id, userID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := projectRowsAffected(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EnsureInbox возвращает Inbox пользователя, создавая его при необходимости
+func (r *projectRepository) EnsureInbox(ctx context.Context, userID uuid.UUID) (*models.Project, error) {
+	id, err := ensureInbox(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// ReorderTodos задает порядок задач проекта: задачи todoIDs располагаются в начале
+// в указанном порядке, остальные следуют за ними, сохраняя прежний порядок
+func (r *projectRepository) ReorderTodos(ctx context.Context, userID, projectID uuid.UUID, todoIDs []uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND user_id = $2)`
+	if err := tx.QueryRowContext(ctx, query, projectID, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProjectNotFound
+	}
+
+	query = `
+		SELECT id FROM todos
+		WHERE project_id = $1 AND deleted_at IS NULL
+		ORDER BY position, created_at
+		FOR UPDATE
+	`
+	rows, err := tx.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return err
+	}
+	var current []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current = append(current, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	order, err := mergeTodoOrder(current, todoIDs)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE todos t
+		SET position = o.position, version = t.version + 1
+		FROM unnest($1::text[]) WITH ORDINALITY AS o(id, position)
+		WHERE t.id = o.id AND t.project_id = $2 AND t.position <> o.position
+	`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(order), projectID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mergeTodoOrder ставит задачи ordered в начало current, сохраняя порядок остальных
+func mergeTodoOrder(current, ordered []uuid.UUID) ([]string, error) {
+	inProject := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		inProject[id] = true
+	}
+
+	placed := make(map[uuid.UUID]bool, len(ordered))
+	result := make([]string, 0, len(current))
+	for _, id := range ordered {
+		if !inProject[id] || placed[id] {
+			return nil, ErrInvalidTodoOrder
+		}
+		placed[id] = true
+		result = append(result, id.String())
+	}
+	for _, id := range current {
+		if !placed[id] {
+			result = append(result, id.String())
+		}
+	}
+	return result, nil
+}
+
+// inboxOrNotFound определяет причину, по которой проект пользователя не был изменен
+func (r *projectRepository) inboxOrNotFound(ctx context.Context, id, userID uuid.UUID) error {
+	var isInbox bool
+	query := `SELECT is_inbox FROM projects WHERE id = $1 AND user_id = $2`
+	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(&isInbox)
+	if err == sql.ErrNoRows {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if isInbox {
+		return ErrInboxProject
+	}
+	return ErrProjectNotFound
+}
+
+// projectRowsAffected возвращает ErrProjectNotFound, если запрос не изменил ни одной строки
+func projectRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
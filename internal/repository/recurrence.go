@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/recurrence"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRecurrence возвращается для некорректного правила повторения, часового пояса
+	// или повторяющейся задачи без срока
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	// ErrSeriesNotFound возвращается, когда серия повторений не найдена
+	ErrSeriesNotFound = errors.New("todo series not found")
+)
+
+// recurringTodoRepository создает серии повторяющихся задач и их следующие повторения
+type recurringTodoRepository struct {
+	TodoRepository
+}
+
+// NewRecurringTodoRepository оборачивает TodoRepository так, что задача с новым правилом
+// повторения (TodoRecurrence с нулевым SeriesID) начинает новую серию, а завершение или
+// отмена повторения создает следующее повторение серии. Следующее повторение создается
+// не более одного раза, поэтому повторные запросы безопасны.
+func NewRecurringTodoRepository(repo TodoRepository) TodoRepository {
+	return &recurringTodoRepository{TodoRepository: repo}
+}
+
+func (r *recurringTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	if todo.Recurrence != nil && todo.Recurrence.SeriesID == uuid.Nil {
+		if err := r.startSeries(ctx, todo, nil); err != nil {
+			return err
+		}
+	}
+	return r.TodoRepository.Create(ctx, todo)
+}
+
+func (r *recurringTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	if todo.Recurrence != nil && todo.Recurrence.SeriesID == uuid.Nil {
+		current, err := r.TodoRepository.GetByID(ctx, todo.ID)
+		if err != nil {
+			return err
+		}
+		if err := r.startSeries(ctx, todo, current.Recurrence); err != nil {
+			return err
+		}
+	}
+
+	if err := r.TodoRepository.Update(ctx, todo); err != nil {
+		return err
+	}
+
+	// Следующее повторение проверяется при каждом сохранении закрытого повторения:
+	// если предыдущая попытка прервалась после обновления задачи, повторный запрос его создаст
+	if todo.Recurrence != nil && todo.IsClosed() {
+		return r.createNext(ctx, todo)
+	}
+	return nil
+}
+
+// startSeries создает серию с правилом todo.Recurrence, начинающуюся со срока задачи,
+// и делает задачу ее первым повторением. previous — серия, из которой задача выделяется
+// изменением "это и последующие повторения"; если правило не меняется, новая серия
+// получает оставшееся число повторений.
+func (r *recurringTodoRepository) startSeries(ctx context.Context, todo *models.Todo, previous *models.TodoRecurrence) error {
+	rule, err := recurrence.Parse(todo.Recurrence.Rule)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	if todo.DueDate.IsZero() {
+		return fmt.Errorf("%w: due date is required", ErrInvalidRecurrence)
+	}
+
+	timezone := todo.Recurrence.Timezone
+	if timezone == "" && previous != nil {
+		timezone = previous.Timezone
+	}
+	if timezone == "" {
+		timezone = models.DefaultRecurrenceTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %s", ErrInvalidRecurrence, timezone)
+	}
+
+	if previous != nil && rule.Count > 0 {
+		if previousRule, err := recurrence.Parse(previous.Rule); err == nil && previousRule.String() == rule.String() {
+			previousLoc, err := previous.Location()
+			if err == nil {
+				done := previousRule.CountBefore(previous.Start.In(previousLoc), previous.OccurrenceDate)
+				rule.Count = max(rule.Count-done, 1)
+			}
+		}
+	}
+
+	start := todo.DueDate.In(loc)
+	series := &models.TodoSeries{
+		ID:          uuid.New(),
+		UserID:      todo.UserID,
+		Rule:        rule.String(),
+		Timezone:    timezone,
+		Start:       start,
+		Title:       todo.Title,
+		Description: todo.Description,
+		Priority:    todo.Priority,
+		CreatedAt:   time.Now(),
+	}
+	if err := r.TodoRepository.CreateSeries(ctx, series); err != nil {
+		return err
+	}
+
+	todo.Recurrence = &models.TodoRecurrence{
+		SeriesID:       series.ID,
+		Rule:           series.Rule,
+		Timezone:       series.Timezone,
+		Start:          series.Start,
+		OccurrenceDate: series.Start,
+	}
+	return nil
+}
+
+// createNext создает повторение серии, следующее за todo, если правило его допускает
+func (r *recurringTodoRepository) createNext(ctx context.Context, todo *models.Todo) error {
+	series, err := r.TodoRepository.GetSeries(ctx, todo.Recurrence.SeriesID)
+	if err != nil {
+		return err
+	}
+	rule, err := recurrence.Parse(series.Rule)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %s", ErrInvalidRecurrence, series.Timezone)
+	}
+
+	date, ok := rule.Next(series.Start.In(loc), todo.Recurrence.OccurrenceDate)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	next := &models.Todo{
+		ID:          uuid.New(),
+		Title:       series.Title,
+		Description: series.Description,
+		Status:      models.TodoStatusPending,
+		Priority:    series.Priority,
+		DueDate:     date,
+		UserID:      todo.UserID,
+		ParentID:    todo.ParentID,
+		ProjectID:   todo.ProjectID,
+		Recurrence: &models.TodoRecurrence{
+			SeriesID:       series.ID,
+			Rule:           series.Rule,
+			Timezone:       series.Timezone,
+			Start:          series.Start,
+			OccurrenceDate: date,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	_, err = r.TodoRepository.CreateOccurrence(ctx, todo, next)
+	return err
+}
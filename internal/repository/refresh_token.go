@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrRefreshTokenNotFound возвращается, если refresh токен не найден
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrRefreshTokenInactive возвращается при ротации токена, который уже был заменен или отозван
+	ErrRefreshTokenInactive = errors.New("refresh token is already rotated or revoked")
+)
+
+type refreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenRepository создает новый экземпляр RefreshTokenRepository
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return insertRefreshToken(ctx, r.db, token)
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, created_at, rotated_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1
+	`
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash,
+		&token.ExpiresAt, &token.CreatedAt, &token.RotatedAt, &token.RevokedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Rotate помечает токен id замененным и сохраняет next в одной транзакции.
+// Если токен уже заменен или отозван (например, параллельным запросом), возвращает ErrRefreshTokenInactive.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, id uuid.UUID, next *models.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE refresh_tokens
+		SET rotated_at = $1
+		WHERE id = $2 AND rotated_at IS NULL AND revoked_at IS NULL
+	`
+	result, err := tx.ExecContext(ctx, query, next.CreatedAt, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRefreshTokenInactive
+	}
+
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = $1
+		WHERE family_id = $2 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, time.Now().UTC(), familyID)
+	return err
+}
+
+func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, time.Now().UTC(), userID)
+	return err
+}
+
+// execer общий интерфейс *sql.DB и *sql.Tx для выполнения запросов
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := db.ExecContext(ctx, query,
+		token.ID, token.UserID, token.FamilyID, token.TokenHash,
+		token.ExpiresAt, token.CreatedAt,
+	)
+	return err
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrReminderNotFound возвращается, когда напоминание не найдено или не принадлежит пользователю
+var ErrReminderNotFound = errors.New("reminder not found")
+
+// reminderColumns колонки напоминания для выборок из reminders r
+const reminderColumns = `r.id, r.todo_id, r.user_id, r.remind_at, r.offset_minutes, r.channel, r.target,
+		r.attempts, r.next_attempt_at, r.sent_at, r.failed_at, r.created_at`
+
+// reminderFireAt время срабатывания напоминания r задачи t
+const reminderFireAt = `COALESCE(r.remind_at, t.due_date - r.offset_minutes * INTERVAL '1 minute')`
+
+// scanReminder читает напоминание, выбранное колонками reminderColumns
+func scanReminder(row rowScanner) (*models.Reminder, error) {
+	reminder := &models.Reminder{}
+	var remindAt, nextAttemptAt, sentAt, failedAt sql.NullTime
+	var offset sql.NullInt64
+	err := row.Scan(
+		&reminder.ID, &reminder.TodoID, &reminder.UserID, &remindAt, &offset, &reminder.Channel,
+		&reminder.Target, &reminder.Attempts, &nextAttemptAt, &sentAt, &failedAt, &reminder.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if offset.Valid {
+		minutes := int(offset.Int64)
+		reminder.OffsetMinutes = &minutes
+	}
+	reminder.RemindAt = nullTimePtr(remindAt)
+	reminder.NextAttemptAt = nullTimePtr(nextAttemptAt)
+	reminder.SentAt = nullTimePtr(sentAt)
+	reminder.FailedAt = nullTimePtr(failedAt)
+	return reminder, nil
+}
+
+// scanFunc позволяет читать одну строку несколькими функциями scan*: каждая передает
+// свои колонки следующей, а последняя вызывает Scan строки
+type scanFunc func(dest ...interface{}) error
+
+func (f scanFunc) Scan(dest ...interface{}) error {
+	return f(dest...)
+}
+
+// nullTimePtr возвращает время или nil для NULL
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+type reminderRepository struct {
+	db *sql.DB
+}
+
+// NewReminderRepository создает новый экземпляр ReminderRepository
+func NewReminderRepository(db *sql.DB) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+func (r *reminderRepository) Create(ctx context.Context, reminder *models.Reminder) error {
+	query := `
+		INSERT INTO reminders (id, todo_id, user_id, remind_at, offset_minutes, channel, target, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		reminder.ID, reminder.TodoID, reminder.UserID, reminder.RemindAt, reminder.OffsetMinutes,
+		reminder.Channel, reminder.Target, reminder.CreatedAt,
+	)
+	return err
+}
+
+func (r *reminderRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.Reminder, error) {
+	query := `
+		SELECT ` + reminderColumns + `
+		FROM reminders r
+		WHERE r.todo_id = $1
+		ORDER BY r.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*models.Reminder{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+// Delete удаляет напоминание пользователя вместе с историей доставки
+func (r *reminderRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	query := `DELETE FROM reminders WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrReminderNotFound
+	}
+	return nil
+}
+
+// ListDue возвращает не более limit напоминаний, которые пора доставить к моменту now:
+// не доставленные, с исчерпанными попытками не помеченные и относящиеся к незакрытым задачам
+// вне корзины.
+// Напоминания со смещением не срабатывают для задач без срока.
+func (r *reminderRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.DueReminder, error) {
+	query := `
+		SELECT ` + todoColumns + `, ` + reminderColumns + `, u.email
+		FROM reminders r
+		JOIN todos t ON t.id = r.todo_id
+		JOIN users u ON u.id = r.user_id
+		` + todoJoins + `
+		WHERE r.sent_at IS NULL AND r.failed_at IS NULL AND t.deleted_at IS NULL
+			AND t.status NOT IN ('completed', 'cancelled')
+			AND (r.remind_at IS NOT NULL OR t.due_date > $2)
+			AND COALESCE(r.next_attempt_at, ` + reminderFireAt + `) <= $1
+		ORDER BY ` + reminderFireAt + `
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, now, time.Time{}, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []*models.DueReminder
+	for rows.Next() {
+		item := &models.DueReminder{}
+		todo, err := scanTodo(scanFunc(func(todoDest ...interface{}) error {
+			var err error
+			item.Reminder, err = scanReminder(scanFunc(func(reminderDest ...interface{}) error {
+				return rows.Scan(append(append(todoDest, reminderDest...), &item.UserEmail)...)
+			}))
+			return err
+		}))
+		if err != nil {
+			return nil, err
+		}
+		item.Todo = todo
+		due = append(due, item)
+	}
+	return due, rows.Err()
+}
+
+// RecordDelivery сохраняет попытку доставки и состояние напоминания после нее:
+// число попыток, время следующей попытки и отметки о доставке или отказе
+func (r *reminderRepository) RecordDelivery(ctx context.Context, reminder *models.Reminder, delivery *models.ReminderDelivery) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO reminder_deliveries (id, reminder_id, channel, success, error, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = tx.ExecContext(ctx, query,
+		delivery.ID, delivery.ReminderID, delivery.Channel, delivery.Success, delivery.Error, delivery.AttemptedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE reminders
+		SET attempts = $1, next_attempt_at = $2, sent_at = $3, failed_at = $4
+		WHERE id = $5
+	`
+	_, err = tx.ExecContext(ctx, query,
+		reminder.Attempts, reminder.NextAttemptAt, reminder.SentAt, reminder.FailedAt, reminder.ID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *reminderRepository) ListDeliveries(ctx context.Context, reminderID uuid.UUID) ([]*models.ReminderDelivery, error) {
+	query := `
+		SELECT id, reminder_id, channel, success, error, attempted_at
+		FROM reminder_deliveries
+		WHERE reminder_id = $1
+		ORDER BY attempted_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, reminderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.ReminderDelivery{}
+	for rows.Next() {
+		delivery := &models.ReminderDelivery{}
+		err := rows.Scan(&delivery.ID, &delivery.ReminderID, &delivery.Channel,
+			&delivery.Success, &delivery.Error, &delivery.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
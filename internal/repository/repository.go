@@ -2,31 +2,52 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"log"
+	"time"
 )
 
 type Repositories struct {
-	User UserRepository
-	Todo TodoRepository
+	User         UserRepository
+	Todo         TodoRepository
+	RefreshToken RefreshTokenRepository
+	Tag          TagRepository
+	Project      ProjectRepository
+	Reminder     ReminderRepository
+	Notification NotificationRepository
+	TodoHistory  TodoHistoryRepository
+	Share        ShareRepository
+	Assignee     AssigneeRepository
+	Comment      CommentRepository
+	Attachment   AttachmentRepository
 }
 
-func NewRepositories(db *pgxpool.Pool) (*Repositories, error) {
+func NewRepositories(db *sql.DB) (*Repositories, error) {
 	if err := createSchema(db); err != nil {
 		return nil, err
 	}
 
 	return &Repositories{
-		User: NewUserRepository(db),
-		Todo: NewTodoRepository(db),
+		User:         NewUserRepository(db),
+		Todo:         NewTodoRepository(db),
+		RefreshToken: NewRefreshTokenRepository(db),
+		Tag:          NewTagRepository(db),
+		Project:      NewProjectRepository(db),
+		Reminder:     NewReminderRepository(db),
+		Notification: NewNotificationRepository(db),
+		TodoHistory:  NewTodoHistoryRepository(db),
+		Share:        NewShareRepository(db),
+		Assignee:     NewAssigneeRepository(db),
+		Comment:      NewCommentRepository(db),
+		Attachment:   NewAttachmentRepository(db),
 	}, nil
 }
 
 // createSchema гарантирует существование необходимых таблиц
-func createSchema(db *pgxpool.Pool) error {
-	_, err := db.Exec(context.Background(),
+func createSchema(db *sql.DB) error {
+	_, err := db.ExecContext(context.Background(),
 		`CREATE TABLE IF NOT EXISTS users (
 			id UUID PRIMARY KEY,
 			username TEXT NOT NULL UNIQUE,
@@ -40,7 +61,7 @@ func createSchema(db *pgxpool.Pool) error {
 		return err
 	}
 
-	_, err = db.Exec(context.Background(),
+	_, err = db.ExecContext(context.Background(),
 		`CREATE TABLE IF NOT EXISTS tasks (
 			id UUID PRIMARY KEY,
 			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
@@ -62,6 +83,8 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetByUsername ищет пользователя по имени без учета регистра
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -70,7 +93,128 @@ type TodoRepository interface {
 	Create(ctx context.Context, todo *models.Todo) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error)
+	List(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]*models.Todo, error)
 	Update(ctx context.Context, todo *models.Todo) error
+	// Delete перемещает задачу с подзадачами в корзину. Задача с ненулевой version удаляется,
+	// только если ее версия не изменилась, иначе возвращается ErrTodoVersionMismatch.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	GetGroupedTodos(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]models.TodoGroup, error)
+	GetSubtree(ctx context.Context, id uuid.UUID) ([]*models.Todo, error)
+	CountOpenSubtasks(ctx context.Context, id uuid.UUID) (int, error)
+	CompleteSubtasks(ctx context.Context, id uuid.UUID, updatedAt time.Time) ([]*models.Todo, error)
+	MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error)
+	CreateSeries(ctx context.Context, series *models.TodoSeries) error
+	GetSeries(ctx context.Context, id uuid.UUID) (*models.TodoSeries, error)
+	CreateOccurrence(ctx context.Context, previous, next *models.Todo) (bool, error)
+	Search(ctx context.Context, userID uuid.UUID, query string, filter models.TodoFilter) ([]*models.TodoSearchResult, error)
+	ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error)
+	Restore(ctx context.Context, id, userID uuid.UUID) ([]*models.Todo, error)
+	Purge(ctx context.Context, id, userID uuid.UUID) error
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// RefreshTokenRepository хранит refresh токены пользователей
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, id uuid.UUID, next *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// TagRepository хранит теги пользователей и их назначение задачам
+type TagRepository interface {
+	Create(ctx context.Context, tag *models.Tag) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Tag, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Tag, error)
+	Update(ctx context.Context, tag *models.Tag) error
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	AttachToTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error
+	DetachFromTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error
+}
+
+// ProjectRepository хранит проекты пользователей и порядок задач в них
+type ProjectRepository interface {
+	Create(ctx context.Context, project *models.Project) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, includeArchived bool) ([]*models.Project, error)
+	Update(ctx context.Context, project *models.Project) error
+	SetArchived(ctx context.Context, id, userID uuid.UUID, archivedAt *time.Time) (*models.Project, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	EnsureInbox(ctx context.Context, userID uuid.UUID) (*models.Project, error)
+	ReorderTodos(ctx context.Context, userID, projectID uuid.UUID, todoIDs []uuid.UUID) error
+}
+
+// ReminderRepository хранит напоминания о задачах и историю их доставки
+type ReminderRepository interface {
+	Create(ctx context.Context, reminder *models.Reminder) error
+	GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.Reminder, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*models.DueReminder, error)
+	RecordDelivery(ctx context.Context, reminder *models.Reminder, delivery *models.ReminderDelivery) error
+	ListDeliveries(ctx context.Context, reminderID uuid.UUID) ([]*models.ReminderDelivery, error)
+}
+
+// NotificationRepository хранит уведомления во входящих пользователей
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	GetByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]*models.Notification, error)
+	MarkRead(ctx context.Context, id, userID uuid.UUID, readAt time.Time) error
+}
+
+// TodoHistoryRepository хранит историю изменений задач
+type TodoHistoryRepository interface {
+	Create(ctx context.Context, entry *models.TodoHistoryEntry) error
+	GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]*models.TodoHistoryEntry, error)
+}
+
+// ShareRepository хранит приглашения и общий доступ к проектам и задачам
+type ShareRepository interface {
+	Create(ctx context.Context, share *models.Share) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Share, error)
+	ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Share, error)
+	ListByProject(ctx context.Context, projectID uuid.UUID) ([]*models.Share, error)
+	ListInvitations(ctx context.Context, userID uuid.UUID) ([]*models.Share, error)
+	Respond(ctx context.Context, id, userID uuid.UUID, status string, respondedAt time.Time) (*models.Share, error)
+	UpdateRole(ctx context.Context, id uuid.UUID, role string) (*models.Share, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetTodoRole возвращает роль пользователя в задаче с учетом приглашений к ее родительским задачам
+	GetTodoRole(ctx context.Context, userID, todoID uuid.UUID) (string, error)
+	// ListTodoParticipants возвращает пользователей с общим доступом к задаче, кроме ее владельца
+	ListTodoParticipants(ctx context.Context, todoID uuid.UUID) ([]uuid.UUID, error)
+	GetProjectRole(ctx context.Context, userID, projectID uuid.UUID) (string, error)
+}
+
+// AssigneeRepository хранит исполнителей задач
+type AssigneeRepository interface {
+	Assign(ctx context.Context, todoID, assignedBy uuid.UUID, userIDs []uuid.UUID, assignedAt time.Time) ([]uuid.UUID, error)
+	Unassign(ctx context.Context, todoID, userID uuid.UUID) error
+}
+
+// CommentRepository хранит комментарии к задачам и историю их изменений
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error)
+	ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Comment, error)
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id uuid.UUID, deletedAt time.Time) error
+	ListRevisions(ctx context.Context, commentID uuid.UUID) ([]*models.CommentRevision, error)
+}
+
+// AttachmentRepository хранит сведения о вложениях задач и очередь удаления их содержимого
+// из хранилища файлов
+type AttachmentRepository interface {
+	// Create сохраняет вложение, если суммарный размер файлов пользователя не превысит quota
+	// байт; quota <= 0 снимает ограничение
+	Create(ctx context.Context, attachment *models.Attachment, quota int64) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Attachment, error)
+	ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Attachment, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	GetGroupedTodos(ctx context.Context, userID uuid.UUID) ([]models.TodoGroup, error)
+	// UsageByUser возвращает суммарный размер файлов, загруженных пользователем
+	UsageByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	// PendingBlobDeletions возвращает не более limit ключей удаленных вложений,
+	// содержимое которых еще не удалено из хранилища
+	PendingBlobDeletions(ctx context.Context, limit int) ([]string, error)
+	// CompleteBlobDeletions убирает ключи из очереди удаления
+	CompleteBlobDeletions(ctx context.Context, keys []string) error
 }
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+// Служебные символы, которыми ts_headline отмечает найденные слова. После экранирования
+// HTML они заменяются метками models.SearchHighlightStart и models.SearchHighlightStop.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// headlineMarks заменяет служебные отметки ts_headline метками выделения
+var headlineMarks = strings.NewReplacer(
+	headlineStart, models.SearchHighlightStart,
+	headlineStop, models.SearchHighlightStop,
+)
+
+// Параметры ts_headline для названия и фрагментов описания
+const (
+	titleHeadlineOptions   = "HighlightAll=true, StartSel=" + headlineStart + ", StopSel=" + headlineStop
+	snippetHeadlineOptions = "MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=\" ... \", " +
+		"StartSel=" + headlineStart + ", StopSel=" + headlineStop
+)
+
+// searchTerms разбивает запрос на слова; знаки препинания и операторы tsquery отбрасываются
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixTSQuery строит запрос to_tsquery, в котором каждое слово ищется по префиксу
+func prefixTSQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// highlight экранирует фрагмент ts_headline и выделяет в нем найденные слова
+func highlight(fragment string) string {
+	return headlineMarks.Replace(html.EscapeString(fragment))
+}
+
+// Search ищет задачи пользователя, подходящие под filter, по словам запроса в названии
+// и описании. Слова ищутся по префиксу с учетом словоформ русского и английского языков,
+// результаты упорядочиваются по релевантности. Если ни одна задача не найдена, выполняется
+// поиск по похожему написанию, который находит задачи и при опечатках в запросе.
+// Сортировка и курсор фильтра не учитываются, filter.Limit ограничивает число результатов.
+func (r *todoRepository) Search(ctx context.Context, userID uuid.UUID, query string, filter models.TodoFilter) ([]*models.TodoSearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []*models.TodoSearchResult{}, nil
+	}
+	filter.Sort, filter.After = "", nil
+
+	results, err := r.searchFullText(ctx, userID, terms, filter)
+	if err != nil || len(results) > 0 {
+		return results, err
+	}
+	return r.searchSimilar(ctx, userID, terms, filter)
+}
+
+// searchFullText ищет задачи по поисковому вектору search_vector
+func (r *todoRepository) searchFullText(ctx context.Context, userID uuid.UUID, terms []string, filter models.TodoFilter) ([]*models.TodoSearchResult, error) {
+	where, args := todoFilterConditions(userID, filter)
+	arg := searchArg(&args)
+	tsquery := arg(prefixTSQuery(terms))
+
+	// Конфигурация russian разбирает слова латиницей английским стеммером,
+	// поэтому подходит для выделения слов на обоих языках
+	query := `
+		WITH q AS (SELECT to_tsquery('russian', ` + tsquery + `) || to_tsquery('english', ` + tsquery + `) AS query)
+		SELECT ` + todoColumns + `, ts_rank_cd(t.search_vector, q.query) AS rank,
+			ts_headline('russian', t.title, q.query, ` + arg(titleHeadlineOptions) + `),
+			ts_headline('russian', t.description, q.query, ` + arg(snippetHeadlineOptions) + `)
+		FROM todos t CROSS JOIN q ` + todoJoins + `
+		WHERE ` + where + ` AND t.search_vector @@ q.query
+		ORDER BY rank DESC, t.id
+		` + searchLimit(arg, filter.Limit) + `
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanSearchResults(rows, false)
+}
+
+// searchSimilar ищет задачи, в названии или описании которых есть слова, похожие
+// на слова запроса (pg_trgm, порог pg_trgm.word_similarity_threshold)
+func (r *todoRepository) searchSimilar(ctx context.Context, userID uuid.UUID, terms []string, filter models.TodoFilter) ([]*models.TodoSearchResult, error) {
+	where, args := todoFilterConditions(userID, filter)
+	arg := searchArg(&args)
+	text := arg(strings.Join(terms, " "))
+
+	query := `
+		SELECT ` + todoColumns + `,
+			GREATEST(word_similarity(` + text + `, t.title), word_similarity(` + text + `, t.description)) AS rank,
+			t.title, ''
+		FROM todos t ` + todoJoins + `
+		WHERE ` + where + ` AND (` + text + ` <% t.title OR ` + text + ` <% t.description)
+		ORDER BY rank DESC, t.id
+		` + searchLimit(arg, filter.Limit) + `
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanSearchResults(rows, true)
+}
+
+// searchArg возвращает функцию, добавляющую аргумент запроса и возвращающую его параметр
+func searchArg(args *[]interface{}) func(value interface{}) string {
+	return func(value interface{}) string {
+		*args = append(*args, value)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+}
+
+// searchLimit возвращает условие LIMIT для limit больше нуля
+func searchLimit(arg func(value interface{}) string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return "LIMIT " + arg(limit)
+}
+
+// scanSearchResults читает задачи с релевантностью, названием и фрагментом описания
+func scanSearchResults(rows *sql.Rows, fuzzy bool) ([]*models.TodoSearchResult, error) {
+	defer rows.Close()
+
+	results := []*models.TodoSearchResult{}
+	for rows.Next() {
+		result := &models.TodoSearchResult{Fuzzy: fuzzy}
+		var title, snippet string
+		todo, err := scanTodo(scanFunc(func(todoDest ...interface{}) error {
+			return rows.Scan(append(todoDest, &result.Rank, &title, &snippet)...)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		result.Todo = todo
+		result.TitleHighlight = highlight(title)
+		result.Snippet = highlight(snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
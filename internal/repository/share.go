@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrShareNotFound возвращается, когда приглашение не найдено или недоступно пользователю
+	ErrShareNotFound = errors.New("share not found")
+	// ErrShareExists возвращается, если адрес уже приглашен к проекту или задаче
+	ErrShareExists = errors.New("share already exists")
+)
+
+// shareColumns колонки общего доступа s в порядке scanShare
+const shareColumns = `s.id, s.project_id, s.todo_id, s.invited_by, s.email, s.user_id, s.role, s.status, s.created_at, s.responded_at`
+
+// todoAncestorsCTE выбирает в ancestors задачу, ID которой передается параметром todoArg,
+// и ее родительские задачи
+func todoAncestorsCTE(todoArg string) string {
+	return `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, project_id FROM todos WHERE id = ` + todoArg + `
+			UNION ALL
+			SELECT p.id, p.parent_id, p.project_id FROM todos p JOIN ancestors a ON p.id = a.parent_id
+		)`
+}
+
+// shareRoleOrder упорядочивает роли s.role от большего уровня прав к меньшему
+const shareRoleOrder = `CASE s.role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC`
+
+// scanShare читает общий доступ, выбранный колонками shareColumns
+func scanShare(row rowScanner) (*models.Share, error) {
+	share := &models.Share{}
+	var projectID, todoID, userID uuid.NullUUID
+	var respondedAt sql.NullTime
+	err := row.Scan(
+		&share.ID, &projectID, &todoID, &share.InvitedBy, &share.Email, &userID,
+		&share.Role, &share.Status, &share.CreatedAt, &respondedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if projectID.Valid {
+		share.ProjectID = &projectID.UUID
+	}
+	if todoID.Valid {
+		share.TodoID = &todoID.UUID
+	}
+	if userID.Valid {
+		share.UserID = &userID.UUID
+	}
+	share.RespondedAt = nullTimePtr(respondedAt)
+	return share, nil
+}
+
+type shareRepository struct {
+	db *sql.DB
+}
+
+// NewShareRepository создает новый экземпляр ShareRepository
+func NewShareRepository(db *sql.DB) ShareRepository {
+	return &shareRepository{db: db}
+}
+
+func (r *shareRepository) Create(ctx context.Context, share *models.Share) error {
+	query := `
+		INSERT INTO shares (id, project_id, todo_id, invited_by, email, role, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		share.ID, share.ProjectID, share.TodoID, share.InvitedBy, share.Email,
+		share.Role, share.Status, share.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrShareExists
+	}
+	return err
+}
+
+func (r *shareRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Share, error) {
+	query := `SELECT ` + shareColumns + ` FROM shares s WHERE s.id = $1`
+	share, err := scanShare(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	return share, err
+}
+
+// ListByTodo возвращает приглашения к задаче в порядке отправки
+func (r *shareRepository) ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Share, error) {
+	return r.list(ctx, `s.todo_id = $1`, todoID)
+}
+
+// ListByProject возвращает приглашения к проекту в порядке отправки
+func (r *shareRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]*models.Share, error) {
+	return r.list(ctx, `s.project_id = $1`, projectID)
+}
+
+// ListInvitations возвращает ожидающие ответа приглашения на адрес пользователя
+func (r *shareRepository) ListInvitations(ctx context.Context, userID uuid.UUID) ([]*models.Share, error) {
+	return r.list(ctx, `s.status = 'pending' AND s.email = (SELECT lower(email) FROM users WHERE id = $1)`, userID)
+}
+
+func (r *shareRepository) list(ctx context.Context, where string, args ...interface{}) ([]*models.Share, error) {
+	query := `SELECT ` + shareColumns + ` FROM shares s WHERE ` + where + ` ORDER BY s.created_at, s.id`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []*models.Share{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// Respond сохраняет ответ пользователя на приглашение, отправленное на его адрес.
+// Ответить можно только на ожидающее приглашение.
+func (r *shareRepository) Respond(ctx context.Context, id, userID uuid.UUID, status string, respondedAt time.Time) (*models.Share, error) {
+	query := `
+		UPDATE shares s
+		SET status = $3, user_id = u.id, responded_at = $4
+		FROM users u
+		WHERE s.id = $1 AND u.id = $2 AND s.email = lower(u.email) AND s.status = 'pending'
+		RETURNING ` + shareColumns
+	share, err := scanShare(conn(ctx, r.db).QueryRowContext(ctx, query, id, userID, status, respondedAt))
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	return share, err
+}
+
+func (r *shareRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string) (*models.Share, error) {
+	query := `UPDATE shares s SET role = $2 WHERE s.id = $1 RETURNING ` + shareColumns
+	share, err := scanShare(conn(ctx, r.db).QueryRowContext(ctx, query, id, role))
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	return share, err
+}
+
+func (r *shareRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM shares WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+// GetTodoRole возвращает наибольшую роль пользователя в принятых приглашениях к задаче,
+// ее родительским задачам и их проектам или пустую строку, если общего доступа к задаче
+// у пользователя нет. Подзадачи общей задачи доступны с ролью приглашения к ней.
+func (r *shareRepository) GetTodoRole(ctx context.Context, userID, todoID uuid.UUID) (string, error) {
+	query := todoAncestorsCTE("$2") + `
+		SELECT s.role
+		FROM shares s
+		JOIN ancestors t ON s.todo_id = t.id OR s.project_id = t.project_id
+		WHERE s.user_id = $1 AND s.status = 'accepted'
+		ORDER BY ` + shareRoleOrder + `
+		LIMIT 1
+	`
+	return r.role(ctx, query, userID, todoID)
+}
+
+// ListTodoParticipants возвращает пользователей, принявших приглашения к задаче,
+// ее родительским задачам или их проектам
+func (r *shareRepository) ListTodoParticipants(ctx context.Context, todoID uuid.UUID) ([]uuid.UUID, error) {
+	query := todoAncestorsCTE("$1") + `
+		SELECT DISTINCT s.user_id
+		FROM shares s
+		JOIN ancestors t ON s.todo_id = t.id OR s.project_id = t.project_id
+		WHERE s.status = 'accepted'
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// GetProjectRole возвращает роль пользователя в принятом приглашении к проекту
+// или пустую строку, если общего доступа к проекту у пользователя нет
+func (r *shareRepository) GetProjectRole(ctx context.Context, userID, projectID uuid.UUID) (string, error) {
+	query := `
+		SELECT s.role
+		FROM shares s
+		WHERE s.user_id = $1 AND s.status = 'accepted' AND s.project_id = $2
+	`
+	return r.role(ctx, query, userID, projectID)
+}
+
+func (r *shareRepository) role(ctx context.Context, query string, args ...interface{}) (string, error) {
+	var role string
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidParent возвращается, если родительская задача не найдена, недоступна
+	// пользователю для изменения или является самой задачей либо ее потомком
+	ErrInvalidParent = errors.New("invalid parent todo")
+	// ErrTodoHasSubtasks возвращается при удалении задачи с подзадачами, если каскадное удаление запрещено
+	ErrTodoHasSubtasks = errors.New("todo has subtasks")
+	// ErrTodoHasOpenSubtasks возвращается при завершении задачи с незавершенными подзадачами,
+	// если каскадное завершение запрещено
+	ErrTodoHasOpenSubtasks = errors.New("todo has open subtasks")
+)
+
+// subtaskTodoRepository проверяет связи между задачами и применяет правила
+// завершения и удаления задач с подзадачами
+type subtaskTodoRepository struct {
+	TodoRepository
+	shares   ShareRepository
+	tx       Transactor
+	policies models.SubtaskPolicies
+}
+
+// NewSubtaskTodoRepository оборачивает TodoRepository так, что родительская задача проверяется
+// при создании и перемещении задачи, а завершение и удаление задачи с подзадачами
+// выполняются согласно policies. Родителем может быть задача владельца или задача, открытая
+// ему через shares с ролью editor или owner; если shares равен nil, только задача владельца.
+// Каскадное завершение подзадач выполняется в транзакции tx вместе с обновлением задачи.
+func NewSubtaskTodoRepository(repo TodoRepository, shares ShareRepository, tx Transactor, policies models.SubtaskPolicies) TodoRepository {
+	return &subtaskTodoRepository{
+		TodoRepository: repo,
+		shares:         shares,
+		tx:             tx,
+		policies:       policies,
+	}
+}
+
+func (r *subtaskTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	if todo.ParentID != nil {
+		if err := r.checkParent(ctx, todo); err != nil {
+			return err
+		}
+	}
+	return r.TodoRepository.Create(ctx, todo)
+}
+
+func (r *subtaskTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	current, err := r.TodoRepository.GetByID(ctx, todo.ID)
+	if err != nil {
+		return err
+	}
+
+	if todo.ParentID != nil && !sameParent(current.ParentID, todo.ParentID) {
+		if err := r.checkParent(ctx, todo); err != nil {
+			return err
+		}
+	}
+
+	completing := todo.Status == models.TodoStatusCompleted && current.Status != models.TodoStatusCompleted
+	if !completing {
+		return r.TodoRepository.Update(ctx, todo)
+	}
+
+	open, err := r.TodoRepository.CountOpenSubtasks(ctx, todo.ID)
+	if err != nil {
+		return err
+	}
+	if open == 0 {
+		return r.TodoRepository.Update(ctx, todo)
+	}
+	if r.policies.Complete != models.SubtaskPolicyCascade {
+		return ErrTodoHasOpenSubtasks
+	}
+
+	// Если задачу не удалось обновить, например из-за изменившейся версии,
+	// завершение подзадач откатывается вместе с ней
+	return r.tx.RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := r.TodoRepository.CompleteSubtasks(ctx, todo.ID, todo.UpdatedAt); err != nil {
+			return err
+		}
+		return r.TodoRepository.Update(ctx, todo)
+	})
+}
+
+func (r *subtaskTodoRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	if r.policies.Delete != models.SubtaskPolicyCascade {
+		subtree, err := r.TodoRepository.GetSubtree(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(subtree) > 1 {
+			return ErrTodoHasSubtasks
+		}
+	}
+
+	// Подзадачи перемещаются в корзину вместе с задачей
+	return r.TodoRepository.Delete(ctx, id, version)
+}
+
+// checkParent проверяет, что пользователь, выполняющий изменение, может изменять родителя
+// и родитель не приводит к циклу. Пользователь берется из контекста, а без автора в контексте
+// проверяется владелец задачи.
+func (r *subtaskTodoRepository) checkParent(ctx context.Context, todo *models.Todo) error {
+	if *todo.ParentID == todo.ID {
+		return ErrInvalidParent
+	}
+
+	parent, err := r.TodoRepository.GetByID(ctx, *todo.ParentID)
+	if errors.Is(err, ErrTodoNotFound) {
+		return ErrInvalidParent
+	}
+	if err != nil {
+		return err
+	}
+	userID := todo.UserID
+	if actor := models.ActorFromContext(ctx); actor.UserID != nil {
+		userID = *actor.UserID
+	}
+	if err := r.checkParentAccess(ctx, userID, parent); err != nil {
+		return err
+	}
+
+	// Цепочка предков родителя не должна содержать саму задачу
+	for parent.ParentID != nil {
+		parent, err = r.TodoRepository.GetByID(ctx, *parent.ParentID)
+		if errors.Is(err, ErrTodoNotFound) {
+			return ErrInvalidParent
+		}
+		if err != nil {
+			return err
+		}
+		if parent.ID == todo.ID {
+			return ErrInvalidParent
+		}
+	}
+	return nil
+}
+
+// checkParentAccess проверяет, что пользователь владеет родителем или родитель открыт ему
+// с ролью editor или owner
+func (r *subtaskTodoRepository) checkParentAccess(ctx context.Context, userID uuid.UUID, parent *models.Todo) error {
+	if parent.UserID == userID {
+		return nil
+	}
+	if r.shares == nil {
+		return ErrInvalidParent
+	}
+	role, err := r.shares.GetTodoRole(ctx, userID, parent.ID)
+	if err != nil {
+		return err
+	}
+	if models.ShareRoleRank(role) < models.ShareRoleRank(models.ShareRoleEditor) {
+		return ErrInvalidParent
+	}
+	return nil
+}
+
+// sameParent сравнивает родительские задачи
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
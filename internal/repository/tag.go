@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTagNotFound возвращается, когда тег не найден или не принадлежит пользователю
+	ErrTagNotFound = errors.New("tag not found")
+	// ErrTagExists возвращается, если у пользователя уже есть тег с таким названием
+	ErrTagExists = errors.New("tag already exists")
+)
+
+type tagRepository struct {
+	db *sql.DB
+}
+
+// NewTagRepository создает новый экземпляр TagRepository
+func NewTagRepository(db *sql.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	query := `
+		INSERT INTO tags (id, user_id, name, color, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		tag.ID, tag.UserID, tag.Name, tag.Color, tag.CreatedAt, tag.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	return err
+}
+
+func (r *tagRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tag, error) {
+	tag := &models.Tag{}
+	query := `
+		SELECT id, user_id, name, color, created_at, updated_at
+		FROM tags WHERE id = $1
+	`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrTagNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (r *tagRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Tag, error) {
+	query := `
+		SELECT id, user_id, name, color, created_at, updated_at
+		FROM tags WHERE user_id = $1
+		ORDER BY name
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.Tag{}
+	for rows.Next() {
+		tag := &models.Tag{}
+		err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r *tagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	query := `
+		UPDATE tags
+		SET name = $1, color = $2, updated_at = $3
+		WHERE id = $4 AND user_id = $5
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		tag.Name, tag.Color, tag.UpdatedAt, tag.ID, tag.UserID,
+	)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	if err != nil {
+		return err
+	}
+	return tagRowsAffected(result)
+}
+
+// Delete удаляет тег пользователя; связи с задачами удаляются каскадно
+func (r *tagRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	query := `DELETE FROM tags WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	return tagRowsAffected(result)
+}
+
+// AttachToTodo назначает тег задаче. Задача и тег должны принадлежать пользователю;
+// повторное назначение не считается ошибкой.
+func (r *tagRepository) AttachToTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	if err := r.checkOwnership(ctx, userID, todoID, tagID); err != nil {
+		return err
+	}
+
+	// Версия задачи увеличивается, только если тег действительно назначен
+	query := `
+		WITH attached AS (
+			INSERT INTO todo_tags (todo_id, tag_id)
+			VALUES ($1, $2)
+			ON CONFLICT (todo_id, tag_id) DO NOTHING
+			RETURNING todo_id
+		)
+		UPDATE todos SET version = version + 1 WHERE id IN (SELECT todo_id FROM attached)
+	`
+	_, err := r.db.ExecContext(ctx, query, todoID, tagID)
+	return err
+}
+
+// DetachFromTodo снимает тег с задачи пользователя
+func (r *tagRepository) DetachFromTodo(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	if err := r.checkOwnership(ctx, userID, todoID, tagID); err != nil {
+		return err
+	}
+
+	query := `
+		WITH detached AS (
+			DELETE FROM todo_tags WHERE todo_id = $1 AND tag_id = $2
+			RETURNING todo_id
+		)
+		UPDATE todos SET version = version + 1 WHERE id IN (SELECT todo_id FROM detached)
+	`
+	_, err := r.db.ExecContext(ctx, query, todoID, tagID)
+	return err
+}
+
+// checkOwnership проверяет, что задача и тег существуют и принадлежат пользователю
+func (r *tagRepository) checkOwnership(ctx context.Context, userID, todoID, tagID uuid.UUID) error {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM todos WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL),
+			EXISTS (SELECT 1 FROM tags WHERE id = $2 AND user_id = $3)
+	`
+	var todoExists, tagExists bool
+	if err := r.db.QueryRowContext(ctx, query, todoID, tagID, userID).Scan(&todoExists, &tagExists); err != nil {
+		return err
+	}
+	if !todoExists {
+		return ErrTodoNotFound
+	}
+	if !tagExists {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// tagRowsAffected возвращает ErrTagNotFound, если запрос не изменил ни одной строки
+func tagRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
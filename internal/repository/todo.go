@@ -3,42 +3,206 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+var (
+	// ErrTodoNotFound возвращается, когда задача не найдена или не принадлежит пользователю
+	ErrTodoNotFound = errors.New("todo not found")
+	// ErrTodoVersionMismatch возвращается, когда задача изменилась после чтения версии,
+	// переданной в Update
+	ErrTodoVersionMismatch = errors.New("todo version mismatch")
+)
+
+// likeEscaper экранирует спецсимволы шаблона LIKE в искомой строке
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// todoColumns колонки задачи вместе с прогрессом подзадач, тегами, исполнителями, числом
+// комментариев и серией повторений для выборок из todos t с todoJoins
+const todoColumns = `t.id, t.title, t.description, t.status, t.priority, t.due_date, t.user_id, t.parent_id,
+		t.project_id, t.position, t.created_at, t.updated_at, p.done, p.total, tg.tags,
+		t.series_id, t.occurrence_date, rs.rrule, rs.timezone, rs.dtstart, t.deleted_at, t.version, asg.assignees,
+		cm.comments`
+
+// todoJoins подсчитывает прогресс прямых подзадач задачи t вне корзины и ее неудаленные
+// комментарии, собирает теги в JSON и исполнителей в массив и присоединяет серию повторений
+const todoJoins = `LEFT JOIN todo_series rs ON rs.id = t.series_id
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) FILTER (WHERE c.status = 'completed') AS done,
+				COUNT(*) FILTER (WHERE c.status <> 'cancelled') AS total
+			FROM todos c WHERE c.parent_id = t.id AND c.deleted_at IS NULL
+		) p ON true
+		LEFT JOIN LATERAL (
+			SELECT COALESCE(json_agg(json_build_object('id', g.id, 'name', g.name, 'color', g.color) ORDER BY g.name), '[]') AS tags
+			FROM todo_tags tt JOIN tags g ON g.id = tt.tag_id
+			WHERE tt.todo_id = t.id
+		) tg ON true
+		LEFT JOIN LATERAL (
+			SELECT COALESCE(array_agg(a.user_id ORDER BY a.assigned_at, a.user_id), '{}') AS assignees
+			FROM todo_assignees a WHERE a.todo_id = t.id
+		) asg ON true
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS comments FROM comments co WHERE co.todo_id = t.id AND co.deleted_at IS NULL
+		) cm ON true`
+
+// todoSubtreeCTE выбирает задачу $1 и всех ее потомков с глубиной вложенности.
+// Задачи в корзине не выбираются.
+const todoSubtreeCTE = `WITH RECURSIVE subtree AS (
+			SELECT id, 0 AS depth FROM todos WHERE id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, s.depth + 1 FROM todos c JOIN subtree s ON c.parent_id = s.id
+			WHERE c.deleted_at IS NULL
+		)`
+
+// rowScanner общий интерфейс sql.Row и sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTodo читает задачу, выбранную колонками todoColumns
+func scanTodo(row rowScanner) (*models.Todo, error) {
+	todo := &models.Todo{}
+	var parentID, projectID uuid.NullUUID
+	var progress models.TodoProgress
+	var tags []byte
+	var seriesID uuid.NullUUID
+	var occurrenceDate, seriesStart sql.NullTime
+	var rule, timezone sql.NullString
+	var deletedAt sql.NullTime
+	var assignees pq.StringArray
+	err := row.Scan(
+		&todo.ID, &todo.Title, &todo.Description, &todo.Status,
+		&todo.Priority, &todo.DueDate, &todo.UserID, &parentID,
+		&projectID, &todo.Position, &todo.CreatedAt, &todo.UpdatedAt, &progress.Done, &progress.Total, &tags,
+		&seriesID, &occurrenceDate, &rule, &timezone, &seriesStart, &deletedAt, &todo.Version, &assignees,
+		&todo.CommentCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(tags, &todo.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode todo tags: %w", err)
+	}
+	if todo.Assignees, err = parseUUIDArray(assignees); err != nil {
+		return nil, fmt.Errorf("failed to decode todo assignees: %w", err)
+	}
+	if parentID.Valid {
+		todo.ParentID = &parentID.UUID
+	}
+	if projectID.Valid {
+		todo.ProjectID = &projectID.UUID
+	}
+	if progress.Total > 0 {
+		todo.Progress = &progress
+	}
+	todo.DeletedAt = nullTimePtr(deletedAt)
+	if seriesID.Valid {
+		todo.Recurrence = &models.TodoRecurrence{
+			SeriesID:       seriesID.UUID,
+			Rule:           rule.String,
+			Timezone:       timezone.String,
+			Start:          seriesStart.Time,
+			OccurrenceDate: occurrenceDate.Time,
+		}
+	}
+	return todo, nil
+}
+
+// scanTodos читает все задачи из rows
+func scanTodos(rows *sql.Rows) ([]*models.Todo, error) {
+	defer rows.Close()
+
+	var todos []*models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
 type todoRepository struct {
 	db *sql.DB
 }
 
+// NewTodoRepository создает новый экземпляр TodoRepository
+func NewTodoRepository(db *sql.DB) TodoRepository {
+	return &todoRepository{db: db}
+}
+
+// Create сохраняет задачу в конец ее проекта. Задача без проекта попадает в проект
+// родительской задачи, а задача верхнего уровня — в Inbox пользователя.
 func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	if todo.ProjectID == nil {
+		projectID, err := r.defaultProject(ctx, todo)
+		if err != nil {
+			return err
+		}
+		todo.ProjectID = &projectID
+	} else if err := checkProject(ctx, conn(ctx, r.db), todo.UserID, *todo.ProjectID); err != nil {
+		return err
+	}
+
+	seriesID, occurrenceDate := recurrenceValues(todo)
 	query := `
-		INSERT INTO todos (id, title, description, status, priority, due_date, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO todos (id, title, description, status, priority, due_date, user_id, parent_id,
+			project_id, position, series_id, occurrence_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM todos WHERE project_id = $9), $10, $11, $12, $13)
+		RETURNING position, version
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
 		todo.ID, todo.Title, todo.Description, todo.Status,
-		todo.Priority, todo.DueDate, todo.UserID,
-		todo.CreatedAt, todo.UpdatedAt,
-	)
-	return err
+		todo.Priority, todo.DueDate, todo.UserID, todo.ParentID,
+		todo.ProjectID, seriesID, occurrenceDate, todo.CreatedAt, todo.UpdatedAt,
+	).Scan(&todo.Position, &todo.Version)
+}
+
+// recurrenceValues возвращает значения колонок series_id и occurrence_date задачи
+func recurrenceValues(todo *models.Todo) (*uuid.UUID, *time.Time) {
+	if todo.Recurrence == nil {
+		return nil, nil
+	}
+	return &todo.Recurrence.SeriesID, &todo.Recurrence.OccurrenceDate
+}
+
+// defaultProject возвращает проект для задачи, созданной без проекта. Доступ к родительской
+// задаче, в том числе общей, проверяет NewSubtaskTodoRepository.
+func (r *todoRepository) defaultProject(ctx context.Context, todo *models.Todo) (uuid.UUID, error) {
+	if todo.ParentID != nil {
+		var projectID uuid.NullUUID
+		query := `SELECT project_id FROM todos WHERE id = $1`
+		err := conn(ctx, r.db).QueryRowContext(ctx, query, todo.ParentID).Scan(&projectID)
+		if err != nil && err != sql.ErrNoRows {
+			return uuid.Nil, err
+		}
+		if projectID.Valid {
+			return projectID.UUID, nil
+		}
+	}
+	return ensureInbox(ctx, conn(ctx, r.db), todo.UserID)
 }
 
 func (r *todoRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
-	todo := &models.Todo{}
 	query := `
-		SELECT id, title, description, status, priority, due_date, user_id, created_at, updated_at
-		FROM todos WHERE id = $1
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE t.id = $1 AND t.deleted_at IS NULL
 	`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&todo.ID, &todo.Title, &todo.Description, &todo.Status,
-		&todo.Priority, &todo.DueDate, &todo.UserID,
-		&todo.CreatedAt, &todo.UpdatedAt,
-	)
+	todo, err := scanTodo(conn(ctx, r.db).QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("todo not found")
+		return nil, ErrTodoNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -46,61 +210,197 @@ func (r *todoRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tod
 	return todo, nil
 }
 
-func (r *todoRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Todo, error) {
+func (r *todoRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, user_id, created_at, updated_at
-		FROM todos WHERE user_id = $1
-		ORDER BY created_at DESC
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE t.user_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.created_at DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanTodos(rows)
+}
 
-	var todos []models.Todo
-	for rows.Next() {
-		var todo models.Todo
-		err := rows.Scan(
-			&todo.ID, &todo.Title, &todo.Description, &todo.Status,
-			&todo.Priority, &todo.DueDate, &todo.UserID,
-			&todo.CreatedAt, &todo.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
+// List возвращает задачи пользователя, подходящие под фильтр, в порядке filter.SortOrder.
+// При равных ключах сортировки задачи упорядочиваются по ID, поэтому порядок однозначен
+// и страницы, начинающиеся после filter.After, не пересекаются.
+func (r *todoRepository) List(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]*models.Todo, error) {
+	where, args := todoFilterConditions(userID, filter)
+	sort, desc := filter.SortOrder()
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	order := todoSortKey(sort) + direction + ", t.id" + direction
+
+	limit := ""
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE ` + where + `
+		ORDER BY ` + order + `
+		` + limit + `
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// todoSortKey возвращает выражение ключа сортировки задач t. Значения ключа совпадают
+// с ключами курсора models.NewTodoCursor.
+func todoSortKey(sort string) string {
+	switch sort {
+	case models.TodoSortUpdatedAt:
+		return "t.updated_at"
+	case models.TodoSortDueDate:
+		// Задачи без срока хранятся с нулевым сроком и следуют за задачами со сроком
+		return "(CASE WHEN t.due_date > '0001-01-01 00:00:00+00' THEN t.due_date ELSE '9999-12-31 00:00:00+00' END)"
+	case models.TodoSortPriority:
+		return "(CASE t.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 ELSE 0 END)"
+	case models.TodoSortPosition:
+		return "t.position"
+	}
+	return "t.created_at"
+}
+
+// todoFilterConditions строит условие WHERE и его аргументы для выборки задач пользователя:
+// его собственных задач и задач, к которым, к родительским задачам которых или к их проектам
+// он принял приглашение
+func todoFilterConditions(userID uuid.UUID, filter models.TodoFilter) (string, []interface{}) {
+	conditions := []string{`(t.user_id = $1 OR EXISTS (
+		` + todoAncestorsCTE("t.id") + `
+		SELECT 1 FROM shares s
+		JOIN ancestors an ON s.todo_id = an.id OR s.project_id = an.project_id
+		WHERE s.user_id = $1 AND s.status = 'accepted'
+	))`, "t.deleted_at IS NULL"}
+	args := []interface{}{userID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ProjectID != nil {
+		conditions = append(conditions, "t.project_id = "+arg(*filter.ProjectID))
+	} else {
+		conditions = append(conditions, `NOT EXISTS (
+			SELECT 1 FROM projects pr WHERE pr.id = t.project_id AND pr.archived_at IS NOT NULL
+		)`)
+	}
+	if len(filter.TagsAny) > 0 {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM todo_tags tt WHERE tt.todo_id = t.id AND tt.tag_id = ANY(`+arg(uuidArray(filter.TagsAny))+`)
+		)`)
+	}
+	if len(filter.TagsAll) > 0 {
+		tagIDs := uuidArray(filter.TagsAll)
+		conditions = append(conditions, `(
+			SELECT COUNT(*) FROM todo_tags tt WHERE tt.todo_id = t.id AND tt.tag_id = ANY(`+arg(tagIDs)+`)
+		) = `+arg(len(tagIDs)))
+	}
+	if len(filter.TagsNone) > 0 {
+		conditions = append(conditions, `NOT EXISTS (
+			SELECT 1 FROM todo_tags tt WHERE tt.todo_id = t.id AND tt.tag_id = ANY(`+arg(uuidArray(filter.TagsNone))+`)
+		)`)
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "t.status = ANY("+arg(pq.Array(filter.Statuses))+")")
+	}
+	if len(filter.Priorities) > 0 {
+		conditions = append(conditions, "t.priority = ANY("+arg(pq.Array(filter.Priorities))+")")
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "t.due_date >= "+arg(*filter.DueAfter))
+	}
+	if filter.DueBefore != nil {
+		// Задачи без срока не попадают в интервал
+		conditions = append(conditions, "t.due_date > '0001-01-01 00:00:00+00' AND t.due_date < "+arg(*filter.DueBefore))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "t.created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "t.created_at < "+arg(*filter.CreatedBefore))
+	}
+	if filter.Text != "" {
+		pattern := arg("%" + likeEscaper.Replace(filter.Text) + "%")
+		conditions = append(conditions, "(t.title ILIKE "+pattern+" OR t.description ILIKE "+pattern+")")
+	}
+	if filter.AssigneeID != nil {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM todo_assignees a WHERE a.todo_id = t.id AND a.user_id = `+arg(*filter.AssigneeID)+`
+		)`)
+	}
+	if filter.After != nil {
+		sort, desc := filter.SortOrder()
+		var key interface{} = filter.After.Time
+		if sort == models.TodoSortPriority || sort == models.TodoSortPosition {
+			key = filter.After.Rank
 		}
-		todos = append(todos, todo)
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		conditions = append(conditions, "("+todoSortKey(sort)+", t.id) "+op+" ("+arg(key)+", "+arg(filter.After.ID)+")")
 	}
-	return todos, nil
+	return strings.Join(conditions, " AND "), args
 }
 
+// Update сохраняет поля задачи и записывает ее новую версию в todo.Version. Задача
+// с ненулевой Version сохраняется, только если ее версия не изменилась, иначе
+// возвращается ErrTodoVersionMismatch.
 func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	seriesID, occurrenceDate := recurrenceValues(todo)
 	query := `
 		UPDATE todos
 		SET title = $1, description = $2, status = $3, priority = $4,
-			due_date = $5, updated_at = $6
-		WHERE id = $7 AND user_id = $8
+			due_date = $5, parent_id = $6, series_id = $7, occurrence_date = $8, updated_at = $9,
+			version = version + 1
+		WHERE id = $10 AND user_id = $11 AND deleted_at IS NULL AND ($12 = 0 OR version = $12)
+		RETURNING version
 	`
-	result, err := r.db.ExecContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		todo.Title, todo.Description, todo.Status, todo.Priority,
-		todo.DueDate, todo.UpdatedAt, todo.ID, todo.UserID,
-	)
-	if err != nil {
+		todo.DueDate, todo.ParentID, seriesID, occurrenceDate, todo.UpdatedAt, todo.ID, todo.UserID,
+		todo.Version,
+	).Scan(&todo.Version)
+	if err != sql.ErrNoRows {
 		return err
 	}
-	rows, err := result.RowsAffected()
-	if err != nil {
+	if todo.Version == 0 {
+		return ErrTodoNotFound
+	}
+
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, todo.ID, todo.UserID).Scan(&exists); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("todo not found or unauthorized")
+	if exists {
+		return ErrTodoVersionMismatch
 	}
-	return nil
+	return ErrTodoNotFound
 }
 
-func (r *todoRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM todos WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, id)
+// Delete перемещает задачу вместе с подзадачами в корзину. Задачи в корзине не выбираются
+// остальными методами и окончательно удаляются Purge или PurgeDeleted. Задача с ненулевой
+// version удаляется, только если ее версия не изменилась, иначе возвращается ErrTodoVersionMismatch.
+func (r *todoRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	query := todoSubtreeCTE + `
+		UPDATE todos SET deleted_at = $2, version = version + 1
+		WHERE id IN (SELECT id FROM subtree)
+			AND ($3 = 0 OR EXISTS (SELECT 1 FROM todos WHERE id = $1 AND version = $3))
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, time.Now(), version)
 	if err != nil {
 		return err
 	}
@@ -108,19 +408,32 @@ func (r *todoRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("todo not found")
+	if rows > 0 {
+		return nil
+	}
+	if version == 0 {
+		return ErrTodoNotFound
 	}
-	return nil
+
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND deleted_at IS NULL)`
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrTodoVersionMismatch
+	}
+	return ErrTodoNotFound
 }
 
 func (r *todoRepository) GetGroupedByStatus(ctx context.Context, userID uuid.UUID) (map[string][]models.Todo, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, user_id, created_at, updated_at
-		FROM todos WHERE user_id = $1
-		ORDER BY status, created_at DESC
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE t.user_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.status, t.created_at DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -128,16 +441,354 @@ func (r *todoRepository) GetGroupedByStatus(ctx context.Context, userID uuid.UUI
 
 	grouped := make(map[string][]models.Todo)
 	for rows.Next() {
-		var todo models.Todo
-		err := rows.Scan(
-			&todo.ID, &todo.Title, &todo.Description, &todo.Status,
-			&todo.Priority, &todo.DueDate, &todo.UserID,
-			&todo.CreatedAt, &todo.UpdatedAt,
-		)
+		todo, err := scanTodo(rows)
 		if err != nil {
 			return nil, err
 		}
-		grouped[todo.Status] = append(grouped[todo.Status], todo)
+		grouped[todo.Status] = append(grouped[todo.Status], *todo)
 	}
 	return grouped, nil
 }
+
+// GetGroupedTodos возвращает задачи пользователя, удовлетворяющие filter, сгруппированные
+// по статусу и приоритету или, если filter.GroupBy равен models.TodoGroupByAssignee, по исполнителю
+func (r *todoRepository) GetGroupedTodos(ctx context.Context, userID uuid.UUID, filter models.TodoFilter) ([]models.TodoGroup, error) {
+	where, args := todoFilterConditions(userID, filter)
+	order := "t.created_at DESC"
+	if filter.ProjectID != nil {
+		order = "t.position, t.created_at"
+	}
+	if filter.GroupBy != models.TodoGroupByAssignee {
+		order = "t.status, t.priority, " + order
+	}
+
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE ` + where + `
+		ORDER BY ` + order + `
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []models.TodoGroup
+	if filter.GroupBy == models.TodoGroupByAssignee {
+		groups = groupByAssignee(todos)
+	} else {
+		groups = groupByStatusAndPriority(todos)
+	}
+	for i := range groups {
+		groups[i].TagCounts = countTags(groups[i].Tasks)
+	}
+	return groups, nil
+}
+
+// groupByStatusAndPriority группирует задачи, отсортированные по статусу и приоритету
+func groupByStatusAndPriority(todos []*models.Todo) []models.TodoGroup {
+	var groups []models.TodoGroup
+	for _, todo := range todos {
+		// Задачи отсортированы, поэтому новая группа начинается при смене статуса или приоритета
+		last := len(groups) - 1
+		if last < 0 || groups[last].Status != todo.Status || groups[last].Priority != todo.Priority {
+			groups = append(groups, models.TodoGroup{Status: todo.Status, Priority: todo.Priority})
+			last++
+		}
+		groups[last].Tasks = append(groups[last].Tasks, todo)
+		groups[last].Count++
+	}
+	return groups
+}
+
+// groupByAssignee группирует задачи по исполнителю с сохранением их порядка. Группы исполнителей
+// следуют в порядке первого появления исполнителя, группа задач без исполнителя — последней.
+func groupByAssignee(todos []*models.Todo) []models.TodoGroup {
+	var groups []models.TodoGroup
+	index := make(map[uuid.UUID]int)
+	var unassigned []*models.Todo
+	for _, todo := range todos {
+		if len(todo.Assignees) == 0 {
+			unassigned = append(unassigned, todo)
+			continue
+		}
+		for _, assignee := range todo.Assignees {
+			i, ok := index[assignee]
+			if !ok {
+				assignee := assignee
+				i = len(groups)
+				index[assignee] = i
+				groups = append(groups, models.TodoGroup{Assignee: &assignee})
+			}
+			groups[i].Tasks = append(groups[i].Tasks, todo)
+			groups[i].Count++
+		}
+	}
+	if len(unassigned) > 0 {
+		groups = append(groups, models.TodoGroup{Tasks: unassigned, Count: len(unassigned)})
+	}
+	return groups
+}
+
+// countTags подсчитывает число задач с каждым тегом
+func countTags(todos []*models.Todo) []models.TagCount {
+	counts := make(map[uuid.UUID]*models.TagCount)
+	for _, todo := range todos {
+		for _, tag := range todo.Tags {
+			if _, ok := counts[tag.ID]; !ok {
+				counts[tag.ID] = &models.TagCount{Tag: tag}
+			}
+			counts[tag.ID].Count++
+		}
+	}
+
+	result := make([]models.TagCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag.Name < result[j].Tag.Name })
+	return result
+}
+
+// parseUUIDArray преобразует массив ID PostgreSQL в ID; пустой массив — в nil
+func parseUUIDArray(values pq.StringArray) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// uuidArray преобразует ID в массив PostgreSQL без повторов
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	result := make(pq.StringArray, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id.String())
+		}
+	}
+	return result
+}
+
+// GetSubtree возвращает задачу и всех ее потомков: сначала более мелкие уровни вложенности,
+// внутри уровня — в порядке создания
+func (r *todoRepository) GetSubtree(ctx context.Context, id uuid.UUID) ([]*models.Todo, error) {
+	query := todoSubtreeCTE + `
+		SELECT ` + todoColumns + `
+		FROM subtree s
+		JOIN todos t ON t.id = s.id
+		` + todoJoins + `
+		ORDER BY s.depth, t.created_at
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, ErrTodoNotFound
+	}
+	return todos, nil
+}
+
+// CountOpenSubtasks возвращает число незавершенных и неотмененных потомков задачи
+func (r *todoRepository) CountOpenSubtasks(ctx context.Context, id uuid.UUID) (int, error) {
+	query := todoSubtreeCTE + `
+		SELECT COUNT(*)
+		FROM subtree s
+		JOIN todos t ON t.id = s.id
+		WHERE s.depth > 0 AND t.status NOT IN ('completed', 'cancelled')
+	`
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&count)
+	return count, err
+}
+
+// CompleteSubtasks завершает всех незавершенных и неотмененных потомков задачи
+// и возвращает измененные задачи
+func (r *todoRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	query := todoSubtreeCTE + `
+		UPDATE todos
+		SET status = 'completed', updated_at = $2, version = version + 1
+		WHERE id IN (SELECT id FROM subtree WHERE depth > 0)
+			AND status NOT IN ('completed', 'cancelled')
+		RETURNING id
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, id, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var completedID string
+		if err := rows.Scan(&completedID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, completedID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query = `
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE t.id = ANY($1)
+	`
+	completed, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(completed)
+}
+
+// MoveToProject переносит задачу вместе с подзадачами в конец проекта projectID. Перенесенная
+// задача становится задачей верхнего уровня. Возвращает перенесенные задачи, начиная с самой задачи.
+func (r *todoRepository) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT user_id FROM todos WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProject(ctx, tx, userID, projectID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE todos
+		SET project_id = $2, parent_id = NULL, updated_at = $3, version = version + 1,
+			position = (SELECT COALESCE(MAX(position), 0) + 1 FROM todos WHERE project_id = $2)
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id, projectID, updatedAt); err != nil {
+		return nil, err
+	}
+
+	query = todoSubtreeCTE + `
+		UPDATE todos
+		SET project_id = $2, updated_at = $3, version = version + 1
+		WHERE id IN (SELECT id FROM subtree WHERE depth > 0)
+	`
+	if _, err := tx.ExecContext(ctx, query, id, projectID, updatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetSubtree(ctx, id)
+}
+
+// CreateSeries сохраняет серию повторяющихся задач
+func (r *todoRepository) CreateSeries(ctx context.Context, series *models.TodoSeries) error {
+	query := `
+		INSERT INTO todo_series (id, user_id, rrule, timezone, dtstart, title, description, priority, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		series.ID, series.UserID, series.Rule, series.Timezone, series.Start,
+		series.Title, series.Description, series.Priority, series.CreatedAt,
+	)
+	return err
+}
+
+func (r *todoRepository) GetSeries(ctx context.Context, id uuid.UUID) (*models.TodoSeries, error) {
+	series := &models.TodoSeries{}
+	query := `
+		SELECT id, user_id, rrule, timezone, dtstart, title, description, priority, created_at
+		FROM todo_series WHERE id = $1
+	`
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&series.ID, &series.UserID, &series.Rule, &series.Timezone, &series.Start,
+		&series.Title, &series.Description, &series.Priority, &series.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrSeriesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// CreateOccurrence создает следующее повторение next задачи previous и копирует ее теги
+// и напоминания со смещением до срока.
+// Повторение с той же плановой датой создается только один раз: при повторном вызове
+// возвращается false без ошибки.
+func (r *todoRepository) CreateOccurrence(ctx context.Context, previous, next *models.Todo) (bool, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	seriesID, occurrenceDate := recurrenceValues(next)
+	query := `
+		INSERT INTO todos (id, title, description, status, priority, due_date, user_id, parent_id,
+			project_id, position, series_id, occurrence_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM todos WHERE project_id = $9), $10, $11, $12, $13)
+		ON CONFLICT (series_id, occurrence_date) DO NOTHING
+		RETURNING position, version
+	`
+	err = tx.QueryRowContext(ctx, query,
+		next.ID, next.Title, next.Description, next.Status,
+		next.Priority, next.DueDate, next.UserID, next.ParentID,
+		next.ProjectID, seriesID, occurrenceDate, next.CreatedAt, next.UpdatedAt,
+	).Scan(&next.Position, &next.Version)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	query = `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT $1, tag_id FROM todo_tags WHERE todo_id = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, next.ID, previous.ID); err != nil {
+		return false, err
+	}
+
+	query = `
+		INSERT INTO reminders (id, todo_id, user_id, offset_minutes, channel, target, created_at)
+		SELECT md5(random()::text || id)::uuid::text, $1, user_id, offset_minutes, channel, target, $3
+		FROM reminders WHERE todo_id = $2 AND offset_minutes IS NOT NULL
+	`
+	if _, err := tx.ExecContext(ctx, query, next.ID, previous.ID, next.CreatedAt); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	next.Tags = previous.Tags
+	return true, nil
+}
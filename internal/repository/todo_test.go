@@ -198,7 +198,7 @@ func TestTodoRepository_Delete(t *testing.T) {
 	require.NoError(t, err)
 
 	// Удаляем задачу
-	err = repo.Delete(context.Background(), todo.ID)
+	err = repo.Delete(context.Background(), todo.ID, 0)
 	assert.NoError(t, err)
 
 	// Проверяем, что задача удалена
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrTodoParentDeleted возвращается при восстановлении подзадачи, родитель которой в корзине
+var ErrTodoParentDeleted = errors.New("parent todo is in trash")
+
+// ListTrash возвращает задачи пользователя в корзине, начиная с удаленных последними.
+// Подзадачи, родитель которых тоже в корзине, не возвращаются: они восстанавливаются
+// вместе с родителем.
+func (r *todoRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos t ` + todoJoins + `
+		WHERE t.user_id = $1 AND t.deleted_at IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM todos pt WHERE pt.id = t.parent_id AND pt.deleted_at IS NOT NULL)
+		ORDER BY t.deleted_at DESC, t.id
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// Restore восстанавливает задачу пользователя из корзины вместе с подзадачами, удаленными
+// одновременно с ней, и возвращает восстановленные задачи, начиная с самой задачи.
+// Подзадачи, удаленные раньше, остаются в корзине.
+func (r *todoRepository) Restore(ctx context.Context, id, userID uuid.UUID) ([]*models.Todo, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	var parentDeleted bool
+	query := `
+		SELECT t.deleted_at, COALESCE(pt.deleted_at IS NOT NULL, false)
+		FROM todos t
+		LEFT JOIN todos pt ON pt.id = t.parent_id
+		WHERE t.id = $1 AND t.user_id = $2 AND t.deleted_at IS NOT NULL
+		FOR UPDATE OF t
+	`
+	err = tx.QueryRowContext(ctx, query, id, userID).Scan(&deletedAt, &parentDeleted)
+	if err == sql.ErrNoRows {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parentDeleted {
+		return nil, ErrTodoParentDeleted
+	}
+
+	query = `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM todos WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM todos c JOIN subtree s ON c.parent_id = s.id
+			WHERE c.deleted_at = $2
+		)
+		UPDATE todos SET deleted_at = NULL, version = version + 1
+		WHERE id IN (SELECT id FROM subtree)
+	`
+	if _, err := tx.ExecContext(ctx, query, id, deletedAt); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetSubtree(ctx, id)
+}
+
+// Purge окончательно удаляет задачу пользователя из корзины вместе с подзадачами
+func (r *todoRepository) Purge(ctx context.Context, id, userID uuid.UUID) error {
+	query := `DELETE FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет не более limit задач, перемещенных в корзину раньше
+// before, вместе с их подзадачами и возвращает число удаленных задач без учета подзадач
+func (r *todoRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	query := `
+		DELETE FROM todos
+		WHERE id IN (
+			SELECT id FROM todos
+			WHERE deleted_at < $1
+			ORDER BY deleted_at
+			LIMIT $2
+		)
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// savepointName имя точек сохранения вложенных транзакций. Точки сохранения вкладываются
+// строго друг в друга, поэтому одного имени достаточно: PostgreSQL откатывает и освобождает
+// последнюю точку с этим именем.
+const savepointName = "repository_tx"
+
+// Статусы операций пакета
+const (
+	BatchStatusOK = "ok"
+	// BatchStatusFailed операция завершилась ошибкой, ее изменения отменены
+	BatchStatusFailed = "failed"
+	// BatchStatusRolledBack операция выполнилась, но отменена вместе с транзакцией пакета
+	BatchStatusRolledBack = "rolled_back"
+	// BatchStatusSkipped операция не выполнялась после ошибки предыдущей
+	BatchStatusSkipped = "skipped"
+)
+
+// Transactor выполняет вызовы репозиториев в одной транзакции базы данных
+type Transactor interface {
+	// RunInTx выполняет fn в транзакции: вызовы репозиториев с контекстом, переданным в fn,
+	// выполняются в ней. Транзакция фиксируется, если fn не вернула ошибку, иначе откатывается.
+	// Вызов внутри другой транзакции создает точку сохранения, и ошибка fn откатывает
+	// только изменения fn.
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// BatchOutcome результат операции пакета
+type BatchOutcome struct {
+	Status string
+	Err    error
+}
+
+// queryer общий интерфейс *sql.DB и *sql.Tx для выполнения запросов
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txConn транзакция метода репозитория: *sql.Tx или точка сохранения в транзакции из контекста
+type txConn interface {
+	queryer
+	Commit() error
+	Rollback() error
+}
+
+// txContextKey тип ключа контекста с транзакцией Transactor
+type txContextKey struct{}
+
+// txState транзакция Transactor и действия, отложенные до ее фиксации
+type txState struct {
+	tx    *sql.Tx
+	hooks []func()
+}
+
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txContextKey{}).(*txState)
+	return state
+}
+
+// conn возвращает транзакцию из контекста или db, если запрос выполняется вне Transactor
+func conn(ctx context.Context, db *sql.DB) queryer {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx
+	}
+	return db
+}
+
+// beginTx начинает транзакцию метода репозитория. Внутри Transactor вместо новой
+// транзакции создается точка сохранения.
+func beginTx(ctx context.Context, db *sql.DB) (txConn, error) {
+	state := txFromContext(ctx)
+	if state == nil {
+		return db.BeginTx(ctx, nil)
+	}
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepointName); err != nil {
+		return nil, err
+	}
+	return &savepointTx{Tx: state.tx, ctx: ctx, state: state, hooks: len(state.hooks)}, nil
+}
+
+// afterCommit выполняет fn после фиксации транзакции Transactor из контекста или сразу,
+// если транзакции нет. При откате транзакции или точки сохранения fn не выполняется.
+func afterCommit(ctx context.Context, fn func()) {
+	if state := txFromContext(ctx); state != nil {
+		state.hooks = append(state.hooks, fn)
+		return
+	}
+	fn()
+}
+
+// savepointTx точка сохранения с интерфейсом транзакции
+type savepointTx struct {
+	*sql.Tx
+	ctx   context.Context
+	state *txState
+	// hooks число отложенных действий на момент создания точки
+	hooks int
+	done  bool
+}
+
+func (t *savepointTx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.ExecContext(t.ctx, "RELEASE SAVEPOINT "+savepointName)
+	return err
+}
+
+func (t *savepointTx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	t.state.hooks = t.state.hooks[:t.hooks]
+	_, err := t.Tx.ExecContext(t.ctx, "ROLLBACK TO SAVEPOINT "+savepointName)
+	return err
+}
+
+type sqlTransactor struct {
+	db *sql.DB
+}
+
+// NewTransactor создает новый экземпляр Transactor
+func NewTransactor(db *sql.DB) Transactor {
+	return &sqlTransactor{db: db}
+}
+
+func (t *sqlTransactor) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	state := txFromContext(ctx)
+	tx, err := beginTx(ctx, t.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	outermost := state == nil
+	if outermost {
+		state = &txState{tx: tx.(*sql.Tx)}
+		ctx = context.WithValue(ctx, txContextKey{}, state)
+	}
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if outermost {
+		for _, hook := range state.hooks {
+			hook()
+		}
+	}
+	return nil
+}
+
+// RunBatch выполняет n операций пакета в одной транзакции, вызывая fn с номером операции.
+// В режиме atomic ошибка операции откатывает весь пакет, и следующие операции не выполняются.
+// Иначе каждая операция выполняется в своей точке сохранения, и ошибка откатывает только ее.
+// Возвращает результаты операций и признак фиксации транзакции; ошибка возвращается, только
+// если транзакцию не удалось выполнить.
+func RunBatch(ctx context.Context, tx Transactor, n int, atomic bool, fn func(ctx context.Context, i int) error) ([]BatchOutcome, bool, error) {
+	outcomes := make([]BatchOutcome, n)
+	failed := -1
+
+	err := tx.RunInTx(ctx, func(ctx context.Context) error {
+		for i := 0; i < n; i++ {
+			var err error
+			if atomic {
+				err = fn(ctx, i)
+			} else {
+				err = tx.RunInTx(ctx, func(ctx context.Context) error { return fn(ctx, i) })
+			}
+			if err == nil {
+				outcomes[i].Status = BatchStatusOK
+				continue
+			}
+
+			outcomes[i] = BatchOutcome{Status: BatchStatusFailed, Err: err}
+			if atomic {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if failed < 0 {
+		return outcomes, err == nil, err
+	}
+
+	for i := range outcomes[:failed] {
+		outcomes[i].Status = BatchStatusRolledBack
+	}
+	for i := failed + 1; i < n; i++ {
+		outcomes[i].Status = BatchStatusSkipped
+	}
+	return outcomes, false, nil
+}
@@ -4,20 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/R-eSPeCT/todo-list/internal/models"
 	"github.com/google/uuid"
 	"strings"
 )
 
-// UserRepository определяет интерфейс для работы с пользователями
-type UserRepository interface {
-	Create(ctx context.Context, user *models.User) error
-	GetByID(ctx context.Context, id string) (*models.User, error)
-	GetByEmail(ctx context.Context, email string) (*models.User, error)
-	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id string) error
-}
+var (
+	// ErrUserNotFound возвращается, если пользователь не найден
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists возвращается, если email или имя пользователя уже заняты
+	ErrUserExists = errors.New("user already exists")
+)
 
 type userRepository struct {
 	db *sql.DB
@@ -30,28 +27,31 @@ func NewUserRepository(db *sql.DB) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, username, email, password, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		user.ID, user.Email, user.Password,
+		user.ID, user.Username, user.Email, user.Password,
 		user.CreatedAt, user.UpdatedAt,
 	)
+	if isUniqueViolation(err) {
+		return ErrUserExists
+	}
 	return err
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, password, created_at, updated_at
+		SELECT id, COALESCE(username, ''), email, password, created_at, updated_at
 		FROM users WHERE id = $1
 	`
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.Password,
+		&user.ID, &user.Username, &user.Email, &user.Password,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -62,15 +62,34 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User,
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, password, created_at, updated_at
+		SELECT id, COALESCE(username, ''), email, password, created_at, updated_at
 		FROM users WHERE email = $1
 	`
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.Password,
+		&user.ID, &user.Username, &user.Email, &user.Password,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	user := &models.User{}
+	query := `
+		SELECT id, COALESCE(username, ''), email, password, created_at, updated_at
+		FROM users WHERE lower(username) = lower($1)
+	`
+	err := r.db.QueryRowContext(ctx, query, username).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -81,13 +100,16 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET email = $1, password = $2, updated_at = $3
-		WHERE id = $4
+		SET username = NULLIF($1, ''), email = $2, password = $3, updated_at = $4
+		WHERE id = $5
 	`
 	result, err := r.db.ExecContext(ctx, query,
-		user.Email, user.Password, user.UpdatedAt,
+		user.Username, user.Email, user.Password, user.UpdatedAt,
 		user.ID,
 	)
+	if isUniqueViolation(err) {
+		return ErrUserExists
+	}
 	if err != nil {
 		return err
 	}
@@ -96,12 +118,12 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
 	}
 	return nil
 }
 
-func (r *userRepository) Delete(ctx context.Context, id string) error {
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -112,7 +134,7 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
 	}
 	return nil
 }
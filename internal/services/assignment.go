@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrAssigneeNoAccess возвращается при назначении исполнителем пользователя без доступа к задаче
+var ErrAssigneeNoAccess = errors.New("assignee has no access to the todo")
+
+type assignmentService struct {
+	assignees     repository.AssigneeRepository
+	todos         TodoService
+	notifications repository.NotificationRepository
+}
+
+// NewAssignmentService создает новый экземпляр AssignmentService. Назначенные и снятые
+// исполнители получают уведомление во входящие, если их назначил или снял другой пользователь.
+func NewAssignmentService(
+	assignees repository.AssigneeRepository,
+	todos TodoService,
+	notifications repository.NotificationRepository,
+) AssignmentService {
+	return &assignmentService{
+		assignees:     assignees,
+		todos:         todos,
+		notifications: notifications,
+	}
+}
+
+func (s *assignmentService) Assign(ctx context.Context, userID, todoID uuid.UUID, assigneeIDs []uuid.UUID) (*models.Todo, error) {
+	todo, err := s.todos.Authorize(ctx, userID, todoID, TodoActionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	// Исполнителем может быть только пользователь, которому видна задача
+	for _, assigneeID := range assigneeIDs {
+		_, err := s.todos.Authorize(ctx, assigneeID, todoID, TodoActionRead)
+		if errors.Is(err, repository.ErrTodoNotFound) {
+			return nil, ErrAssigneeNoAccess
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	added, err := s.assignees.Assign(ctx, todoID, userID, assigneeIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, assigneeID := range added {
+		if assigneeID != userID {
+			s.notify(ctx, assigneeID, todoID, "New assignment", fmt.Sprintf("You have been assigned to the todo %q", todo.Title))
+		}
+	}
+	return s.todos.GetByID(ctx, userID, todoID)
+}
+
+func (s *assignmentService) Unassign(ctx context.Context, userID, todoID, assigneeID uuid.UUID) (*models.Todo, error) {
+	// Исполнитель может сам отказаться от задачи
+	action := TodoActionWrite
+	if assigneeID == userID {
+		action = TodoActionRead
+	}
+	todo, err := s.todos.Authorize(ctx, userID, todoID, action)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.assignees.Unassign(ctx, todoID, assigneeID); err != nil {
+		return nil, err
+	}
+	if assigneeID != userID {
+		s.notify(ctx, assigneeID, todoID, "Assignment removed", fmt.Sprintf("You have been unassigned from the todo %q", todo.Title))
+	}
+	return s.todos.GetByID(ctx, userID, todoID)
+}
+
+// notify отправляет уведомление о назначении во входящие исполнителя.
+// Ошибка уведомления не отменяет назначение.
+func (s *assignmentService) notify(ctx context.Context, assigneeID, todoID uuid.UUID, title, body string) {
+	if s.notifications == nil {
+		return
+	}
+
+	notification := &models.Notification{
+		ID:        uuid.New(),
+		UserID:    assigneeID,
+		TodoID:    &todoID,
+		Title:     title,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := s.notifications.Create(ctx, notification); err != nil {
+		log.Printf("Failed to notify assignee: %v", err)
+	}
+}
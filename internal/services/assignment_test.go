@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assigneeStore хранит исполнителей одной задачи в памяти
+type assigneeStore struct {
+	assignees map[uuid.UUID]bool
+}
+
+func (s *assigneeStore) Assign(ctx context.Context, todoID, assignedBy uuid.UUID, userIDs []uuid.UUID, assignedAt time.Time) ([]uuid.UUID, error) {
+	var added []uuid.UUID
+	for _, userID := range userIDs {
+		if !s.assignees[userID] {
+			s.assignees[userID] = true
+			added = append(added, userID)
+		}
+	}
+	return added, nil
+}
+
+func (s *assigneeStore) Unassign(ctx context.Context, todoID, userID uuid.UUID) error {
+	if !s.assignees[userID] {
+		return repository.ErrAssigneeNotFound
+	}
+	delete(s.assignees, userID)
+	return nil
+}
+
+// notificationStore запоминает созданные уведомления
+type notificationStore struct {
+	repository.NotificationRepository
+	created []*models.Notification
+}
+
+func (s *notificationStore) Create(ctx context.Context, notification *models.Notification) error {
+	s.created = append(s.created, notification)
+	return nil
+}
+
+func TestAssignmentService_Assign(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	strangerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+
+	tests := []struct {
+		name         string
+		userID       uuid.UUID
+		assigneeIDs  []uuid.UUID
+		assigned     []uuid.UUID
+		wantErr      error
+		wantNotified []uuid.UUID
+	}{
+		{
+			name:         "владелец назначает участников",
+			userID:       ownerID,
+			assigneeIDs:  []uuid.UUID{editorID, viewerID},
+			wantNotified: []uuid.UUID{editorID, viewerID},
+		},
+		{
+			name:        "редактор назначает себя",
+			userID:      editorID,
+			assigneeIDs: []uuid.UUID{editorID},
+		},
+		{
+			name:         "уже назначенный исполнитель не уведомляется повторно",
+			userID:       ownerID,
+			assigneeIDs:  []uuid.UUID{editorID, viewerID},
+			assigned:     []uuid.UUID{editorID},
+			wantNotified: []uuid.UUID{viewerID},
+		},
+		{
+			name:        "исполнитель без доступа к задаче",
+			userID:      ownerID,
+			assigneeIDs: []uuid.UUID{viewerID, strangerID},
+			wantErr:     ErrAssigneeNoAccess,
+		},
+		{
+			name:        "наблюдатель не может назначать",
+			userID:      viewerID,
+			assigneeIDs: []uuid.UUID{viewerID},
+			wantErr:     repository.ErrTodoNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{
+				editorID: models.ShareRoleEditor,
+				viewerID: models.ShareRoleViewer,
+			}}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			assignees := &assigneeStore{assignees: map[uuid.UUID]bool{}}
+			for _, id := range tt.assigned {
+				assignees.assignees[id] = true
+			}
+			notifications := &notificationStore{}
+			service := NewAssignmentService(assignees, todos, notifications)
+
+			got, err := service.Assign(context.Background(), tt.userID, todo.ID, tt.assigneeIDs)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Len(t, assignees.assignees, len(tt.assigned))
+				assert.Empty(t, notifications.created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, todo.ID, got.ID)
+			for _, id := range tt.assigneeIDs {
+				assert.True(t, assignees.assignees[id])
+			}
+
+			var notified []uuid.UUID
+			for _, notification := range notifications.created {
+				assert.Equal(t, &todo.ID, notification.TodoID)
+				notified = append(notified, notification.UserID)
+			}
+			assert.Equal(t, tt.wantNotified, notified)
+		})
+	}
+}
+
+func TestAssignmentService_Unassign(t *testing.T) {
+	ownerID := uuid.New()
+	viewerID := uuid.New()
+	editorID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		assigneeID uuid.UUID
+		wantErr    error
+		wantNotify bool
+	}{
+		{name: "владелец снимает исполнителя", userID: ownerID, assigneeID: viewerID, wantNotify: true},
+		{name: "исполнитель снимает себя", userID: viewerID, assigneeID: viewerID},
+		{name: "наблюдатель не может снять другого", userID: viewerID, assigneeID: editorID, wantErr: repository.ErrTodoNotFound},
+		{name: "пользователь не назначен", userID: ownerID, assigneeID: uuid.New(), wantErr: repository.ErrAssigneeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{
+				editorID: models.ShareRoleEditor,
+				viewerID: models.ShareRoleViewer,
+			}}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			assignees := &assigneeStore{assignees: map[uuid.UUID]bool{viewerID: true, editorID: true}}
+			notifications := &notificationStore{}
+			service := NewAssignmentService(assignees, todos, notifications)
+
+			_, err := service.Unassign(context.Background(), tt.userID, todo.ID, tt.assigneeID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Len(t, assignees.assignees, 2)
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, assignees.assignees[tt.assigneeID])
+			assert.Equal(t, tt.wantNotify, len(notifications.created) == 1)
+		})
+	}
+}
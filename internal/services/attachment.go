@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAttachmentTooLarge возвращается для пустого файла или файла больше AttachmentLimits.MaxSize
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	// ErrAttachmentTypeNotAllowed возвращается, если тип содержимого файла не входит в AttachmentLimits.AllowedTypes
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+)
+
+// maxFilenameLength наибольшая длина имени вложения в символах
+const maxFilenameLength = 255
+
+// AttachmentLimits задает ограничения вложений
+type AttachmentLimits struct {
+	// MaxSize наибольший размер файла в байтах
+	MaxSize int64
+	// AllowedTypes допустимые типы содержимого; "image/*" разрешает все подтипы
+	AllowedTypes []string
+	// UserQuota суммарный размер файлов, которые может загрузить пользователь; 0 — без ограничения
+	UserQuota int64
+	// URLExpiration срок действия ссылок на скачивание
+	URLExpiration time.Duration
+}
+
+type attachmentService struct {
+	attachments repository.AttachmentRepository
+	todos       TodoService
+	store       storage.BlobStore
+	limits      AttachmentLimits
+	now         func() time.Time
+}
+
+// NewAttachmentService создает новый экземпляр AttachmentService, хранящий содержимое файлов в store
+func NewAttachmentService(
+	attachments repository.AttachmentRepository,
+	todos TodoService,
+	store storage.BlobStore,
+	limits AttachmentLimits,
+) AttachmentService {
+	return &attachmentService{
+		attachments: attachments,
+		todos:       todos,
+		store:       store,
+		limits:      limits,
+		now:         time.Now,
+	}
+}
+
+func (s *attachmentService) Upload(ctx context.Context, userID, todoID uuid.UUID, filename string, size int64, content io.Reader) (*models.Attachment, error) {
+	if _, err := s.todos.Authorize(ctx, userID, todoID, TodoActionWrite); err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > s.limits.MaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	// Тип определяется по содержимому: заголовку клиента доверять нельзя
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := detectContentType(head)
+	if !isAllowedType(s.limits.AllowedTypes, contentType) {
+		return nil, ErrAttachmentTypeNotAllowed
+	}
+
+	// Квота проверяется до загрузки, чтобы не передавать в хранилище файл, который не поместится
+	if s.limits.UserQuota > 0 {
+		used, err := s.attachments.UsageByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used+size > s.limits.UserQuota {
+			return nil, repository.ErrStorageQuotaExceeded
+		}
+	}
+
+	id := uuid.New()
+	attachment := &models.Attachment{
+		ID:          id,
+		TodoID:      todoID,
+		UserID:      userID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  models.AttachmentStorageKey(todoID, id),
+		CreatedAt:   s.now(),
+	}
+	body := io.MultiReader(bytes.NewReader(head), content)
+	if err := s.store.Put(ctx, attachment.StorageKey, body, size, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.attachments.Create(ctx, attachment, s.limits.UserQuota); err != nil {
+		if deleteErr := s.store.Delete(ctx, attachment.StorageKey); deleteErr != nil {
+			log.Printf("Failed to delete blob %s of rejected attachment: %v", attachment.StorageKey, deleteErr)
+		}
+		return nil, err
+	}
+
+	if err := s.sign(ctx, attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) List(ctx context.Context, userID, todoID uuid.UUID) ([]*models.Attachment, error) {
+	if _, err := s.todos.Authorize(ctx, userID, todoID, TodoActionRead); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachments.ListByTodo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		if err := s.sign(ctx, attachment); err != nil {
+			return nil, err
+		}
+	}
+	return attachments, nil
+}
+
+func (s *attachmentService) Get(ctx context.Context, userID, id uuid.UUID) (*models.Attachment, error) {
+	attachment, _, err := s.authorizeAttachment(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sign(ctx, attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	attachment, todo, err := s.authorizeAttachment(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	// Чужие файлы удаляют пользователи с правом удаления задачи
+	if attachment.UserID != userID {
+		if _, err := s.todos.Authorize(ctx, userID, todo.ID, TodoActionDelete); err != nil {
+			if errors.Is(err, repository.ErrTodoNotFound) {
+				return repository.ErrAttachmentNotFound
+			}
+			return err
+		}
+	}
+
+	if err := s.attachments.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Содержимое удаляется сразу; если хранилище недоступно, ключ остается в очереди
+	// удаления и содержимое удалит очистка корзины
+	if err := s.store.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Printf("Failed to delete blob %s, deferring to cleanup: %v", attachment.StorageKey, err)
+		return nil
+	}
+	if err := s.attachments.CompleteBlobDeletions(ctx, []string{attachment.StorageKey}); err != nil {
+		log.Printf("Failed to complete deletion of blob %s: %v", attachment.StorageKey, err)
+	}
+	return nil
+}
+
+// authorizeAttachment загружает вложение и его задачу и проверяет, что задача видна
+// пользователю. Вложение недоступной задачи возвращается как repository.ErrAttachmentNotFound.
+func (s *attachmentService) authorizeAttachment(ctx context.Context, userID, id uuid.UUID) (*models.Attachment, *models.Todo, error) {
+	attachment, err := s.attachments.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	todo, err := s.todos.Authorize(ctx, userID, attachment.TodoID, TodoActionRead)
+	if errors.Is(err, repository.ErrTodoNotFound) {
+		return nil, nil, repository.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, todo, nil
+}
+
+// sign заполняет подписанную ссылку на скачивание вложения
+func (s *attachmentService) sign(ctx context.Context, attachment *models.Attachment) error {
+	expiresAt := s.now().Add(s.limits.URLExpiration)
+	url, err := s.store.SignedURL(ctx, attachment.StorageKey, storage.DownloadOptions{
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Expires:     s.limits.URLExpiration,
+	})
+	if err != nil {
+		return err
+	}
+	attachment.URL = url
+	attachment.URLExpiresAt = &expiresAt
+	return nil
+}
+
+// detectContentType определяет тип содержимого по первым байтам файла без параметров вроде charset
+func detectContentType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// isAllowedType проверяет, входит ли тип содержимого в список допустимых
+func isAllowedType(allowed []string, contentType string) bool {
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanFilename оставляет от имени файла клиента только последний элемент пути без управляющих
+// символов и обрезает его до maxFilenameLength символов
+func cleanFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filename))
+	if filename == "" || filename == "." || filename == "/" {
+		return "file"
+	}
+	if runes := []rune(filename); len(runes) > maxFilenameLength {
+		filename = string(runes[:maxFilenameLength])
+	}
+	return filename
+}
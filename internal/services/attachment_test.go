@@ -0,0 +1,232 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader начало PNG файла, по которому определяется тип содержимого
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// attachmentStore хранит вложения и очередь удаления в памяти
+type attachmentStore struct {
+	attachments map[uuid.UUID]*models.Attachment
+	pending     []string
+}
+
+func (s *attachmentStore) Create(ctx context.Context, attachment *models.Attachment, quota int64) error {
+	used, _ := s.UsageByUser(ctx, attachment.UserID)
+	if quota > 0 && used+attachment.Size > quota {
+		return repository.ErrStorageQuotaExceeded
+	}
+	s.attachments[attachment.ID] = attachment
+	return nil
+}
+
+func (s *attachmentStore) GetByID(ctx context.Context, id uuid.UUID) (*models.Attachment, error) {
+	attachment, ok := s.attachments[id]
+	if !ok {
+		return nil, repository.ErrAttachmentNotFound
+	}
+	copied := *attachment
+	return &copied, nil
+}
+
+func (s *attachmentStore) ListByTodo(ctx context.Context, todoID uuid.UUID) ([]*models.Attachment, error) {
+	attachments := []*models.Attachment{}
+	for _, attachment := range s.attachments {
+		if attachment.TodoID == todoID {
+			copied := *attachment
+			attachments = append(attachments, &copied)
+		}
+	}
+	return attachments, nil
+}
+
+func (s *attachmentStore) Delete(ctx context.Context, id uuid.UUID) error {
+	attachment, ok := s.attachments[id]
+	if !ok {
+		return repository.ErrAttachmentNotFound
+	}
+	delete(s.attachments, id)
+	s.pending = append(s.pending, attachment.StorageKey)
+	return nil
+}
+
+func (s *attachmentStore) UsageByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var used int64
+	for _, attachment := range s.attachments {
+		if attachment.UserID == userID {
+			used += attachment.Size
+		}
+	}
+	return used, nil
+}
+
+func (s *attachmentStore) PendingBlobDeletions(ctx context.Context, limit int) ([]string, error) {
+	return s.pending[:min(limit, len(s.pending))], nil
+}
+
+func (s *attachmentStore) CompleteBlobDeletions(ctx context.Context, keys []string) error {
+	done := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		done[key] = true
+	}
+	var pending []string
+	for _, key := range s.pending {
+		if !done[key] {
+			pending = append(pending, key)
+		}
+	}
+	s.pending = pending
+	return nil
+}
+
+// blobStore хранит содержимое файлов в памяти
+type blobStore struct {
+	blobs map[string][]byte
+	err   error
+}
+
+func (s *blobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.blobs[key] = content
+	return nil
+}
+
+func (s *blobStore) Delete(ctx context.Context, key string) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *blobStore) SignedURL(ctx context.Context, key string, opts storage.DownloadOptions) (string, error) {
+	return "https://files.example.com/" + key + "?expires=" + opts.Expires.String(), nil
+}
+
+func TestAttachmentService_Upload(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 600)...)
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		filename string
+		content  []byte
+		used     int64
+		wantErr  error
+		wantType string
+		wantName string
+	}{
+		{name: "картинка от владельца", userID: ownerID, filename: "screen.png", content: png, wantType: "image/png", wantName: "screen.png"},
+		{name: "текст от редактора", userID: editorID, filename: `C:\docs\notes.txt`, content: []byte("заметки"), wantType: "text/plain", wantName: "notes.txt"},
+		{name: "тип определяется по содержимому", userID: ownerID, filename: "page.png", content: []byte("<html><body>x</body></html>"), wantErr: ErrAttachmentTypeNotAllowed},
+		{name: "файл больше лимита", userID: ownerID, filename: "big.txt", content: bytes.Repeat([]byte("a"), 1025), wantErr: ErrAttachmentTooLarge},
+		{name: "квота исчерпана", userID: ownerID, filename: "screen.png", content: png, used: 1800, wantErr: repository.ErrStorageQuotaExceeded},
+		{name: "наблюдатель не может загружать", userID: viewerID, filename: "screen.png", content: png, wantErr: repository.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{
+				editorID: models.ShareRoleEditor,
+				viewerID: models.ShareRoleViewer,
+			}}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			attachments := &attachmentStore{attachments: map[uuid.UUID]*models.Attachment{}}
+			if tt.used > 0 {
+				existing := &models.Attachment{ID: uuid.New(), TodoID: uuid.New(), UserID: tt.userID, Size: tt.used}
+				attachments.attachments[existing.ID] = existing
+			}
+			blobs := &blobStore{blobs: map[string][]byte{}}
+			service := NewAttachmentService(attachments, todos, blobs, AttachmentLimits{
+				MaxSize:       1024,
+				AllowedTypes:  []string{"image/*", "text/plain"},
+				UserQuota:     2048,
+				URLExpiration: 15 * time.Minute,
+			})
+
+			attachment, err := service.Upload(context.Background(), tt.userID, todo.ID, tt.filename,
+				int64(len(tt.content)), bytes.NewReader(tt.content))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, blobs.blobs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, attachment.ContentType)
+			assert.Equal(t, tt.wantName, attachment.Filename)
+			assert.Equal(t, tt.content, blobs.blobs[attachment.StorageKey])
+			assert.Equal(t, models.AttachmentStorageKey(todo.ID, attachment.ID), attachment.StorageKey)
+			assert.True(t, strings.HasPrefix(attachment.URL, "https://files.example.com/"+attachment.StorageKey))
+			require.NotNil(t, attachment.URLExpiresAt)
+		})
+	}
+}
+
+func TestAttachmentService_Delete(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		storeErr    error
+		wantErr     error
+		wantPending bool
+	}{
+		{name: "загрузивший удаляет свой файл", userID: viewerID},
+		{name: "редактор удаляет чужой файл", userID: editorID},
+		{name: "хранилище недоступно", userID: ownerID, storeErr: errors.New("unavailable"), wantPending: true},
+		{name: "пользователь без доступа", userID: uuid.New(), wantErr: repository.ErrAttachmentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attachment := &models.Attachment{ID: uuid.New(), TodoID: todo.ID, UserID: viewerID, Size: 10}
+			attachment.StorageKey = models.AttachmentStorageKey(todo.ID, attachment.ID)
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{
+				editorID: models.ShareRoleEditor,
+				viewerID: models.ShareRoleViewer,
+			}}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			attachments := &attachmentStore{attachments: map[uuid.UUID]*models.Attachment{attachment.ID: attachment}}
+			blobs := &blobStore{blobs: map[string][]byte{attachment.StorageKey: []byte("x")}, err: tt.storeErr}
+			service := NewAttachmentService(attachments, todos, blobs, AttachmentLimits{URLExpiration: time.Minute})
+
+			err := service.Delete(context.Background(), tt.userID, attachment.ID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Len(t, attachments.attachments, 1)
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, attachments.attachments)
+			// Если содержимое не удалось удалить сразу, ключ остается в очереди удаления
+			assert.Equal(t, tt.wantPending, len(attachments.pending) == 1)
+			assert.Equal(t, tt.wantPending, len(blobs.blobs) == 1)
+		})
+	}
+}
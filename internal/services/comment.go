@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCommentParent возвращается при ответе на удаленный комментарий
+// или комментарий другой задачи
+var ErrInvalidCommentParent = errors.New("invalid parent comment")
+
+type commentService struct {
+	comments      repository.CommentRepository
+	todos         TodoService
+	users         repository.UserRepository
+	notifications repository.NotificationRepository
+	publisher     events.Publisher
+}
+
+// NewCommentService создает новый экземпляр CommentService. Упомянутые в комментарии
+// пользователи получают уведомление во входящие, а изменения комментариев публикуются
+// через publisher, если он задан.
+func NewCommentService(
+	comments repository.CommentRepository,
+	todos TodoService,
+	users repository.UserRepository,
+	notifications repository.NotificationRepository,
+	publisher events.Publisher,
+) CommentService {
+	return &commentService{
+		comments:      comments,
+		todos:         todos,
+		users:         users,
+		notifications: notifications,
+		publisher:     publisher,
+	}
+}
+
+func (s *commentService) Create(ctx context.Context, userID, todoID uuid.UUID, req models.CommentRequest) (*models.Comment, error) {
+	todo, err := s.todos.Authorize(ctx, userID, todoID, TodoActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.comments.GetByID(ctx, *req.ParentID)
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return nil, ErrInvalidCommentParent
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.TodoID != todoID || parent.DeletedAt != nil {
+			return nil, ErrInvalidCommentParent
+		}
+	}
+
+	comment := &models.Comment{
+		ID:        uuid.New(),
+		TodoID:    todoID,
+		UserID:    userID,
+		ParentID:  req.ParentID,
+		Body:      req.Body,
+		Mentions:  s.resolveMentions(ctx, todoID, req.Body),
+		CreatedAt: time.Now(),
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	s.notifyMentions(ctx, todo, comment, nil)
+	todo.CommentCount++
+	s.publish(ctx, models.TodoEventCommentCreated, todo, comment)
+	return comment, nil
+}
+
+func (s *commentService) List(ctx context.Context, userID, todoID uuid.UUID) ([]*models.CommentNode, error) {
+	if _, err := s.todos.Authorize(ctx, userID, todoID, TodoActionRead); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.comments.ListByTodo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildCommentThreads(comments), nil
+}
+
+func (s *commentService) Update(ctx context.Context, userID, id uuid.UUID, body string) (*models.Comment, error) {
+	comment, todo, err := s.authorizeComment(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	// Изменять текст может только автор
+	if comment.UserID != userID || comment.DeletedAt != nil {
+		return nil, repository.ErrCommentNotFound
+	}
+
+	previous := comment.Mentions
+	now := time.Now()
+	comment.Body = body
+	comment.Mentions = s.resolveMentions(ctx, todo.ID, body)
+	comment.EditedAt = &now
+	if err := s.comments.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	s.notifyMentions(ctx, todo, comment, previous)
+	s.publish(ctx, models.TodoEventCommentUpdated, todo, comment)
+	return comment, nil
+}
+
+func (s *commentService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	comment, todo, err := s.authorizeComment(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if comment.DeletedAt != nil {
+		return repository.ErrCommentNotFound
+	}
+	// Чужие комментарии удаляют пользователи с правом удаления задачи
+	if comment.UserID != userID {
+		if _, err := s.todos.Authorize(ctx, userID, todo.ID, TodoActionDelete); err != nil {
+			if errors.Is(err, repository.ErrTodoNotFound) {
+				return repository.ErrCommentNotFound
+			}
+			return err
+		}
+	}
+
+	now := time.Now()
+	if err := s.comments.Delete(ctx, id, now); err != nil {
+		return err
+	}
+
+	comment.Body = ""
+	comment.Mentions = []uuid.UUID{}
+	comment.DeletedAt = &now
+	todo.CommentCount--
+	s.publish(ctx, models.TodoEventCommentDeleted, todo, comment)
+	return nil
+}
+
+func (s *commentService) ListRevisions(ctx context.Context, userID, id uuid.UUID) ([]*models.CommentRevision, error) {
+	if _, _, err := s.authorizeComment(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.comments.ListRevisions(ctx, id)
+}
+
+// authorizeComment загружает комментарий и его задачу и проверяет, что задача видна
+// пользователю. Комментарий недоступной задачи возвращается как repository.ErrCommentNotFound.
+func (s *commentService) authorizeComment(ctx context.Context, userID, id uuid.UUID) (*models.Comment, *models.Todo, error) {
+	comment, err := s.comments.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	todo, err := s.todos.Authorize(ctx, userID, comment.TodoID, TodoActionRead)
+	if errors.Is(err, repository.ErrTodoNotFound) {
+		return nil, nil, repository.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return comment, todo, nil
+}
+
+// resolveMentions находит пользователей, упомянутых в тексте по имени или email.
+// Упоминания неизвестных пользователей и пользователей, которым не видна задача, пропускаются.
+func (s *commentService) resolveMentions(ctx context.Context, todoID uuid.UUID, body string) []uuid.UUID {
+	mentions := []uuid.UUID{}
+	if s.users == nil {
+		return mentions
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, handle := range models.ParseMentions(body) {
+		var user *models.User
+		var err error
+		if strings.Contains(handle, "@") {
+			user, err = s.users.GetByEmail(ctx, handle)
+		} else {
+			user, err = s.users.GetByUsername(ctx, handle)
+		}
+		if err != nil || seen[user.ID] {
+			continue
+		}
+		if _, err := s.todos.Authorize(ctx, user.ID, todoID, TodoActionRead); err != nil {
+			continue
+		}
+		seen[user.ID] = true
+		mentions = append(mentions, user.ID)
+	}
+	return mentions
+}
+
+// notifyMentions уведомляет упомянутых в комментарии пользователей, кроме автора и уже
+// упомянутых в прежнем тексте previous. Ошибка уведомления не отменяет изменение комментария.
+func (s *commentService) notifyMentions(ctx context.Context, todo *models.Todo, comment *models.Comment, previous []uuid.UUID) {
+	if s.notifications == nil {
+		return
+	}
+
+	notified := make(map[uuid.UUID]bool, len(previous)+1)
+	notified[comment.UserID] = true
+	for _, userID := range previous {
+		notified[userID] = true
+	}
+	for _, userID := range comment.Mentions {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+
+		notification := &models.Notification{
+			ID:        uuid.New(),
+			UserID:    userID,
+			TodoID:    &todo.ID,
+			Title:     "New mention",
+			Body:      fmt.Sprintf("You have been mentioned in a comment on the todo %q", todo.Title),
+			CreatedAt: time.Now(),
+		}
+		if err := s.notifications.Create(ctx, notification); err != nil {
+			log.Printf("Failed to notify mentioned user: %v", err)
+		}
+	}
+}
+
+// publish отправляет событие комментария владельцу задачи, участникам общего доступа, исполнителям
+// и автору комментария. Изменение уже сохранено, поэтому ошибка только логируется.
+func (s *commentService) publish(ctx context.Context, eventType string, todo *models.Todo, comment *models.Comment) {
+	if s.publisher == nil {
+		return
+	}
+
+	todoSnapshot, commentSnapshot := *todo, *comment
+	recipients := append([]uuid.UUID{todo.UserID, comment.UserID}, todo.Assignees...)
+	participants, err := s.todos.Participants(ctx, todo)
+	if err != nil {
+		log.Printf("Failed to load participants of todo %s for comment event: %v", todo.ID, err)
+	}
+	recipients = append(recipients, participants...)
+	published := make(map[uuid.UUID]bool, len(recipients))
+	for _, userID := range recipients {
+		if published[userID] {
+			continue
+		}
+		published[userID] = true
+
+		event := models.NewCommentEvent(eventType, userID, &todoSnapshot, &commentSnapshot)
+		if err := s.publisher.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish %s event for comment %s: %v", eventType, comment.ID, err)
+		}
+	}
+}
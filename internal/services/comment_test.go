@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commentStore хранит комментарии в памяти
+type commentStore struct {
+	repository.CommentRepository
+	comments map[uuid.UUID]*models.Comment
+	deleted  []uuid.UUID
+}
+
+func (s *commentStore) Create(ctx context.Context, comment *models.Comment) error {
+	s.comments[comment.ID] = comment
+	return nil
+}
+
+func (s *commentStore) GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error) {
+	comment, ok := s.comments[id]
+	if !ok {
+		return nil, repository.ErrCommentNotFound
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (s *commentStore) Update(ctx context.Context, comment *models.Comment) error {
+	s.comments[comment.ID] = comment
+	return nil
+}
+
+func (s *commentStore) Delete(ctx context.Context, id uuid.UUID, deletedAt time.Time) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+// userStore находит пользователей по имени и email
+type userStore struct {
+	repository.UserRepository
+	users []*models.User
+}
+
+func (s *userStore) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	for _, user := range s.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (s *userStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// eventRecorder запоминает опубликованные события
+type eventRecorder struct {
+	events []models.TodoEvent
+}
+
+func (r *eventRecorder) Publish(ctx context.Context, event models.TodoEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestCommentService_Create(t *testing.T) {
+	owner := &models.User{ID: uuid.New(), Username: "anna", Email: "anna@example.com"}
+	viewer := &models.User{ID: uuid.New(), Username: "boris", Email: "boris@example.com"}
+	stranger := &models.User{ID: uuid.New(), Username: "vera", Email: "vera@example.com"}
+	todo := &models.Todo{ID: uuid.New(), UserID: owner.ID, Title: "Отчет"}
+	otherTodoID := uuid.New()
+
+	parent := &models.Comment{ID: uuid.New(), TodoID: todo.ID, UserID: owner.ID, Body: "Начало"}
+	foreign := &models.Comment{ID: uuid.New(), TodoID: otherTodoID, UserID: owner.ID, Body: "Чужой"}
+	deletedAt := time.Now()
+	deleted := &models.Comment{ID: uuid.New(), TodoID: todo.ID, UserID: owner.ID, DeletedAt: &deletedAt}
+	missingID := uuid.New()
+
+	tests := []struct {
+		name         string
+		userID       uuid.UUID
+		req          models.CommentRequest
+		wantErr      error
+		wantMentions []uuid.UUID
+	}{
+		{
+			name:         "упоминания по имени и email",
+			userID:       owner.ID,
+			req:          models.CommentRequest{Body: "@Boris и @boris@example.com, посмотрите"},
+			wantMentions: []uuid.UUID{viewer.ID},
+		},
+		{
+			name:         "пользователь без доступа не упоминается",
+			userID:       viewer.ID,
+			req:          models.CommentRequest{Body: "@vera @anna", ParentID: &parent.ID},
+			wantMentions: []uuid.UUID{owner.ID},
+		},
+		{
+			name:    "ответ на комментарий другой задачи",
+			userID:  owner.ID,
+			req:     models.CommentRequest{Body: "Ответ", ParentID: &foreign.ID},
+			wantErr: ErrInvalidCommentParent,
+		},
+		{
+			name:    "ответ на удаленный комментарий",
+			userID:  owner.ID,
+			req:     models.CommentRequest{Body: "Ответ", ParentID: &deleted.ID},
+			wantErr: ErrInvalidCommentParent,
+		},
+		{
+			name:    "ответ на несуществующий комментарий",
+			userID:  owner.ID,
+			req:     models.CommentRequest{Body: "Ответ", ParentID: &missingID},
+			wantErr: ErrInvalidCommentParent,
+		},
+		{
+			name:    "задача недоступна",
+			userID:  stranger.ID,
+			req:     models.CommentRequest{Body: "Привет"},
+			wantErr: repository.ErrTodoNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{viewer.ID: models.ShareRoleViewer}}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			comments := &commentStore{comments: map[uuid.UUID]*models.Comment{
+				parent.ID: parent, foreign.ID: foreign, deleted.ID: deleted,
+			}}
+			notifications := &notificationStore{}
+			publisher := &eventRecorder{}
+			service := NewCommentService(comments, todos,
+				&userStore{users: []*models.User{owner, viewer, stranger}}, notifications, publisher)
+
+			comment, err := service.Create(context.Background(), tt.userID, todo.ID, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Len(t, comments.comments, 3)
+				assert.Empty(t, publisher.events)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMentions, comment.Mentions)
+
+			var notified []uuid.UUID
+			for _, notification := range notifications.created {
+				assert.Equal(t, "New mention", notification.Title)
+				notified = append(notified, notification.UserID)
+			}
+			assert.Equal(t, tt.wantMentions, notified)
+
+			// Событие получают владелец задачи, участник общего доступа и автор комментария
+			var recipients []uuid.UUID
+			for _, event := range publisher.events {
+				assert.Equal(t, models.TodoEventCommentCreated, event.Type)
+				assert.Equal(t, comment.ID, event.Comment.ID)
+				assert.Equal(t, 1, event.Todo.CommentCount)
+				recipients = append(recipients, event.UserID)
+			}
+			assert.ElementsMatch(t, uniqueIDs(owner.ID, viewer.ID, tt.userID), recipients)
+		})
+	}
+}
+
+func TestCommentService_UpdateAndDelete(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет", Assignees: []uuid.UUID{viewerID}}
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		delete    bool
+		wantErr   error
+		wantEvent string
+	}{
+		{name: "автор меняет текст", userID: viewerID, wantEvent: models.TodoEventCommentUpdated},
+		{name: "владелец задачи не меняет чужой текст", userID: ownerID, wantErr: repository.ErrCommentNotFound},
+		{name: "автор удаляет комментарий", userID: viewerID, delete: true, wantEvent: models.TodoEventCommentDeleted},
+		{name: "редактор удаляет чужой комментарий", userID: editorID, delete: true, wantEvent: models.TodoEventCommentDeleted},
+		{name: "пользователь без доступа к задаче", userID: uuid.New(), delete: true, wantErr: repository.ErrCommentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := &models.Comment{ID: uuid.New(), TodoID: todo.ID, UserID: viewerID, Body: "Черновик", Mentions: []uuid.UUID{}}
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{
+				editorID: models.ShareRoleEditor,
+				viewerID: models.ShareRoleViewer,
+			}}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			comments := &commentStore{comments: map[uuid.UUID]*models.Comment{comment.ID: comment}}
+			publisher := &eventRecorder{}
+			service := NewCommentService(comments, todos, nil, nil, publisher)
+
+			if tt.delete {
+				err := service.Delete(context.Background(), tt.userID, comment.ID)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+					assert.Empty(t, comments.deleted)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, []uuid.UUID{comment.ID}, comments.deleted)
+			} else {
+				updated, err := service.Update(context.Background(), tt.userID, comment.ID, "Итог")
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+					assert.Equal(t, "Черновик", comments.comments[comment.ID].Body)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, "Итог", updated.Body)
+				assert.NotNil(t, updated.EditedAt)
+			}
+
+			// Исполнитель задачи — автор комментария, поэтому события получают владелец
+			// и участники общего доступа
+			var recipients []uuid.UUID
+			for _, event := range publisher.events {
+				assert.Equal(t, tt.wantEvent, event.Type)
+				recipients = append(recipients, event.UserID)
+			}
+			assert.ElementsMatch(t, []uuid.UUID{ownerID, editorID, viewerID}, recipients)
+		})
+	}
+}
+
+// uniqueIDs возвращает идентификаторы без повторов в исходном порядке
+func uniqueIDs(ids ...uuid.UUID) []uuid.UUID {
+	var unique []uuid.UUID
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -1,37 +1,116 @@
 package services
 
 import (
-	"TODO-list/internal/models"
-	"TODO-list/internal/repository"
 	"context"
+	"io"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
 	"github.com/google/uuid"
 )
 
 type Services struct {
-	User UserService
-	Todo TodoService
+	User       UserService
+	Todo       TodoService
+	Share      ShareService
+	Assignment AssignmentService
+	Comment    CommentService
 }
 
 type UserService interface {
-	Create(ctx context.Context, userCreate *models.UserCreate) error
+	Create(ctx context.Context, input *models.RegisterRequest) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// TodoService выполняет операции над задачами от имени пользователя userID и проверяет
+// его доступ к задаче: владельцу задачи разрешены все действия, участникам общего доступа
+// к задаче или ее проекту — действия их роли. Задача, к которой у пользователя нет доступа,
+// не отличается от несуществующей: возвращается repository.ErrTodoNotFound.
 type TodoService interface {
+	// Create создает задачу пользователя todo.UserID
 	Create(ctx context.Context, todo *models.Todo) error
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error)
+	// Authorize загружает задачу и проверяет, что пользователь может выполнить над ней action
+	Authorize(ctx context.Context, userID, id uuid.UUID, action TodoAction) (*models.Todo, error)
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Todo, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error)
-	Update(ctx context.Context, todo *models.Todo) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Update сохраняет задачу; владелец задачи при этом не меняется
+	Update(ctx context.Context, userID uuid.UUID, todo *models.Todo) error
+	// Delete перемещает задачу в корзину; задача с ненулевой version удаляется,
+	// только если ее версия не изменилась
+	Delete(ctx context.Context, userID, id uuid.UUID, version int) error
+	// Participants возвращает владельца задачи и участников общего доступа к ней
+	Participants(ctx context.Context, todo *models.Todo) ([]uuid.UUID, error)
+	GetSubtree(ctx context.Context, userID, id uuid.UUID) ([]*models.Todo, error)
+	MoveToProject(ctx context.Context, userID, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error)
 	GetGroupedTodos(ctx context.Context, userID uuid.UUID) ([]models.TodoGroup, error)
 }
 
+// ShareService управляет приглашениями и общим доступом к проектам и задачам. Приглашать,
+// менять роли и отзывать доступ могут владелец и участники с ролью owner; недоступные проекты,
+// задачи и приглашения не отличаются от несуществующих.
+type ShareService interface {
+	ShareTodo(ctx context.Context, userID, todoID uuid.UUID, req models.ShareRequest) (*models.Share, error)
+	ShareProject(ctx context.Context, userID, projectID uuid.UUID, req models.ShareRequest) (*models.Share, error)
+	ListTodoShares(ctx context.Context, userID, todoID uuid.UUID) ([]*models.Share, error)
+	ListProjectShares(ctx context.Context, userID, projectID uuid.UUID) ([]*models.Share, error)
+	UpdateRole(ctx context.Context, userID, id uuid.UUID, role string) (*models.Share, error)
+	// Revoke отзывает приглашение; участник может отозвать и собственный доступ
+	Revoke(ctx context.Context, userID, id uuid.UUID) error
+	// ListInvitations возвращает ожидающие ответа приглашения на адрес пользователя
+	ListInvitations(ctx context.Context, userID uuid.UUID) ([]*models.Share, error)
+	// Respond принимает или отклоняет приглашение на адрес пользователя
+	Respond(ctx context.Context, userID, id uuid.UUID, accept bool) (*models.Share, error)
+}
+
+// AssignmentService назначает исполнителей задач. Назначать и снимать исполнителей могут
+// пользователи с правом изменения задачи, а исполнителем может быть только пользователь,
+// которому видна задача: ее владелец или участник общего доступа. Методы возвращают задачу
+// с обновленным списком исполнителей.
+type AssignmentService interface {
+	// Assign назначает исполнителей; уже назначенные исполнители пропускаются
+	Assign(ctx context.Context, userID, todoID uuid.UUID, assigneeIDs []uuid.UUID) (*models.Todo, error)
+	// Unassign снимает исполнителя; исполнитель может снять себя сам
+	Unassign(ctx context.Context, userID, todoID, assigneeID uuid.UUID) (*models.Todo, error)
+}
+
+// CommentService управляет комментариями к задачам. Читать и оставлять комментарии могут все,
+// кому видна задача; изменять текст может только автор, а удалять — автор и пользователи с правом
+// удаления задачи. Комментарии недоступных задач не отличаются от несуществующих.
+type CommentService interface {
+	Create(ctx context.Context, userID, todoID uuid.UUID, req models.CommentRequest) (*models.Comment, error)
+	// List возвращает ветки обсуждения задачи, включая удаленные комментарии без текста
+	List(ctx context.Context, userID, todoID uuid.UUID) ([]*models.CommentNode, error)
+	// Update заменяет текст комментария, сохраняя прежний в истории изменений
+	Update(ctx context.Context, userID, id uuid.UUID, body string) (*models.Comment, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// ListRevisions возвращает прежние версии текста комментария
+	ListRevisions(ctx context.Context, userID, id uuid.UUID) ([]*models.CommentRevision, error)
+}
+
+// AttachmentService управляет вложениями задач. Загружать файлы могут пользователи с правом
+// изменения задачи, просматривать и скачивать — все, кому видна задача, а удалять — загрузивший
+// файл и пользователи с правом удаления задачи. Вложения возвращаются с подписанными ссылками
+// на скачивание.
+type AttachmentService interface {
+	// Upload сохраняет файл size байт из content, проверяя размер, тип содержимого и квоту пользователя
+	Upload(ctx context.Context, userID, todoID uuid.UUID, filename string, size int64, content io.Reader) (*models.Attachment, error)
+	List(ctx context.Context, userID, todoID uuid.UUID) ([]*models.Attachment, error)
+	// Get возвращает вложение с новой ссылкой на скачивание
+	Get(ctx context.Context, userID, id uuid.UUID) (*models.Attachment, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+}
+
 func NewServices(repos *repository.Repositories) *Services {
+	todos := NewTodoService(repos.Todo, repos.Share, repos.Project)
 	return &Services{
-		User: NewUserService(repos.User),
-		Todo: NewTodoService(repos.Todo),
+		User:       NewUserService(repos.User),
+		Todo:       todos,
+		Share:      NewShareService(repos.Share, todos, repos.Project, repos.User, repos.Notification),
+		Assignment: NewAssignmentService(repos.Assignee, todos, repos.Notification),
+		Comment:    NewCommentService(repos.Comment, todos, repos.User, repos.Notification, nil),
 	}
 }
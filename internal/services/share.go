@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+)
+
+type shareService struct {
+	shares        repository.ShareRepository
+	todos         TodoService
+	projects      repository.ProjectRepository
+	users         repository.UserRepository
+	notifications repository.NotificationRepository
+}
+
+// NewShareService создает новый экземпляр ShareService. Приглашенным пользователям,
+// которые уже зарегистрированы, отправляется уведомление во входящие.
+func NewShareService(
+	shares repository.ShareRepository,
+	todos TodoService,
+	projects repository.ProjectRepository,
+	users repository.UserRepository,
+	notifications repository.NotificationRepository,
+) ShareService {
+	return &shareService{
+		shares:        shares,
+		todos:         todos,
+		projects:      projects,
+		users:         users,
+		notifications: notifications,
+	}
+}
+
+func (s *shareService) ShareTodo(ctx context.Context, userID, todoID uuid.UUID, req models.ShareRequest) (*models.Share, error) {
+	todo, err := s.todos.Authorize(ctx, userID, todoID, TodoActionShare)
+	if err != nil {
+		return nil, err
+	}
+
+	share := newShare(userID, req)
+	share.TodoID = &todo.ID
+	if err := s.shares.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	s.notifyInvitee(ctx, req.Email, &todo.ID, fmt.Sprintf("You have been invited to the todo %q as %s", todo.Title, share.Role))
+	return share, nil
+}
+
+func (s *shareService) ShareProject(ctx context.Context, userID, projectID uuid.UUID, req models.ShareRequest) (*models.Share, error) {
+	project, err := s.authorizeProject(ctx, userID, projectID, models.ShareRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	share := newShare(userID, req)
+	share.ProjectID = &project.ID
+	if err := s.shares.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	s.notifyInvitee(ctx, req.Email, nil, fmt.Sprintf("You have been invited to the project %q as %s", project.Name, share.Role))
+	return share, nil
+}
+
+func (s *shareService) ListTodoShares(ctx context.Context, userID, todoID uuid.UUID) ([]*models.Share, error) {
+	if _, err := s.todos.Authorize(ctx, userID, todoID, TodoActionRead); err != nil {
+		return nil, err
+	}
+	return s.shares.ListByTodo(ctx, todoID)
+}
+
+func (s *shareService) ListProjectShares(ctx context.Context, userID, projectID uuid.UUID) ([]*models.Share, error) {
+	if _, err := s.authorizeProject(ctx, userID, projectID, models.ShareRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.shares.ListByProject(ctx, projectID)
+}
+
+func (s *shareService) UpdateRole(ctx context.Context, userID, id uuid.UUID, role string) (*models.Share, error) {
+	share, err := s.shares.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeManage(ctx, userID, share); err != nil {
+		return nil, err
+	}
+	return s.shares.UpdateRole(ctx, id, role)
+}
+
+func (s *shareService) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	share, err := s.shares.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Участник может сам отказаться от принятого доступа
+	if share.UserID == nil || *share.UserID != userID {
+		if err := s.authorizeManage(ctx, userID, share); err != nil {
+			return err
+		}
+	}
+	return s.shares.Delete(ctx, id)
+}
+
+func (s *shareService) ListInvitations(ctx context.Context, userID uuid.UUID) ([]*models.Share, error) {
+	return s.shares.ListInvitations(ctx, userID)
+}
+
+func (s *shareService) Respond(ctx context.Context, userID, id uuid.UUID, accept bool) (*models.Share, error) {
+	status := models.ShareStatusDeclined
+	if accept {
+		status = models.ShareStatusAccepted
+	}
+	return s.shares.Respond(ctx, id, userID, status, time.Now())
+}
+
+// newShare создает приглашение от пользователя userID, ожидающее ответа
+func newShare(userID uuid.UUID, req models.ShareRequest) *models.Share {
+	return &models.Share{
+		ID:        uuid.New(),
+		InvitedBy: userID,
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Role:      req.Role,
+		Status:    models.ShareStatusPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// authorizeProject загружает проект и проверяет, что пользователь — его владелец или участник
+// с ролью не ниже minRole. Недоступный проект возвращается как repository.ErrProjectNotFound.
+func (s *shareService) authorizeProject(ctx context.Context, userID, projectID uuid.UUID, minRole string) (*models.Project, error) {
+	project, err := s.projects.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.UserID == userID {
+		return project, nil
+	}
+
+	role, err := s.shares.GetProjectRole(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" || models.ShareRoleRank(role) < models.ShareRoleRank(minRole) {
+		return nil, repository.ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// authorizeManage проверяет, что пользователь может управлять доступом к задаче или проекту
+// приглашения. Если не может, приглашение возвращается как repository.ErrShareNotFound.
+func (s *shareService) authorizeManage(ctx context.Context, userID uuid.UUID, share *models.Share) error {
+	var err error
+	if share.TodoID != nil {
+		_, err = s.todos.Authorize(ctx, userID, *share.TodoID, TodoActionShare)
+	} else {
+		_, err = s.authorizeProject(ctx, userID, *share.ProjectID, models.ShareRoleOwner)
+	}
+	if errors.Is(err, repository.ErrTodoNotFound) || errors.Is(err, repository.ErrProjectNotFound) {
+		return repository.ErrShareNotFound
+	}
+	return err
+}
+
+// notifyInvitee отправляет уведомление о приглашении во входящие пользователя с адресом email,
+// если он зарегистрирован. Ошибка уведомления не отменяет приглашение.
+func (s *shareService) notifyInvitee(ctx context.Context, email string, todoID *uuid.UUID, body string) {
+	if s.users == nil || s.notifications == nil {
+		return
+	}
+	user, err := s.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return
+	}
+
+	notification := &models.Notification{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TodoID:    todoID,
+		Title:     "New invitation",
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := s.notifications.Create(ctx, notification); err != nil {
+		log.Printf("Failed to notify invitee: %v", err)
+	}
+}
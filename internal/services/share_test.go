@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectStore хранит проекты в памяти
+type projectStore struct {
+	repository.ProjectRepository
+	projects map[uuid.UUID]*models.Project
+}
+
+func (s *projectStore) GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, repository.ErrProjectNotFound
+	}
+	return project, nil
+}
+
+func TestShareService_ShareProject(t *testing.T) {
+	ownerID := uuid.New()
+	project := &models.Project{ID: uuid.New(), UserID: ownerID, Name: "Работа"}
+
+	tests := []struct {
+		name    string
+		role    string
+		owner   bool
+		wantErr error
+	}{
+		{name: "владелец проекта", owner: true},
+		{name: "участник с ролью owner", role: models.ShareRoleOwner},
+		{name: "редактор", role: models.ShareRoleEditor, wantErr: repository.ErrProjectNotFound},
+		{name: "без доступа", wantErr: repository.ErrProjectNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			if tt.owner {
+				userID = ownerID
+			}
+			shares := &shareStore{projectRoles: map[uuid.UUID]string{userID: tt.role}}
+			service := NewShareService(shares, nil, &projectStore{projects: map[uuid.UUID]*models.Project{project.ID: project}}, nil, nil)
+
+			share, err := service.ShareProject(context.Background(), userID, project.ID,
+				models.ShareRequest{Email: " Anna@Example.com ", Role: models.ShareRoleViewer})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, shares.created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "anna@example.com", share.Email)
+			assert.Equal(t, models.ShareStatusPending, share.Status)
+			assert.Equal(t, userID, share.InvitedBy)
+			assert.Equal(t, &project.ID, share.ProjectID)
+			assert.Len(t, shares.created, 1)
+		})
+	}
+}
+
+func TestShareService_Revoke(t *testing.T) {
+	ownerID := uuid.New()
+	participantID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+	share := &models.Share{ID: uuid.New(), TodoID: &todo.ID, UserID: &participantID, Role: models.ShareRoleEditor}
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{name: "владелец задачи", userID: ownerID},
+		{name: "участник отказывается от доступа", userID: participantID},
+		{name: "посторонний пользователь", userID: uuid.New(), wantErr: repository.ErrShareNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := &shareStore{
+				todoRoles: map[uuid.UUID]string{participantID: models.ShareRoleEditor},
+				shares:    map[uuid.UUID]*models.Share{share.ID: share},
+			}
+			todos := NewTodoService(&todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}, shares, nil)
+			service := NewShareService(shares, todos, nil, nil, nil)
+
+			err := service.Revoke(context.Background(), tt.userID, share.ID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, shares.deleted)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []uuid.UUID{share.ID}, shares.deleted)
+		})
+	}
+}
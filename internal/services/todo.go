@@ -1,104 +1,179 @@
 package services
 
 import (
-	"TODO-list/internal/models"
-	"TODO-list/internal/repository"
 	"context"
 	"errors"
-	"github.com/google/uuid"
 	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+)
+
+// TodoAction действие над задачей, доступ к которому проверяет TodoService
+type TodoAction int
+
+const (
+	// TodoActionRead чтение задачи и ее подзадач
+	TodoActionRead TodoAction = iota
+	// TodoActionWrite изменение задачи и перенос в другой проект
+	TodoActionWrite
+	// TodoActionDelete перемещение задачи в корзину
+	TodoActionDelete
+	// TodoActionShare управление общим доступом к задаче
+	TodoActionShare
 )
 
+// todoActionRoles минимальная роль участника, которой разрешено действие
+var todoActionRoles = map[TodoAction]string{
+	TodoActionRead:   models.ShareRoleViewer,
+	TodoActionWrite:  models.ShareRoleEditor,
+	TodoActionDelete: models.ShareRoleEditor,
+	TodoActionShare:  models.ShareRoleOwner,
+}
+
 type todoService struct {
-	repo repository.TodoRepository
+	repo     repository.TodoRepository
+	shares   repository.ShareRepository
+	projects repository.ProjectRepository
 }
 
-func NewTodoService(repo repository.TodoRepository) TodoService {
+// NewTodoService создает новый экземпляр TodoService. Если shares равен nil,
+// задачи доступны только их владельцам. Если projects равен nil, проект новой задачи
+// проверяет только репозиторий задач.
+func NewTodoService(repo repository.TodoRepository, shares repository.ShareRepository, projects repository.ProjectRepository) TodoService {
 	return &todoService{
-		repo: repo,
+		repo:     repo,
+		shares:   shares,
+		projects: projects,
 	}
 }
 
+// Create сохраняет задачу, созданную пользователем todo.UserID. Задача в общем проекте или
+// подзадача общей задачи принадлежит владельцу проекта или родительской задачи, чтобы тот видел
+// ее так же, как свои задачи; создающему нужна в них роль не ниже editor.
 func (s *todoService) Create(ctx context.Context, todo *models.Todo) error {
-	if todo.Title == "" {
-		return errors.New("title is required")
+	userID := todo.UserID
+	if todo.ParentID != nil {
+		parent, err := s.Authorize(ctx, userID, *todo.ParentID, TodoActionWrite)
+		if errors.Is(err, repository.ErrTodoNotFound) {
+			return repository.ErrInvalidParent
+		}
+		if err != nil {
+			return err
+		}
+		todo.UserID = parent.UserID
 	}
-
-	// Генерация UUID если не установлен
-	if todo.ID == uuid.Nil {
-		todo.ID = uuid.New()
+	if todo.ProjectID != nil && s.projects != nil {
+		project, err := s.authorizeProject(ctx, userID, *todo.ProjectID)
+		if err != nil {
+			return err
+		}
+		todo.UserID = project.UserID
 	}
+	return s.repo.Create(ctx, todo)
+}
 
-	// Установка времени создания и обновления
-	now := time.Now()
-	todo.CreatedAt = now
-	todo.UpdatedAt = now
+// authorizeProject загружает проект новой задачи и проверяет, что пользователь — его владелец
+// или участник с ролью не ниже editor. Недоступный проект возвращается как repository.ErrInvalidProject.
+func (s *todoService) authorizeProject(ctx context.Context, userID, projectID uuid.UUID) (*models.Project, error) {
+	project, err := s.projects.GetByID(ctx, projectID)
+	if errors.Is(err, repository.ErrProjectNotFound) {
+		return nil, repository.ErrInvalidProject
+	}
+	if err != nil {
+		return nil, err
+	}
+	if project.UserID == userID {
+		return project, nil
+	}
+	if s.shares == nil {
+		return nil, repository.ErrInvalidProject
+	}
 
-	// Установка статуса по умолчанию
-	if todo.Status == "" {
-		todo.Status = "new"
+	role, err := s.shares.GetProjectRole(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
 	}
+	if models.ShareRoleRank(role) < models.ShareRoleRank(models.ShareRoleEditor) {
+		return nil, repository.ErrInvalidProject
+	}
+	return project, nil
+}
 
-	// Установка приоритета по умолчанию
-	if todo.Priority == "" {
-		todo.Priority = "medium"
+func (s *todoService) Authorize(ctx context.Context, userID, id uuid.UUID, action TodoAction) (*models.Todo, error) {
+	todo, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if todo.UserID == userID {
+		return todo, nil
+	}
+	if s.shares == nil {
+		return nil, repository.ErrTodoNotFound
 	}
 
-	return s.repo.Create(ctx, todo)
+	role, err := s.shares.GetTodoRole(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" || models.ShareRoleRank(role) < models.ShareRoleRank(todoActionRoles[action]) {
+		return nil, repository.ErrTodoNotFound
+	}
+	return todo, nil
 }
 
-func (s *todoService) GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
-	return s.repo.GetByID(ctx, id)
+func (s *todoService) GetByID(ctx context.Context, userID, id uuid.UUID) (*models.Todo, error) {
+	return s.Authorize(ctx, userID, id, TodoActionRead)
 }
 
 func (s *todoService) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Todo, error) {
 	return s.repo.GetByUserID(ctx, userID)
 }
 
-func (s *todoService) Update(ctx context.Context, todo *models.Todo) error {
-	if todo.Title == "" {
-		return errors.New("title is required")
+func (s *todoService) Update(ctx context.Context, userID uuid.UUID, todo *models.Todo) error {
+	// Доступ проверяется по сохраненной задаче, а не по переданной
+	current, err := s.Authorize(ctx, userID, todo.ID, TodoActionWrite)
+	if err != nil {
+		return err
 	}
-
-	// Проверка валидности статуса и приоритета
-	if !isValidStatus(todo.Status) {
-		return errors.New("invalid status")
-	}
-	if !isValidPriority(todo.Priority) {
-		return errors.New("invalid priority")
-	}
-
-	// Обновление времени изменения
-	todo.UpdatedAt = time.Now()
-
+	todo.UserID = current.UserID
 	return s.repo.Update(ctx, todo)
 }
 
-func (s *todoService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+func (s *todoService) Delete(ctx context.Context, userID, id uuid.UUID, version int) error {
+	if _, err := s.Authorize(ctx, userID, id, TodoActionDelete); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id, version)
 }
 
-func (s *todoService) GetGroupedTodos(ctx context.Context, userID uuid.UUID) ([]models.TodoGroup, error) {
-	return s.repo.GetGroupedTodos(ctx, userID)
+func (s *todoService) Participants(ctx context.Context, todo *models.Todo) ([]uuid.UUID, error) {
+	participants := []uuid.UUID{todo.UserID}
+	if s.shares == nil {
+		return participants, nil
+	}
+	shared, err := s.shares.ListTodoParticipants(ctx, todo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append(participants, shared...), nil
 }
 
-// Вспомогательные функции для валидации
-func isValidStatus(status string) bool {
-	validStatuses := []string{"new", "in_progress", "done"}
-	for _, s := range validStatuses {
-		if status == s {
-			return true
-		}
+func (s *todoService) GetSubtree(ctx context.Context, userID, id uuid.UUID) ([]*models.Todo, error) {
+	if _, err := s.Authorize(ctx, userID, id, TodoActionRead); err != nil {
+		return nil, err
 	}
-	return false
+	return s.repo.GetSubtree(ctx, id)
 }
 
-func isValidPriority(priority string) bool {
-	validPriorities := []string{"low", "medium", "high"}
-	for _, p := range validPriorities {
-		if priority == p {
-			return true
-		}
+func (s *todoService) MoveToProject(ctx context.Context, userID, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	if _, err := s.Authorize(ctx, userID, id, TodoActionWrite); err != nil {
+		return nil, err
 	}
-	return false
+	return s.repo.MoveToProject(ctx, id, projectID, updatedAt)
+}
+
+func (s *todoService) GetGroupedTodos(ctx context.Context, userID uuid.UUID) ([]models.TodoGroup, error) {
+	return s.repo.GetGroupedTodos(ctx, userID, models.TodoFilter{})
 }
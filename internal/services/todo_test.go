@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// todoStore хранит задачи в памяти и записывает изменения
+type todoStore struct {
+	repository.TodoRepository
+	todos   map[uuid.UUID]*models.Todo
+	created []*models.Todo
+	updated []*models.Todo
+	deleted []uuid.UUID
+	moved   []uuid.UUID
+}
+
+func (s *todoStore) Create(ctx context.Context, todo *models.Todo) error {
+	s.created = append(s.created, todo)
+	s.todos[todo.ID] = todo
+	return nil
+}
+
+func (s *todoStore) GetByID(ctx context.Context, id uuid.UUID) (*models.Todo, error) {
+	todo, ok := s.todos[id]
+	if !ok {
+		return nil, repository.ErrTodoNotFound
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (s *todoStore) Update(ctx context.Context, todo *models.Todo) error {
+	s.updated = append(s.updated, todo)
+	return nil
+}
+
+func (s *todoStore) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func (s *todoStore) GetSubtree(ctx context.Context, id uuid.UUID) ([]*models.Todo, error) {
+	return []*models.Todo{s.todos[id]}, nil
+}
+
+func (s *todoStore) MoveToProject(ctx context.Context, id, projectID uuid.UUID, updatedAt time.Time) ([]*models.Todo, error) {
+	s.moved = append(s.moved, id)
+	return []*models.Todo{s.todos[id]}, nil
+}
+
+func TestTodoService_Access(t *testing.T) {
+	ownerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		todoID  uuid.UUID
+		wantErr error
+	}{
+		{name: "владелец", userID: ownerID, todoID: todo.ID},
+		{name: "другой пользователь", userID: uuid.New(), todoID: todo.ID, wantErr: repository.ErrTodoNotFound},
+		{name: "задача не существует", userID: ownerID, todoID: uuid.New(), wantErr: repository.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}
+			service := NewTodoService(store, nil, nil)
+			ctx := context.Background()
+
+			got, err := service.GetByID(ctx, tt.userID, tt.todoID)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				require.NotNil(t, got)
+				assert.Equal(t, todo.ID, got.ID)
+			}
+
+			_, err = service.GetSubtree(ctx, tt.userID, tt.todoID)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			err = service.Update(ctx, tt.userID, &models.Todo{ID: tt.todoID, UserID: tt.userID, Title: "План"})
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			_, err = service.MoveToProject(ctx, tt.userID, tt.todoID, uuid.New(), time.Now())
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			err = service.Delete(ctx, tt.userID, tt.todoID, 0)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			if tt.wantErr != nil {
+				assert.Empty(t, store.updated)
+				assert.Empty(t, store.moved)
+				assert.Empty(t, store.deleted)
+				return
+			}
+			require.Len(t, store.updated, 1)
+			assert.Equal(t, []uuid.UUID{todo.ID}, store.moved)
+			assert.Equal(t, []uuid.UUID{todo.ID}, store.deleted)
+		})
+	}
+}
+
+func TestTodoService_UpdateKeepsOwner(t *testing.T) {
+	ownerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+	store := &todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}
+
+	err := NewTodoService(store, nil, nil).Update(context.Background(), ownerID, &models.Todo{ID: todo.ID, UserID: uuid.New(), Title: "План"})
+	require.NoError(t, err)
+	require.Len(t, store.updated, 1)
+	assert.Equal(t, ownerID, store.updated[0].UserID)
+}
+
+func TestTodoService_UpdateParentChecksActor(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	shared := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Релиз"}
+	private := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Личное"}
+
+	tests := []struct {
+		name    string
+		actorID uuid.UUID
+		wantErr error
+	}{
+		{name: "владелец перемещает задачу", actorID: ownerID},
+		{name: "редактор не может переместить задачу под закрытую", actorID: editorID, wantErr: repository.ErrInvalidParent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &todoStore{todos: map[uuid.UUID]*models.Todo{shared.ID: shared, private.ID: private}}
+			shares := &shareStore{
+				todoRoles:   map[uuid.UUID]string{editorID: models.ShareRoleEditor},
+				sharedTodos: map[uuid.UUID]bool{shared.ID: true},
+			}
+			repo := repository.NewSubtaskTodoRepository(store, shares, nil, models.SubtaskPolicies{})
+			ctx := models.WithActor(context.Background(), models.Actor{UserID: &tt.actorID, Source: models.ChangeSourceREST})
+
+			err := NewTodoService(repo, shares, nil).Update(ctx, tt.actorID, &models.Todo{ID: shared.ID, ParentID: &private.ID, Title: "Релиз"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, store.updated)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, store.updated, 1)
+		})
+	}
+}
+
+// shareStore хранит роли участников в памяти
+type shareStore struct {
+	repository.ShareRepository
+	todoRoles map[uuid.UUID]string
+	// sharedTodos ограничивает роли todoRoles этими задачами; nil — роли действуют для всех задач
+	sharedTodos  map[uuid.UUID]bool
+	projectRoles map[uuid.UUID]string
+	shares       map[uuid.UUID]*models.Share
+	created      []*models.Share
+	deleted      []uuid.UUID
+}
+
+func (s *shareStore) GetTodoRole(ctx context.Context, userID, todoID uuid.UUID) (string, error) {
+	if s.sharedTodos != nil && !s.sharedTodos[todoID] {
+		return "", nil
+	}
+	return s.todoRoles[userID], nil
+}
+
+func (s *shareStore) ListTodoParticipants(ctx context.Context, todoID uuid.UUID) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	for userID := range s.todoRoles {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+func (s *shareStore) GetProjectRole(ctx context.Context, userID, projectID uuid.UUID) (string, error) {
+	return s.projectRoles[userID], nil
+}
+
+func (s *shareStore) Create(ctx context.Context, share *models.Share) error {
+	s.created = append(s.created, share)
+	return nil
+}
+
+func (s *shareStore) GetByID(ctx context.Context, id uuid.UUID) (*models.Share, error) {
+	share, ok := s.shares[id]
+	if !ok {
+		return nil, repository.ErrShareNotFound
+	}
+	return share, nil
+}
+
+func (s *shareStore) Delete(ctx context.Context, id uuid.UUID) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func TestTodoService_CreateInSharedProject(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	project := &models.Project{ID: uuid.New(), UserID: ownerID, Name: "Работа"}
+	parent := &models.Todo{ID: uuid.New(), UserID: ownerID, ProjectID: &project.ID, Title: "Релиз"}
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		parentID  *uuid.UUID
+		projectID *uuid.UUID
+		wantErr   error
+	}{
+		{name: "владелец создает подзадачу", userID: ownerID, parentID: &parent.ID},
+		{name: "редактор создает задачу в общем проекте", userID: editorID, projectID: &project.ID},
+		{name: "редактор создает подзадачу общей задачи", userID: editorID, parentID: &parent.ID},
+		{name: "наблюдатель не может создать задачу в проекте", userID: viewerID, projectID: &project.ID, wantErr: repository.ErrInvalidProject},
+		{name: "наблюдатель не может создать подзадачу", userID: viewerID, parentID: &parent.ID, wantErr: repository.ErrInvalidParent},
+		{name: "пользователь без доступа к проекту", userID: uuid.New(), projectID: &project.ID, wantErr: repository.ErrInvalidProject},
+		{name: "пользователь без доступа к задаче", userID: uuid.New(), parentID: &parent.ID, wantErr: repository.ErrInvalidParent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &todoStore{todos: map[uuid.UUID]*models.Todo{parent.ID: parent}}
+			roles := map[uuid.UUID]string{
+				editorID: models.ShareRoleEditor,
+				viewerID: models.ShareRoleViewer,
+			}
+			shares := &shareStore{todoRoles: roles, projectRoles: roles}
+			publisher := &eventRecorder{}
+			repo := repository.NewSubtaskTodoRepository(repository.NewEventTodoRepository(store, shares, publisher),
+				shares, nil, models.SubtaskPolicies{})
+			projects := &projectStore{projects: map[uuid.UUID]*models.Project{project.ID: project}}
+			service := NewTodoService(repo, shares, projects)
+			ctx := context.Background()
+
+			todo := &models.Todo{ID: uuid.New(), UserID: tt.userID, ParentID: tt.parentID, ProjectID: tt.projectID, Title: "Changelog"}
+			err := service.Create(ctx, todo)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, store.created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []*models.Todo{todo}, store.created)
+
+			// Задача принадлежит владельцу проекта: он видит ее и получает события о ней
+			assert.Equal(t, ownerID, todo.UserID)
+			_, err = service.Authorize(ctx, ownerID, todo.ID, TodoActionDelete)
+			assert.NoError(t, err)
+			_, err = service.GetByID(ctx, tt.userID, todo.ID)
+			assert.NoError(t, err)
+			var recipients []uuid.UUID
+			for _, event := range publisher.events {
+				if event.Type == models.TodoEventCreated {
+					recipients = append(recipients, event.UserID)
+				}
+			}
+			assert.Contains(t, recipients, ownerID)
+		})
+	}
+}
+
+func TestTodoService_EventsReachParticipants(t *testing.T) {
+	ownerID := uuid.New()
+	editorID := uuid.New()
+	viewerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+
+	store := &todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}
+	shares := &shareStore{todoRoles: map[uuid.UUID]string{
+		editorID: models.ShareRoleEditor,
+		viewerID: models.ShareRoleViewer,
+	}}
+	publisher := &eventRecorder{}
+	service := NewTodoService(repository.NewEventTodoRepository(store, shares, publisher), shares, nil)
+
+	err := service.Update(context.Background(), editorID, &models.Todo{ID: todo.ID, Title: "План"})
+	require.NoError(t, err)
+
+	// Изменение, сделанное участником, получают владелец и все участники общего доступа
+	var recipients []uuid.UUID
+	for _, event := range publisher.events {
+		assert.Equal(t, models.TodoEventUpdated, event.Type)
+		assert.Equal(t, todo.ID, event.Todo.ID)
+		recipients = append(recipients, event.UserID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{ownerID, editorID, viewerID}, recipients)
+}
+
+func TestTodoService_AuthorizeRoles(t *testing.T) {
+	ownerID := uuid.New()
+	todo := &models.Todo{ID: uuid.New(), UserID: ownerID, Title: "Отчет"}
+
+	tests := []struct {
+		name    string
+		role    string
+		allowed []TodoAction
+		denied  []TodoAction
+	}{
+		{
+			name:    "наблюдатель",
+			role:    models.ShareRoleViewer,
+			allowed: []TodoAction{TodoActionRead},
+			denied:  []TodoAction{TodoActionWrite, TodoActionDelete, TodoActionShare},
+		},
+		{
+			name:    "редактор",
+			role:    models.ShareRoleEditor,
+			allowed: []TodoAction{TodoActionRead, TodoActionWrite, TodoActionDelete},
+			denied:  []TodoAction{TodoActionShare},
+		},
+		{
+			name:    "совладелец",
+			role:    models.ShareRoleOwner,
+			allowed: []TodoAction{TodoActionRead, TodoActionWrite, TodoActionDelete, TodoActionShare},
+		},
+		{
+			name:   "без доступа",
+			denied: []TodoAction{TodoActionRead, TodoActionWrite, TodoActionDelete, TodoActionShare},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			store := &todoStore{todos: map[uuid.UUID]*models.Todo{todo.ID: todo}}
+			shares := &shareStore{todoRoles: map[uuid.UUID]string{userID: tt.role}}
+			service := NewTodoService(store, shares, nil)
+
+			for _, action := range tt.allowed {
+				_, err := service.Authorize(context.Background(), userID, todo.ID, action)
+				assert.NoError(t, err, action)
+			}
+			for _, action := range tt.denied {
+				_, err := service.Authorize(context.Background(), userID, todo.ID, action)
+				assert.ErrorIs(t, err, repository.ErrTodoNotFound, action)
+			}
+		})
+	}
+}
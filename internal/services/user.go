@@ -1,15 +1,15 @@
 package services
 
 import (
-	"TODO-list/internal/models"
-	"TODO-list/internal/repository"
 	"context"
 	"errors"
-	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"regexp"
-	"strings"
 	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/models"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type userService struct {
@@ -20,31 +20,30 @@ func NewUserService(repo repository.UserRepository) UserService {
 	return &userService{repo: repo}
 }
 
-func (s *userService) Create(ctx context.Context, userCreate *models.UserCreate) error {
+func (s *userService) Create(ctx context.Context, input *models.RegisterRequest) error {
 	// Валидация данных создания пользователя
-	if err := validateUserCreate(userCreate); err != nil {
+	if err := validateRegisterRequest(input); err != nil {
 		return err
 	}
 
 	// Проверка существования пользователя с таким email
-	existingUser, _ := s.repo.GetByEmail(ctx, userCreate.Email)
+	existingUser, _ := s.repo.GetByEmail(ctx, input.Email)
 	if existingUser != nil {
 		return errors.New("user with this email already exists")
 	}
 
 	// Создание нового пользователя
 	user := &models.User{
-		ID:       uuid.New(),
-		Username: userCreate.Username,
-		Email:    userCreate.Email,
+		ID:    uuid.New(),
+		Email: input.Email,
 	}
 
 	// Хеширование пароля
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userCreate.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	user.PasswordHash = string(hashedPassword)
+	user.Password = string(hashedPassword)
 
 	// Установка времени создания и обновления
 	now := time.Now()
@@ -87,36 +86,26 @@ func (s *userService) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 // Вспомогательные функции для валидации
-func validateUserCreate(user *models.UserCreate) error {
-	if user.Username == "" {
-		return errors.New("username is required")
-	}
-	if len(user.Username) < 3 {
-		return errors.New("username must be at least 3 characters long")
-	}
-
-	if user.Email == "" {
+func validateRegisterRequest(input *models.RegisterRequest) error {
+	if input.Email == "" {
 		return errors.New("email is required")
 	}
-	if !isValidEmail(user.Email) {
+	if !isValidEmail(input.Email) {
 		return errors.New("invalid email format")
 	}
 
-	if user.Password == "" {
+	if input.Password == "" {
 		return errors.New("password is required")
 	}
-	if len(user.Password) < 6 {
-		return errors.New("password must be at least 6 characters long")
+	if len(input.Password) < 8 {
+		return errors.New("password must be at least 8 characters long")
 	}
 
 	return nil
 }
 
 func validateUser(user *models.User) error {
-	if user.Username == "" {
-		return errors.New("username is required")
-	}
-	if len(user.Username) < 3 {
+	if user.Username != "" && len(user.Username) < 3 {
 		return errors.New("username must be at least 3 characters long")
 	}
 
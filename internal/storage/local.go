@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalFilesPath путь API, по которому локальное хранилище отдает файлы по подписанным ссылкам
+const LocalFilesPath = "/api/files/"
+
+// LocalStore хранит файлы в каталоге на диске. Ссылки на скачивание ведут на LocalFilesPath
+// API и подписываются HMAC-SHA256 вместе со сроком действия, именем и типом файла.
+type LocalStore struct {
+	dir     string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocalStore создает новый экземпляр LocalStore, создавая каталог dir при необходимости
+func NewLocalStore(dir, baseURL, secret string) (*LocalStore, error) {
+	if secret == "" {
+		return nil, errors.New("signing secret is required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  []byte(secret),
+		now:     time.Now,
+	}, nil
+}
+
+// Put записывает файл во временный файл рядом и переименовывает его, чтобы по ключу
+// никогда не читалось недописанное содержимое
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(r, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("expected %d bytes, got %d", size, written)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, key string, opts DownloadOptions) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(s.now().Add(opts.Expires).Unix(), 10))
+	query.Set("name", opts.Filename)
+	query.Set("type", opts.ContentType)
+	query.Set("signature", s.sign(key, query))
+	return s.baseURL + LocalFilesPath + key + "?" + query.Encode(), nil
+}
+
+// Open проверяет подпись ссылки на файл key с параметрами query и открывает файл.
+// Возвращает параметры скачивания из ссылки; ErrInvalidSignature — если подпись неверна
+// или срок действия ссылки истек.
+func (s *LocalStore) Open(key string, query url.Values) (*os.File, DownloadOptions, error) {
+	if !ValidKey(key) {
+		return nil, DownloadOptions{}, ErrInvalidSignature
+	}
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, s.mac(key, query)) {
+		return nil, DownloadOptions{}, ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || s.now().Unix() > expires {
+		return nil, DownloadOptions{}, ErrInvalidSignature
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, DownloadOptions{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, DownloadOptions{}, err
+	}
+	return file, DownloadOptions{
+		Filename:    query.Get("name"),
+		ContentType: query.Get("type"),
+	}, nil
+}
+
+// path возвращает путь файла key в каталоге хранилища
+func (s *LocalStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) sign(key string, query url.Values) string {
+	return hex.EncodeToString(s.mac(key, query))
+}
+
+// mac подписывает ключ файла вместе со сроком действия, именем и типом из query
+func (s *LocalStore) mac(key string, query url.Values) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	for _, part := range []string{key, query.Get("expires"), query.Get("name"), query.Get("type")} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	return mac.Sum(nil)
+}
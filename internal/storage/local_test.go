@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_PutAndOpen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "http://localhost:3000/", "secret")
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	key := "attachments/todo/file"
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("содержимое"), int64(len("содержимое")), "text/plain"))
+
+	link, err := store.SignedURL(ctx, key, DownloadOptions{Filename: "отчет.txt", ContentType: "text/plain", Expires: time.Minute})
+	require.NoError(t, err)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:3000", u.Host)
+	assert.Equal(t, LocalFilesPath+key, u.Path)
+
+	tampered := func(name, value string) url.Values {
+		query := u.Query()
+		query.Set(name, value)
+		return query
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		query   url.Values
+		after   time.Duration
+		wantErr error
+	}{
+		{name: "действующая ссылка", key: key, query: u.Query()},
+		{name: "истекшая ссылка", key: key, query: u.Query(), after: 2 * time.Minute, wantErr: ErrInvalidSignature},
+		{name: "измененный срок", key: key, query: tampered("expires", "9999999999"), wantErr: ErrInvalidSignature},
+		{name: "измененный тип", key: key, query: tampered("type", "text/html"), wantErr: ErrInvalidSignature},
+		{name: "другой ключ", key: "attachments/todo/other", query: u.Query(), wantErr: ErrInvalidSignature},
+		{name: "выход за пределы каталога", key: "attachments/../../etc/passwd", query: u.Query(), wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.now = func() time.Time { return now.Add(tt.after) }
+
+			file, opts, err := store.Open(tt.key, tt.query)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer file.Close()
+
+			content, err := io.ReadAll(file)
+			require.NoError(t, err)
+			assert.Equal(t, "содержимое", string(content))
+			assert.Equal(t, "отчет.txt", opts.Filename)
+			assert.Equal(t, "text/plain", opts.ContentType)
+		})
+	}
+}
+
+func TestLocalStore_PutSizeMismatch(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "http://localhost:3000", "secret")
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "file", strings.NewReader("short"), 10, "text/plain")
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "file"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLocalStore_Delete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "http://localhost:3000", "secret")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "a/b", strings.NewReader("x"), 1, "text/plain"))
+	require.NoError(t, store.Delete(ctx, "a/b"))
+	_, err = os.Stat(filepath.Join(dir, "a", "b"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Повторное удаление не считается ошибкой
+	assert.NoError(t, store.Delete(ctx, "a/b"))
+	assert.Error(t, store.Delete(ctx, "../b"))
+}
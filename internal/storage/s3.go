@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedPayload хеш содержимого запроса, который не подписывается
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config содержит настройки S3-совместимого хранилища
+type S3Config struct {
+	// Endpoint адрес хранилища, например https://s3.eu-central-1.amazonaws.com или http://localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle адресует бакет в пути (http://host/bucket/key), как принято в MinIO;
+	// иначе бакет указывается в имени хоста (http://bucket.host/key)
+	PathStyle bool
+}
+
+// S3Store хранит файлы в бакете S3-совместимого хранилища (AWS S3, MinIO).
+// Запросы подписываются AWS Signature Version 4.
+type S3Store struct {
+	client   *http.Client
+	cfg      S3Config
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// NewS3Store создает новый экземпляр S3Store
+func NewS3Store(client *http.Client, cfg S3Config) (*S3Store, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.Region == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3 bucket, region and credentials are required")
+	}
+	return &S3Store{
+		client:   client,
+		cfg:      cfg,
+		endpoint: endpoint,
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req, http.StatusOK)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	// S3 отвечает 204 и на удаление отсутствующего объекта; 404 возвращается, если нет бакета
+	return s.do(req, http.StatusNoContent, http.StatusOK)
+}
+
+// SignedURL возвращает presigned URL запроса GET объекта. Имя и тип файла передаются
+// параметрами response-content-disposition и response-content-type.
+func (s *S3Store) SignedURL(ctx context.Context, key string, opts DownloadOptions) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	if opts.Expires <= 0 || opts.Expires > MaxURLExpiration {
+		return "", fmt.Errorf("URL expiration must be between 1s and %s", MaxURLExpiration)
+	}
+
+	query := url.Values{}
+	query.Set("response-content-disposition", ContentDisposition(opts.Filename))
+	if opts.ContentType != "" {
+		query.Set("response-content-type", opts.ContentType)
+	}
+	return s.presign(http.MethodGet, key, opts.Expires, query, s.now()), nil
+}
+
+// presign подписывает запрос method к объекту key параметрами запроса по схеме
+// AWS Signature Version 4 с подписью в строке запроса
+func (s *S3Store) presign(method, key string, expires time.Duration, query url.Values, t time.Time) string {
+	u := s.objectURL(key)
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+s.scope(t))
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	signature := s.signature(t, canonicalRequest)
+	return u.Scheme + "://" + u.Host + u.EscapedPath() + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// newRequest создает запрос к объекту key, подписанный в заголовке Authorization
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+	u := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		"",
+		"host:" + u.Host + "\nx-amz-content-sha256:" + unsignedPayload + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, s.scope(t), signedHeaders, s.signature(t, canonicalRequest)))
+	return req, nil
+}
+
+// do выполняет запрос и проверяет, что хранилище ответило одним из статусов expected
+func (s *S3Store) do(req *http.Request, expected ...int) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 %s %s responded with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// objectURL возвращает адрес объекта key с учетом способа адресации бакета
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimRight(u.Path, "/")
+	if s.cfg.PathStyle {
+		path += "/" + s.cfg.Bucket
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	u.Path = path + "/" + key
+	u.RawPath = uriEncode(path, false) + "/" + uriEncode(key, false)
+	u.RawQuery = ""
+	return &u
+}
+
+// scope возвращает область действия подписи date/region/s3/aws4_request
+func (s *S3Store) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature подписывает канонический запрос ключом, производным от секретного ключа
+func (s *S3Store) signature(t time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + t.Format("20060102T150405Z") + "\n" + s.scope(t) + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQueryString кодирует параметры запроса, отсортированные по имени, как требует Signature Version 4
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode кодирует все байты, кроме A-Z, a-z, 0-9, '-', '.', '_', '~' и, если encodeSlash
+// не задан, '/'
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
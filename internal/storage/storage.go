@@ -0,0 +1,100 @@
+// Package storage хранит содержимое вложений задач в файловом хранилище
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Драйверы хранилища
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// MaxURLExpiration наибольший срок действия подписанной ссылки, который принимают S3-совместимые хранилища
+const MaxURLExpiration = 7 * 24 * time.Hour
+
+// ErrInvalidSignature возвращается для ссылки на скачивание с неверной или истекшей подписью
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// keyRegexp допустимые ключи файлов: сегменты пути из латинских букв, цифр, '.', '_' и '-'
+var keyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// DownloadOptions задает параметры подписанной ссылки на скачивание
+type DownloadOptions struct {
+	// Filename имя файла, под которым его сохранит браузер
+	Filename string
+	// ContentType тип содержимого ответа
+	ContentType string
+	// Expires срок действия ссылки
+	Expires time.Duration
+}
+
+// BlobStore хранит содержимое файлов по ключу. Содержимое отдается клиентам не через API,
+// а по подписанным ссылкам с ограниченным сроком действия.
+type BlobStore interface {
+	// Put сохраняет size байт из r под ключом key, заменяя прежнее содержимое
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete удаляет файл. Удаление отсутствующего файла не считается ошибкой.
+	Delete(ctx context.Context, key string) error
+	// SignedURL возвращает ссылку на скачивание файла, действующую opts.Expires
+	SignedURL(ctx context.Context, key string, opts DownloadOptions) (string, error)
+}
+
+// Config содержит настройки хранилища файлов
+type Config struct {
+	// Driver хранилище: local или s3
+	Driver string
+	// LocalDir каталог файлов локального хранилища
+	LocalDir string
+	// PublicURL внешний адрес API, по которому строятся ссылки локального хранилища
+	PublicURL string
+	// SigningSecret ключ подписи ссылок локального хранилища
+	SigningSecret string
+	S3            S3Config
+}
+
+// New создает хранилище, выбранное cfg.Driver. Если ключ подписи локального хранилища
+// не задан, используется случайный ключ, и выданные ссылки перестают действовать после перезапуска.
+func New(cfg Config) (BlobStore, error) {
+	switch cfg.Driver {
+	case DriverLocal:
+		secret := cfg.SigningSecret
+		if secret == "" {
+			log.Println("ATTACHMENT_SIGNING_SECRET is not set, download links will expire on restart")
+			random := make([]byte, 32)
+			if _, err := rand.Read(random); err != nil {
+				return nil, err
+			}
+			secret = hex.EncodeToString(random)
+		}
+		return NewLocalStore(cfg.LocalDir, cfg.PublicURL, secret)
+	case DriverS3:
+		return NewS3Store(&http.Client{Timeout: 5 * time.Minute}, cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+// ValidKey проверяет, что ключ файла не выходит за пределы хранилища
+func ValidKey(key string) bool {
+	return len(key) <= 1024 && keyRegexp.MatchString(key) && !strings.Contains(key, "..")
+}
+
+// ContentDisposition возвращает заголовок Content-Disposition для скачивания файла под именем filename
+func ContentDisposition(filename string) string {
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); filename != "" && disposition != "" {
+		return disposition
+	}
+	return "attachment"
+}
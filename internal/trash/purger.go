@@ -0,0 +1,131 @@
+// Package trash окончательно удаляет задачи, срок хранения которых в корзине истек
+package trash
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+)
+
+// Config содержит настройки очистки корзины
+type Config struct {
+	// Retention срок, после которого задача удаляется из корзины окончательно
+	Retention time.Duration
+	// PurgeInterval период очистки корзины
+	PurgeInterval time.Duration
+	// BatchSize максимальное число задач, удаляемых одним запросом
+	BatchSize int
+}
+
+// DefaultConfig возвращает настройки очистки корзины по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		Retention:     30 * 24 * time.Hour,
+		PurgeInterval: time.Hour,
+		BatchSize:     1000,
+	}
+}
+
+// Purger периодически окончательно удаляет задачи, которые находятся в корзине дольше
+// Retention. Удаление идет пакетами по BatchSize задач, чтобы не блокировать таблицу
+// надолго; одновременная работа нескольких экземпляров безопасна. После удаления задач
+// из хранилища файлов удаляется содержимое их вложений и других удаленных вложений.
+type Purger struct {
+	repo        repository.TodoRepository
+	attachments repository.AttachmentRepository
+	store       storage.BlobStore
+	cfg         Config
+	now         func() time.Time
+}
+
+// NewPurger создает новый экземпляр Purger. Если attachments или store не заданы,
+// содержимое вложений не удаляется.
+func NewPurger(repo repository.TodoRepository, attachments repository.AttachmentRepository, store storage.BlobStore, cfg Config) *Purger {
+	return &Purger{
+		repo:        repo,
+		attachments: attachments,
+		store:       store,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Run очищает корзину каждые PurgeInterval до отмены ctx
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d todos from trash", purged)
+		}
+		if deleted, err := p.DeleteBlobs(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to delete attachment blobs: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d attachment blobs", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce окончательно удаляет все задачи с истекшим сроком хранения и возвращает их число
+func (p *Purger) RunOnce(ctx context.Context) (int, error) {
+	before := p.now().Add(-p.cfg.Retention)
+
+	total := 0
+	for {
+		purged, err := p.repo.PurgeDeleted(ctx, before, p.cfg.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to purge deleted todos: %w", err)
+		}
+		total += purged
+		if purged < p.cfg.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// DeleteBlobs удаляет из хранилища содержимое удаленных вложений из очереди удаления
+// и возвращает число удаленных файлов. Ключ остается в очереди, пока файл не удален,
+// и при ошибке хранилища обработка останавливается до следующего запуска.
+func (p *Purger) DeleteBlobs(ctx context.Context) (int, error) {
+	if p.attachments == nil || p.store == nil {
+		return 0, nil
+	}
+
+	total := 0
+	for {
+		keys, err := p.attachments.PendingBlobDeletions(ctx, p.cfg.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to get pending blob deletions: %w", err)
+		}
+
+		var deleted []string
+		var deleteErr error
+		for _, key := range keys {
+			if deleteErr = p.store.Delete(ctx, key); deleteErr != nil {
+				deleteErr = fmt.Errorf("failed to delete blob %s: %w", key, deleteErr)
+				break
+			}
+			deleted = append(deleted, key)
+		}
+		if err := p.attachments.CompleteBlobDeletions(ctx, deleted); err != nil {
+			return total, fmt.Errorf("failed to complete blob deletions: %w", err)
+		}
+		total += len(deleted)
+		if deleteErr != nil || len(keys) < p.cfg.BatchSize {
+			return total, deleteErr
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// purgeRecorder реализует только PurgeDeleted репозитория задач: удаляет из remaining
+// не более limit задач и запоминает вызовы
+type purgeRecorder struct {
+	repository.TodoRepository
+	remaining int
+	err       error
+	calls     []time.Time
+}
+
+func (r *purgeRecorder) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	r.calls = append(r.calls, before)
+	if r.err != nil {
+		return 0, r.err
+	}
+	purged := min(r.remaining, limit)
+	r.remaining -= purged
+	return purged, nil
+}
+
+func TestPurger_RunOnce(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		remaining int
+		err       error
+		wantTotal int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "корзина пуста", remaining: 0, wantTotal: 0, wantCalls: 1},
+		{name: "меньше пакета", remaining: 7, wantTotal: 7, wantCalls: 1},
+		{name: "несколько пакетов", remaining: 25, wantTotal: 25, wantCalls: 3},
+		{name: "ровно пакет", remaining: 10, wantTotal: 10, wantCalls: 2},
+		{name: "ошибка репозитория", err: errors.New("db error"), wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &purgeRecorder{remaining: tt.remaining, err: tt.err}
+			purger := NewPurger(repo, nil, nil, Config{Retention: 24 * time.Hour, PurgeInterval: time.Hour, BatchSize: 10})
+			purger.now = func() time.Time { return now }
+
+			total, err := purger.RunOnce(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantTotal, total)
+			require.Len(t, repo.calls, tt.wantCalls)
+			for _, before := range repo.calls {
+				assert.Equal(t, now.Add(-24*time.Hour), before)
+			}
+		})
+	}
+}
+
+// blobQueue реализует только очередь удаления репозитория вложений
+type blobQueue struct {
+	repository.AttachmentRepository
+	pending []string
+}
+
+func (q *blobQueue) PendingBlobDeletions(ctx context.Context, limit int) ([]string, error) {
+	return q.pending[:min(limit, len(q.pending))], nil
+}
+
+func (q *blobQueue) CompleteBlobDeletions(ctx context.Context, keys []string) error {
+	q.pending = q.pending[len(keys):]
+	return nil
+}
+
+// blobRemover реализует только Delete хранилища и отказывает на ключе failKey
+type blobRemover struct {
+	storage.BlobStore
+	failKey string
+	deleted []string
+}
+
+func (s *blobRemover) Delete(ctx context.Context, key string) error {
+	if key == s.failKey {
+		return errors.New("storage unavailable")
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestPurger_DeleteBlobs(t *testing.T) {
+	tests := []struct {
+		name        string
+		pending     int
+		failKey     string
+		wantDeleted int
+		wantPending int
+		wantErr     bool
+	}{
+		{name: "очередь пуста", pending: 0},
+		{name: "несколько пакетов", pending: 25, wantDeleted: 25},
+		{name: "хранилище недоступно", pending: 25, failKey: "key-12", wantDeleted: 12, wantPending: 13, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &blobQueue{}
+			for i := 0; i < tt.pending; i++ {
+				queue.pending = append(queue.pending, fmt.Sprintf("key-%d", i))
+			}
+			store := &blobRemover{failKey: tt.failKey}
+			purger := NewPurger(&purgeRecorder{}, queue, store, Config{Retention: time.Hour, PurgeInterval: time.Hour, BatchSize: 10})
+
+			deleted, err := purger.DeleteBlobs(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantDeleted, deleted)
+			assert.Len(t, store.deleted, tt.wantDeleted)
+			assert.Len(t, queue.pending, tt.wantPending)
+		})
+	}
+}
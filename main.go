@@ -1,51 +1,141 @@
 package main
 
 import (
-	"TODO-list/internal/config"
-	"TODO-list/internal/handlers"
-	"TODO-list/internal/middleware"
-	"TODO-list/internal/repository"
-	"TODO-list/internal/services"
-	"TODO-list/pkg/cache"
 	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/R-eSPeCT/todo-list/internal/auth"
+	"github.com/R-eSPeCT/todo-list/internal/config"
+	"github.com/R-eSPeCT/todo-list/internal/events"
+	"github.com/R-eSPeCT/todo-list/internal/handler"
+	"github.com/R-eSPeCT/todo-list/internal/middleware"
+	"github.com/R-eSPeCT/todo-list/internal/repository"
+	"github.com/R-eSPeCT/todo-list/internal/services"
+	"github.com/R-eSPeCT/todo-list/internal/storage"
+	"github.com/R-eSPeCT/todo-list/pkg/cache"
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
-	"os"
-	"time"
+	_ "github.com/lib/pq"
 )
 
 func main() {
-	// Загрузка конфигурации Redis
-	redisConfig := config.NewRedisConfig()
+	// Загрузка конфигурации
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
 	// Инициализация Redis
-	redisCache, err := cache.NewRedisCache(redisConfig)
+	redisCache, err := cache.NewRedisCache(*config.NewRedisConfig())
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 	defer redisCache.Close()
 
 	// Подключение к базе данных
-	dbpool, err := pgxpool.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Unable to connect to database: %v", err)
 	}
-	defer dbpool.Close()
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Unable to ping database: %v", err)
+	}
+
+	// Брокер событий изменения задач
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := events.NewBroker(redisCache, events.DefaultConfig())
+	go func() {
+		if err := broker.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Todo event subscription stopped, falling back to in-process delivery: %v", err)
+		}
+	}()
+	defer broker.Close()
 
 	// Инициализация репозиториев
-	repos := repository.NewRepositories(dbpool)
+	userRepo := repository.NewUserRepository(db)
+	todoHistoryRepo := repository.NewTodoHistoryRepository(db)
+	transactor := repository.NewTransactor(db)
+	shareRepo := repository.NewShareRepository(db)
+	todoRepo := repository.NewRecurringTodoRepository(repository.NewSubtaskTodoRepository(
+		repository.NewEventTodoRepository(
+			repository.NewHistoryTodoRepository(repository.NewTodoRepository(db), todoHistoryRepo), shareRepo, broker),
+		shareRepo,
+		transactor,
+		cfg.Subtasks.Policies(),
+	))
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	tagRepo := repository.NewEventTagRepository(
+		repository.NewHistoryTagRepository(repository.NewTagRepository(db), todoRepo, todoHistoryRepo), todoRepo, shareRepo, broker)
+	projectRepo := repository.NewProjectRepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	assigneeRepo := repository.NewAssigneeRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+
+	// Инициализация сервисов: доступ к задачам по ID проверяется с учетом общего доступа
+	todoService := services.NewTodoService(todoRepo, shareRepo, projectRepo)
+	shareService := services.NewShareService(shareRepo, todoService, projectRepo, userRepo, notificationRepo)
+	assignmentService := services.NewAssignmentService(assigneeRepo, todoService, notificationRepo)
+	commentService := services.NewCommentService(commentRepo, todoService, userRepo, notificationRepo, broker)
+
+	// Хранилище содержимого вложений
+	blobStore, err := storage.New(cfg.Attachments.StorageConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	attachmentService := services.NewAttachmentService(attachmentRepo, todoService, blobStore, services.AttachmentLimits{
+		MaxSize:       int64(cfg.Attachments.MaxSize),
+		AllowedTypes:  cfg.Attachments.AllowedTypes,
+		UserQuota:     int64(cfg.Attachments.UserQuota),
+		URLExpiration: cfg.Attachments.URLExpiration,
+	})
+
+	// Ключи подписи токенов. Замененные ключи, как и отметка "выйти со всех устройств",
+	// должны храниться не меньше времени жизни самых долгоживущих access токенов,
+	// включая токены gRPC сервера.
+	maxTokenDuration := max(cfg.JWTExpiration, cfg.GRPC.TokenDuration)
+	keys, err := auth.LoadKeySet(cfg.JWT.KeysDir, cfg.JWT.SigningAlgorithm)
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	go keys.RunRotation(ctx, cfg.JWT.KeyRotationInterval, maxTokenDuration)
 
-	// Инициализация сервисов
-	services := services.NewServices(repos)
+	// Инициализация аутентификации
+	jwtManager := auth.NewJWTManagerWithKeys(keys, cfg.JWTExpiration)
+	denylist := auth.NewTokenDenylist(redisCache, maxTokenDuration)
+	refreshTokens := auth.NewRefreshTokenManager(refreshTokenRepo, cfg.GRPC.RefreshTokenDuration)
 
 	// Инициализация обработчиков
-	handlers := handlers.NewHandler(services)
+	userHandler := handler.NewUserHandler(userRepo, jwtManager)
+	sessionHandler := handler.NewSessionHandler(denylist, refreshTokens)
+	todoHandler := handler.NewTodoHandler(todoRepo, todoService, jwtManager)
+	tagHandler := handler.NewTagHandler(tagRepo)
+	quickAddHandler := handler.NewQuickAddHandler(todoRepo, tagRepo)
+	projectHandler := handler.NewProjectHandler(projectRepo, todoRepo)
+	reminderHandler := handler.NewReminderHandler(reminderRepo, todoService)
+	historyHandler := handler.NewHistoryHandler(todoService, todoHistoryRepo)
+	batchHandler := handler.NewBatchHandler(todoService, transactor)
+	shareHandler := handler.NewShareHandler(shareService)
+	assignmentHandler := handler.NewAssignmentHandler(assignmentService)
+	commentHandler := handler.NewCommentHandler(commentService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	notificationHandler := handler.NewNotificationHandler(notificationRepo)
+	streamHandler := handler.NewStreamHandler(broker, 15*time.Second)
+	jwksHandler := handler.NewJWKSHandler(keys, 5*time.Minute)
 
-	// Создание Fiber приложения
+	// Создание Fiber приложения. Тело запроса загрузки вложения ограничено размером вложения,
+	// остальных запросов — BodyLimit по умолчанию.
 	app := fiber.New()
+	app.Server().HeaderReceived = handler.AttachmentUploadBodyLimit(cfg.Attachments.MaxSize)
 
 	// Middleware
 	app.Use(logger.New())
@@ -65,25 +155,124 @@ func main() {
 		KeyPrefix: "rate_limit_auth",
 	})
 
+	// Ключи идемпотентности: повтор POST, PATCH и DELETE запроса с тем же Idempotency-Key
+	// получает сохраненный ответ
+	idempotent := middleware.Idempotency(redisCache, middleware.IdempotencyConfig{
+		TTL:         24 * time.Hour,
+		LockTimeout: time.Minute,
+		KeyPrefix:   "idempotency",
+	})
+
+	// Проверка токена, включая отозванные токены
+	authRequired := middleware.AuthMiddleware(jwtManager, denylist)
+
+	// Аутентификация для потоков: браузерные EventSource и WebSocket передают токен в параметре access_token
+	streamAuth := []fiber.Handler{
+		middleware.TokenFromQuery("access_token"),
+		authRequired,
+	}
+
+	// Открытые ключи для проверки токенов другими сервисами
+	app.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Роуты для пользователей
 	users := app.Group("/api/users")
-	users.Post("/register", handlers.Register)
-	users.Post("/login", authLimiter, handlers.Login)
+	users.Post("/register", userHandler.Register)
+	users.Post("/login", authLimiter, userHandler.Login)
+	users.Post("/logout", authRequired, sessionHandler.Logout)
+	users.Post("/logout-all", authRequired, sessionHandler.LogoutAll)
 
 	// Роуты для задач с rate limiting
 	todos := app.Group("/api/todos", apiLimiter)
-	todos.Get("/", handlers.GetTodos)
-	todos.Post("/", handlers.CreateTodo)
-	todos.Get("/grouped", handlers.GetGroupedTodos)
-	todos.Get("/:id", handlers.GetTodoByID)
-	todos.Put("/:id", handlers.UpdateTodo)
-	todos.Delete("/:id", handlers.DeleteTodo)
+	todos.Get("/", authRequired, todoHandler.GetTodos)
+	todos.Post("/", authRequired, idempotent, todoHandler.CreateTodo)
+	todos.Post("/quick", authRequired, idempotent, quickAddHandler.QuickAddTodo)
+	todos.Post("/batch", authRequired, idempotent, batchHandler.BatchTodos)
+	todos.Get("/grouped", authRequired, todoHandler.GetGroupedTodos)
+	todos.Get("/assigned", authRequired, todoHandler.GetAssignedTodos)
+	todos.Get("/search", authRequired, todoHandler.SearchTodos)
+	todos.Get("/trash", authRequired, todoHandler.GetTrash)
+	todos.Post("/trash/:id/restore", authRequired, idempotent, todoHandler.RestoreTodo)
+	todos.Delete("/trash/:id", authRequired, idempotent, todoHandler.PurgeTodo)
+	todos.Get("/stream", append(streamAuth, streamHandler.StreamTodos)...)
+	todos.Get("/stream/ws", append(streamAuth, streamHandler.WebSocketUpgrade, websocket.New(streamHandler.StreamTodosWebSocket))...)
+	todos.Get("/:id", authRequired, todoHandler.GetTodoByID)
+	todos.Get("/:id/subtasks", authRequired, todoHandler.GetSubtasks)
+	todos.Put("/:id", authRequired, todoHandler.UpdateTodo)
+	todos.Patch("/:id", authRequired, idempotent, todoHandler.PatchTodo)
+	todos.Post("/:id/move", authRequired, idempotent, todoHandler.MoveTodo)
+	todos.Post("/:id/skip", authRequired, idempotent, todoHandler.SkipOccurrence)
+	todos.Get("/:id/occurrences", authRequired, todoHandler.GetOccurrences)
+	todos.Get("/:id/history", authRequired, historyHandler.GetTodoHistory)
+	todos.Post("/:id/history/:revision/revert", authRequired, idempotent, historyHandler.RevertTodo)
+	todos.Delete("/:id", authRequired, idempotent, todoHandler.DeleteTodo)
+	todos.Post("/:id/tags/:tagId", authRequired, idempotent, tagHandler.AttachTag)
+	todos.Delete("/:id/tags/:tagId", authRequired, idempotent, tagHandler.DetachTag)
+	todos.Get("/:id/reminders", authRequired, reminderHandler.GetReminders)
+	todos.Post("/:id/reminders", authRequired, idempotent, reminderHandler.CreateReminder)
+	todos.Delete("/:id/reminders/:reminderId", authRequired, idempotent, reminderHandler.DeleteReminder)
+	todos.Get("/:id/reminders/:reminderId/deliveries", authRequired, reminderHandler.GetReminderDeliveries)
+	todos.Get("/:id/shares", authRequired, shareHandler.GetTodoShares)
+	todos.Post("/:id/shares", authRequired, idempotent, shareHandler.ShareTodo)
+	todos.Post("/:id/assignees", authRequired, idempotent, assignmentHandler.AssignTodo)
+	todos.Delete("/:id/assignees/:userId", authRequired, idempotent, assignmentHandler.UnassignTodo)
+	todos.Get("/:id/comments", authRequired, commentHandler.GetComments)
+	todos.Post("/:id/comments", authRequired, idempotent, commentHandler.CreateComment)
+	todos.Get("/:id/attachments", authRequired, attachmentHandler.GetAttachments)
+	todos.Post("/:id/attachments", authRequired, idempotent, attachmentHandler.UploadAttachment)
 
-	// Запуск сервера
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	// Роуты для тегов
+	tags := app.Group("/api/tags", apiLimiter, authRequired, idempotent)
+	tags.Get("/", tagHandler.GetTags)
+	tags.Post("/", tagHandler.CreateTag)
+	tags.Put("/:id", tagHandler.UpdateTag)
+	tags.Delete("/:id", tagHandler.DeleteTag)
+
+	// Роуты для проектов
+	projects := app.Group("/api/projects", apiLimiter, authRequired, idempotent)
+	projects.Get("/", projectHandler.GetProjects)
+	projects.Post("/", projectHandler.CreateProject)
+	projects.Put("/:id", projectHandler.UpdateProject)
+	projects.Delete("/:id", projectHandler.DeleteProject)
+	projects.Post("/:id/archive", projectHandler.ArchiveProject)
+	projects.Post("/:id/unarchive", projectHandler.UnarchiveProject)
+	projects.Get("/:id/todos", projectHandler.GetProjectTodos)
+	projects.Get("/:id/todos/grouped", projectHandler.GetGroupedProjectTodos)
+	projects.Put("/:id/todos/order", projectHandler.ReorderTodos)
+	projects.Get("/:id/shares", shareHandler.GetProjectShares)
+	projects.Post("/:id/shares", shareHandler.ShareProject)
+
+	// Роуты для общего доступа и приглашений
+	shares := app.Group("/api/shares", apiLimiter, authRequired, idempotent)
+	shares.Put("/:id", shareHandler.UpdateShare)
+	shares.Delete("/:id", shareHandler.DeleteShare)
+
+	invitations := app.Group("/api/invitations", apiLimiter, authRequired, idempotent)
+	invitations.Get("/", shareHandler.GetInvitations)
+	invitations.Post("/:id/accept", shareHandler.AcceptInvitation)
+	invitations.Post("/:id/decline", shareHandler.DeclineInvitation)
+
+	// Роуты для комментариев
+	comments := app.Group("/api/comments", apiLimiter, authRequired, idempotent)
+	comments.Put("/:id", commentHandler.UpdateComment)
+	comments.Delete("/:id", commentHandler.DeleteComment)
+	comments.Get("/:id/history", commentHandler.GetCommentHistory)
+
+	// Роуты для вложений
+	attachments := app.Group("/api/attachments", apiLimiter, authRequired, idempotent)
+	attachments.Get("/:id/download", attachmentHandler.DownloadAttachment)
+	attachments.Delete("/:id", attachmentHandler.DeleteAttachment)
+
+	// Файлы локального хранилища отдаются по подписанным ссылкам без токена
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		app.Get(storage.LocalFilesPath+"*", handler.NewFileHandler(localStore).DownloadFile)
 	}
 
-	log.Fatal(app.Listen(":" + port))
+	// Роуты для входящих уведомлений
+	notifications := app.Group("/api/notifications", apiLimiter, authRequired, idempotent)
+	notifications.Get("/", notificationHandler.GetNotifications)
+	notifications.Post("/:id/read", notificationHandler.MarkNotificationRead)
+
+	// Запуск сервера
+	log.Fatal(app.Listen(":" + cfg.Port))
 }
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36) REFERENCES todos(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_todos_parent_id ON todos(parent_id);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    archived_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id);
+-- У каждого пользователя ровно один Inbox
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_id_inbox ON projects(user_id) WHERE is_inbox;
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project_id VARCHAR(36) REFERENCES projects(id);
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+-- Существующие задачи переносятся в Inbox своих владельцев
+INSERT INTO projects (id, user_id, name, is_inbox, created_at, updated_at)
+SELECT md5(random()::text || u.id)::uuid::text, u.id, 'Inbox', TRUE, now(), now()
+FROM users u
+WHERE EXISTS (SELECT 1 FROM todos t WHERE t.user_id = u.id)
+ON CONFLICT DO NOTHING;
+
+UPDATE todos t
+SET project_id = p.id
+FROM projects p
+WHERE p.user_id = t.user_id AND p.is_inbox AND t.project_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_project_id_position ON todos(project_id, position);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_todos_series_id_occurrence_date;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS occurrence_date;
+ALTER TABLE todos DROP COLUMN IF EXISTS series_id;
+
+DROP TABLE IF EXISTS todo_series;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS todo_series (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rrule TEXT NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    dtstart TIMESTAMP WITH TIME ZONE NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS series_id VARCHAR(36) REFERENCES todo_series(id) ON DELETE SET NULL;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS occurrence_date TIMESTAMP WITH TIME ZONE;
+
+-- Повторение серии создается не более одного раза, даже при повторных запросах
+CREATE UNIQUE INDEX IF NOT EXISTS idx_todos_series_id_occurrence_date ON todos(series_id, occurrence_date);
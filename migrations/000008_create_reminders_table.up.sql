@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS reminders (
+    id VARCHAR(36) PRIMARY KEY,
+    todo_id VARCHAR(36) NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Задается либо абсолютное время, либо смещение до срока задачи
+    remind_at TIMESTAMP WITH TIME ZONE,
+    offset_minutes INTEGER CHECK (offset_minutes >= 0),
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('email', 'webhook', 'in_app')),
+    target VARCHAR(2048) NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK ((remind_at IS NULL) <> (offset_minutes IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_todo_id ON reminders(todo_id);
+-- Планировщик выбирает только ожидающие напоминания
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(remind_at) WHERE sent_at IS NULL AND failed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS reminder_deliveries (
+    id VARCHAR(36) PRIMARY KEY,
+    reminder_id VARCHAR(36) NOT NULL REFERENCES reminders(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    success BOOLEAN NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    attempted_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminder_deliveries_reminder_id ON reminder_deliveries(reminder_id, attempted_at);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_id VARCHAR(36) REFERENCES todos(id) ON DELETE SET NULL,
+    reminder_id VARCHAR(36) REFERENCES reminders(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_todos_description_trgm;
+DROP INDEX IF EXISTS idx_todos_title_trgm;
+DROP INDEX IF EXISTS idx_todos_search_vector;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS search_vector;
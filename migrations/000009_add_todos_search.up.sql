@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поисковый вектор задачи: название важнее описания. Пользователи пишут на русском и английском,
+-- поэтому текст разбирается обеими конфигурациями.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_todos_search_vector ON todos USING GIN (search_vector);
+
+-- Триграммы используются для поиска с опечатками, когда полнотекстовый поиск ничего не нашел
+CREATE INDEX IF NOT EXISTS idx_todos_title_trgm ON todos USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_todos_description_trgm ON todos USING GIN (description gin_trgm_ops);
@@ -0,0 +1,4 @@
+-- Удаленные задачи попадают в корзину и окончательно удаляются после срока хранения
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
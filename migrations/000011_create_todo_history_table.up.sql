@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS todo_history (
+    id VARCHAR(36) PRIMARY KEY,
+    todo_id VARCHAR(36) NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('created', 'updated', 'deleted', 'restored', 'reverted')),
+    -- Автор изменения; NULL для автоматических изменений
+    actor_id VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('rest', 'grpc', 'automation')),
+    -- Изменения полей: [{"field": ..., "old": ..., "new": ...}]
+    changes JSONB NOT NULL DEFAULT '[]',
+    reverted_to INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (todo_id, revision)
+);
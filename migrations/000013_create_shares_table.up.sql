@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS shares (
+    id VARCHAR(36) PRIMARY KEY,
+    -- Общий доступ выдается либо к проекту, либо к отдельной задаче
+    project_id VARCHAR(36) REFERENCES projects(id) ON DELETE CASCADE,
+    todo_id VARCHAR(36) REFERENCES todos(id) ON DELETE CASCADE,
+    invited_by VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Адрес приглашенного в нижнем регистре
+    email VARCHAR(255) NOT NULL,
+    -- Пользователь, принявший приглашение
+    user_id VARCHAR(36) REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    CHECK ((project_id IS NULL) <> (todo_id IS NULL))
+);
+
+-- Один адрес приглашается к проекту или задаче один раз
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_project_id_email ON shares(project_id, email) WHERE project_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_todo_id_email ON shares(todo_id, email) WHERE todo_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_email ON shares(email) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_shares_user_id ON shares(user_id) WHERE status = 'accepted';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS todo_assignees (
+    todo_id VARCHAR(36) NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Пользователь, назначивший исполнителя
+    assigned_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (todo_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_assignees_user_id ON todo_assignees(user_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id VARCHAR(36) PRIMARY KEY,
+    todo_id VARCHAR(36) NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Комментарий, на который дан ответ
+    parent_id VARCHAR(36) REFERENCES comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    -- Упомянутые пользователи
+    mentions VARCHAR(36)[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    edited_at TIMESTAMP WITH TIME ZONE,
+    -- Удаленный комментарий остается в ветке без текста
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_todo_id ON comments(todo_id, created_at);
+
+-- Прежние версии текста комментариев
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id VARCHAR(36) PRIMARY KEY,
+    comment_id VARCHAR(36) NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    replaced_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_revisions_comment_id ON comment_revisions(comment_id, replaced_at);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id VARCHAR(36) PRIMARY KEY,
+    todo_id VARCHAR(36) NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    -- Пользователь, загрузивший файл; размер файла учитывается в его квоте
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    -- Ключ содержимого в хранилище файлов
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_todo_id ON attachments(todo_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_attachments_user_id ON attachments(user_id);
+
+-- Очередь удаления содержимого из хранилища файлов. Вложения удаляются и каскадно вместе
+-- с задачей при очистке корзины, поэтому ключ попадает в очередь триггером при любом удалении.
+CREATE TABLE IF NOT EXISTS blob_deletions (
+    storage_key VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION enqueue_blob_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO blob_deletions (storage_key) VALUES (OLD.storage_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS attachments_enqueue_blob_deletion ON attachments;
+CREATE TRIGGER attachments_enqueue_blob_deletion
+    AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_blob_deletion();
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// PubSub определяет интерфейс для публикации сообщений и подписки на каналы
+type PubSub interface {
+	// Publish отправляет сообщение всем подписчикам канала
+	Publish(ctx context.Context, channel string, message interface{}) error
+
+	// Subscribe подписывается на канал. Канал сообщений закрывается после отмены ctx.
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+func (c *RedisCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	var bytes []byte
+	var err error
+
+	switch v := message.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		bytes, err = json.Marshal(message)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.client.Publish(ctx, channel, bytes).Err()
+}
+
+func (c *RedisCache) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	sub := c.client.Subscribe(ctx, channel)
+
+	// Дожидаемся подтверждения подписки, чтобы не потерять первые сообщения
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		defer sub.Close()
+
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}